@@ -0,0 +1,241 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of go-DEWH.
+//
+// go-DEWH is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-DEWH is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-DEWH. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/DEWH/go-DEWH/accounts/abi/bind"
+	"github.com/DEWH/go-DEWH/cmd/utils"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle/contract"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/ethclient"
+	"github.com/DEWH/go-DEWH/light"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var commandDeploy = cli.Command{
+	Name:  "deploy",
+	Usage: "Deploys a new checkpoint oracle",
+	Description: `
+Deploys a checkpoint oracle owned by the given admins (--admins), accepting the
+checkpoints signed by at least the given number of them (--threshold). The
+transaction is sent from the given keyfile, which doesn't need to be an admin.
+The oracle accepts checkpoints of the light client sections once they are old
+enough for the LES servers to have processed them.`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		keyFileFlag,
+		passwordFileFlag,
+		adminsFlag,
+		thresholdFlag,
+	},
+	Action: utils.MigrateFlags(deploy),
+}
+
+var commandStatus = cli.Command{
+	Name:  "status",
+	Usage: "Fetches the admins and the latest checkpoint of the oracle",
+	Description: `
+Prints the admin list and signature threshold of the checkpoint oracle, along
+with the latest registered checkpoint.`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+	},
+	Action: utils.MigrateFlags(status),
+}
+
+var commandSign = cli.Command{
+	Name:  "sign",
+	Usage: "Signs a checkpoint with an admin key",
+	Description: `
+Retrieves the checkpoint of the requested section (--index) from the local LES
+server and signs it for the oracle with the given admin keyfile. The checkpoint
+roots are printed so that other admins can cross-check them against their own
+nodes before signing.`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+		indexFlag,
+		keyFileFlag,
+		passwordFileFlag,
+	},
+	Action: utils.MigrateFlags(sign),
+}
+
+var commandPublish = cli.Command{
+	Name:  "publish",
+	Usage: "Publishes a checkpoint with enough admin signatures into the oracle",
+	Description: `
+Retrieves the checkpoint of the requested section (--index) from the local LES
+server and registers it in the oracle with the given admin signatures, sending
+the transaction from the given admin keyfile.`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+		indexFlag,
+		keyFileFlag,
+		passwordFileFlag,
+		signaturesFlag,
+	},
+	Action: utils.MigrateFlags(publish),
+}
+
+// deploy deploys a new checkpoint oracle with the given admins and threshold.
+func deploy(ctx *cli.Context) error {
+	var (
+		admins    = getAdmins(ctx)
+		threshold = ctx.GlobalUint64(thresholdFlag.Name)
+	)
+	if len(admins) == 0 {
+		utils.Fatalf("No oracle admins specified (--%s)", adminsFlag.Name)
+	}
+	if threshold == 0 || threshold > uint64(len(admins)) {
+		utils.Fatalf("Invalid signature threshold %d for %d admins", threshold, len(admins))
+	}
+	var (
+		client = ethclient.NewClient(newClient(ctx))
+		key    = getKey(ctx)
+	)
+	addr, tx, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(key.PrivateKey), client, admins,
+		big.NewInt(light.CHTFrequencyClient), big.NewInt(light.HelperTrieProcessConfirmations), new(big.Int).SetUint64(threshold))
+	if err != nil {
+		utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+	}
+	for i, admin := range admins {
+		fmt.Printf("Admin %d => %s\n", i+1, admin.Hex())
+	}
+	fmt.Printf("\nSignatures needed => %d\n", threshold)
+	fmt.Printf("\nOracle      => %s\n", addr.Hex())
+	fmt.Printf("Transaction => %s\n", tx.Hash().Hex())
+	return nil
+}
+
+// status fetches the admin list and the latest checkpoint from the oracle.
+func status(ctx *cli.Context) error {
+	oracle := newOracle(ctx, newClient(ctx))
+
+	admins, err := oracle.Contract().Admins(nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve admins: %v", err)
+	}
+	for i, admin := range admins {
+		fmt.Printf("Admin %d => %s\n", i+1, admin.Hex())
+	}
+	threshold, err := oracle.Contract().Threshold(nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve signature threshold: %v", err)
+	}
+	fmt.Printf("\nSignatures needed => %d\n", threshold)
+
+	cp, height, err := oracle.LatestCheckpoint(nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve latest checkpoint: %v", err)
+	}
+	if height == 0 {
+		fmt.Println("\nNo checkpoint registered yet")
+		return nil
+	}
+	fmt.Printf("\nCheckpoint (published at #%d) %d => %s\n", height, cp.SectionIndex, cp.Hash().Hex())
+	fmt.Printf("  Section head => %s\n", cp.SectionHead.Hex())
+	fmt.Printf("  CHT root     => %s\n", cp.CHTRoot.Hex())
+	fmt.Printf("  Bloom root   => %s\n", cp.BloomRoot.Hex())
+	return nil
+}
+
+// sign signs the locally generated checkpoint with an admin key.
+func sign(ctx *cli.Context) error {
+	var (
+		client = newClient(ctx)
+		oracle = newOracle(ctx, client)
+		cp     = getCheckpoint(ctx, client)
+		key    = getKey(ctx)
+	)
+	sig, err := crypto.Sign(checkpointoracle.SigningHash(oracle.ContractAddr(), cp).Bytes(), key.PrivateKey)
+	if err != nil {
+		utils.Fatalf("Failed to sign checkpoint: %v", err)
+	}
+	fmt.Printf("Checkpoint %d => %s\n", cp.SectionIndex, cp.Hash().Hex())
+	fmt.Printf("  Section head => %s\n", cp.SectionHead.Hex())
+	fmt.Printf("  CHT root     => %s\n", cp.CHTRoot.Hex())
+	fmt.Printf("  Bloom root   => %s\n", cp.BloomRoot.Hex())
+	fmt.Printf("\nSigner    => %s\n", key.Address.Hex())
+	fmt.Printf("Signature => %s\n", hexutil.Encode(sig))
+	return nil
+}
+
+// publish registers the locally generated checkpoint in the oracle along with
+// the collected admin signatures.
+func publish(ctx *cli.Context) error {
+	var (
+		client = newClient(ctx)
+		oracle = newOracle(ctx, client)
+		cp     = getCheckpoint(ctx, client)
+		sigs   = getSignatures(ctx)
+	)
+	// Sanity check the signatures before wasting gas on a failing transaction
+	admins, err := oracle.Contract().Admins(nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve admins: %v", err)
+	}
+	threshold, err := oracle.Contract().Threshold(nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve signature threshold: %v", err)
+	}
+	if uint64(len(sigs)) < threshold.Uint64() {
+		utils.Fatalf("Not enough signatures: have %d, want %d", len(sigs), threshold)
+	}
+	hash := checkpointoracle.SigningHash(oracle.ContractAddr(), cp)
+	for _, sig := range sigs {
+		signer, err := checkpointoracle.RecoverSigner(hash, sig)
+		if err != nil {
+			utils.Fatalf("Invalid checkpoint signature: %v", err)
+		}
+		if !isAdmin(admins, signer) {
+			utils.Fatalf("Checkpoint signed by non-admin %s", signer.Hex())
+		}
+	}
+	// Anchor the transaction to the current head to prevent replays on forks
+	head, err := ethclient.NewClient(client).HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve head header: %v", err)
+	}
+	key := getKey(ctx)
+	tx, err := oracle.RegisterCheckpoint(bind.NewKeyedTransactor(key.PrivateKey), cp, head.Number, head.Hash(), sigs)
+	if err != nil {
+		utils.Fatalf("Failed to register checkpoint: %v", err)
+	}
+	fmt.Printf("Checkpoint %d => %s\n", cp.SectionIndex, cp.Hash().Hex())
+	fmt.Printf("Transaction => %s\n", tx.Hash().Hex())
+	return nil
+}
+
+// isAdmin returns whether the address is in the admin list.
+func isAdmin(admins []common.Address, addr common.Address) bool {
+	for _, admin := range admins {
+		if admin == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of go-DEWH.
+//
+// go-DEWH is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-DEWH is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-DEWH. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+
+	"github.com/DEWH/go-DEWH/accounts/keystore"
+	"github.com/DEWH/go-DEWH/cmd/utils"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/console"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle"
+	"github.com/DEWH/go-DEWH/ethclient"
+	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// newClient creates a client with the specified remote URL.
+func newClient(ctx *cli.Context) *rpc.Client {
+	client, err := rpc.Dial(ctx.GlobalString(rpcFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to connect to the node: %v", err)
+	}
+	return client
+}
+
+// newOracle binds the checkpoint oracle contract specified on the command line
+// through the given client.
+func newOracle(ctx *cli.Context, client *rpc.Client) *checkpointoracle.CheckpointOracle {
+	addr := ctx.GlobalString(oracleFlag.Name)
+	if !common.IsHexAddress(addr) {
+		utils.Fatalf("Invalid checkpoint oracle address: %q", addr)
+	}
+	oracle, err := checkpointoracle.NewCheckpointOracle(common.HexToAddress(addr), ethclient.NewClient(client))
+	if err != nil {
+		utils.Fatalf("Failed to bind checkpoint oracle: %v", err)
+	}
+	return oracle
+}
+
+// getCheckpoint retrieves the checkpoint requested on the command line from the
+// connected LES server, defaulting to its latest local one.
+func getCheckpoint(ctx *cli.Context, client *rpc.Client) *params.TrustedCheckpoint {
+	var (
+		cp  = new(params.TrustedCheckpoint)
+		err error
+	)
+	if index := ctx.GlobalInt64(indexFlag.Name); index >= 0 {
+		err = client.CallContext(context.Background(), cp, "les_getCheckpoint", uint64(index))
+	} else {
+		err = client.CallContext(context.Background(), cp, "les_latestCheckpoint")
+	}
+	if err != nil {
+		utils.Fatalf("Failed to retrieve checkpoint: %v", err)
+	}
+	return cp
+}
+
+// getKey loads and unlocks the admin key specified on the command line.
+func getKey(ctx *cli.Context) *keystore.Key {
+	keyfile := ctx.GlobalString(keyFileFlag.Name)
+	if keyfile == "" {
+		utils.Fatalf("No admin keyfile specified (--%s)", keyFileFlag.Name)
+	}
+	keyjson, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to read the keyfile at '%s': %v", keyfile, err)
+	}
+	var password string
+	if path := ctx.GlobalString(passwordFileFlag.Name); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			utils.Fatalf("Failed to read password file '%s': %v", path, err)
+		}
+		password = strings.TrimRight(string(content), "\r\n")
+	} else {
+		password, err = console.Stdin.PromptPassword("Password: ")
+		if err != nil {
+			utils.Fatalf("Failed to read password: %v", err)
+		}
+	}
+	key, err := keystore.DEWHryptKey(keyjson, password)
+	if err != nil {
+		utils.Fatalf("Failed to unlock keyfile: %v", err)
+	}
+	return key
+}
+
+// getSignatures parses the admin signatures specified on the command line.
+func getSignatures(ctx *cli.Context) [][]byte {
+	var sigs [][]byte
+	for _, sig := range strings.Split(ctx.GlobalString(signaturesFlag.Name), ",") {
+		if sig = strings.TrimSpace(sig); sig == "" {
+			continue
+		}
+		blob, err := hexutil.DEWHode(sig)
+		if err != nil || len(blob) != 65 {
+			utils.Fatalf("Invalid checkpoint signature: %s", sig)
+		}
+		sigs = append(sigs, blob)
+	}
+	return sigs
+}
+
+// getAdmins parses the admin addresses specified on the command line.
+func getAdmins(ctx *cli.Context) []common.Address {
+	var admins []common.Address
+	for _, admin := range strings.Split(ctx.GlobalString(adminsFlag.Name), ",") {
+		if admin = strings.TrimSpace(admin); admin == "" {
+			continue
+		}
+		if !common.IsHexAddress(admin) {
+			utils.Fatalf("Invalid admin address: %q", admin)
+		}
+		admins = append(admins, common.HexToAddress(admin))
+	}
+	return admins
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of go-DEWH.
+//
+// go-DEWH is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-DEWH is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-DEWH. If not, see <http://www.gnu.org/licenses/>.
+
+// checkpoint-admin is a utility that can be used to deploy a checkpoint oracle
+// contract, query checkpoint information and sign and publish new checkpoints
+// into the oracle.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DEWH/go-DEWH/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var app *cli.App
+
+func init() {
+	app = utils.NewApp(gitCommit, "a checkpoint oracle admin tool")
+	app.Commands = []cli.Command{
+		commandDeploy,
+		commandStatus,
+		commandSign,
+		commandPublish,
+	}
+	app.Flags = []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+		indexFlag,
+		keyFileFlag,
+		passwordFileFlag,
+		signaturesFlag,
+		adminsFlag,
+		thresholdFlag,
+	}
+}
+
+// Commonly used command line flags.
+var (
+	rpcFlag = cli.StringFlag{
+		Name:  "rpc",
+		Value: "http://localhost:8545",
+		Usage: "The rpc endpoint of a local LES server (with the les API enabled)",
+	}
+	oracleFlag = cli.StringFlag{
+		Name:  "oracle",
+		Usage: "Address of the checkpoint oracle contract",
+	}
+	indexFlag = cli.Int64Flag{
+		Name:  "index",
+		Value: -1,
+		Usage: "Section index of the checkpoint (defaults to the latest local one)",
+	}
+	keyFileFlag = cli.StringFlag{
+		Name:  "keyfile",
+		Usage: "The keyfile of an oracle admin",
+	}
+	passwordFileFlag = cli.StringFlag{
+		Name:  "passwordfile",
+		Usage: "The file that contains the password for the keyfile",
+	}
+	signaturesFlag = cli.StringFlag{
+		Name:  "signatures",
+		Usage: "Comma separated list of admin signatures of the checkpoint",
+	}
+	adminsFlag = cli.StringFlag{
+		Name:  "admins",
+		Usage: "Comma separated list of the admin addresses of a new oracle",
+	}
+	thresholdFlag = cli.Uint64Flag{
+		Name:  "threshold",
+		Value: 1,
+		Usage: "Number of admin signatures a new oracle requires for a checkpoint",
+	}
+)
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
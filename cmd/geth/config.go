@@ -30,8 +30,10 @@ import (
 	"github.com/DEWH/go-DEWH/cmd/utils"
 	"github.com/DEWH/go-DEWH/dashboard"
 	"github.com/DEWH/go-DEWH/eth"
+	"github.com/DEWH/go-DEWH/ethgrpc"
 	"github.com/DEWH/go-DEWH/node"
 	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/stratum"
 	whisper "github.com/DEWH/go-DEWH/whisper/whisperv6"
 	"github.com/naoina/toml"
 )
@@ -74,12 +76,24 @@ type ethstatsConfig struct {
 	URL string `toml:",omitempty"`
 }
 
+type grpcConfig struct {
+	Enabled bool `toml:",omitempty"`
+	Config  ethgrpc.Config
+}
+
+type stratumConfig struct {
+	Enabled bool `toml:",omitempty"`
+	Config  stratum.Config
+}
+
 type gethConfig struct {
 	Eth       eth.Config
 	Shh       whisper.Config
 	Node      node.Config
 	Ethstats  ethstatsConfig
 	Dashboard dashboard.Config
+	GRPC      grpcConfig
+	Stratum   stratumConfig
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -114,6 +128,8 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 		Shh:       whisper.DefaultConfig,
 		Node:      defaultNoDEWHonfig(),
 		Dashboard: dashboard.DefaultConfig,
+		GRPC:      grpcConfig{Config: ethgrpc.Config{ListenAddr: utils.GRPCListenAddrFlag.Value}},
+		Stratum:   stratumConfig{Config: stratum.DefaultConfig},
 	}
 
 	// Load config file.
@@ -134,6 +150,16 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.EthStatsURLFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(utils.GRPCEnabledFlag.Name) {
+		cfg.GRPC.Enabled = ctx.GlobalBool(utils.GRPCEnabledFlag.Name)
+	}
+	utils.SetGRPCConfig(ctx, &cfg.GRPC.Config)
+
+	if ctx.GlobalIsSet(utils.StratumEnabledFlag.Name) {
+		cfg.Stratum.Enabled = ctx.GlobalBool(utils.StratumEnabledFlag.Name)
+	}
+	utils.SetStratumConfig(ctx, &cfg.Stratum.Config)
+
 	utils.SetShhConfig(ctx, stack, &cfg.Shh)
 	utils.SetDashboardConfig(ctx, &cfg.Dashboard)
 
@@ -175,6 +201,14 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
 	}
+	// Add the gRPC endpoint if requested.
+	if cfg.GRPC.Enabled {
+		utils.RegisterGRPCService(stack, cfg.GRPC.Config)
+	}
+	// Add the Stratum mining server if requested.
+	if cfg.Stratum.Enabled {
+		utils.RegisterStratumService(stack, cfg.Stratum.Config)
+	}
 	return stack
 }
 
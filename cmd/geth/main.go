@@ -36,6 +36,7 @@ import (
 	"github.com/DEWH/go-DEWH/eth"
 	"github.com/DEWH/go-DEWH/ethclient"
 	"github.com/DEWH/go-DEWH/internal/debug"
+	"github.com/DEWH/go-DEWH/les"
 	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/metrics"
 	"github.com/DEWH/go-DEWH/node"
@@ -75,6 +76,9 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolRemoteJournalFlag,
+		utils.TxPoolRemoteJournalLimitFlag,
+		utils.TxPoolRemoteJournalAgeFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
@@ -82,12 +86,16 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateLifetimeFlag,
+		utils.TxPoolPrioritySendersFlag,
+		utils.TxPoolNoGossipSendersFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
+		utils.LightOracleFlag,
 		utils.LightKDFFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
@@ -101,6 +109,13 @@ var (
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
 		utils.TargetGasLimitFlag,
+		utils.MinerGasCeilFlag,
+		utils.MinerNotifyFlag,
+		utils.StratumEnabledFlag,
+		utils.StratumListenAddrFlag,
+		utils.StratumDifficultyFlag,
+		utils.StratumPasswordFlag,
+		utils.CliqueAutoDropFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -121,7 +136,12 @@ var (
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
+		utils.GpoModeFlag,
+		utils.GpoConfirmBlocksFlag,
 		utils.ExtraDataFlag,
+		utils.MinerTxOrderingFlag,
+		utils.MinerPrioritySendersFlag,
+		utils.MinerRecommitIntervalFlag,
 		configFileFlag,
 	}
 
@@ -135,6 +155,10 @@ var (
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.GRPCEnabledFlag,
+		utils.GRPCListenAddrFlag,
+		utils.GRPCCertFlag,
+		utils.GRPCKeyFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 	}
@@ -269,6 +293,18 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	// Start up the node itself
 	utils.StartNode(stack)
 
+	// Let a light client consult the checkpoint oracle through its own APIs
+	var lightDEWH *les.LightDEWH
+	if err := stack.Service(&lightDEWH); err == nil {
+		rpcClient, err := stack.Attach()
+		if err != nil {
+			utils.Fatalf("Failed to attach to self: %v", err)
+		}
+		if err := lightDEWH.SetContractBackend(ethclient.NewClient(rpcClient)); err != nil {
+			utils.Fatalf("Failed to start checkpoint oracle: %v", err)
+		}
+	}
+
 	// Unlock any account specifically requested
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 
@@ -80,6 +80,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
+			utils.LightOracleFlag,
 			utils.LightKDFFlag,
 		},
 	},
@@ -117,6 +118,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
+			utils.TxPoolRemoteJournalFlag,
+			utils.TxPoolRemoteJournalLimitFlag,
+			utils.TxPoolRemoteJournalAgeFlag,
 			utils.TxPoolPriceLimitFlag,
 			utils.TxPoolPriceBumpFlag,
 			utils.TxPoolAccountSlotsFlag,
@@ -124,6 +128,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolPrivateLifetimeFlag,
+			utils.TxPoolPrioritySendersFlag,
+			utils.TxPoolNoGossipSendersFlag,
 		},
 	},
 	{
@@ -154,6 +161,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.GRPCEnabledFlag,
+			utils.GRPCListenAddrFlag,
+			utils.GRPCCertFlag,
+			utils.GRPCKeyFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
@@ -187,8 +198,17 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerThreadsFlag,
 			utils.EtherbaseFlag,
 			utils.TargetGasLimitFlag,
+			utils.MinerGasCeilFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.MinerTxOrderingFlag,
+			utils.MinerRecommitIntervalFlag,
+			utils.MinerNotifyFlag,
+			utils.StratumEnabledFlag,
+			utils.StratumListenAddrFlag,
+			utils.StratumDifficultyFlag,
+			utils.StratumPasswordFlag,
+			utils.CliqueAutoDropFlag,
 		},
 	},
 	{
@@ -196,6 +216,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.GpoBlocksFlag,
 			utils.GpoPercentileFlag,
+			utils.GpoModeFlag,
+			utils.GpoConfirmBlocksFlag,
 		},
 	},
 	{
@@ -232,6 +254,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.FastSyncFlag,
 			utils.LightModeFlag,
+			utils.MinerPrioritySendersFlag,
 		},
 	},
 	{
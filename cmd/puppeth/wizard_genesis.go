@@ -64,8 +64,9 @@ func (w *wizard) makeGenesis() {
 		// In the case of clique, configure the consensus parameters
 		genesis.Difficulty = big.NewInt(1)
 		genesis.Config.Clique = &params.CliqueConfig{
-			Period: 15,
-			Epoch:  30000,
+			Period:         15,
+			Epoch:          30000,
+			ParamVoteBlock: big.NewInt(0),
 		}
 		fmt.Println()
 		fmt.Println("How many seconds should blocks take? (default = 15)")
@@ -163,6 +164,36 @@ func (w *wizard) manageGenesis() {
 		fmt.Printf("Which block should Byzantium come into effect? (default = %v)\n", w.conf.Genesis.Config.ByzantiumBlock)
 		w.conf.Genesis.Config.ByzantiumBlock = w.readDefaultBigInt(w.conf.Genesis.Config.ByzantiumBlock)
 
+		fmt.Println()
+		byTime := w.conf.Genesis.Config.ConstantinopleTime != nil
+		if byTime {
+			fmt.Println("Should Constantinople be scheduled by block number or timestamp? (default = timestamp)")
+		} else {
+			fmt.Println("Should Constantinople be scheduled by block number or timestamp? (default = block number)")
+		}
+		fmt.Println(" 1. Block number")
+		fmt.Println(" 2. Timestamp")
+		switch w.read() {
+		case "1":
+			byTime = false
+		case "2":
+			byTime = true
+		}
+		if byTime {
+			fmt.Println()
+			fmt.Printf("Which timestamp should Constantinople come into effect? (default = %v)\n", w.conf.Genesis.Config.ConstantinopleTime)
+			w.conf.Genesis.Config.ConstantinopleTime = w.readDefaultBigInt(w.conf.Genesis.Config.ConstantinopleTime)
+			w.conf.Genesis.Config.ConstantinopleBlock = nil
+		} else {
+			fmt.Println()
+			fmt.Printf("Which block should Constantinople come into effect? (default = %v)\n", w.conf.Genesis.Config.ConstantinopleBlock)
+			w.conf.Genesis.Config.ConstantinopleBlock = w.readDefaultBigInt(w.conf.Genesis.Config.ConstantinopleBlock)
+			w.conf.Genesis.Config.ConstantinopleTime = nil
+		}
+		if err := w.conf.Genesis.Config.Validate(); err != nil {
+			log.Error("Invalid chain configuration", "err", err)
+		}
+
 		out, _ := json.MarshalIndent(w.conf.Genesis.Config, "", "  ")
 		fmt.Printf("Chain configuration updated:\n\n%s\n", out)
 
@@ -35,7 +35,9 @@ import (
 	"github.com/DEWH/go-DEWH/common/fdlimit"
 	"github.com/DEWH/go-DEWH/consensus"
 	"github.com/DEWH/go-DEWH/consensus/clique"
+	"github.com/DEWH/go-DEWH/consensus/dpos"
 	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/consensus/istanbul"
 	"github.com/DEWH/go-DEWH/core"
 	"github.com/DEWH/go-DEWH/core/state"
 	"github.com/DEWH/go-DEWH/core/vm"
@@ -45,6 +47,7 @@ import (
 	"github.com/DEWH/go-DEWH/eth/downloader"
 	"github.com/DEWH/go-DEWH/eth/gasprice"
 	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/ethgrpc"
 	"github.com/DEWH/go-DEWH/ethstats"
 	"github.com/DEWH/go-DEWH/les"
 	"github.com/DEWH/go-DEWH/log"
@@ -57,6 +60,7 @@ import (
 	"github.com/DEWH/go-DEWH/p2p/nat"
 	"github.com/DEWH/go-DEWH/p2p/netutil"
 	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/stratum"
 	whisper "github.com/DEWH/go-DEWH/whisper/whisperv6"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -187,6 +191,10 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: eth.DefaultConfig.LightPeers,
 	}
+	LightOracleFlag = cli.StringFlag{
+		Name:  "lightoracle",
+		Usage: "Address of the checkpoint oracle contract consulted for trusted light client checkpoints",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -253,9 +261,24 @@ var (
 	}
 	TxPoolRejournalFlag = cli.DurationFlag{
 		Name:  "txpool.rejournal",
-		Usage: "Time interval to regenerate the local transaction journal",
+		Usage: "Time interval to regenerate the local and remote transaction journals",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for remote transactions to survive node restarts (disabled if empty)",
+		Value: core.DefaultTxPoolConfig.RemoteJournal,
+	}
+	TxPoolRemoteJournalLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.remotejournallimit",
+		Usage: "Maximum number of remote transactions to journal",
+		Value: core.DefaultTxPoolConfig.RemoteJournalLimit,
+	}
+	TxPoolRemoteJournalAgeFlag = cli.DurationFlag{
+		Name:  "txpool.remotejournalage",
+		Usage: "Maximum age of journaled remote transactions to reload on startup",
+		Value: core.DefaultTxPoolConfig.RemoteJournalAge,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -291,6 +314,19 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPrivateLifetimeFlag = cli.Uint64Flag{
+		Name:  "txpool.privatelifetime",
+		Usage: "Number of blocks private transactions are kept before being dropped",
+		Value: eth.DefaultConfig.TxPool.PrivateLifetime,
+	}
+	TxPoolPrioritySendersFlag = cli.StringFlag{
+		Name:  "txpool.prioritysenders",
+		Usage: "Comma separated list of senders mined first and exempt from the global slot limits",
+	}
+	TxPoolNoGossipSendersFlag = cli.StringFlag{
+		Name:  "txpool.nogossip",
+		Usage: "Comma separated list of senders whose transactions are never broadcast",
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -327,6 +363,36 @@ var (
 		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine",
 		Value: params.GenesisGasLimit,
 	}
+	MinerGasCeilFlag = cli.Uint64Flag{
+		Name:  "targetgasceil",
+		Usage: "Target gas ceiling sets the artificial gas ceiling for the blocks to mine (0 = no ceiling)",
+	}
+	MinerNotifyFlag = cli.StringFlag{
+		Name:  "miner.notify",
+		Usage: "Comma separated HTTP URL list to notify of new work packages",
+	}
+	StratumEnabledFlag = cli.BoolFlag{
+		Name:  "stratum",
+		Usage: "Enable the Stratum mining server (ethash only)",
+	}
+	StratumListenAddrFlag = cli.StringFlag{
+		Name:  "stratum.addr",
+		Usage: "Stratum server listening address",
+		Value: stratum.DefaultConfig.ListenAddr,
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.difficulty",
+		Usage: "Share difficulty handed out to Stratum miners, in hashes",
+		Value: stratum.DefaultConfig.Difficulty,
+	}
+	StratumPasswordFlag = cli.StringFlag{
+		Name:  "stratum.password",
+		Usage: "Password the Stratum workers must authorize with",
+	}
+	CliqueAutoDropFlag = cli.Uint64Flag{
+		Name:  "clique.autodrop",
+		Usage: "Number of in-turn blocks a clique signer may miss per epoch before proposing its removal (0 = disabled)",
+	}
 	EtherbaseFlag = cli.StringFlag{
 		Name:  "etherbase",
 		Usage: "Public address for block mining rewards (default = first account created)",
@@ -341,6 +407,20 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerTxOrderingFlag = cli.StringFlag{
+		Name:  "txordering",
+		Usage: `Order of transactions in mined blocks ("price", "fifo" or "fair")`,
+		Value: "price",
+	}
+	MinerPrioritySendersFlag = cli.StringFlag{
+		Name:  "txpriority",
+		Usage: "Comma separated list of senders whose transactions are mined first (deprecated, use --txpool.prioritysenders)",
+	}
+	MinerRecommitIntervalFlag = cli.DurationFlag{
+		Name:  "minerrecommit",
+		Usage: "Time interval to rebuild the block being mined with new transactions (0 = disabled)",
+		Value: eth.DefaultConfig.MinerRecommit,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -432,6 +512,23 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	GRPCEnabledFlag = cli.BoolFlag{
+		Name:  "grpc",
+		Usage: "Enable the gRPC server (requires --grpccert and --grpckey)",
+	}
+	GRPCListenAddrFlag = cli.StringFlag{
+		Name:  "grpcaddr",
+		Usage: "gRPC server listening address",
+		Value: "localhost:8549",
+	}
+	GRPCCertFlag = cli.StringFlag{
+		Name:  "grpccert",
+		Usage: "TLS certificate file of the gRPC server",
+	}
+	GRPCKeyFlag = cli.StringFlag{
+		Name:  "grpckey",
+		Usage: "TLS private key file of the gRPC server",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -516,6 +613,16 @@ var (
 		Usage: "Suggested gas price is the given percentile of a set of recent transaction gas prices",
 		Value: eth.DefaultConfig.GPO.Percentile,
 	}
+	GpoModeFlag = cli.StringFlag{
+		Name:  "gpomode",
+		Usage: `Gas price suggestion mode ("lowest" or "weighted")`,
+		Value: gasprice.ModeLowest,
+	}
+	GpoConfirmBlocksFlag = cli.IntFlag{
+		Name:  "gpoconfirmblocks",
+		Usage: "Number of blocks a transaction should be included within (weighted mode)",
+		Value: eth.DefaultConfig.GPO.ConfirmBlocks,
+	}
 	WhisperEnabledFlag = cli.BoolFlag{
 		Name:  "shh",
 		Usage: "Enable Whisper",
@@ -941,6 +1048,12 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	if ctx.GlobalIsSet(GpoPercentileFlag.Name) {
 		cfg.Percentile = ctx.GlobalInt(GpoPercentileFlag.Name)
 	}
+	if ctx.GlobalIsSet(GpoModeFlag.Name) {
+		cfg.Mode = ctx.GlobalString(GpoModeFlag.Name)
+	}
+	if ctx.GlobalIsSet(GpoConfirmBlocksFlag.Name) {
+		cfg.ConfirmBlocks = ctx.GlobalInt(GpoConfirmBlocksFlag.Name)
+	}
 }
 
 func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
@@ -953,6 +1066,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalLimitFlag.Name) {
+		cfg.RemoteJournalLimit = ctx.GlobalUint64(TxPoolRemoteJournalLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalAgeFlag.Name) {
+		cfg.RemoteJournalAge = ctx.GlobalDuration(TxPoolRemoteJournalAgeFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -974,6 +1096,32 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPrivateLifetimeFlag.Name) {
+		cfg.PrivateLifetime = ctx.GlobalUint64(TxPoolPrivateLifetimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerPrioritySendersFlag.Name) {
+		log.Warn("The --txpriority flag is deprecated and will be removed, use --txpool.prioritysenders")
+		cfg.PrioritySenders = makeSenderList(ctx, MinerPrioritySendersFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPrioritySendersFlag.Name) {
+		cfg.PrioritySenders = makeSenderList(ctx, TxPoolPrioritySendersFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolNoGossipSendersFlag.Name) {
+		cfg.NoGossipSenders = makeSenderList(ctx, TxPoolNoGossipSendersFlag.Name)
+	}
+}
+
+// makeSenderList parses a comma separated list of sender accounts from a flag.
+func makeSenderList(ctx *cli.Context, name string) []common.Address {
+	var senders []common.Address
+	for _, account := range strings.Split(ctx.GlobalString(name), ",") {
+		if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+			Fatalf("Invalid sender for --%s: %s", name, trimmed)
+		} else {
+			senders = append(senders, common.HexToAddress(trimmed))
+		}
+	}
+	return senders
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -1073,6 +1221,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightOracleFlag.Name) {
+		oracle := ctx.GlobalString(LightOracleFlag.Name)
+		if !common.IsHexAddress(oracle) {
+			Fatalf("Invalid checkpoint oracle address: %s", oracle)
+		}
+		cfg.LightOracle = common.HexToAddress(oracle)
+	}
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1099,6 +1254,24 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(ExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.GlobalString(ExtraDataFlag.Name))
 	}
+	if ctx.GlobalIsSet(TargetGasLimitFlag.Name) {
+		cfg.MinerGasFloor = ctx.GlobalUint64(TargetGasLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerGasCeilFlag.Name) {
+		cfg.MinerGasCeil = ctx.GlobalUint64(MinerGasCeilFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerNotifyFlag.Name) {
+		cfg.MinerNotify = strings.Split(ctx.GlobalString(MinerNotifyFlag.Name), ",")
+	}
+	if ctx.GlobalIsSet(CliqueAutoDropFlag.Name) {
+		cfg.CliqueAutoDrop = ctx.GlobalUint64(CliqueAutoDropFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerRecommitIntervalFlag.Name) {
+		cfg.MinerRecommit = ctx.GlobalDuration(MinerRecommitIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerTxOrderingFlag.Name) {
+		cfg.MinerTxOrdering = ctx.GlobalString(MinerTxOrderingFlag.Name)
+	}
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
@@ -1214,9 +1387,63 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// SetGRPCConfig applies gRPC related command line flags to the config.
+func SetGRPCConfig(ctx *cli.Context, cfg *ethgrpc.Config) {
+	if ctx.GlobalIsSet(GRPCListenAddrFlag.Name) {
+		cfg.ListenAddr = ctx.GlobalString(GRPCListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(GRPCCertFlag.Name) {
+		cfg.CertFile = ctx.GlobalString(GRPCCertFlag.Name)
+	}
+	if ctx.GlobalIsSet(GRPCKeyFlag.Name) {
+		cfg.KeyFile = ctx.GlobalString(GRPCKeyFlag.Name)
+	}
+}
+
+// SetStratumConfig applies Stratum server related command line flags to the config.
+func SetStratumConfig(ctx *cli.Context, cfg *stratum.Config) {
+	if ctx.GlobalIsSet(StratumListenAddrFlag.Name) {
+		cfg.ListenAddr = ctx.GlobalString(StratumListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDifficultyFlag.Name) {
+		cfg.Difficulty = ctx.GlobalUint64(StratumDifficultyFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumPasswordFlag.Name) {
+		cfg.Password = ctx.GlobalString(StratumPasswordFlag.Name)
+	}
+}
+
+// RegisterStratumService configures the Stratum mining server and adds it to the
+// given node.
+func RegisterStratumService(stack *node.Node, cfg stratum.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.DEWH
+		ctx.Service(&ethServ)
+
+		return stratum.New(cfg, ethServ)
+	}); err != nil {
+		Fatalf("Failed to register the Stratum service: %v", err)
+	}
+}
+
+// RegisterGRPCService configures the gRPC endpoint and adds it to the given node.
+func RegisterGRPCService(stack *node.Node, cfg ethgrpc.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Retrieve both eth and les services
+		var ethServ *eth.DEWH
+		ctx.Service(&ethServ)
+
+		var lesServ *les.LightDEWH
+		ctx.Service(&lesServ)
+
+		return ethgrpc.New(cfg, ethServ, lesServ)
+	}); err != nil {
+		Fatalf("Failed to register the gRPC service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
-	// TODO(fjl): move target gas limit into config
 	params.TargetGasLimit = ctx.GlobalUint64(TargetGasLimitFlag.Name)
 }
 
@@ -1283,6 +1510,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var engine consensus.Engine
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
+	} else if config.Istanbul != nil {
+		engine = istanbul.New(config.Istanbul, chainDb)
+	} else if config.Dpos != nil {
+		engine = dpos.New(config.Dpos, chainDb)
 	} else {
 		engine = ethash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -1293,7 +1524,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 				DatasetDir:     stack.ResolvePath(eth.DefaultConfig.Ethash.DatasetDir),
 				DatasetsInMem:  eth.DefaultConfig.Ethash.DatasetsInMem,
 				DatasetsOnDisk: eth.DefaultConfig.Ethash.DatasetsOnDisk,
-			})
+			}, nil)
 		}
 	}
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
@@ -17,6 +17,8 @@
 package clique
 
 import (
+	"fmt"
+
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/consensus"
 	"github.com/DEWH/go-DEWH/core/types"
@@ -88,6 +90,34 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	return snap.signers(), nil
 }
 
+// GetSignerStatus retrieves the sealing record of every authorized signer in the
+// epoch of the specified block.
+func (api *API) GetSignerStatus(number *rpc.BlockNumber) (map[common.Address]*SignerStatus, error) {
+	// Retrieve the requested block number (or current if none requested)
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	// Ensure we have an actually valid block and return the records from its snapshot
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	status := make(map[common.Address]*SignerStatus)
+	for signer := range snap.Signers {
+		status[signer] = new(SignerStatus)
+		if record, ok := snap.Status[signer]; ok {
+			*status[signer] = *record
+		}
+	}
+	return status, nil
+}
+
 // Proposals returns the current proposals the node tries to uphold and vote on.
 func (api *API) Proposals() map[common.Address]bool {
 	api.clique.lock.RLock()
@@ -117,3 +147,57 @@ func (api *API) Discard(address common.Address) {
 
 	delete(api.clique.proposals, address)
 }
+
+// ParamProposals returns the current chain parameter changes the node tries to
+// uphold and vote on.
+func (api *API) ParamProposals() map[Param]uint64 {
+	api.clique.lock.RLock()
+	defer api.clique.lock.RUnlock()
+
+	proposals := make(map[Param]uint64)
+	for param, value := range api.clique.paramProposals {
+		proposals[param] = value
+	}
+	return proposals
+}
+
+// ProposeParam injects a new chain parameter change that the signer will attempt
+// to push through. Once a majority of the signers agree, the new value becomes
+// effective at the next epoch checkpoint. Gas limit bounds are rejected if they
+// would invert the range, counting in the other bound the signer pushes for.
+func (api *API) ProposeParam(param Param, value uint64) error {
+	snap, err := api.GetSnapshot(nil)
+	if err != nil {
+		return err
+	}
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	if param == ParamGasLimitMin || param == ParamGasLimitMax {
+		bounds := map[Param]uint64{
+			ParamGasLimitMin: snap.pendingParam(ParamGasLimitMin),
+			ParamGasLimitMax: snap.pendingParam(ParamGasLimitMax),
+		}
+		for bound := range bounds {
+			if proposal, ok := api.clique.paramProposals[bound]; ok {
+				bounds[bound] = proposal
+			}
+		}
+		bounds[param] = value
+
+		if !validGasLimitBounds(bounds[ParamGasLimitMin], bounds[ParamGasLimitMax]) {
+			return fmt.Errorf("gas limit minimum %d above maximum %d", bounds[ParamGasLimitMin], bounds[ParamGasLimitMax])
+		}
+	}
+	api.clique.paramProposals[param] = value
+	return nil
+}
+
+// DiscardParam drops a currently running chain parameter proposal, stopping the
+// signer from casting further votes on it.
+func (api *API) DiscardParam(param Param) {
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	delete(api.clique.paramProposals, param)
+}
@@ -19,7 +19,9 @@ package clique
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"sync"
@@ -129,8 +131,84 @@ var (
 	// on an instant chain (0 second period). It's important to refuse these as the
 	// block reward is zero, so an empty block just bloats the chain... fast.
 	errWaitTransactions = errors.New("waiting for transactions")
+
+	// errInvalidParamVote is returned if a block's mix digest doesn't encode a
+	// valid chain parameter vote.
+	errInvalidParamVote = errors.New("invalid parameter vote")
+
+	// errGasLimitOutOfBounds is returned if a block's gas limit is outside of the
+	// bounds voted in by the signers.
+	errGasLimitOutOfBounds = errors.New("gas limit out of bounds")
+)
+
+// Param is a chain parameter that the signers may change by voting on it.
+type Param uint8
+
+const (
+	ParamPeriod      Param = iota + 1 // Number of seconds between blocks
+	ParamGasLimitMin                  // Minimum gas limit of blocks (0 = unbounded)
+	ParamGasLimitMax                  // Maximum gas limit of blocks (0 = unbounded)
 )
 
+var paramNames = map[Param]string{
+	ParamPeriod:      "period",
+	ParamGasLimitMin: "gasLimitMin",
+	ParamGasLimitMax: "gasLimitMax",
+}
+
+// String implements the stringer interface.
+func (p Param) String() string {
+	if name, ok := paramNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(p))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p Param) MarshalText() ([]byte, error) {
+	if _, ok := paramNames[p]; !ok {
+		return nil, fmt.Errorf("unknown chain parameter %d", uint8(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Param) UnmarshalText(text []byte) error {
+	for param, name := range paramNames {
+		if name == string(text) {
+			*p = param
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown chain parameter %q", text)
+}
+
+// paramVote extracts the chain parameter vote from the mix digest of a header.
+// The first byte of the digest identifies the parameter and the last eight the
+// proposed value, all other bytes must be zero. A zero parameter means no vote.
+func paramVote(header *types.Header) (Param, uint64, error) {
+	if header.MixDigest == (common.Hash{}) {
+		return 0, 0, nil
+	}
+	param := Param(header.MixDigest[0])
+	if _, ok := paramNames[param]; !ok {
+		return 0, 0, errInvalidParamVote
+	}
+	for _, b := range header.MixDigest[1 : common.HashLength-8] {
+		if b != 0 {
+			return 0, 0, errInvalidParamVote
+		}
+	}
+	return param, binary.BigEndian.Uint64(header.MixDigest[common.HashLength-8:]), nil
+}
+
+// encodeParamVote creates the mix digest casting a chain parameter vote.
+func encodeParamVote(param Param, value uint64) (digest common.Hash) {
+	digest[0] = byte(param)
+	binary.BigEndian.PutUint64(digest[common.HashLength-8:], value)
+	return digest
+}
+
 // SignerFn is a signer callback function to request a hash to be signed by a
 // backing account.
 type SignerFn func(accounts.Account, []byte) ([]byte, error)
@@ -200,7 +278,11 @@ type Clique struct {
 	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
 	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
 
-	proposals map[common.Address]bool // Current list of proposals we are pushing
+	proposals     map[common.Address]bool     // Current list of proposals we are pushing
+	autoProposals map[common.Address]struct{} // Signers we automatically propose dropping for missing blocks
+	autoDrop      uint64                      // Missed blocks in an epoch after which to propose dropping a signer (0 = off)
+
+	paramProposals map[Param]uint64 // Current list of chain parameter changes we are pushing
 
 	signer common.Address // DEWH address of the signing key
 	signFn SignerFn       // Signer function to authorize hashes with
@@ -225,6 +307,9 @@ func New(config *params.CliqueConfig, db ethdb.Database) *Clique {
 		recents:    recents,
 		signatures: signatures,
 		proposals:  make(map[common.Address]bool),
+
+		autoProposals:  make(map[common.Address]struct{}),
+		paramProposals: make(map[Param]uint64),
 	}
 }
 
@@ -301,10 +386,14 @@ func (c *Clique) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	if checkpoint && signersBytes%common.AddressLength != 0 {
 		return errInvalidCheckpointSigners
 	}
-	// Ensure that the mix digest is zero as we don't have fork protection currently
-	if header.MixDigest != (common.Hash{}) {
+	// Ensure that the mix digest is either zero or a parameter vote, zeroes enforced on checkpoints
+	// and before parameter votes are enabled
+	if (checkpoint || !c.config.IsParamVote(header.Number)) && header.MixDigest != (common.Hash{}) {
 		return errInvalidMixDigest
 	}
+	if _, _, err := paramVote(header); err != nil {
+		return err
+	}
 	// Ensure that the block doesn't contain any uncles which are meaningless in PoA
 	if header.UncleHash != uncleHash {
 		return errInvalidUncleHash
@@ -343,14 +432,21 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainReader, header *type
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
-		return ErrInvalidTimestamp
-	}
 	// Retrieve the snapshot needed to verify this header and cache it
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
 	if err != nil {
 		return err
 	}
+	if parent.Time.Uint64()+snap.param(ParamPeriod) > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	// Ensure that the gas limit is within the bounds voted in by the signers
+	if min := snap.param(ParamGasLimitMin); min != 0 && header.GasLimit < min {
+		return errGasLimitOutOfBounds
+	}
+	if max := snap.param(ParamGasLimitMax); max != 0 && header.GasLimit > max {
+		return errGasLimitOutOfBounds
+	}
 	// If the block is a checkpoint block, verify the signer list
 	if number%c.config.Epoch == 0 {
 		signers := make([]byte, len(snap.Signers)*common.AddressLength)
@@ -507,6 +603,7 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	// If the block isn't a checkpoint, cast a random vote (good enough for now)
 	header.Coinbase = common.Address{}
 	header.Nonce = types.BlockNonce{}
+	header.MixDigest = common.Hash{}
 
 	number := header.Number.Uint64()
 	// Assemble the voting snapshot to check which votes make sense
@@ -515,11 +612,21 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 		return err
 	}
 	if number%c.config.Epoch != 0 {
+		c.proposeDrops(snap)
+
 		c.lock.RLock()
 
-		// Gather all the proposals that make sense voting on
-		addresses := make([]common.Address, 0, len(c.proposals))
+		// Gather all the proposals that make sense voting on, the ones of the user
+		// taking precedence over the automatic drops
+		proposals := make(map[common.Address]bool, len(c.proposals)+len(c.autoProposals))
+		for address := range c.autoProposals {
+			proposals[address] = false
+		}
 		for address, authorize := range c.proposals {
+			proposals[address] = authorize
+		}
+		addresses := make([]common.Address, 0, len(proposals))
+		for address, authorize := range proposals {
 			if snap.validVote(address, authorize) {
 				addresses = append(addresses, address)
 			}
@@ -527,12 +634,23 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 		// If there's pending proposals, cast a vote on them
 		if len(addresses) > 0 {
 			header.Coinbase = addresses[rand.Intn(len(addresses))]
-			if c.proposals[header.Coinbase] {
+			if proposals[header.Coinbase] {
 				copy(header.Nonce[:], nonceAuthVote)
 			} else {
 				copy(header.Nonce[:], nonceDropVote)
 			}
 		}
+		// Cast a vote on a chain parameter too if there are pending changes
+		params := make([]Param, 0, len(c.paramProposals))
+		for param, value := range c.paramProposals {
+			if c.config.IsParamVote(header.Number) && snap.validParamVote(param, value) {
+				params = append(params, param)
+			}
+		}
+		if len(params) > 0 {
+			param := params[rand.Intn(len(params))]
+			header.MixDigest = encodeParamVote(param, c.paramProposals[param])
+		}
 		c.lock.RUnlock()
 	}
 	// Set the correct difficulty
@@ -551,21 +669,79 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	}
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 
-	// Mix digest is reserved for now, set to empty
-	header.MixDigest = common.Hash{}
+	// Keep the gas limit within the bounds voted in by the signers
+	if min := snap.param(ParamGasLimitMin); min != 0 && header.GasLimit < min {
+		header.GasLimit = min
+	}
+	if max := snap.param(ParamGasLimitMax); max != 0 && header.GasLimit > max {
+		header.GasLimit = max
+	}
 
 	// Ensure the timestamp has the correct delay
 	parent := chain.GetHeader(header.ParentHash, number-1)
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(c.config.Period))
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(snap.param(ParamPeriod)))
 	if header.Time.Int64() < time.Now().Unix() {
 		header.Time = big.NewInt(time.Now().Unix())
 	}
 	return nil
 }
 
+// proposeDrops proposes removing the signers that missed more in-turn blocks
+// in the current epoch than the auto-drop threshold allows, and withdraws the
+// automatic proposals of signers back within it, e.g. as the sealing records
+// are reset at the epoch checkpoint. Proposals of the user are left alone.
+func (c *Clique) proposeDrops(snap *Snapshot) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for signer := range c.autoProposals {
+		_, authorized := snap.Signers[signer]
+		if status := snap.Status[signer]; c.autoDrop == 0 || !authorized || status == nil || status.Missed <= c.autoDrop {
+			log.Info("Withdrawing proposal to drop signer", "signer", signer)
+			delete(c.autoProposals, signer)
+		}
+	}
+	if c.autoDrop == 0 {
+		return
+	}
+	for signer, status := range snap.Status {
+		if signer == c.signer || status.Missed <= c.autoDrop {
+			continue
+		}
+		if _, ok := c.autoProposals[signer]; ok {
+			continue
+		}
+		if _, ok := snap.Signers[signer]; !ok {
+			continue
+		}
+		log.Info("Proposing to drop unresponsive signer", "signer", signer, "missed", status.Missed, "threshold", c.autoDrop)
+		c.autoProposals[signer] = struct{}{}
+	}
+}
+
+// SetAutoDrop sets the number of in-turn blocks a signer may miss within an
+// epoch before the local signer proposes to drop it. Zero disables automatic
+// proposals.
+func (c *Clique) SetAutoDrop(threshold uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.autoDrop = threshold
+}
+
+// Period returns the minimum number of seconds between the given block and its
+// child, as voted in by the signers.
+func (c *Clique) Period(chain consensus.ChainReader, header *types.Header) (uint64, error) {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return 0, err
+	}
+	return snap.param(ParamPeriod), nil
+}
+
 // Finalize implements consensus.Engine, ensuring no uncles are set, nor block
 // rewards given, and returns the final block.
 func (c *Clique) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
@@ -597,10 +773,6 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	if number == 0 {
 		return nil, errUnknownBlock
 	}
-	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
-	if c.config.Period == 0 && len(block.Transactions()) == 0 {
-		return nil, errWaitTransactions
-	}
 	// Don't hold the signer fields for the entire sealing procedure
 	c.lock.RLock()
 	signer, signFn := c.signer, c.signFn
@@ -611,6 +783,10 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	if err != nil {
 		return nil, err
 	}
+	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
+	if snap.param(ParamPeriod) == 0 && len(block.Transactions()) == 0 {
+		return nil, errWaitTransactions
+	}
 	if _, authorized := snap.Signers[signer]; !authorized {
 		return nil, errUnauthorized
 	}
@@ -44,6 +44,22 @@ type Tally struct {
 	Votes     int  `json:"votes"`     // Number of votes until now wanting to pass the proposal
 }
 
+// ParamVote represents a single vote that an authorized signer made to change
+// one of the chain parameters.
+type ParamVote struct {
+	Signer common.Address `json:"signer"` // Authorized signer that cast this vote
+	Block  uint64         `json:"block"`  // Block number the vote was cast in (expire old votes)
+	Param  Param          `json:"param"`  // Chain parameter being voted on
+	Value  uint64         `json:"value"`  // New value proposed for the parameter
+}
+
+// SignerStatus is the sealing record of a signer within the current epoch.
+type SignerStatus struct {
+	InTurn    uint64 `json:"inTurn"`    // Number of blocks sealed while in-turn
+	OutOfTurn uint64 `json:"outOfTurn"` // Number of blocks sealed while out-of-turn
+	Missed    uint64 `json:"missed"`    // Number of in-turn blocks sealed by someone else
+}
+
 // Snapshot is the state of the authorization voting at a given point in time.
 type Snapshot struct {
 	config   *params.CliqueConfig // Consensus engine parameters to fine tune behavior
@@ -55,6 +71,12 @@ type Snapshot struct {
 	Recents map[uint64]common.Address   `json:"recents"` // Set of recent signers for spam protections
 	Votes   []*Vote                     `json:"votes"`   // List of votes cast in chronological order
 	Tally   map[common.Address]Tally    `json:"tally"`   // Current vote tally to avoid recalculating
+
+	Status map[common.Address]*SignerStatus `json:"status"` // Sealing record of the signers in the current epoch
+
+	Params     map[Param]uint64 `json:"params"`     // Chain parameters changed by votes since genesis
+	Pending    map[Param]uint64 `json:"pending"`    // Parameter changes agreed upon, effective at the next checkpoint
+	ParamVotes []*ParamVote     `json:"paramVotes"` // List of parameter votes cast in chronological order
 }
 
 // signers implements the sort interface to allow sorting a list of addresses
@@ -76,6 +98,9 @@ func newSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, number uin
 		Signers:  make(map[common.Address]struct{}),
 		Recents:  make(map[uint64]common.Address),
 		Tally:    make(map[common.Address]Tally),
+		Status:   make(map[common.Address]*SignerStatus),
+		Params:   make(map[Param]uint64),
+		Pending:  make(map[Param]uint64),
 	}
 	for _, signer := range signers {
 		snap.Signers[signer] = struct{}{}
@@ -96,6 +121,16 @@ func loadSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, db ethdb.
 	snap.config = config
 	snap.sigcache = sigcache
 
+	// Snapshots stored by older versions lack the sealing records
+	if snap.Status == nil {
+		snap.Status = make(map[common.Address]*SignerStatus)
+	}
+	if snap.Params == nil {
+		snap.Params = make(map[Param]uint64)
+	}
+	if snap.Pending == nil {
+		snap.Pending = make(map[Param]uint64)
+	}
 	return snap, nil
 }
 
@@ -119,6 +154,11 @@ func (s *Snapshot) copy() *Snapshot {
 		Recents:  make(map[uint64]common.Address),
 		Votes:    make([]*Vote, len(s.Votes)),
 		Tally:    make(map[common.Address]Tally),
+		Status:   make(map[common.Address]*SignerStatus),
+
+		Params:     make(map[Param]uint64),
+		Pending:    make(map[Param]uint64),
+		ParamVotes: make([]*ParamVote, len(s.ParamVotes)),
 	}
 	for signer := range s.Signers {
 		cpy.Signers[signer] = struct{}{}
@@ -129,7 +169,17 @@ func (s *Snapshot) copy() *Snapshot {
 	for address, tally := range s.Tally {
 		cpy.Tally[address] = tally
 	}
+	for signer, status := range s.Status {
+		cpy.Status[signer] = &SignerStatus{InTurn: status.InTurn, OutOfTurn: status.OutOfTurn, Missed: status.Missed}
+	}
+	for param, value := range s.Params {
+		cpy.Params[param] = value
+	}
+	for param, value := range s.Pending {
+		cpy.Pending[param] = value
+	}
 	copy(cpy.Votes, s.Votes)
+	copy(cpy.ParamVotes, s.ParamVotes)
 
 	return cpy
 }
@@ -203,6 +253,14 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		if number%s.config.Epoch == 0 {
 			snap.Votes = nil
 			snap.Tally = make(map[common.Address]Tally)
+			snap.Status = make(map[common.Address]*SignerStatus)
+
+			// Activate any agreed upon parameter changes and reset the votes
+			for param, value := range snap.Pending {
+				snap.Params[param] = value
+			}
+			snap.Pending = make(map[Param]uint64)
+			snap.ParamVotes = nil
 		}
 		// Delete the oldest signer from the recent list to allow it signing again
 		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
@@ -223,6 +281,13 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		}
 		snap.Recents[number] = signer
 
+		// Update the sealing record, blaming the in-turn signer for out-of-turn blocks
+		if snap.inturn(number, signer) {
+			snap.status(signer).InTurn++
+		} else {
+			snap.status(signer).OutOfTurn++
+			snap.status(snap.signers()[number%uint64(len(snap.Signers))]).Missed++
+		}
 		// Header authorized, discard any previous votes from the signer
 		for i, vote := range snap.Votes {
 			if vote.Signer == signer && vote.Address == header.Coinbase {
@@ -258,12 +323,19 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 				snap.Signers[header.Coinbase] = struct{}{}
 			} else {
 				delete(snap.Signers, header.Coinbase)
+				delete(snap.Status, header.Coinbase)
 
 				// Signer list shrunk, delete any leftover recent caches
 				if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
 					delete(snap.Recents, number-limit)
 				}
 				// Discard any previous votes the deauthorized signer cast
+				for i := 0; i < len(snap.ParamVotes); i++ {
+					if snap.ParamVotes[i].Signer == header.Coinbase {
+						snap.ParamVotes = append(snap.ParamVotes[:i], snap.ParamVotes[i+1:]...)
+						i--
+					}
+				}
 				for i := 0; i < len(snap.Votes); i++ {
 					if snap.Votes[i].Signer == header.Coinbase {
 						// Uncast the vote from the cached tally
@@ -285,6 +357,16 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 			}
 			delete(snap.Tally, header.Coinbase)
 		}
+		// Tally up any parameter vote from the signer, once enabled
+		if s.config.IsParamVote(header.Number) {
+			param, value, err := paramVote(header)
+			if err != nil {
+				return nil, err
+			}
+			if param != 0 {
+				snap.castParam(signer, number, param, value)
+			}
+		}
 	}
 	snap.Number += uint64(len(headers))
 	snap.Hash = headers[len(headers)-1].Hash()
@@ -292,6 +374,103 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// castParam replaces any previous vote of a signer on a chain parameter with a
+// new one, scheduling the change if a majority of the signers agree on it. Votes
+// that would invert the gas limit bounds are ignored.
+func (s *Snapshot) castParam(signer common.Address, number uint64, param Param, value uint64) bool {
+	if !s.consistentParam(param, value) {
+		return false
+	}
+	votes := 0
+	for i := 0; i < len(s.ParamVotes); i++ {
+		vote := s.ParamVotes[i]
+		if vote.Param != param {
+			continue
+		}
+		if vote.Signer == signer {
+			s.ParamVotes = append(s.ParamVotes[:i], s.ParamVotes[i+1:]...)
+			i--
+			continue
+		}
+		if vote.Value == value {
+			votes++
+		}
+	}
+	s.ParamVotes = append(s.ParamVotes, &ParamVote{
+		Signer: signer,
+		Block:  number,
+		Param:  param,
+		Value:  value,
+	})
+	// If the vote passed, schedule the change and discard the votes around it
+	if votes+1 > len(s.Signers)/2 {
+		s.Pending[param] = value
+		for i := 0; i < len(s.ParamVotes); i++ {
+			if s.ParamVotes[i].Param == param {
+				s.ParamVotes = append(s.ParamVotes[:i], s.ParamVotes[i+1:]...)
+				i--
+			}
+		}
+	}
+	return true
+}
+
+// param retrieves the current value of a chain parameter, falling back to the
+// one configured in the genesis block if it was never voted on.
+func (s *Snapshot) param(param Param) uint64 {
+	if value, ok := s.Params[param]; ok {
+		return value
+	}
+	if param == ParamPeriod {
+		return s.config.Period
+	}
+	return 0
+}
+
+// pendingParam retrieves the value of a chain parameter effective from the next
+// checkpoint on, including the changes already agreed upon.
+func (s *Snapshot) pendingParam(param Param) uint64 {
+	if value, ok := s.Pending[param]; ok {
+		return value
+	}
+	return s.param(param)
+}
+
+// consistentParam returns whether changing a chain parameter to the given value
+// keeps the gas limit minimum below the maximum, taking the changes already
+// agreed upon into account.
+func (s *Snapshot) consistentParam(param Param, value uint64) bool {
+	switch param {
+	case ParamGasLimitMin:
+		return validGasLimitBounds(value, s.pendingParam(ParamGasLimitMax))
+	case ParamGasLimitMax:
+		return validGasLimitBounds(s.pendingParam(ParamGasLimitMin), value)
+	}
+	return true
+}
+
+// validGasLimitBounds returns whether the gas limit bounds are in order, a zero
+// bound meaning unbounded.
+func validGasLimitBounds(min, max uint64) bool {
+	return min == 0 || max == 0 || min <= max
+}
+
+// validParamVote returns whether it makes sense to cast the specified parameter
+// vote in the given snapshot context (e.g. don't propose the current value).
+func (s *Snapshot) validParamVote(param Param, value uint64) bool {
+	return s.pendingParam(param) != value && s.consistentParam(param, value)
+}
+
+// status retrieves the sealing record of a signer, creating it if needed.
+func (s *Snapshot) status(signer common.Address) *SignerStatus {
+	status, ok := s.Status[signer]
+	if !ok {
+		status = new(SignerStatus)
+		s.Status[signer] = status
+	}
+	return status
+}
+
 // signers retrieves the list of authorized signers in ascending order.
 func (s *Snapshot) signers() []common.Address {
 	sigs := make([]common.Address, 0, len(s.Signers))
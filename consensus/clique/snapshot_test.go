@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/DEWH/go-DEWH/common"
@@ -29,6 +30,7 @@ import (
 	"github.com/DEWH/go-DEWH/crypto"
 	"github.com/DEWH/go-DEWH/ethdb"
 	"github.com/DEWH/go-DEWH/params"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 type testerVote struct {
@@ -404,3 +406,325 @@ func TestVoting(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the sealing records of the signers are tracked, blaming in-turn
+// signers for blocks sealed by others.
+func TestSignerStatus(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	names := make(map[common.Address]string)
+	for _, name := range []string{"A", "B", "C"} {
+		names[accounts.address(name)] = name
+	}
+	signers := make([]common.Address, 0, len(names))
+	for signer := range names {
+		signers = append(signers, signer)
+	}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	snap := newSnapshot(&params.CliqueConfig{Epoch: 30000}, sigcache, 0, common.Hash{}, signers)
+	sorted := snap.signers()
+
+	// Seal three blocks, the third one by the first signer instead of the in-turn one
+	sealers := []common.Address{sorted[1], sorted[2], sorted[1]}
+
+	headers := make([]*types.Header, len(sealers))
+	for i, sealer := range sealers {
+		headers[i] = &types.Header{
+			Number: big.NewInt(int64(i) + 1),
+			Time:   big.NewInt(int64(i) * 15),
+			Extra:  make([]byte, extraVanity+extraSeal),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		accounts.sign(headers[i], names[sealer])
+	}
+	result, err := snap.apply(headers)
+	if err != nil {
+		t.Fatalf("failed to apply headers: %v", err)
+	}
+	want := map[common.Address]SignerStatus{
+		sorted[0]: {Missed: 1},
+		sorted[1]: {InTurn: 1, OutOfTurn: 1},
+		sorted[2]: {InTurn: 1},
+	}
+	for signer, status := range want {
+		if have := result.Status[signer]; have == nil || *have != status {
+			t.Errorf("signer %s: status mismatch: have %+v, want %+v", names[signer], have, status)
+		}
+	}
+	// Ensure the records don't leak into the parent snapshot
+	if len(snap.Status) != 0 {
+		t.Errorf("parent snapshot modified: have %d records", len(snap.Status))
+	}
+}
+
+// Tests that signers missing too many blocks are proposed to be dropped, and the
+// proposals withdrawn once they are back within the threshold, without touching
+// the proposals of the user.
+func TestAutoDropProposals(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C"), accounts.address("D")}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	snap := newSnapshot(&params.CliqueConfig{Epoch: 30000}, sigcache, 0, common.Hash{}, signers)
+
+	engine := New(&params.CliqueConfig{}, ethdb.NewMemDatabase())
+	engine.Authorize(accounts.address("A"), nil)
+	engine.SetAutoDrop(2)
+
+	// The user keeps proposing B, and wants to drop D regardless of its record
+	engine.proposals[accounts.address("B")] = true
+	engine.proposals[accounts.address("D")] = false
+
+	snap.Status[accounts.address("A")] = &SignerStatus{Missed: 5} // Local signer, never dropped
+	snap.Status[accounts.address("B")] = &SignerStatus{Missed: 3}
+	snap.Status[accounts.address("C")] = &SignerStatus{Missed: 2} // Within the threshold
+	snap.Status[accounts.address("D")] = &SignerStatus{Missed: 4}
+	engine.proposeDrops(snap)
+
+	if len(engine.autoProposals) != 2 {
+		t.Fatalf("automatic proposal count mismatch: have %d, want 2", len(engine.autoProposals))
+	}
+	for _, name := range []string{"B", "D"} {
+		if _, ok := engine.autoProposals[accounts.address(name)]; !ok {
+			t.Errorf("signer %s: drop not proposed", name)
+		}
+	}
+	// The user's proposals are left alone
+	proposals := map[common.Address]bool{accounts.address("B"): true, accounts.address("D"): false}
+	if !reflect.DeepEqual(engine.proposals, proposals) {
+		t.Errorf("user proposals modified: have %v, want %v", engine.proposals, proposals)
+	}
+
+	// Reset the sealing records as on an epoch checkpoint, withdrawing all drops
+	snap.Status = make(map[common.Address]*SignerStatus)
+	engine.proposeDrops(snap)
+
+	if len(engine.autoProposals) != 0 {
+		t.Errorf("automatic proposals not withdrawn: %v", engine.autoProposals)
+	}
+	if !reflect.DeepEqual(engine.proposals, proposals) {
+		t.Errorf("user proposals modified: have %v, want %v", engine.proposals, proposals)
+	}
+	// Disabling the automatic drops withdraws any proposals made so far
+	snap.Status[accounts.address("C")] = &SignerStatus{Missed: 3}
+	engine.proposeDrops(snap)
+	if len(engine.autoProposals) != 1 {
+		t.Fatalf("automatic proposal count mismatch: have %d, want 1", len(engine.autoProposals))
+	}
+	engine.SetAutoDrop(0)
+	engine.proposeDrops(snap)
+	if len(engine.autoProposals) != 0 {
+		t.Errorf("automatic proposals not withdrawn after disabling: %v", engine.autoProposals)
+	}
+}
+
+// Tests that chain parameter votes are tallied and the agreed upon values only
+// take effect at the next checkpoint.
+func TestParamVoting(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	snap := newSnapshot(&params.CliqueConfig{Period: 15, Epoch: 5, ParamVoteBlock: big.NewInt(0)}, sigcache, 0, common.Hash{}, signers)
+
+	// Assemble a chain where two signers agree on a new period, the last block
+	// being a checkpoint without any votes
+	votes := []struct {
+		signer string
+		param  Param
+		value  uint64
+	}{
+		{signer: "A", param: ParamPeriod, value: 10},
+		{signer: "B", param: ParamGasLimitMax, value: 8000000},
+		{signer: "C", param: ParamPeriod, value: 5},
+		{signer: "A", param: ParamPeriod, value: 5}, // Changes its mind, reaching majority
+		{signer: "B"},
+	}
+	headers := make([]*types.Header, len(votes))
+	for i, vote := range votes {
+		headers[i] = &types.Header{
+			Number: big.NewInt(int64(i) + 1),
+			Time:   big.NewInt(int64(i) * 15),
+			Extra:  make([]byte, extraVanity+extraSeal),
+		}
+		if vote.param != 0 {
+			headers[i].MixDigest = encodeParamVote(vote.param, vote.value)
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		accounts.sign(headers[i], vote.signer)
+	}
+	// Without a majority, nothing may be scheduled
+	snap, err := snap.apply(headers[:3])
+	if err != nil {
+		t.Fatalf("failed to apply votes: %v", err)
+	}
+	if len(snap.Pending) != 0 {
+		t.Fatalf("change scheduled without a majority: %v", snap.Pending)
+	}
+	if len(snap.ParamVotes) != 3 {
+		t.Fatalf("parameter vote count mismatch: have %d, want %d", len(snap.ParamVotes), 3)
+	}
+	// With a majority, the change must be scheduled but not yet effective
+	if snap, err = snap.apply(headers[3:4]); err != nil {
+		t.Fatalf("failed to apply votes: %v", err)
+	}
+	if pending := snap.Pending[ParamPeriod]; pending != 5 {
+		t.Fatalf("pending period mismatch: have %d, want %d", pending, 5)
+	}
+	if period := snap.param(ParamPeriod); period != 15 {
+		t.Fatalf("period changed before checkpoint: have %d, want %d", period, 15)
+	}
+	if len(snap.ParamVotes) != 1 {
+		t.Fatalf("parameter vote count mismatch: have %d, want %d", len(snap.ParamVotes), 1)
+	}
+	// After the checkpoint, the change must be effective and the votes reset
+	if snap, err = snap.apply(headers[4:]); err != nil {
+		t.Fatalf("failed to apply checkpoint: %v", err)
+	}
+	if period := snap.param(ParamPeriod); period != 5 {
+		t.Errorf("period mismatch after checkpoint: have %d, want %d", period, 5)
+	}
+	if limit := snap.param(ParamGasLimitMax); limit != 0 {
+		t.Errorf("gas limit bound changed without a majority: have %d", limit)
+	}
+	if len(snap.ParamVotes) != 0 || len(snap.Pending) != 0 {
+		t.Errorf("parameter votes not reset at checkpoint: %d votes, %d pending", len(snap.ParamVotes), len(snap.Pending))
+	}
+}
+
+// Tests that chain parameter votes are only accepted and tallied from the block
+// enabling them onwards.
+func TestParamVotingActivation(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	config := &params.CliqueConfig{Period: 15, Epoch: 100, ParamVoteBlock: big.NewInt(3)}
+	engine := New(config, ethdb.NewMemDatabase())
+
+	// Votes are rejected before the activation block, and on checkpoints after it
+	tests := []struct {
+		number uint64
+		vote   common.Hash
+		err    error
+	}{
+		{1, encodeParamVote(ParamPeriod, 5), errInvalidMixDigest},
+		{2, common.Hash{0xff}, errInvalidMixDigest},
+		{3, common.Hash{0xff}, errInvalidParamVote}, // Past the mix digest check, failing the vote check
+		{100, encodeParamVote(ParamPeriod, 5), errInvalidMixDigest},
+	}
+	for i, tt := range tests {
+		header := &types.Header{
+			Number:    new(big.Int).SetUint64(tt.number),
+			Time:      big.NewInt(0),
+			Extra:     make([]byte, extraVanity+extraSeal),
+			MixDigest: tt.vote,
+		}
+		if err := engine.verifyHeader(nil, header, nil); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Votes before the activation block are not tallied, even if already in the chain
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	snap := newSnapshot(config, sigcache, 0, common.Hash{}, signers)
+
+	headers := make([]*types.Header, 4)
+	for i, signer := range []string{"A", "B", "C", "A"} {
+		headers[i] = &types.Header{
+			Number:    big.NewInt(int64(i) + 1),
+			Time:      big.NewInt(int64(i) * 15),
+			Extra:     make([]byte, extraVanity+extraSeal),
+			MixDigest: encodeParamVote(ParamPeriod, 5),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		accounts.sign(headers[i], signer)
+	}
+	snap, err := snap.apply(headers[:3])
+	if err != nil {
+		t.Fatalf("failed to apply votes: %v", err)
+	}
+	if len(snap.ParamVotes) != 1 || len(snap.Pending) != 0 {
+		t.Fatalf("votes before activation tallied: %d votes, %d pending", len(snap.ParamVotes), len(snap.Pending))
+	}
+	if snap, err = snap.apply(headers[3:]); err != nil {
+		t.Fatalf("failed to apply votes: %v", err)
+	}
+	if pending := snap.Pending[ParamPeriod]; pending != 5 {
+		t.Errorf("pending period mismatch: have %d, want %d", pending, 5)
+	}
+}
+
+// Tests that votes inverting the gas limit bounds are ignored, checked against
+// the changes already agreed upon.
+func TestParamVotingGasLimitBounds(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	snap := newSnapshot(&params.CliqueConfig{Period: 15, Epoch: 100, ParamVoteBlock: big.NewInt(0)}, sigcache, 0, common.Hash{}, signers)
+
+	votes := []struct {
+		signer string
+		param  Param
+		value  uint64
+	}{
+		{signer: "A", param: ParamGasLimitMax, value: 5000000},
+		{signer: "B", param: ParamGasLimitMax, value: 5000000}, // Scheduled
+		{signer: "C", param: ParamGasLimitMin, value: 6000000}, // Above the scheduled maximum
+		{signer: "A", param: ParamGasLimitMin, value: 6000000},
+		{signer: "B", param: ParamGasLimitMin, value: 4000000},
+		{signer: "C", param: ParamGasLimitMin, value: 4000000}, // Scheduled
+		{signer: "A", param: ParamGasLimitMax, value: 3000000}, // Below the scheduled minimum
+		{signer: "B", param: ParamGasLimitMax, value: 3000000},
+	}
+	headers := make([]*types.Header, len(votes))
+	for i, vote := range votes {
+		headers[i] = &types.Header{
+			Number:    big.NewInt(int64(i) + 1),
+			Time:      big.NewInt(int64(i) * 15),
+			Extra:     make([]byte, extraVanity+extraSeal),
+			MixDigest: encodeParamVote(vote.param, vote.value),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		accounts.sign(headers[i], vote.signer)
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		t.Fatalf("failed to apply votes: %v", err)
+	}
+	if max := snap.Pending[ParamGasLimitMax]; max != 5000000 {
+		t.Errorf("pending maximum mismatch: have %d, want %d", max, 5000000)
+	}
+	if min := snap.Pending[ParamGasLimitMin]; min != 4000000 {
+		t.Errorf("pending minimum mismatch: have %d, want %d", min, 4000000)
+	}
+	if len(snap.ParamVotes) != 0 {
+		t.Errorf("inverting votes tallied: %d votes", len(snap.ParamVotes))
+	}
+	// Local proposals must be checked against the pending bounds too
+	tests := []struct {
+		param Param
+		value uint64
+		valid bool
+	}{
+		{ParamGasLimitMin, 5000000, true},
+		{ParamGasLimitMin, 5000001, false},
+		{ParamGasLimitMin, 0, true},
+		{ParamGasLimitMax, 4000000, true},
+		{ParamGasLimitMax, 3999999, false},
+		{ParamGasLimitMax, 0, true},
+		{ParamGasLimitMax, 5000000, false}, // Already pending
+	}
+	for i, tt := range tests {
+		if valid := snap.validParamVote(tt.param, tt.value); valid != tt.valid {
+			t.Errorf("test %d: %v=%d validity mismatch: have %v, want %v", i, tt.param, tt.value, valid, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/rpc"
+)
+
+// API is a user facing RPC API to query the validator elections of the
+// delegated proof-of-stake scheme.
+type API struct {
+	chain consensus.ChainReader
+	dpos  *Dpos
+}
+
+// Election is the state of the validator election at a given block.
+type Election struct {
+	Epoch      uint64                          `json:"epoch"`      // Current epoch number
+	Validators []common.Address                `json:"validators"` // Block producers in schedule order
+	Candidates map[common.Address]*hexutil.Big `json:"candidates"` // Stake voting for every candidate
+	Missed     map[common.Address]uint64       `json:"missed"`     // Slots missed by every producer this epoch
+}
+
+// context retrieves the election context at a given block.
+func (api *API) context(number *rpc.BlockNumber) (*Context, error) {
+	// Retrieve the requested block number (or current if none requested)
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	// Ensure we have an actually valid block and return its context
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.dpos.context(header)
+}
+
+// GetElection retrieves the state of the election at the specified block.
+func (api *API) GetElection(number *rpc.BlockNumber) (*Election, error) {
+	ctx, err := api.context(number)
+	if err != nil {
+		return nil, err
+	}
+	election := &Election{
+		Epoch:      ctx.Epoch(),
+		Validators: ctx.Validators(),
+		Candidates: make(map[common.Address]*hexutil.Big),
+		Missed:     make(map[common.Address]uint64),
+	}
+	for candidate, votes := range ctx.Votes() {
+		election.Candidates[candidate] = (*hexutil.Big)(votes)
+	}
+	for _, validator := range election.Validators {
+		election.Missed[validator] = ctx.Missed(election.Epoch, validator)
+	}
+	return election, nil
+}
+
+// GetValidators retrieves the block producers of the epoch of the specified
+// block, in schedule order.
+func (api *API) GetValidators(number *rpc.BlockNumber) ([]common.Address, error) {
+	ctx, err := api.context(number)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Validators(), nil
+}
+
+// GetCandidates retrieves the registered candidates at the specified block along
+// with the stake voting for them.
+func (api *API) GetCandidates(number *rpc.BlockNumber) (map[common.Address]*hexutil.Big, error) {
+	ctx, err := api.context(number)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[common.Address]*hexutil.Big)
+	for candidate, votes := range ctx.Votes() {
+		candidates[candidate] = (*hexutil.Big)(votes)
+	}
+	return candidates, nil
+}
+
+// GetStake retrieves the amount staked by an account at the specified block.
+func (api *API) GetStake(address common.Address, number *rpc.BlockNumber) (*hexutil.Big, error) {
+	ctx, err := api.context(number)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(ctx.Stake(address)), nil
+}
+
+// GetVote retrieves the candidate an account votes for at the specified block,
+// or nil if it doesn't vote.
+func (api *API) GetVote(address common.Address, number *rpc.BlockNumber) (*common.Address, error) {
+	ctx, err := api.context(number)
+	if err != nil {
+		return nil, err
+	}
+	if candidate, ok := ctx.Vote(address); ok {
+		return &candidate, nil
+	}
+	return nil, nil
+}
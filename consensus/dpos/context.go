@@ -0,0 +1,363 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/rlp"
+	"github.com/DEWH/go-DEWH/trie"
+)
+
+var (
+	contextPrefix = []byte("dpos-") // Database prefix of the context roots, followed by the context hash
+
+	epochKey      = []byte("epoch")      // Epoch trie key of the current epoch number
+	validatorsKey = []byte("validators") // Epoch trie key of the elected validators
+)
+
+// errUnknownContext is returned if the election context a header commits to is
+// not available locally, e.g. on light clients only syncing the headers.
+var errUnknownContext = errors.New("unknown election context")
+
+// contextRoots are the roots of the election tries, the hash of which is
+// committed to by the mix digest of every header.
+type contextRoots struct {
+	Epoch     common.Hash // Trie of the current epoch number and its validators
+	Candidate common.Hash // Trie of the registered candidates
+	Stake     common.Hash // Trie of the staked amount of every account
+	Vote      common.Hash // Trie of the candidate every account votes for
+	Delegate  common.Hash // Trie of the voters of every candidate
+	Miss      common.Hash // Trie of the slots missed by validators per epoch
+}
+
+// hash returns the commitment to the context roots.
+func (r *contextRoots) hash() common.Hash {
+	blob, _ := rlp.EncodeToBytes(r)
+	return crypto.Keccak256Hash(blob)
+}
+
+// Context is the election state at a given block, stored in dedicated tries.
+type Context struct {
+	triedb *trie.Database
+
+	epochTrie     *trie.Trie
+	candidateTrie *trie.Trie
+	stakeTrie     *trie.Trie
+	voteTrie      *trie.Trie
+	delegateTrie  *trie.Trie
+	missTrie      *trie.Trie
+}
+
+// newContext opens the election tries at the given roots.
+func newContext(triedb *trie.Database, roots contextRoots) (*Context, error) {
+	var (
+		ctx = &Context{triedb: triedb}
+		err error
+	)
+	if ctx.epochTrie, err = trie.New(roots.Epoch, triedb); err != nil {
+		return nil, err
+	}
+	if ctx.candidateTrie, err = trie.New(roots.Candidate, triedb); err != nil {
+		return nil, err
+	}
+	if ctx.stakeTrie, err = trie.New(roots.Stake, triedb); err != nil {
+		return nil, err
+	}
+	if ctx.voteTrie, err = trie.New(roots.Vote, triedb); err != nil {
+		return nil, err
+	}
+	if ctx.delegateTrie, err = trie.New(roots.Delegate, triedb); err != nil {
+		return nil, err
+	}
+	if ctx.missTrie, err = trie.New(roots.Miss, triedb); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// loadContext opens the election context committed to by the given hash.
+func loadContext(triedb *trie.Database, db ethdb.Database, hash common.Hash) (*Context, error) {
+	blob, err := db.Get(append(contextPrefix, hash[:]...))
+	if err != nil {
+		return nil, errUnknownContext
+	}
+	var roots contextRoots
+	if err := rlp.DEWHodeBytes(blob, &roots); err != nil {
+		return nil, err
+	}
+	return newContext(triedb, roots)
+}
+
+// roots returns the current roots of the election tries.
+func (c *Context) roots() contextRoots {
+	return contextRoots{
+		Epoch:     c.epochTrie.Hash(),
+		Candidate: c.candidateTrie.Hash(),
+		Stake:     c.stakeTrie.Hash(),
+		Vote:      c.voteTrie.Hash(),
+		Delegate:  c.delegateTrie.Hash(),
+		Miss:      c.missTrie.Hash(),
+	}
+}
+
+// Hash returns the commitment to the election context.
+func (c *Context) Hash() common.Hash {
+	roots := c.roots()
+	return roots.hash()
+}
+
+// commit writes the election tries and their roots into the database, returning
+// the commitment to the context.
+func (c *Context) commit(db ethdb.Database) (common.Hash, error) {
+	for _, t := range []*trie.Trie{c.epochTrie, c.candidateTrie, c.stakeTrie, c.voteTrie, c.delegateTrie, c.missTrie} {
+		root, err := t.Commit(nil)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err := c.triedb.Commit(root, false); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	roots := c.roots()
+	blob, err := rlp.EncodeToBytes(&roots)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := roots.hash()
+	if err := db.Put(append(contextPrefix, hash[:]...), blob); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
+
+// Epoch returns the number of the epoch the context is in.
+func (c *Context) Epoch() uint64 {
+	blob := c.epochTrie.Get(epochKey)
+	if len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+// Validators returns the block producers of the current epoch, in the order of
+// their production slots.
+func (c *Context) Validators() []common.Address {
+	var validators []common.Address
+	if blob := c.epochTrie.Get(validatorsKey); len(blob) > 0 {
+		if err := rlp.DEWHodeBytes(blob, &validators); err != nil {
+			log.Error("Corrupted validator list", "err", err)
+		}
+	}
+	return validators
+}
+
+// setEpoch moves the context into the given epoch with the given validators.
+func (c *Context) setEpoch(epoch uint64, validators []common.Address) {
+	var number [8]byte
+	binary.BigEndian.PutUint64(number[:], epoch)
+	c.epochTrie.Update(epochKey, number[:])
+
+	blob, _ := rlp.EncodeToBytes(validators)
+	c.epochTrie.Update(validatorsKey, blob)
+}
+
+// IsCandidate returns whether the account is registered as a candidate.
+func (c *Context) IsCandidate(addr common.Address) bool {
+	return len(c.candidateTrie.Get(addr.Bytes())) > 0
+}
+
+// Candidates returns the registered candidates in ascending order.
+func (c *Context) Candidates() []common.Address {
+	var candidates []common.Address
+	it := trie.NewIterator(c.candidateTrie.NodeIterator(nil))
+	for it.Next() {
+		candidates = append(candidates, common.BytesToAddress(it.Value))
+	}
+	return candidates
+}
+
+// register adds the account to the candidates.
+func (c *Context) register(addr common.Address) {
+	c.candidateTrie.Update(addr.Bytes(), addr.Bytes())
+}
+
+// retire removes the account from the candidates. Votes for it are kept, but
+// not counted unless it registers again.
+func (c *Context) retire(addr common.Address) {
+	c.candidateTrie.Delete(addr.Bytes())
+}
+
+// Stake returns the amount staked by the account.
+func (c *Context) Stake(addr common.Address) *big.Int {
+	return new(big.Int).SetBytes(c.stakeTrie.Get(addr.Bytes()))
+}
+
+// setStake sets the amount staked by the account.
+func (c *Context) setStake(addr common.Address, amount *big.Int) {
+	if amount.Sign() == 0 {
+		c.stakeTrie.Delete(addr.Bytes())
+		return
+	}
+	c.stakeTrie.Update(addr.Bytes(), amount.Bytes())
+}
+
+// Vote returns the candidate the account votes for, if any.
+func (c *Context) Vote(addr common.Address) (common.Address, bool) {
+	blob := c.voteTrie.Get(addr.Bytes())
+	if len(blob) == 0 {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(blob), true
+}
+
+// vote makes the account vote for the candidate, replacing any previous vote.
+func (c *Context) vote(voter, candidate common.Address) {
+	c.unvote(voter)
+	c.voteTrie.Update(voter.Bytes(), candidate.Bytes())
+	c.delegateTrie.Update(append(candidate.Bytes(), voter.Bytes()...), voter.Bytes())
+}
+
+// unvote withdraws the vote of the account.
+func (c *Context) unvote(voter common.Address) {
+	if candidate, ok := c.Vote(voter); ok {
+		c.delegateTrie.Delete(append(candidate.Bytes(), voter.Bytes()...))
+		c.voteTrie.Delete(voter.Bytes())
+	}
+}
+
+// Votes tallies the stake voting for every registered candidate.
+func (c *Context) Votes() map[common.Address]*big.Int {
+	votes := make(map[common.Address]*big.Int)
+	for _, candidate := range c.Candidates() {
+		votes[candidate] = new(big.Int)
+	}
+	it := trie.NewIterator(c.delegateTrie.NodeIterator(nil))
+	for it.Next() {
+		candidate := common.BytesToAddress(it.Key[:common.AddressLength])
+		if total, ok := votes[candidate]; ok {
+			total.Add(total, c.Stake(common.BytesToAddress(it.Value)))
+		}
+	}
+	return votes
+}
+
+// missKey returns the miss trie key of a validator in an epoch.
+func missKey(epoch uint64, validator common.Address) []byte {
+	key := make([]byte, 8+common.AddressLength)
+	binary.BigEndian.PutUint64(key, epoch)
+	copy(key[8:], validator.Bytes())
+	return key
+}
+
+// Missed returns the number of slots the validator missed in the given epoch.
+func (c *Context) Missed(epoch uint64, validator common.Address) uint64 {
+	blob := c.missTrie.Get(missKey(epoch, validator))
+	if len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+// miss records a slot missed by the validator in the given epoch.
+func (c *Context) miss(epoch uint64, validator common.Address) {
+	var count [8]byte
+	binary.BigEndian.PutUint64(count[:], c.Missed(epoch, validator)+1)
+	c.missTrie.Update(missKey(epoch, validator), count[:])
+}
+
+// pruneMissed drops the missed slot records of the given epoch.
+func (c *Context) pruneMissed(epoch uint64) {
+	var prefix [8]byte
+	binary.BigEndian.PutUint64(prefix[:], epoch)
+
+	var keys [][]byte
+	it := trie.NewIterator(c.missTrie.NodeIterator(prefix[:]))
+	for it.Next() && bytes.HasPrefix(it.Key, prefix[:]) {
+		keys = append(keys, common.CopyBytes(it.Key))
+	}
+	for _, key := range keys {
+		c.missTrie.Delete(key)
+	}
+}
+
+// elect closes the current epoch and elects the validators of the given one.
+// Validators that missed more than half of their slots are dropped from the
+// candidates, as long as enough candidates remain. The candidates backed by
+// the most stake are then elected, and shuffled by the seed to determine the
+// production schedule.
+func (c *Context) elect(epoch uint64, maxValidators uint64, slotsPerEpoch uint64, seed common.Hash) {
+	prevEpoch, prevValidators := c.Epoch(), c.Validators()
+
+	// Kick out the validators that didn't do their job in the last epoch
+	if len(prevValidators) > 0 {
+		expected := slotsPerEpoch / uint64(len(prevValidators))
+		safeSize := int(maxValidators*2/3 + 1)
+
+		candidates := len(c.Candidates())
+		for _, validator := range prevValidators {
+			if candidates <= safeSize {
+				break
+			}
+			if missed := c.Missed(prevEpoch, validator); missed > expected/2 && c.IsCandidate(validator) {
+				log.Info("Kicking out unreliable validator", "epoch", prevEpoch, "validator", validator, "missed", missed, "expected", expected)
+				c.retire(validator)
+				candidates--
+			}
+		}
+	}
+	if prevEpoch > 0 {
+		c.pruneMissed(prevEpoch - 1)
+	}
+	// Elect the candidates with the most stake behind them
+	votes := c.Votes()
+	candidates := make([]common.Address, 0, len(votes))
+	for candidate := range votes {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if cmp := votes[candidates[i]].Cmp(votes[candidates[j]]); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(candidates[i][:], candidates[j][:]) < 0
+	})
+	if uint64(len(candidates)) > maxValidators {
+		candidates = candidates[:maxValidators]
+	}
+	if len(candidates) == 0 {
+		log.Warn("No candidates elected, keeping validators", "epoch", epoch)
+		candidates = prevValidators
+	}
+	// Shuffle the producers deterministically for the epoch
+	keys := make(map[common.Address]common.Hash, len(candidates))
+	for _, candidate := range candidates {
+		keys[candidate] = crypto.Keccak256Hash(seed.Bytes(), candidate.Bytes())
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ki, kj := keys[candidates[i]], keys[candidates[j]]
+		return bytes.Compare(ki[:], kj[:]) < 0
+	})
+	c.setEpoch(epoch, candidates)
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/trie"
+)
+
+func newTestContext(t *testing.T) (*Context, ethdb.Database) {
+	db := ethdb.NewMemDatabase()
+	ctx, err := newContext(trie.NewDatabase(db), contextRoots{})
+	if err != nil {
+		t.Fatalf("failed to create context: %v", err)
+	}
+	return ctx, db
+}
+
+// Tests that the candidates backed by the most stake are elected, and that
+// moving votes around changes the outcome.
+func TestElection(t *testing.T) {
+	ctx, _ := newTestContext(t)
+
+	var (
+		candA  = common.Address{0x0a}
+		candB  = common.Address{0x0b}
+		candC  = common.Address{0x0c}
+		voter1 = common.Address{0x01}
+		voter2 = common.Address{0x02}
+	)
+	for _, candidate := range []common.Address{candA, candB, candC} {
+		ctx.register(candidate)
+	}
+	ctx.setStake(voter1, big.NewInt(100))
+	ctx.setStake(voter2, big.NewInt(50))
+	ctx.vote(voter1, candB)
+	ctx.vote(voter2, candC)
+
+	ctx.elect(1, 2, 10, common.Hash{})
+	if epoch := ctx.Epoch(); epoch != 1 {
+		t.Fatalf("epoch mismatch: have %d, want %d", epoch, 1)
+	}
+	assertValidators(t, ctx.Validators(), candB, candC)
+
+	// Move the bigger stake over and retire a candidate
+	ctx.vote(voter1, candA)
+	ctx.retire(candC)
+	if votes := ctx.Votes(); votes[candB].Sign() != 0 || votes[candA].Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("vote tally mismatch: have %v", votes)
+	}
+	ctx.elect(2, 2, 10, common.Hash{})
+	assertValidators(t, ctx.Validators(), candA, candB)
+
+	// Ensure the context survives a database round trip
+	db := ethdb.NewMemDatabase()
+	hash, err := ctx.commit(db)
+	if err != nil {
+		t.Fatalf("failed to commit context: %v", err)
+	}
+	loaded, err := loadContext(ctx.triedb, db, hash)
+	if err != nil {
+		t.Fatalf("failed to load context: %v", err)
+	}
+	if loaded.Hash() != hash {
+		t.Errorf("context hash mismatch: have %x, want %x", loaded.Hash(), hash)
+	}
+	assertValidators(t, loaded.Validators(), candA, candB)
+}
+
+// Tests that validators missing too many slots lose their candidacy.
+func TestElectionKickout(t *testing.T) {
+	ctx, _ := newTestContext(t)
+
+	candidates := []common.Address{{0x01}, {0x02}, {0x03}, {0x04}, {0x05}}
+	for _, candidate := range candidates {
+		ctx.register(candidate)
+	}
+	ctx.setEpoch(1, candidates[:3])
+
+	// Every validator expects 10 slots, the first one missed most of them
+	for i := 0; i < 6; i++ {
+		ctx.miss(1, candidates[0])
+	}
+	ctx.miss(1, candidates[1])
+
+	ctx.elect(2, 3, 30, common.Hash{})
+	if ctx.IsCandidate(candidates[0]) {
+		t.Errorf("unreliable validator not kicked out")
+	}
+	if !ctx.IsCandidate(candidates[1]) {
+		t.Errorf("reliable validator kicked out")
+	}
+	for _, validator := range ctx.Validators() {
+		if validator == candidates[0] {
+			t.Errorf("kicked out validator re-elected")
+		}
+	}
+}
+
+func assertValidators(t *testing.T, have []common.Address, want ...common.Address) {
+	if len(have) != len(want) {
+		t.Fatalf("validator count mismatch: have %x, want %x", have, want)
+	}
+	set := make(map[common.Address]bool)
+	for _, validator := range have {
+		set[validator] = true
+	}
+	for _, validator := range want {
+		if !set[validator] {
+			t.Errorf("validator %x missing from %x", validator, have)
+		}
+	}
+}
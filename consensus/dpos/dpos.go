@@ -0,0 +1,644 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dpos implements the delegated proof-of-stake consensus engine.
+//
+// Accounts take part in the election by sending system transactions to the
+// SystemAddress. Any value sent along is staked, and the first byte of the
+// transaction data selects an operation:
+//
+//	OpStake:    no-op, only stake the value sent
+//	OpRegister: register the sender as a candidate
+//	OpRetire:   withdraw the candidacy of the sender
+//	OpVote:     vote with the whole stake for the candidate following the opcode
+//	OpUnvote:   withdraw the vote of the sender
+//	OpUnstake:  return the 32 byte big endian amount following the opcode
+//
+// Time is divided into slots of a fixed period, and slots into epochs. At the
+// start of every epoch the candidates backed by the most stake are elected as
+// the block producers of the epoch, taking turns in a deterministic order. The
+// slots missed by every producer are tracked, and unreliable producers lose
+// their candidacy at the next election.
+//
+// The election state lives in dedicated tries, the roots of which are committed
+// to by the mix digest of every header.
+package dpos
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/accounts"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/consensus/misc"
+	"github.com/DEWH/go-DEWH/core/state"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/crypto/sha3"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rlp"
+	"github.com/DEWH/go-DEWH/rpc"
+	"github.com/DEWH/go-DEWH/trie"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+
+// System transaction operations, selected by the first byte of the data.
+const (
+	OpStake    byte = iota // Stake the value sent along
+	OpRegister             // Register the sender as a candidate
+	OpRetire               // Withdraw the candidacy of the sender
+	OpVote                 // Vote for the candidate following the opcode
+	OpUnvote               // Withdraw the vote of the sender
+	OpUnstake              // Return the 32 byte amount following the opcode
+)
+
+// DPoS protocol constants.
+var (
+	defaultPeriod     = uint64(3)   // Default number of seconds in a slot
+	defaultEpoch      = uint64(600) // Default number of slots in an epoch
+	defaultValidators = uint64(21)  // Default number of elected block producers
+
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for producer vanity
+	extraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for producer seal
+
+	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
+
+	defaultDifficulty = big.NewInt(1) // Block difficulty, all producers are equal
+
+	// SystemAddress is the address system transactions are sent to. It holds
+	// the staked funds of all accounts.
+	SystemAddress = common.HexToAddress("0x0000000000000000000000000000000000001000")
+)
+
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when the election context is requested for a
+	// block that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errMissingVanity is returned if a block's extra-data section is shorter than
+	// 32 bytes, which is required to store the producer vanity.
+	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
+
+	// errMissingSignature is returned if a block's extra-data section doesn't seem
+	// to contain a 65 byte secp256k1 signature.
+	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
+
+	// errInvalidMixDigest is returned if a block's mix digest doesn't commit to
+	// the election context resulting from the block.
+	errInvalidMixDigest = errors.New("invalid election context digest")
+
+	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// ErrInvalidTimestamp is returned if the timestamp of a block is not aligned
+	// to a slot, or not after the previous block's timestamp.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errUnauthorized is returned if a header is signed by someone else than the
+	// producer scheduled for its slot.
+	errUnauthorized = errors.New("unauthorized")
+)
+
+// SignerFn is a signer callback function to request a hash to be signed by a
+// backing account.
+type SignerFn func(accounts.Account, []byte) ([]byte, error)
+
+// sigHash returns the hash which is used as input for the block producer
+// signature. It is the hash of the entire header apart from the 65 byte
+// signature contained at the end of the extra data.
+//
+// Note, the method requires the extra data to be at least 65 bytes, otherwise it
+// panics.
+func sigHash(header *types.Header) (hash common.Hash) {
+	hasher := sha3.NewKeccak256()
+
+	rlp.Encode(hasher, []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-65], // Yes, this will panic if extra is too short
+		header.MixDigest,
+		header.Nonce,
+	})
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// ecrecover extracts the DEWH account address from a signed header.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	// If the signature's already cached, return that
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address.(common.Address), nil
+	}
+	// Retrieve the signature from the header extra-data
+	if len(header.Extra) < extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	// Recover the public key and the DEWH address
+	pubkey, err := crypto.Ecrecover(sigHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
+
+// Dpos is the delegated proof-of-stake consensus engine.
+type Dpos struct {
+	config *params.DposConfig // Consensus engine configuration parameters
+	db     ethdb.Database     // Database to store the election contexts
+	triedb *trie.Database     // Trie database of the election tries
+
+	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+
+	signer common.Address // DEWH address of the signing key
+	signFn SignerFn       // Signer function to authorize hashes with
+	lock   sync.RWMutex   // Protects the signer fields
+}
+
+// New creates a delegated proof-of-stake consensus engine with the initial
+// candidates set to the validators listed in the genesis block.
+func New(config *params.DposConfig, db ethdb.Database) *Dpos {
+	// Set any missing consensus parameters to their defaults
+	conf := *config
+	if conf.Period == 0 {
+		conf.Period = defaultPeriod
+	}
+	if conf.Epoch == 0 {
+		conf.Epoch = defaultEpoch
+	}
+	if conf.Validators == 0 {
+		conf.Validators = defaultValidators
+	}
+	signatures, _ := lru.NewARC(inmemorySignatures)
+
+	return &Dpos{
+		config:     &conf,
+		db:         db,
+		triedb:     trie.NewDatabase(db),
+		signatures: signatures,
+	}
+}
+
+// slot returns the production slot a timestamp falls into.
+func (d *Dpos) slot(time uint64) uint64 {
+	return time / d.config.Period
+}
+
+// producer returns the validator scheduled to produce the block in the slot.
+func producer(validators []common.Address, slot uint64) common.Address {
+	if len(validators) == 0 {
+		return common.Address{}
+	}
+	return validators[slot%uint64(len(validators))]
+}
+
+// context retrieves the election context resulting from the given block.
+func (d *Dpos) context(header *types.Header) (*Context, error) {
+	if header.Number.Uint64() == 0 {
+		return d.genesisContext(header)
+	}
+	return loadContext(d.triedb, d.db, header.MixDigest)
+}
+
+// genesisContext creates the initial election context, with the validators in
+// the genesis extra-data registered as candidates and elected for the epoch of
+// the genesis block.
+func (d *Dpos) genesisContext(genesis *types.Header) (*Context, error) {
+	if len(genesis.Extra) < extraVanity+extraSeal {
+		return nil, errMissingSignature
+	}
+	validators := make([]common.Address, (len(genesis.Extra)-extraVanity-extraSeal)/common.AddressLength)
+	for i := 0; i < len(validators); i++ {
+		copy(validators[i][:], genesis.Extra[extraVanity+i*common.AddressLength:])
+	}
+	ctx, err := newContext(d.triedb, contextRoots{})
+	if err != nil {
+		return nil, err
+	}
+	for _, validator := range validators {
+		ctx.register(validator)
+	}
+	ctx.setEpoch(d.slot(genesis.Time.Uint64())/d.config.Epoch, validators)
+
+	if _, err := ctx.commit(d.db); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// advance moves the election context of the parent up to the slot of the header,
+// recording the slots missed in between and running the elections of any new
+// epochs entered.
+func (d *Dpos) advance(ctx *Context, parent, header *types.Header) {
+	first, last := d.slot(parent.Time.Uint64())+1, d.slot(header.Time.Uint64())
+
+	// Skip over long stretches without blocks, only the latest epochs matter
+	if gap := 2 * d.config.Epoch; last > first+gap {
+		first = last - gap
+	}
+	for slot := first; slot <= last; slot++ {
+		if epoch := slot / d.config.Epoch; epoch != ctx.Epoch() {
+			ctx.elect(epoch, d.config.Validators, d.config.Epoch, parent.Hash())
+		}
+		if slot < last {
+			if missed := producer(ctx.Validators(), slot); missed != (common.Address{}) {
+				ctx.miss(ctx.Epoch(), missed)
+			}
+		}
+	}
+}
+
+// Author implements consensus.Engine, returning the DEWH address recovered
+// from the signature in the header's extra-data section.
+func (d *Dpos) Author(header *types.Header) (common.Address, error) {
+	return ecrecover(header, d.signatures)
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules.
+func (d *Dpos) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	return d.verifyHeader(chain, header, nil)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (d *Dpos) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := d.verifyHeader(chain, header, headers[:i])
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
+// caller may optionally pass in a batch of parents (ascending order) to avoid
+// looking those up from the database.
+func (d *Dpos) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+
+	// Don't waste time checking blocks from the future
+	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
+		return consensus.ErrFutureBlock
+	}
+	// Check that the extra-data contains both the vanity and signature
+	if len(header.Extra) < extraVanity {
+		return errMissingVanity
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	// Ensure that the block doesn't contain any uncles which are meaningless in DPoS
+	if header.UncleHash != uncleHash {
+		return errInvalidUncleHash
+	}
+	if number > 0 {
+		// Blocks are produced at the start of their slot
+		if header.Time.Uint64()%d.config.Period != 0 {
+			return ErrInvalidTimestamp
+		}
+		// Every block commits to the resulting election context
+		if header.MixDigest == (common.Hash{}) {
+			return errInvalidMixDigest
+		}
+		if header.Difficulty == nil || header.Difficulty.Cmp(defaultDifficulty) != 0 {
+			return errInvalidDifficulty
+		}
+	}
+	// If all checks passed, validate any special fields for hard forks
+	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
+		return err
+	}
+	// The genesis block is the always valid dead-end
+	if number == 0 {
+		return nil
+	}
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Time.Cmp(header.Time) >= 0 {
+		return ErrInvalidTimestamp
+	}
+	return d.verifySeal(header, parent)
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (d *Dpos) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errors.New("uncles not allowed")
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine, checking whether the signature contained
+// in the header satisfies the consensus protocol requirements.
+func (d *Dpos) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	// Verifying the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return d.verifySeal(header, parent)
+}
+
+// verifySeal checks that the header is signed by its coinbase, and that the
+// coinbase is the producer scheduled for the slot of the header. The schedule
+// can only be checked if the election context of the parent is available, it
+// is otherwise enforced when the block is finalized on import.
+func (d *Dpos) verifySeal(header, parent *types.Header) error {
+	signer, err := ecrecover(header, d.signatures)
+	if err != nil {
+		return err
+	}
+	if signer != header.Coinbase {
+		return errUnauthorized
+	}
+	ctx, err := d.context(parent)
+	if err == errUnknownContext {
+		log.Trace("Deferring producer check, no election context", "number", header.Number, "parent", parent.Hash())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	d.advance(ctx, parent, header)
+	if producer(ctx.Validators(), d.slot(header.Time.Uint64())) != signer {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of the
+// header for running the transactions on top. The timestamp is set to the next
+// slot of the local producer, as far as it can be predicted.
+func (d *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	header.Nonce = types.BlockNonce{}
+	header.Difficulty = new(big.Int).Set(defaultDifficulty)
+
+	// Mix digest is set to the resulting election context on finalization
+	header.MixDigest = common.Hash{}
+
+	// Ensure the extra data has all it's components
+	if len(header.Extra) < extraVanity {
+		header.Extra = append(header.Extra, make([]byte, extraVanity-len(header.Extra))...)
+	}
+	header.Extra = append(header.Extra[:extraVanity], make([]byte, extraSeal)...)
+
+	// Find the next slot the local producer is scheduled for
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	ctx, err := d.context(parent)
+	if err != nil {
+		return err
+	}
+	d.lock.RLock()
+	signer := d.signer
+	d.lock.RUnlock()
+
+	next := d.slot(parent.Time.Uint64()) + 1
+	if now := d.slot(uint64(time.Now().Unix())); now > next {
+		next = now
+	}
+	header.Time = new(big.Int).SetUint64(next * d.config.Period)
+	for slot := next; slot < next+2*d.config.Epoch; slot++ {
+		if epoch := slot / d.config.Epoch; epoch != ctx.Epoch() {
+			ctx.elect(epoch, d.config.Validators, d.config.Epoch, parent.Hash())
+		}
+		if producer(ctx.Validators(), slot) == signer {
+			header.Time = new(big.Int).SetUint64(slot * d.config.Period)
+			break
+		}
+	}
+	return nil
+}
+
+// Finalize implements consensus.Engine, advancing the election context to the
+// block, applying the system transactions and committing to the resulting
+// context in the mix digest. No block rewards are given.
+func (d *Dpos) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	ctx, err := d.context(parent)
+	if err != nil {
+		return nil, err
+	}
+	d.advance(ctx, parent, header)
+
+	// Imported blocks already commit to a context and must follow the schedule
+	imported := header.MixDigest != (common.Hash{})
+	if imported && producer(ctx.Validators(), d.slot(header.Time.Uint64())) != header.Coinbase {
+		return nil, errUnauthorized
+	}
+	if err := d.applySystemTxs(chain.Config(), header, state, ctx, txs, receipts); err != nil {
+		return nil, err
+	}
+	hash, err := ctx.commit(d.db)
+	if err != nil {
+		return nil, err
+	}
+	if imported && hash != header.MixDigest {
+		return nil, errInvalidMixDigest
+	}
+	header.MixDigest = hash
+
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	// Assemble and return the final block for sealing
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// applySystemTxs updates the election context with the successful system
+// transactions of a block, returning unstaked funds to their owners.
+func (d *Dpos) applySystemTxs(config *params.ChainConfig, header *types.Header, state *state.StateDB, ctx *Context, txs []*types.Transaction, receipts []*types.Receipt) error {
+	signer := types.MakeSigner(config, header.Number)
+	for i, tx := range txs {
+		if to := tx.To(); to == nil || *to != SystemAddress {
+			continue
+		}
+		if i < len(receipts) && receipts[i].Status == types.ReceiptStatusFailed {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		if tx.Value().Sign() > 0 {
+			ctx.setStake(from, new(big.Int).Add(ctx.Stake(from), tx.Value()))
+		}
+		data := tx.Data()
+		if len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case OpRegister:
+			ctx.register(from)
+		case OpRetire:
+			ctx.retire(from)
+		case OpVote:
+			if len(data) == 1+common.AddressLength {
+				if candidate := common.BytesToAddress(data[1:]); ctx.IsCandidate(candidate) {
+					ctx.vote(from, candidate)
+				}
+			}
+		case OpUnvote:
+			ctx.unvote(from)
+		case OpUnstake:
+			if len(data) == 1+common.HashLength {
+				stake, amount := ctx.Stake(from), new(big.Int).SetBytes(data[1:])
+				if amount.Cmp(stake) > 0 {
+					amount = stake
+				}
+				ctx.setStake(from, new(big.Int).Sub(stake, amount))
+				state.SubBalance(SystemAddress, amount)
+				state.AddBalance(from, amount)
+			}
+		}
+	}
+	return nil
+}
+
+// Authorize injects a private key into the consensus engine to produce new
+// blocks with.
+func (d *Dpos) Authorize(signer common.Address, signFn SignerFn) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.signer = signer
+	d.signFn = signFn
+}
+
+// Seal implements consensus.Engine, attempting to create a sealed block using
+// the local signing credentials if the local producer is scheduled for the slot.
+func (d *Dpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	header := block.Header()
+
+	// Sealing the genesis block is not supported
+	if header.Number.Uint64() == 0 {
+		return nil, errUnknownBlock
+	}
+	// Don't hold the signer fields for the entire sealing procedure
+	d.lock.RLock()
+	signer, signFn := d.signer, d.signFn
+	d.lock.RUnlock()
+
+	if header.Coinbase != signer {
+		return nil, errUnauthorized
+	}
+	// If it's someone else's slot, wait for the next block
+	ctx, err := loadContext(d.triedb, d.db, header.MixDigest)
+	if err != nil {
+		return nil, err
+	}
+	if producer(ctx.Validators(), d.slot(header.Time.Uint64())) != signer {
+		log.Debug("Not scheduled for the slot, waiting for others", "number", header.Number, "time", header.Time)
+		<-stop
+		return nil, nil
+	}
+	// Sweet, the slot is ours, wait for it
+	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()) // nolint: gosimple
+	log.Trace("Waiting for slot to produce", "delay", common.PrettyDuration(delay))
+
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	// Sign all the things!
+	sighash, err := signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
+
+	return block.WithSeal(header), nil
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm. All DPoS blocks have
+// the same difficulty of 1.
+func (d *Dpos) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
+	return new(big.Int).Set(defaultDifficulty)
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to query
+// the elections.
+func (d *Dpos) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "dpos",
+		Version:   "1.0",
+		Service:   &API{chain: chain, dpos: d},
+		Public:    true,
+	}}
+}
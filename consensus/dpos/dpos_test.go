@@ -0,0 +1,481 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DEWH/go-DEWH/accounts"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/core/state"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+// testerChainReader implements consensus.ChainReader to access the headers
+// added by the tests. All other methods and requests will panic.
+type testerChainReader struct {
+	config  *params.ChainConfig
+	headers map[common.Hash]*types.Header
+}
+
+func (r *testerChainReader) Config() *params.ChainConfig               { return r.config }
+func (r *testerChainReader) CurrentHeader() *types.Header              { panic("not supported") }
+func (r *testerChainReader) GetHeaderByNumber(uint64) *types.Header    { panic("not supported") }
+func (r *testerChainReader) GetHeaderByHash(common.Hash) *types.Header { panic("not supported") }
+func (r *testerChainReader) GetBlock(common.Hash, uint64) *types.Block { panic("not supported") }
+func (r *testerChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := r.headers[hash]; ok && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+// tester is a dpos engine on top of a genesis block electing three validators
+// for the first epoch, producing in the order of their listing.
+type tester struct {
+	t          *testing.T
+	engine     *Dpos
+	chain      *testerChainReader
+	keys       []*ecdsa.PrivateKey
+	validators []common.Address
+	genesis    *types.Header
+}
+
+func newTester(t *testing.T) *tester {
+	tester := &tester{t: t}
+
+	extra := make([]byte, extraVanity)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+
+		tester.keys = append(tester.keys, key)
+		tester.validators = append(tester.validators, addr)
+		extra = append(extra, addr.Bytes()...)
+	}
+	extra = append(extra, make([]byte, extraSeal)...)
+
+	config := *params.TestChainConfig
+	config.Ethash, config.Dpos = nil, &params.DposConfig{Period: 3, Epoch: 10, Validators: 3}
+
+	tester.engine = New(config.Dpos, ethdb.NewMemDatabase())
+	tester.genesis = &types.Header{
+		Number:     new(big.Int),
+		Time:       new(big.Int),
+		Difficulty: new(big.Int).Set(defaultDifficulty),
+		Extra:      extra,
+		UncleHash:  uncleHash,
+	}
+	tester.chain = &testerChainReader{
+		config:  &config,
+		headers: map[common.Hash]*types.Header{tester.genesis.Hash(): tester.genesis},
+	}
+	return tester
+}
+
+// header creates an unsealed header on top of the parent in the given slot,
+// produced by the given validator.
+func (ts *tester) header(parent *types.Header, slot uint64, validator int) *types.Header {
+	return &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       new(big.Int).SetUint64(slot * ts.engine.config.Period),
+		Coinbase:   ts.validators[validator],
+		Difficulty: new(big.Int).Set(defaultDifficulty),
+		Extra:      make([]byte, extraVanity+extraSeal),
+		UncleHash:  uncleHash,
+	}
+}
+
+// sign seals the header with the key of the given validator.
+func (ts *tester) sign(header *types.Header, validator int) {
+	sig, err := crypto.Sign(sigHash(header).Bytes(), ts.keys[validator])
+	if err != nil {
+		ts.t.Fatalf("failed to sign header: %v", err)
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+}
+
+// finalize finalizes and seals a block over the given transactions, adding it to
+// the chain.
+func (ts *tester) finalize(header *types.Header, validator int, txs []*types.Transaction, receipts []*types.Receipt) *types.Header {
+	block, err := ts.engine.Finalize(ts.chain, header, newTestState(), txs, nil, receipts)
+	if err != nil {
+		ts.t.Fatalf("failed to finalize block: %v", err)
+	}
+	header = block.Header()
+	ts.sign(header, validator)
+	ts.chain.headers[header.Hash()] = header
+	return header
+}
+
+// producer returns the validator scheduled for a slot after the parent.
+func (ts *tester) producer(parent *types.Header, slot uint64) common.Address {
+	ctx, err := ts.engine.context(parent)
+	if err != nil {
+		ts.t.Fatalf("failed to load election context: %v", err)
+	}
+	ts.engine.advance(ctx, parent, &types.Header{Time: new(big.Int).SetUint64(slot * ts.engine.config.Period)})
+	return producer(ctx.Validators(), slot)
+}
+
+func newTestState() *state.StateDB {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	return statedb
+}
+
+// systemTx creates a signed system transaction of the key.
+func systemTx(t *testing.T, config *params.ChainConfig, key *ecdsa.PrivateKey, nonce uint64, value int64, data ...byte) *types.Transaction {
+	tx := types.NewTransaction(nonce, SystemAddress, big.NewInt(value), 100000, new(big.Int), data)
+	tx, err := types.SignTx(tx, types.MakeSigner(config, common.Big1), key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that blocks are finalized on top of the parent's election context, with
+// the header committing to the resulting context.
+func TestFinalize(t *testing.T) {
+	tester := newTester(t)
+
+	// Produce a block in the second slot, staking and voting along the way
+	key, _ := crypto.GenerateKey()
+	voter := crypto.PubkeyToAddress(key.PublicKey)
+
+	txs := []*types.Transaction{
+		systemTx(t, tester.chain.config, key, 0, 100, append([]byte{OpVote}, tester.validators[2].Bytes()...)...),
+	}
+	receipts := []*types.Receipt{{Status: types.ReceiptStatusSuccessful}}
+
+	header := tester.finalize(tester.header(tester.genesis, 1, 1), 1, txs, receipts)
+	if header.MixDigest == (common.Hash{}) {
+		t.Fatalf("election context not committed to")
+	}
+	ctx, err := loadContext(tester.engine.triedb, tester.engine.db, header.MixDigest)
+	if err != nil {
+		t.Fatalf("failed to load election context: %v", err)
+	}
+	if stake := ctx.Stake(voter); stake.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("stake mismatch: have %v, want %v", stake, 100)
+	}
+	if candidate, ok := ctx.Vote(voter); !ok || candidate != tester.validators[2] {
+		t.Errorf("vote mismatch: have %x, want %x", candidate, tester.validators[2])
+	}
+	// Importing the same block again must result in the same context
+	imported := types.CopyHeader(header)
+	if _, err := tester.engine.Finalize(tester.chain, imported, newTestState(), txs, nil, receipts); err != nil {
+		t.Errorf("failed to import valid block: %v", err)
+	}
+	// Imported blocks must commit to the right context and follow the schedule
+	imported = types.CopyHeader(header)
+	imported.MixDigest = common.Hash{0x01}
+	if _, err := tester.engine.Finalize(tester.chain, imported, newTestState(), txs, nil, receipts); err != errInvalidMixDigest {
+		t.Errorf("invalid context commitment: error mismatch: have %v, want %v", err, errInvalidMixDigest)
+	}
+	imported = types.CopyHeader(header)
+	imported.Coinbase = tester.validators[0]
+	if _, err := tester.engine.Finalize(tester.chain, imported, newTestState(), txs, nil, receipts); err != errUnauthorized {
+		t.Errorf("unscheduled producer: error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	// Skip a slot and check that its producer gets penalized
+	child := tester.finalize(tester.header(header, 4, 1), 1, nil, nil)
+	if ctx, err = loadContext(tester.engine.triedb, tester.engine.db, child.MixDigest); err != nil {
+		t.Fatalf("failed to load election context: %v", err)
+	}
+	for i, want := range []uint64{1, 0, 1} {
+		if missed := ctx.Missed(0, tester.validators[i]); missed != want {
+			t.Errorf("validator %d: missed slot count mismatch: have %d, want %d", i, missed, want)
+		}
+	}
+	// Entering a new epoch must run an election among the candidates
+	child = tester.finalize(tester.header(child, 10, 0), 0, nil, nil)
+	if ctx, err = loadContext(tester.engine.triedb, tester.engine.db, child.MixDigest); err != nil {
+		t.Fatalf("failed to load election context: %v", err)
+	}
+	if ctx.Epoch() != 1 {
+		t.Errorf("epoch mismatch: have %d, want %d", ctx.Epoch(), 1)
+	}
+	assertValidators(t, ctx.Validators(), tester.validators...)
+}
+
+// Tests that blocks can't be finalized without the election context of their
+// parent, e.g. if the chain was synced without the election tries.
+func TestFinalizeUnknownContext(t *testing.T) {
+	tester := newTester(t)
+
+	parent := tester.header(tester.genesis, 1, 1)
+	parent.MixDigest = common.Hash{0x01}
+	tester.sign(parent, 1)
+	tester.chain.headers[parent.Hash()] = parent
+
+	if _, err := tester.engine.Finalize(tester.chain, tester.header(parent, 2, 2), newTestState(), nil, nil, nil); err != errUnknownContext {
+		t.Errorf("error mismatch: have %v, want %v", err, errUnknownContext)
+	}
+	if _, err := tester.engine.Finalize(tester.chain, tester.header(&types.Header{Number: common.Big1}, 2, 2), newTestState(), nil, nil, nil); err != consensus.ErrUnknownAncestor {
+		t.Errorf("error mismatch: have %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+}
+
+// Tests that the system transactions update the election context, ignoring any
+// failed, malformed or unrelated transactions.
+func TestApplySystemTxs(t *testing.T) {
+	tester := newTester(t)
+	config := tester.chain.config
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	candidate := tester.validators[0]
+
+	unstake := func(amount int64) []byte {
+		return append([]byte{OpUnstake}, common.BigToHash(big.NewInt(amount)).Bytes()...)
+	}
+	tests := []struct {
+		name     string
+		txs      []*types.Transaction
+		failed   []bool // Transactions with failed receipts
+		stake    int64
+		balance  int64 // Balance of the sender after unstaking
+		vote     *common.Address
+		register bool
+	}{
+		{
+			name:  "stake",
+			txs:   []*types.Transaction{systemTx(t, config, key, 0, 10), systemTx(t, config, key, 1, 5, OpStake)},
+			stake: 15,
+		},
+		{
+			name:   "failed stake",
+			txs:    []*types.Transaction{systemTx(t, config, key, 0, 10), systemTx(t, config, key, 1, 5)},
+			failed: []bool{false, true},
+			stake:  10,
+		},
+		{
+			name: "unrelated transaction",
+			txs: []*types.Transaction{
+				func() *types.Transaction {
+					tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(10), 100000, new(big.Int), []byte{OpRegister}), types.MakeSigner(config, common.Big1), key)
+					return tx
+				}(),
+			},
+		},
+		{
+			name:     "register",
+			txs:      []*types.Transaction{systemTx(t, config, key, 0, 0, OpRegister)},
+			register: true,
+		},
+		{
+			name: "register and retire",
+			txs:  []*types.Transaction{systemTx(t, config, key, 0, 0, OpRegister), systemTx(t, config, key, 1, 0, OpRetire)},
+		},
+		{
+			name:  "vote",
+			txs:   []*types.Transaction{systemTx(t, config, key, 0, 10, append([]byte{OpVote}, candidate.Bytes()...)...)},
+			stake: 10,
+			vote:  &candidate,
+		},
+		{
+			name:  "vote non-candidate",
+			txs:   []*types.Transaction{systemTx(t, config, key, 0, 10, append([]byte{OpVote}, common.Address{0x01}.Bytes()...)...)},
+			stake: 10,
+		},
+		{
+			name:  "vote malformed",
+			txs:   []*types.Transaction{systemTx(t, config, key, 0, 10, append([]byte{OpVote}, candidate.Bytes()[1:]...)...)},
+			stake: 10,
+		},
+		{
+			name:  "vote and unvote",
+			txs:   []*types.Transaction{systemTx(t, config, key, 0, 10, append([]byte{OpVote}, candidate.Bytes()...)...), systemTx(t, config, key, 1, 0, OpUnvote)},
+			stake: 10,
+		},
+		{
+			name:    "unstake",
+			txs:     []*types.Transaction{systemTx(t, config, key, 0, 10), systemTx(t, config, key, 1, 0, unstake(4)...)},
+			stake:   6,
+			balance: 4,
+		},
+		{
+			name:    "unstake more than staked",
+			txs:     []*types.Transaction{systemTx(t, config, key, 0, 10), systemTx(t, config, key, 1, 0, unstake(100)...)},
+			balance: 10,
+		},
+		{
+			name:  "unstake malformed",
+			txs:   []*types.Transaction{systemTx(t, config, key, 0, 10), systemTx(t, config, key, 1, 0, unstake(4)[:20]...)},
+			stake: 10,
+		},
+	}
+	for _, tt := range tests {
+		ctx, err := tester.engine.context(tester.genesis)
+		if err != nil {
+			t.Fatalf("%s: failed to create election context: %v", tt.name, err)
+		}
+		statedb := newTestState()
+		statedb.AddBalance(SystemAddress, big.NewInt(1000))
+
+		receipts := make([]*types.Receipt, len(tt.txs))
+		for i := range receipts {
+			receipts[i] = &types.Receipt{Status: types.ReceiptStatusSuccessful}
+			if i < len(tt.failed) && tt.failed[i] {
+				receipts[i].Status = types.ReceiptStatusFailed
+			}
+		}
+		header := tester.header(tester.genesis, 1, 1)
+		if err := tester.engine.applySystemTxs(config, header, statedb, ctx, tt.txs, receipts); err != nil {
+			t.Errorf("%s: failed to apply system transactions: %v", tt.name, err)
+			continue
+		}
+		if stake := ctx.Stake(sender); stake.Int64() != tt.stake {
+			t.Errorf("%s: stake mismatch: have %v, want %v", tt.name, stake, tt.stake)
+		}
+		if balance := statedb.GetBalance(sender); balance.Int64() != tt.balance {
+			t.Errorf("%s: balance mismatch: have %v, want %v", tt.name, balance, tt.balance)
+		}
+		if balance := statedb.GetBalance(SystemAddress); balance.Int64() != 1000-tt.balance {
+			t.Errorf("%s: system balance mismatch: have %v, want %v", tt.name, balance, 1000-tt.balance)
+		}
+		vote, voted := ctx.Vote(sender)
+		if (tt.vote == nil) == voted || (voted && vote != *tt.vote) {
+			t.Errorf("%s: vote mismatch: have %x/%v, want %v", tt.name, vote, voted, tt.vote)
+		}
+		if ctx.IsCandidate(sender) != tt.register {
+			t.Errorf("%s: candidacy mismatch: have %v, want %v", tt.name, ctx.IsCandidate(sender), tt.register)
+		}
+	}
+}
+
+// Tests that headers need to be signed by the producer scheduled for their slot,
+// unless the schedule is unknown locally.
+func TestVerifySeal(t *testing.T) {
+	tester := newTester(t)
+
+	tests := []struct {
+		slot     uint64
+		coinbase int
+		signer   int
+		err      error
+	}{
+		{slot: 1, coinbase: 1, signer: 1, err: nil},
+		{slot: 2, coinbase: 2, signer: 2, err: nil},
+		{slot: 4, coinbase: 1, signer: 1, err: nil},
+		{slot: 1, coinbase: 0, signer: 0, err: errUnauthorized},
+		{slot: 1, coinbase: 1, signer: 0, err: errUnauthorized},
+		{slot: 2, coinbase: 1, signer: 1, err: errUnauthorized},
+	}
+	for i, tt := range tests {
+		header := tester.header(tester.genesis, tt.slot, tt.coinbase)
+		tester.sign(header, tt.signer)
+
+		if err := tester.engine.VerifySeal(tester.chain, header); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// The genesis block and orphans can't be verified
+	if err := tester.engine.VerifySeal(tester.chain, tester.genesis); err != errUnknownBlock {
+		t.Errorf("genesis: error mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+	orphan := tester.header(&types.Header{Number: common.Big1}, 2, 2)
+	tester.sign(orphan, 2)
+	if err := tester.engine.VerifySeal(tester.chain, orphan); err != consensus.ErrUnknownAncestor {
+		t.Errorf("orphan: error mismatch: have %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	// Without the parent's election context, only the signer is checked
+	parent := tester.header(tester.genesis, 1, 1)
+	parent.MixDigest = common.Hash{0x01}
+	tester.sign(parent, 1)
+	tester.chain.headers[parent.Hash()] = parent
+
+	header := tester.header(parent, 2, 0)
+	tester.sign(header, 0)
+	if err := tester.engine.VerifySeal(tester.chain, header); err != nil {
+		t.Errorf("unknown context: unexpected error: %v", err)
+	}
+	tester.sign(header, 1)
+	if err := tester.engine.VerifySeal(tester.chain, header); err != errUnauthorized {
+		t.Errorf("unknown context: error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+}
+
+// Tests that headers are prepared for the next slot of the local producer.
+func TestPrepare(t *testing.T) {
+	tester := newTester(t)
+
+	// Start from a recent parent to keep the schedule predictable
+	now := uint64(time.Now().Unix()) / tester.engine.config.Period
+	parent := tester.finalize(tester.header(tester.genesis, now-1, int((now-1)%3)), int((now-1)%3), nil, nil)
+
+	for validator := range tester.validators {
+		tester.engine.Authorize(tester.validators[validator], func(accounts.Account, []byte) ([]byte, error) {
+			return nil, nil
+		})
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Extra:      []byte("vanity"),
+			MixDigest:  common.Hash{0x01},
+			Nonce:      types.BlockNonce{0x01},
+		}
+		if err := tester.engine.Prepare(tester.chain, header); err != nil {
+			t.Fatalf("validator %d: failed to prepare header: %v", validator, err)
+		}
+		if len(header.Extra) != extraVanity+extraSeal || string(header.Extra[:6]) != "vanity" {
+			t.Errorf("validator %d: extra-data mismatch: have %x", validator, header.Extra)
+		}
+		if header.MixDigest != (common.Hash{}) || header.Nonce != (types.BlockNonce{}) || header.Difficulty.Cmp(defaultDifficulty) != 0 {
+			t.Errorf("validator %d: consensus fields not reset", validator)
+		}
+		// The block must be in the first slot of the validator not in the past
+		slot := header.Time.Uint64() / tester.engine.config.Period
+		if header.Time.Uint64()%tester.engine.config.Period != 0 {
+			t.Errorf("validator %d: timestamp %v not aligned to slot", validator, header.Time)
+		}
+		if slot <= parent.Time.Uint64()/tester.engine.config.Period || slot < now {
+			t.Errorf("validator %d: slot %d in the past", validator, slot)
+		}
+		for s := parent.Time.Uint64()/tester.engine.config.Period + 1; s < slot; s++ {
+			if s >= now && tester.producer(parent, s) == tester.validators[validator] {
+				t.Errorf("validator %d: earlier slot %d skipped", validator, s)
+			}
+		}
+		if producer := tester.producer(parent, slot); producer != tester.validators[validator] {
+			t.Errorf("validator %d: slot %d scheduled for %x", validator, slot, producer)
+		}
+	}
+	// Non-producers get the next slot, without any hope to seal it
+	tester.engine.Authorize(common.Address{0x01}, nil)
+
+	header := &types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number, common.Big1)}
+	if err := tester.engine.Prepare(tester.chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	if slot := header.Time.Uint64() / tester.engine.config.Period; slot != now && slot != now+1 {
+		t.Errorf("non-producer slot mismatch: have %d, want %d", slot, now)
+	}
+	// Headers without the parent can't be prepared
+	header = &types.Header{ParentHash: common.Hash{0x01}, Number: big.NewInt(2)}
+	if err := tester.engine.Prepare(tester.chain, header); err != consensus.ErrUnknownAncestor {
+		t.Errorf("error mismatch: have %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+}
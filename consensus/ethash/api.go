@@ -0,0 +1,140 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/core/types"
+)
+
+var (
+	errEthashStopped = errors.New("ethash stopped")
+	errNotSupported  = errors.New("remote mining not supported")
+)
+
+// API exposes ethash related methods for the RPC interface.
+type API struct {
+	ethash *Ethash
+}
+
+// GetWork returns a work package for external miner.
+//
+// The work package consists of 3 strings:
+//
+//	result[0] - 32 bytes hex encoded current block header pow-hash
+//	result[1] - 32 bytes hex encoded seed hash used for DAG
+//	result[2] - 32 bytes hex encoded boundary condition ("target"), 2^256/difficulty
+func (api *API) GetWork() ([3]string, error) {
+	return api.ethash.GetWork()
+}
+
+// SubmitWork can be used by external miner to submit their POW solution.
+// It returns an indication if the work was accepted.
+// Note either an invalid solution, a stale work or a non-existent work will
+// return false.
+func (api *API) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash) bool {
+	return api.ethash.SubmitWork(nonce, hash, digest) == nil
+}
+
+// SubmitHashRate can be used for remote miners to submit their hash rate.
+// This enables the node to report the combined hash rate of all miners
+// which submit work through this node.
+//
+// It accepts the miner hash rate and an identifier which must be unique
+// between nodes.
+func (api *API) SubmitHashRate(rate hexutil.Uint64, id common.Hash) bool {
+	return api.ethash.SubmitHashRate(uint64(rate), id) == nil
+}
+
+// GetHashrate returns the current hashrate for local CPU miner and remote miner.
+func (api *API) GetHashrate() uint64 {
+	return uint64(api.ethash.Hashrate())
+}
+
+// GetHashrates returns the hash rates recently reported by each remote miner.
+func (api *API) GetHashrates() map[common.Hash]hexutil.Uint64 {
+	rates := make(map[common.Hash]hexutil.Uint64)
+	for id, rate := range api.ethash.remoteHashrates() {
+		rates[id] = hexutil.Uint64(rate)
+	}
+	return rates
+}
+
+// GetWork returns the current work package for remote miners.
+func (ethash *Ethash) GetWork() ([3]string, error) {
+	if ethash.shared != nil {
+		return ethash.shared.GetWork()
+	}
+	if ethash.fetchWorkCh == nil {
+		return [3]string{}, errNotSupported
+	}
+	var (
+		workCh = make(chan [3]string, 1)
+		errc   = make(chan error, 1)
+	)
+	select {
+	case ethash.fetchWorkCh <- &sealWork{errc: errc, res: workCh}:
+	case <-ethash.closeCh:
+		return [3]string{}, errEthashStopped
+	}
+	select {
+	case work := <-workCh:
+		return work, nil
+	case err := <-errc:
+		return [3]string{}, err
+	}
+}
+
+// SubmitWork injects a proof-of-work solution of a remote miner into the engine,
+// returning an error if it's invalid or doesn't belong to a recent work package.
+func (ethash *Ethash) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash) error {
+	if ethash.shared != nil {
+		return ethash.shared.SubmitWork(nonce, hash, digest)
+	}
+	if ethash.submitWorkCh == nil {
+		return errNotSupported
+	}
+	errc := make(chan error, 1)
+	select {
+	case ethash.submitWorkCh <- &mineResult{nonce: nonce, mixDigest: digest, hash: hash, errc: errc}:
+	case <-ethash.closeCh:
+		return errEthashStopped
+	}
+	return <-errc
+}
+
+// SubmitHashRate records the hash rate reported by a remote miner.
+func (ethash *Ethash) SubmitHashRate(rate uint64, id common.Hash) error {
+	if ethash.shared != nil {
+		return ethash.shared.SubmitHashRate(rate, id)
+	}
+	if ethash.submitRateCh == nil {
+		return errNotSupported
+	}
+	done := make(chan struct{})
+	select {
+	case ethash.submitRateCh <- &hashrate{id: id, ping: time.Now(), rate: rate, done: done}:
+	case <-ethash.closeCh:
+		return errEthashStopped
+	}
+	<-done
+	return nil
+}
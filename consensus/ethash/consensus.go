@@ -524,14 +524,20 @@ func (ethash *Ethash) Finalize(chain consensus.ChainReader, header *types.Header
 
 // Some weird constants to avoid constant memory allocs for them.
 var (
-	big8  = big.NewInt(8)
-	big32 = big.NewInt(32)
+	big8   = big.NewInt(8)
+	big32  = big.NewInt(32)
+	big100 = big.NewInt(100)
 )
 
 // AccumulateRewards credits the coinbase of the given block with the mining
 // reward. The total reward consists of the static block reward and rewards for
 // included uncles. The coinbase of each uncle block is also rewarded.
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	// Use the configured reward schedule if it covers this block
+	if era := config.Rewards.Era(header.Number); era != nil {
+		accumulateEraRewards(era, state, header, uncles)
+		return
+	}
 	// Select the correct block reward based on chain progression
 	blockReward := FrontierBlockReward
 	if config.IsByzantium(header.Number) {
@@ -552,3 +558,32 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 	}
 	state.AddBalance(header.Coinbase, reward)
 }
+
+// accumulateEraRewards credits the coinbase of the given block, the coinbase of
+// each included uncle and the optional treasury according to a configured reward
+// era. Uncle and inclusion rewards are derived from the full static reward, the
+// treasury share is only deducted from the miner's static reward.
+func accumulateEraRewards(era *params.RewardEra, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	reward := new(big.Int).Set(era.Reward)
+	if era.Treasury != nil && era.TreasuryPercent > 0 {
+		share := new(big.Int).Mul(era.Reward, new(big.Int).SetUint64(era.TreasuryPercent))
+		share.Div(share, big100)
+		state.AddBalance(*era.Treasury, share)
+		reward.Sub(reward, share)
+	}
+	r := new(big.Int)
+	for _, uncle := range uncles {
+		if depth := header.Number.Uint64() - uncle.Number.Uint64(); depth < era.UncleDivisor {
+			divisor := new(big.Int).SetUint64(era.UncleDivisor)
+			r.Sub(divisor, new(big.Int).SetUint64(depth))
+			r.Mul(r, era.Reward)
+			r.Div(r, divisor)
+			state.AddBalance(uncle.Coinbase, r)
+		}
+		if era.NephewDivisor > 0 {
+			r.Div(era.Reward, new(big.Int).SetUint64(era.NephewDivisor))
+			reward.Add(reward, r)
+		}
+	}
+	state.AddBalance(header.Coinbase, reward)
+}
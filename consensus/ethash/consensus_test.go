@@ -23,8 +23,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/common/math"
+	"github.com/DEWH/go-DEWH/core/state"
 	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/ethdb"
 	"github.com/DEWH/go-DEWH/params"
 )
 
@@ -84,3 +87,52 @@ func TestCalcDifficulty(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a configured reward schedule overrides the default block rewards
+// and splits the issuance between the miner, the uncles and the treasury.
+func TestRewardSchedule(t *testing.T) {
+	var (
+		miner    = common.HexToAddress("0x01")
+		uncler   = common.HexToAddress("0x02")
+		treasury = common.HexToAddress("0x03")
+	)
+	config := &params.ChainConfig{
+		ByzantiumBlock: big.NewInt(0),
+		Rewards: &params.RewardConfig{Eras: []*params.RewardEra{{
+			Block:           big.NewInt(100),
+			Reward:          big.NewInt(1000),
+			UncleDivisor:    4,
+			NephewDivisor:   10,
+			Treasury:        &treasury,
+			TreasuryPercent: 20,
+		}}},
+	}
+	tests := []struct {
+		number, uncle           int64
+		miner, uncler, treasury int64
+	}{
+		// Before the schedule starts, the Byzantium defaults apply
+		{number: 99, uncle: 98, miner: 3e18 + 3e18/32, uncler: 3e18 * 7 / 8},
+		// Within the schedule, uncle rewards shrink with depth and vanish beyond the divisor
+		{number: 100, uncle: 99, miner: 800 + 100, uncler: 750, treasury: 200},
+		{number: 103, uncle: 100, miner: 800 + 100, uncler: 250, treasury: 200},
+		{number: 106, uncle: 100, miner: 800 + 100, uncler: 0, treasury: 200},
+	}
+	for i, test := range tests {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		header := &types.Header{Number: big.NewInt(test.number), Coinbase: miner}
+		uncles := []*types.Header{{Number: big.NewInt(test.uncle), Coinbase: uncler}}
+
+		accumulateRewards(config, statedb, header, uncles)
+
+		if have := statedb.GetBalance(miner); have.Cmp(big.NewInt(test.miner)) != 0 {
+			t.Errorf("test %d: miner balance mismatch: have %v, want %v", i, have, test.miner)
+		}
+		if have := statedb.GetBalance(uncler); have.Cmp(big.NewInt(test.uncler)) != 0 {
+			t.Errorf("test %d: uncle balance mismatch: have %v, want %v", i, have, test.uncler)
+		}
+		if have := statedb.GetBalance(treasury); have.Cmp(big.NewInt(test.treasury)) != 0 {
+			t.Errorf("test %d: treasury balance mismatch: have %v, want %v", i, have, test.treasury)
+		}
+	}
+}
@@ -33,7 +33,10 @@ import (
 	"unsafe"
 
 	mmap "github.com/edsrzf/mmap-go"
+	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/event"
 	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/metrics"
 	"github.com/DEWH/go-DEWH/rpc"
@@ -47,7 +50,7 @@ var (
 	maxUint256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
 
 	// sharedEthash is a full instance that can be shared between multiple users.
-	sharedEthash = New(Config{"", 3, 0, "", 1, 0, ModeNormal})
+	sharedEthash = New(Config{"", 3, 0, "", 1, 0, ModeNormal}, nil)
 
 	// algorithmRevision is the data structure version used for file naming.
 	algorithmRevision = 23
@@ -403,6 +406,17 @@ type Ethash struct {
 	update   chan struct{} // Notification channel to update mining parameters
 	hashrate metrics.Meter // Meter tracking the average hashrate
 
+	// Remote sealer related fields
+	workCh       chan *types.Block                // Notification channel to push new work to remote sealer
+	resultCh     chan *types.Block                // Channel used by the remote sealer to return results
+	fetchWorkCh  chan *sealWork                   // Channel used for remote sealer to fetch mining work
+	submitWorkCh chan *mineResult                 // Channel used for remote sealer to submit their mining result
+	fetchRateCh  chan chan map[common.Hash]uint64 // Channel used to gather the hash rates submitted by remote sealers
+	submitRateCh chan *hashrate                   // Channel used for remote sealer to submit their mining hashrate
+	closeCh      chan struct{}                    // Channel to terminate the remote sealer
+	workFeed     event.Feed                       // Feed announcing the new work packages to remote miners
+	closeOnce    sync.Once                        // Ensures the remote sealer is only terminated once
+
 	// The fields below are hooks for testing
 	shared    *Ethash       // Shared PoW verifier to avoid cache regeneration
 	fakeFail  uint64        // Block number which fails PoW check even in fake mode
@@ -411,8 +425,10 @@ type Ethash struct {
 	lock sync.Mutex // Ensures thread safety for the in-memory caches and mining fields
 }
 
-// New creates a full sized ethash PoW scheme.
-func New(config Config) *Ethash {
+// New creates a full sized ethash PoW scheme and starts a background thread for
+// remote mining, also optionally notifying a batch of remote services of new work
+// packages.
+func New(config Config, notify []string) *Ethash {
 	if config.CachesInMem <= 0 {
 		log.Warn("One ethash cache must always be in memory", "requested", config.CachesInMem)
 		config.CachesInMem = 1
@@ -423,19 +439,28 @@ func New(config Config) *Ethash {
 	if config.DatasetDir != "" && config.DatasetsOnDisk > 0 {
 		log.Info("Disk storage enabled for ethash DAGs", "dir", config.DatasetDir, "count", config.DatasetsOnDisk)
 	}
-	return &Ethash{
-		config:   config,
-		caches:   newlru("cache", config.CachesInMem, newCache),
-		datasets: newlru("dataset", config.DatasetsInMem, newDataset),
-		update:   make(chan struct{}),
-		hashrate: metrics.NewMeter(),
+	ethash := &Ethash{
+		config:       config,
+		caches:       newlru("cache", config.CachesInMem, newCache),
+		datasets:     newlru("dataset", config.DatasetsInMem, newDataset),
+		update:       make(chan struct{}),
+		hashrate:     metrics.NewMeter(),
+		workCh:       make(chan *types.Block),
+		resultCh:     make(chan *types.Block),
+		fetchWorkCh:  make(chan *sealWork),
+		submitWorkCh: make(chan *mineResult),
+		fetchRateCh:  make(chan chan map[common.Hash]uint64),
+		submitRateCh: make(chan *hashrate),
+		closeCh:      make(chan struct{}),
 	}
+	go ethash.remote(notify)
+	return ethash
 }
 
 // NewTester creates a small sized ethash PoW scheme useful only for testing
 // purposes.
 func NewTester() *Ethash {
-	return New(Config{CachesInMem: 1, PowMode: ModeTest})
+	return New(Config{CachesInMem: 1, PowMode: ModeTest}, nil)
 }
 
 // NewFaker creates a ethash consensus engine with a fake PoW scheme that accepts
@@ -560,17 +585,85 @@ func (ethash *Ethash) SetThreads(threads int) {
 }
 
 // Hashrate implements PoW, returning the measured rate of the search invocations
-// per second over the last minute.
+// per second over the last minute, including the rates reported by remote miners.
 func (ethash *Ethash) Hashrate() float64 {
-	return ethash.hashrate.Rate1()
+	// If we're running a shared PoW, report the hashrate of that instead
+	if ethash.shared != nil {
+		return ethash.shared.Hashrate()
+	}
+	rate := ethash.hashrate.Rate1()
+	for _, remote := range ethash.remoteHashrates() {
+		rate += float64(remote)
+	}
+	return rate
 }
 
-// APIs implements consensus.Engine, returning the user facing RPC APIs. Currently
-// that is empty.
-func (ethash *Ethash) APIs(chain consensus.ChainReader) []rpc.API {
+// remoteHashrates gathers the hash rates recently submitted by remote miners.
+func (ethash *Ethash) remoteHashrates() map[common.Hash]uint64 {
+	if ethash.shared != nil {
+		return ethash.shared.remoteHashrates()
+	}
+	if ethash.fetchRateCh == nil {
+		return nil
+	}
+	req := make(chan map[common.Hash]uint64, 1)
+	select {
+	case ethash.fetchRateCh <- req:
+		return <-req
+	case <-ethash.closeCh:
+		return nil
+	}
+}
+
+// SubscribeWork registers a subscription to be notified of the new work packages
+// handed out to remote miners.
+func (ethash *Ethash) SubscribeWork(ch chan<- *Work) event.Subscription {
+	if ethash.shared != nil {
+		return ethash.shared.SubscribeWork(ch)
+	}
+	return ethash.workFeed.Subscribe(ch)
+}
+
+// Hashimoto runs the light ethash algorithm on a header hash and nonce of the
+// given block, returning the mix digest and the proof-of-work value to compare
+// against the target. It allows checking remote solutions against other targets
+// than the block difficulty (e.g. mining pool shares).
+func (ethash *Ethash) Hashimoto(number uint64, hash common.Hash, nonce uint64) (common.Hash, common.Hash) {
+	if ethash.shared != nil {
+		return ethash.shared.Hashimoto(number, hash, nonce)
+	}
+	cache := ethash.cache(number)
+	size := datasetSize(number)
+	if ethash.config.PowMode == ModeTest {
+		size = 32 * 1024
+	}
+	digest, result := hashimotoLight(size, cache.cache, hash.Bytes(), nonce)
+	// Caches are unmapped in a finalizer. Ensure that the cache stays live
+	// until after the call to hashimotoLight so it's not unmapped while being used.
+	runtime.KeepAlive(cache)
+
+	return common.BytesToHash(digest), common.BytesToHash(result)
+}
+
+// Close terminates the remote sealer of the ethash engine, if it's running.
+func (ethash *Ethash) Close() error {
+	if ethash.closeCh != nil {
+		ethash.closeOnce.Do(func() { close(ethash.closeCh) })
+	}
 	return nil
 }
 
+// APIs implements consensus.Engine, returning the user facing RPC APIs to let
+// remote miners fetch work packages and submit solutions and hash rates.
+func (ethash *Ethash) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "ethash",
+		Version:   "1.0",
+		Service:   &API{ethash},
+		Public:    true,
+	}}
+}
+
 // SeedHash is the seed to use for generating a verification cache and the mining
 // dataset.
 func SeedHash(block uint64) []byte {
@@ -17,12 +17,17 @@
 package ethash
 
 import (
+	"bytes"
 	crand "crypto/rand"
+	"encoding/json"
+	"errors"
 	"math"
 	"math/big"
 	"math/rand"
+	"net/http"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/consensus"
@@ -30,6 +35,21 @@ import (
 	"github.com/DEWH/go-DEWH/log"
 )
 
+const (
+	// staleThreshold is the maximum number of work packages kept valid for the
+	// same parent block, older ones are discarded.
+	staleThreshold = 8
+
+	// hashrateTimeout is the time after which a remote miner's reported hashrate
+	// is dropped if it isn't refreshed.
+	hashrateTimeout = 10 * time.Second
+)
+
+var (
+	errNoMiningWork      = errors.New("no mining work available yet")
+	errInvalidSealResult = errors.New("invalid or stale proof-of-work solution")
+)
+
 // Seal implements consensus.Engine, attempting to find a nonce that satisfies
 // the block's difficulty requirements.
 func (ethash *Ethash) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
@@ -43,6 +63,13 @@ func (ethash *Ethash) Seal(chain consensus.ChainReader, block *types.Block, stop
 	if ethash.shared != nil {
 		return ethash.shared.Seal(chain, block, stop)
 	}
+	// Push the new work to the remote sealer
+	if ethash.workCh != nil {
+		select {
+		case ethash.workCh <- block:
+		case <-ethash.closeCh:
+		}
+	}
 	// Create a runner and the multiple search threads it directs
 	abort := make(chan struct{})
 	found := make(chan *types.Block)
@@ -81,6 +108,9 @@ func (ethash *Ethash) Seal(chain consensus.ChainReader, block *types.Block, stop
 	case result = <-found:
 		// One of the threads found a block, abort all others
 		close(abort)
+	case result = <-ethash.resultCh:
+		// A remote miner found a block (maybe for older work), abort all threads
+		close(abort)
 	case <-ethash.update:
 		// Thread count was changed on user request, restart
 		close(abort)
@@ -150,3 +180,165 @@ search:
 	// during sealing so it's not unmapped while being read.
 	runtime.KeepAlive(dataset)
 }
+
+// Work is a work package handed out to remote miners.
+type Work struct {
+	Hash   common.Hash // Header hash without the nonce to search a seal for
+	Seed   common.Hash // Seed hash of the DAG to use for the search
+	Target *big.Int    // Boundary the proof-of-work value must not exceed
+	Number uint64      // Number of the block being sealed
+}
+
+// sealWork wraps a request of a remote miner for a work package.
+type sealWork struct {
+	errc chan error
+	res  chan [3]string
+}
+
+// mineResult wraps the proof-of-work solution submitted by a remote miner.
+type mineResult struct {
+	nonce     types.BlockNonce
+	mixDigest common.Hash
+	hash      common.Hash
+
+	errc chan error
+}
+
+// hashrate wraps the hash rate submitted by a remote miner.
+type hashrate struct {
+	id   common.Hash
+	ping time.Time
+	rate uint64
+
+	done chan struct{}
+}
+
+// remote is a standalone goroutine to handle remote mining related stuff. It
+// keeps the most recent work packages of the current parent block valid so that
+// remote miners may submit solutions for any of them.
+func (ethash *Ethash) remote(notify []string) {
+	var (
+		works = make(map[common.Hash]*types.Block)
+		order []common.Hash
+		rates = make(map[common.Hash]hashrate)
+
+		currentWork  *types.Block
+		notifyClient = &http.Client{Timeout: time.Second}
+	)
+	// makeWork creates a work package for the external miner, consisting of the
+	// header pow-hash, the seed hash used for the DAG and the boundary condition
+	// ("target"), 2^256/difficulty.
+	makeWork := func(block *types.Block) [3]string {
+		var work [3]string
+		work[0] = block.HashNoNonce().Hex()
+		work[1] = common.BytesToHash(SeedHash(block.NumberU64())).Hex()
+		work[2] = common.BytesToHash(new(big.Int).Div(maxUint256, block.Difficulty()).Bytes()).Hex()
+		return work
+	}
+	// notifyWork pushes the current work package to all the notification URLs.
+	notifyWork := func() {
+		blob, _ := json.Marshal(makeWork(currentWork))
+		for _, url := range notify {
+			go func(url string) {
+				res, err := notifyClient.Post(url, "application/json", bytes.NewReader(blob))
+				if err != nil {
+					log.Warn("Failed to notify remote miner", "url", url, "err", err)
+					return
+				}
+				res.Body.Close()
+			}(url)
+		}
+	}
+	// submitWork verifies the submitted pow solution, returning whether it was
+	// accepted or not. If it was, the sealed block is handed to the running seal.
+	submitWork := func(nonce types.BlockNonce, mixDigest common.Hash, hash common.Hash) error {
+		block := works[hash]
+		if block == nil {
+			log.Info("Work submitted but none pending", "hash", hash)
+			return errInvalidSealResult
+		}
+		header := block.Header()
+		header.Nonce = nonce
+		header.MixDigest = mixDigest
+		if err := ethash.VerifySeal(nil, header); err != nil {
+			log.Warn("Invalid proof-of-work submitted", "hash", hash, "err", err)
+			return errInvalidSealResult
+		}
+		select {
+		case ethash.resultCh <- block.WithSeal(header):
+			delete(works, hash)
+			return nil
+		default:
+			log.Info("Work submitted is stale", "hash", hash)
+			return errInvalidSealResult
+		}
+	}
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case block := <-ethash.workCh:
+			// Start a new round of packages if the parent changed, otherwise keep
+			// the most recent ones valid
+			if currentWork != nil && block.ParentHash() != currentWork.ParentHash() {
+				works, order = make(map[common.Hash]*types.Block), nil
+			}
+			hash := block.HashNoNonce()
+			_, known := works[hash]
+			if !known {
+				order = append(order, hash)
+			}
+			works[hash] = block
+			for len(order) > staleThreshold {
+				delete(works, order[0])
+				order = order[1:]
+			}
+			currentWork = block
+
+			if !known {
+				ethash.workFeed.Send(&Work{
+					Hash:   hash,
+					Seed:   common.BytesToHash(SeedHash(block.NumberU64())),
+					Target: new(big.Int).Div(maxUint256, block.Difficulty()),
+					Number: block.NumberU64(),
+				})
+				if len(notify) > 0 {
+					notifyWork()
+				}
+			}
+
+		case req := <-ethash.fetchWorkCh:
+			if currentWork == nil {
+				req.errc <- errNoMiningWork
+			} else {
+				req.res <- makeWork(currentWork)
+			}
+
+		case result := <-ethash.submitWorkCh:
+			result.errc <- submitWork(result.nonce, result.mixDigest, result.hash)
+
+		case result := <-ethash.submitRateCh:
+			rates[result.id] = hashrate{rate: result.rate, ping: time.Now()}
+			close(result.done)
+
+		case req := <-ethash.fetchRateCh:
+			res := make(map[common.Hash]uint64, len(rates))
+			for id, rate := range rates {
+				res[id] = rate.rate
+			}
+			req <- res
+
+		case <-ticker.C:
+			// Drop the hash rates of miners that stopped reporting
+			for id, rate := range rates {
+				if time.Since(rate.ping) > hashrateTimeout {
+					delete(rates, id)
+				}
+			}
+
+		case <-ethash.closeCh:
+			return
+		}
+	}
+}
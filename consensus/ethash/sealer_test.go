@@ -0,0 +1,149 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+)
+
+// Tests that remote miners get notified of new work packages.
+func TestRemoteNotify(t *testing.T) {
+	// Start a simple webserver to capture notifications
+	sink := make(chan [3]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		blob, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("failed to read miner notification: %v", err)
+		}
+		var work [3]string
+		if err := json.Unmarshal(blob, &work); err != nil {
+			t.Errorf("failed to unmarshal miner notification: %v", err)
+		}
+		sink <- work
+	}))
+	defer server.Close()
+
+	// Create the custom ethash engine and push a block to the remote sealer
+	ethash := New(Config{CachesInMem: 1, PowMode: ModeTest}, []string{server.URL})
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	block := types.NewBlockWithHeader(header)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go ethash.Seal(nil, block, stop)
+
+	select {
+	case work := <-sink:
+		if want := header.HashNoNonce().Hex(); work[0] != want {
+			t.Errorf("work packet hash mismatch: have %s, want %s", work[0], want)
+		}
+		if want := common.BytesToHash(SeedHash(header.Number.Uint64())).Hex(); work[1] != want {
+			t.Errorf("work packet seed mismatch: have %s, want %s", work[1], want)
+		}
+		target := new(big.Int).Div(maxUint256, header.Difficulty)
+		if want := common.BytesToHash(target.Bytes()).Hex(); work[2] != want {
+			t.Errorf("work packet target mismatch: have %s, want %s", work[2], want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("notification timed out")
+	}
+}
+
+// Tests that solutions for any of the recent work packages are accepted, and
+// that invalid ones are rejected.
+func TestRemoteSealer(t *testing.T) {
+	ethash := NewTester()
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	if _, err := ethash.GetWork(); err != errNoMiningWork {
+		t.Fatalf("work retrieval error mismatch: have %v, want %v", err, errNoMiningWork)
+	}
+	// Push two work packages on the same parent, sealing only the older one
+	older := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)})
+	newer := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100), Time: big.NewInt(1)})
+
+	stop := make(chan struct{})
+	go ethash.Seal(nil, older, stop)
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+
+	results := make(chan *types.Block)
+	go func() {
+		block, _ := ethash.Seal(nil, newer, nil)
+		results <- block
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if work, err := ethash.GetWork(); err != nil || work[0] != newer.HashNoNonce().Hex() {
+		t.Fatalf("current work mismatch: have %v (err %v), want %s", work[0], err, newer.HashNoNonce().Hex())
+	}
+	// Submit an invalid solution, then mine and submit a valid one for the older package
+	if err := ethash.SubmitWork(types.BlockNonce{}, older.HashNoNonce(), common.Hash{}); err == nil {
+		t.Fatalf("invalid solution accepted")
+	}
+	sealer := NewTester()
+	defer sealer.Close()
+
+	sealed, err := sealer.Seal(nil, older, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if err := ethash.SubmitWork(types.EncodeNonce(sealed.Nonce()), older.HashNoNonce(), sealed.MixDigest()); err != nil {
+		t.Fatalf("valid solution rejected: %v", err)
+	}
+	select {
+	case block := <-results:
+		if block.HashNoNonce() != older.HashNoNonce() {
+			t.Errorf("sealed block mismatch: have %x, want %x", block.HashNoNonce(), older.HashNoNonce())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealing result timed out")
+	}
+}
+
+// Tests that the hash rates of remote miners are tracked individually.
+func TestRemoteHashrate(t *testing.T) {
+	ethash := NewTester()
+	defer ethash.Close()
+
+	ethash.SubmitHashRate(100, common.Hash{0x01})
+	ethash.SubmitHashRate(200, common.Hash{0x02})
+	ethash.SubmitHashRate(300, common.Hash{0x01})
+
+	api := &API{ethash}
+	rates := api.GetHashrates()
+	if len(rates) != 2 || rates[common.Hash{0x01}] != 300 || rates[common.Hash{0x02}] != 200 {
+		t.Errorf("remote hashrates mismatch: have %v", rates)
+	}
+	if rate := ethash.Hashrate(); rate < 500 {
+		t.Errorf("total hashrate mismatch: have %f, want at least %d", rate, 500)
+	}
+}
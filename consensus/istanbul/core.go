@@ -0,0 +1,586 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/accounts"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+const (
+	requestChanSize = 1    // Size of the channel feeding local proposals
+	messageChanSize = 256  // Size of the channel feeding consensus messages from the network
+	headChanSize    = 10   // Size of the channel listening to chain head events
+	maxBacklog      = 1024 // Maximum number of future messages to keep around
+	maxTimeoutShift = 8    // Maximum exponent of the round timeout backoff
+)
+
+// step is the progress of the state machine within a round.
+type step int
+
+const (
+	stepNew         step = iota // Waiting for the proposal of the round
+	stepPreprepared             // Proposal accepted, collecting prepares
+	stepPrepared                // Quorum prepared, collecting commits
+	stepCommitted               // Quorum committed, waiting for the block import
+)
+
+// machine is the consensus state machine agreeing on the block of a sequence
+// (block number). Every round starts with the proposer of the round sending a
+// PRE-PREPARE with its proposal. Validators accepting it send a PREPARE, and
+// once a quorum of prepares is seen the proposal is locked and a COMMIT with a
+// committed seal is sent. A quorum of commits finalizes the block. If a round
+// doesn't complete in time, validators send ROUND-CHANGE messages and move on
+// to the next round, with the next validator proposing. A ROUND-CHANGE carries
+// the prepared certificate of the sender's locked proposal, i.e. the proposal
+// and the prepares of the quorum which locked it. Validators shown a certificate
+// of a later round than their own lock switch over to it, so the proposers of
+// later rounds re-propose the highest certified block instead of validators
+// staying stuck on conflicting locks.
+//
+// All the consensus state is only accessed from the event loop.
+type machine struct {
+	engine *Istanbul
+	chain  Chain
+
+	requestCh chan *types.Block
+	messageCh chan *message
+	headCh    chan core.ChainHeadEvent
+	headSub   event.Subscription
+	timer     *time.Timer
+	quit      chan struct{}
+	wg        sync.WaitGroup
+
+	sequence   uint64           // Block number being agreed upon
+	round      uint64           // Current round within the sequence
+	parent     *types.Header    // Chain head the sequence builds upon
+	validators []common.Address // Validator set of the sequence, ascending
+	step       step             // Progress within the current round
+
+	request  *types.Block // Latest block handed over by the local sealer
+	proposal *types.Block // Proposal accepted in the current round
+	digest   common.Hash  // Proposal hash of the accepted proposal
+
+	locked         *types.Block // Proposal prepared in this sequence, the only one acceptable afterwards
+	lockedDigest   common.Hash  // Proposal hash of the locked proposal
+	lockedRound    uint64       // Round the locked proposal was prepared in
+	lockedPrepares []*message   // Prepares of the quorum certifying the locked proposal
+
+	prepares     map[common.Address]*message            // Prepare messages of the current round
+	commits      map[common.Address]*message            // Commit messages of the current round
+	roundChanges map[uint64]map[common.Address]struct{} // Validators asking to move to future rounds
+	sentRound    uint64                                 // Highest round a round change was sent for
+	backlog      []*message                             // Messages for future views
+}
+
+// newMachine creates a consensus state machine on top of the given chain.
+func newMachine(engine *Istanbul, chain Chain) *machine {
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	return &machine{
+		engine:    engine,
+		chain:     chain,
+		requestCh: make(chan *types.Block, requestChanSize),
+		messageCh: make(chan *message, messageChanSize),
+		headCh:    make(chan core.ChainHeadEvent, headChanSize),
+		timer:     timer,
+		quit:      make(chan struct{}),
+	}
+}
+
+// start subscribes to the chain head and launches the event loop.
+func (m *machine) start() {
+	m.headSub = m.chain.SubscribeChainHeadEvent(m.headCh)
+
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// stop terminates the event loop and waits for it to return.
+func (m *machine) stop() {
+	m.headSub.Unsubscribe()
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// propose hands a sealed block of the local node over for agreement.
+func (m *machine) propose(block *types.Block) {
+	select {
+	case m.requestCh <- block:
+	case <-m.quit:
+	}
+}
+
+// deliver feeds a verified consensus message from the network to the state
+// machine, dropping it if the machine cannot keep up.
+func (m *machine) deliver(msg *message) {
+	select {
+	case m.messageCh <- msg:
+	case <-m.quit:
+	default:
+		log.Debug("Dropping consensus message, queue full", "msg", msg)
+	}
+}
+
+// loop is the event loop of the state machine.
+func (m *machine) loop() {
+	defer m.wg.Done()
+	defer m.timer.Stop()
+
+	if head := m.chain.CurrentHeader(); head != nil {
+		m.startSequence(head)
+	}
+	for {
+		select {
+		case block := <-m.requestCh:
+			m.handleRequest(block)
+
+		case msg := <-m.messageCh:
+			m.handleMessage(msg)
+
+		case ev := <-m.headCh:
+			if ev.Block.NumberU64() >= m.sequence {
+				m.startSequence(ev.Block.Header())
+			}
+
+		case <-m.timer.C:
+			m.handleTimeout()
+
+		case <-m.headSub.Err():
+			return
+
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// startSequence starts agreeing on the block following the given head.
+func (m *machine) startSequence(parent *types.Header) {
+	snap, err := m.engine.snapshot(m.chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		log.Warn("Failed to retrieve validator set", "number", parent.Number, "hash", parent.Hash(), "err", err)
+		return
+	}
+	m.sequence = parent.Number.Uint64() + 1
+	m.parent = parent
+	m.validators = snap.validators()
+
+	m.locked, m.lockedDigest, m.lockedRound, m.lockedPrepares = nil, common.Hash{}, 0, nil
+	m.roundChanges = make(map[uint64]map[common.Address]struct{})
+	m.sentRound = 0
+	if m.request != nil && m.request.NumberU64() != m.sequence {
+		m.request = nil
+	}
+	m.startRound(0)
+}
+
+// startRound resets the round state and starts the given round of the current
+// sequence, proposing if the local node is the proposer of the round.
+func (m *machine) startRound(round uint64) {
+	m.round = round
+	m.step = stepNew
+	m.proposal, m.digest = nil, common.Hash{}
+	m.prepares = make(map[common.Address]*message)
+	m.commits = make(map[common.Address]*message)
+	for r := range m.roundChanges {
+		if r <= round {
+			delete(m.roundChanges, r)
+		}
+	}
+	m.resetTimer(round)
+
+	log.Debug("Starting consensus round", "sequence", m.sequence, "round", round, "proposer", proposer(m.validators, m.sequence, round))
+	m.tryPropose()
+
+	// Replay any messages that were waiting for this view
+	backlog := m.backlog
+	m.backlog = nil
+	for _, msg := range backlog {
+		m.handleMessage(msg)
+	}
+}
+
+// resetTimer arms the round timeout for the given round. Every round change
+// doubles the timeout to give slow validators a chance to catch up.
+func (m *machine) resetTimer(round uint64) {
+	if !m.timer.Stop() {
+		select {
+		case <-m.timer.C:
+		default:
+		}
+	}
+	shift := round
+	if shift > maxTimeoutShift {
+		shift = maxTimeoutShift
+	}
+	timeout := time.Duration(m.engine.config.Period)*time.Second + time.Duration(m.engine.config.RequestTimeout<<shift)*time.Millisecond
+	m.timer.Reset(timeout)
+}
+
+// handleRequest accepts a block of the local sealer as the proposal to make
+// when the local node is the proposer.
+func (m *machine) handleRequest(block *types.Block) {
+	if m.parent == nil || block.NumberU64() != m.sequence || block.ParentHash() != m.parent.Hash() {
+		log.Debug("Ignoring stale proposal request", "number", block.Number(), "sequence", m.sequence)
+		return
+	}
+	m.request = block
+	m.tryPropose()
+}
+
+// tryPropose sends the proposal of the round if the local node is the proposer
+// and has something to propose. A locked proposal, the highest certified one
+// seen, takes precedence over any new block of the local sealer.
+func (m *machine) tryPropose() {
+	if m.step != stepNew {
+		return
+	}
+	signer, _ := m.signer()
+	if signer != proposer(m.validators, m.sequence, m.round) {
+		return
+	}
+	block := m.locked
+	if block == nil {
+		block = m.request
+	}
+	if block == nil {
+		return
+	}
+	digest, err := proposalHash(block.Header())
+	if err != nil {
+		return
+	}
+	blob, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		log.Error("Failed to encode proposal", "err", err)
+		return
+	}
+	log.Debug("Proposing block", "sequence", m.sequence, "round", m.round, "hash", block.Hash())
+	m.broadcast(&message{Code: msgPreprepare, Round: m.round, Digest: digest, Proposal: blob})
+}
+
+// signer returns the local signing credentials.
+func (m *machine) signer() (common.Address, SignerFn) {
+	m.engine.lock.RLock()
+	defer m.engine.lock.RUnlock()
+
+	return m.engine.signer, m.engine.signFn
+}
+
+// isValidator returns whether the address is a validator of the sequence.
+func (m *machine) isValidator(address common.Address) bool {
+	for _, validator := range m.validators {
+		if validator == address {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcast signs a message of the local validator about the current sequence,
+// gossips it to the network and processes it locally. Nodes that are not
+// validators only follow the agreement without sending anything.
+func (m *machine) broadcast(msg *message) {
+	signer, signFn := m.signer()
+	if signFn == nil || !m.isValidator(signer) {
+		return
+	}
+	msg.Sequence = m.sequence
+	msg.Address = signer
+
+	sig, err := signFn(accounts.Account{Address: signer}, msg.sigHash())
+	if err != nil {
+		log.Warn("Failed to sign consensus message", "msg", msg, "err", err)
+		return
+	}
+	msg.Signature = sig
+
+	m.engine.gossip(msg)
+	m.handleMessage(msg)
+}
+
+// handleMessage processes a consensus message, keeping it for later if it is
+// about a view the state machine didn't reach yet. Messages of non-validators
+// are dropped before backlogging so they can't crowd out the real ones; those
+// of the next sequence are checked against the current validator set, and once
+// more against their own when replayed.
+func (m *machine) handleMessage(msg *message) {
+	if m.parent == nil || msg.Sequence < m.sequence {
+		return
+	}
+	if !m.isValidator(msg.Address) {
+		return
+	}
+	future := msg.Sequence > m.sequence || (msg.Round > m.round && msg.Code != msgRoundChange)
+	if future {
+		if msg.Sequence <= m.sequence+1 && len(m.backlog) < maxBacklog {
+			m.backlog = append(m.backlog, msg)
+		}
+		return
+	}
+	log.Trace("Handling consensus message", "msg", msg)
+
+	switch msg.Code {
+	case msgPreprepare:
+		m.handlePreprepare(msg)
+	case msgPrepare:
+		m.handlePrepare(msg)
+	case msgCommit:
+		m.handleCommit(msg)
+	case msgRoundChange:
+		m.handleRoundChange(msg)
+	}
+}
+
+// handlePreprepare validates the proposal of the current round and prepares it
+// if acceptable.
+func (m *machine) handlePreprepare(msg *message) {
+	if msg.Round != m.round || m.step != stepNew {
+		return
+	}
+	if msg.Address != proposer(m.validators, m.sequence, m.round) {
+		log.Debug("Pre-prepare from non-proposer", "msg", msg)
+		return
+	}
+	block, err := msg.block()
+	if err != nil {
+		log.Debug("Invalid proposal encoding", "msg", msg, "err", err)
+		return
+	}
+	if block.NumberU64() != m.sequence || block.ParentHash() != m.parent.Hash() {
+		log.Debug("Proposal not on top of the chain head", "msg", msg, "number", block.Number())
+		return
+	}
+	digest, err := proposalHash(block.Header())
+	if err != nil || digest != msg.Digest {
+		log.Debug("Proposal digest mismatch", "msg", msg)
+		return
+	}
+	if m.locked != nil && digest != m.lockedDigest {
+		log.Debug("Proposal conflicts with locked block", "msg", msg, "locked", m.locked.Hash())
+		return
+	}
+	if err := m.engine.verifyProposal(m.chain, block); err != nil {
+		log.Warn("Invalid block proposal", "msg", msg, "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	m.proposal, m.digest, m.step = block, digest, stepPreprepared
+
+	m.broadcast(&message{Code: msgPrepare, Round: m.round, Digest: digest})
+	m.checkPrepared()
+	m.checkCommitted()
+}
+
+// handlePrepare records the prepare of a validator in the current round.
+func (m *machine) handlePrepare(msg *message) {
+	if msg.Round != m.round {
+		return
+	}
+	m.prepares[msg.Address] = msg
+	m.checkPrepared()
+}
+
+// checkPrepared locks the accepted proposal and commits to it once a quorum of
+// validators prepared it.
+func (m *machine) checkPrepared() {
+	if m.step != stepPreprepared {
+		return
+	}
+	var prepares []*message
+	for _, validator := range m.validators {
+		if msg, ok := m.prepares[validator]; ok && msg.Digest == m.digest {
+			prepares = append(prepares, msg)
+		}
+	}
+	if len(prepares) < quorum(len(m.validators)) {
+		return
+	}
+	m.step = stepPrepared
+	m.locked, m.lockedDigest, m.lockedRound, m.lockedPrepares = m.proposal, m.digest, m.round, prepares
+
+	signer, signFn := m.signer()
+	if signFn == nil || !m.isValidator(signer) {
+		return
+	}
+	seal, err := signFn(accounts.Account{Address: signer}, commitHash(m.digest))
+	if err != nil {
+		log.Warn("Failed to sign committed seal", "err", err)
+		return
+	}
+	m.broadcast(&message{Code: msgCommit, Round: m.round, Digest: m.digest, CommittedSeal: seal})
+}
+
+// handleCommit records the commit of a validator in the current round.
+func (m *machine) handleCommit(msg *message) {
+	if msg.Round != m.round {
+		return
+	}
+	m.commits[msg.Address] = msg
+	m.checkCommitted()
+}
+
+// checkCommitted finalizes the accepted proposal once a quorum of validators
+// committed to it, storing their committed seals in the header.
+func (m *machine) checkCommitted() {
+	if m.proposal == nil || m.step == stepCommitted {
+		return
+	}
+	var seals [][]byte
+	for _, validator := range m.validators {
+		if msg, ok := m.commits[validator]; ok && msg.Digest == m.digest {
+			seals = append(seals, msg.CommittedSeal)
+		}
+	}
+	if len(seals) < quorum(len(m.validators)) {
+		return
+	}
+	m.step = stepCommitted
+
+	header := m.proposal.Header()
+	extra, err := ExtractIstanbulExtra(header)
+	if err != nil {
+		return
+	}
+	extra.CommittedSeal = seals
+	if err := writeExtra(header, extra); err != nil {
+		log.Error("Failed to write committed seals", "err", err)
+		return
+	}
+	block := m.proposal.WithSeal(header)
+	log.Info("Committed block", "number", block.Number(), "hash", block.Hash(), "round", m.round, "seals", len(seals))
+
+	// Import outside of the event loop, the import feeds back a head event
+	go m.engine.commit(m.chain, block, m.digest)
+}
+
+// handleRoundChange records a validator asking to move to a future round. The
+// local node joins once enough validators moved on that at least one of them
+// is honest, and starts the round once a quorum did. A prepared certificate of
+// a later round than the local lock replaces the lock.
+func (m *machine) handleRoundChange(msg *message) {
+	if msg.Round <= m.round {
+		return
+	}
+	if len(msg.Proposal) > 0 || len(msg.Prepares) > 0 {
+		block, round, err := m.verifyCertificate(msg)
+		if err != nil {
+			log.Debug("Invalid prepared certificate", "msg", msg, "err", err)
+			return
+		}
+		if m.locked == nil || round > m.lockedRound {
+			if m.locked != nil && m.lockedDigest != msg.Digest {
+				log.Debug("Releasing lock for later prepared certificate", "sequence", m.sequence, "locked", m.lockedRound, "certified", round)
+			}
+			m.locked, m.lockedDigest, m.lockedRound, m.lockedPrepares = block, msg.Digest, round, msg.Prepares
+		}
+	}
+	set, ok := m.roundChanges[msg.Round]
+	if !ok {
+		set = make(map[common.Address]struct{})
+		m.roundChanges[msg.Round] = set
+	}
+	set[msg.Address] = struct{}{}
+
+	if len(set) > faulty(len(m.validators)) && msg.Round > m.sentRound {
+		m.sendRoundChange(msg.Round)
+	}
+	if len(set) >= quorum(len(m.validators)) && msg.Round > m.round {
+		m.startRound(msg.Round)
+	}
+}
+
+// verifyCertificate checks the prepared certificate carried by a round change:
+// a proposal on top of the chain head, prepared by a quorum of validators in a
+// single round before the requested one. It returns the certified proposal and
+// the round it was prepared in.
+func (m *machine) verifyCertificate(msg *message) (*types.Block, uint64, error) {
+	block, err := msg.block()
+	if err != nil {
+		return nil, 0, err
+	}
+	if block.NumberU64() != m.sequence || block.ParentHash() != m.parent.Hash() {
+		return nil, 0, errInvalidCertificate
+	}
+	if digest, err := proposalHash(block.Header()); err != nil || digest != msg.Digest {
+		return nil, 0, errInvalidCertificate
+	}
+	if len(msg.Prepares) == 0 || msg.Prepares[0].Round >= msg.Round {
+		return nil, 0, errInvalidCertificate
+	}
+	round := msg.Prepares[0].Round
+
+	prepared := make(map[common.Address]struct{})
+	for _, prepare := range msg.Prepares {
+		if prepare.Code != msgPrepare || prepare.Sequence != m.sequence || prepare.Round != round || prepare.Digest != msg.Digest || len(prepare.Prepares) > 0 {
+			return nil, 0, errInvalidCertificate
+		}
+		if !m.isValidator(prepare.Address) {
+			return nil, 0, errInvalidCertificate
+		}
+		if err := prepare.verify(); err != nil {
+			return nil, 0, err
+		}
+		prepared[prepare.Address] = struct{}{}
+	}
+	if len(prepared) < quorum(len(m.validators)) {
+		return nil, 0, errInvalidCertificate
+	}
+	return block, round, nil
+}
+
+// sendRoundChange asks the validators to move to the given round, handing them
+// the prepared certificate of the locked proposal if there is one.
+func (m *machine) sendRoundChange(round uint64) {
+	m.sentRound = round
+	m.resetTimer(round)
+
+	msg := &message{Code: msgRoundChange, Round: round}
+	if m.locked != nil {
+		blob, err := rlp.EncodeToBytes(m.locked)
+		if err != nil {
+			log.Error("Failed to encode locked proposal", "err", err)
+			return
+		}
+		msg.Digest, msg.Proposal, msg.Prepares = m.lockedDigest, blob, m.lockedPrepares
+	}
+	log.Debug("Requesting round change", "sequence", m.sequence, "round", round, "locked", m.locked != nil)
+	m.broadcast(msg)
+}
+
+// handleTimeout moves on to the next round if the current one didn't finish in
+// time.
+func (m *machine) handleTimeout() {
+	if m.parent == nil || m.step == stepCommitted {
+		return
+	}
+	round := m.round
+	if m.sentRound > round {
+		round = m.sentRound
+	}
+	m.sendRoundChange(round + 1)
+}
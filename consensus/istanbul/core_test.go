@@ -0,0 +1,502 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DEWH/go-DEWH/accounts"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/core/vm"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+// testerMachine drives the consensus state machine of a local validator by
+// feeding it the messages of the remote validators directly, without running
+// the event loop.
+type testerMachine struct {
+	t          *testing.T
+	accounts   *testerAccountPool
+	names      map[common.Address]string // Tester account names of the validators
+	validators []string                  // Validator names in ascending address order
+	local      string                    // Validator name of the local node
+	engine     *Istanbul
+	chain      *core.BlockChain
+	machine    *machine
+}
+
+// newTesterMachine creates a chain with four validators and starts agreeing on
+// block #1. The local node is the validator at the given index of the proposer
+// order, i.e. index i proposes round i-1 of block #1.
+func newTesterMachine(t *testing.T, local int) *testerMachine {
+	return newTesterPeer(t, newTesterAccountPool(), local)
+}
+
+// newTesterPeer creates a tester machine of the validator at the given index,
+// using the given accounts so multiple machines can share the validator set.
+func newTesterPeer(t *testing.T, pool *testerAccountPool, local int) *testerMachine {
+	tester := &testerMachine{
+		t:        t,
+		accounts: pool,
+		names:    make(map[common.Address]string),
+	}
+	for _, name := range []string{"A", "B", "C", "D"} {
+		tester.names[tester.accounts.address(name)] = name
+	}
+	addrs := tester.accounts.addresses([]string{"A", "B", "C", "D"})
+	for _, addr := range addrs {
+		tester.validators = append(tester.validators, tester.names[addr])
+	}
+	tester.local = tester.validators[local]
+
+	db := newTesterGenesis(t, addrs)
+	tester.engine = New(&params.IstanbulConfig{RequestTimeout: 60000}, db)
+	tester.engine.Authorize(tester.accounts.address(tester.local), func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, tester.accounts.key(tester.local))
+	})
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, tester.engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	tester.chain = chain
+	tester.machine = newMachine(tester.engine, chain)
+	tester.machine.startSequence(chain.CurrentHeader())
+
+	return tester
+}
+
+// proposer returns the name of the validator proposing the given round.
+func (tm *testerMachine) proposer(round uint64) string {
+	return tm.names[proposer(tm.machine.validators, tm.machine.sequence, round)]
+}
+
+// proposal creates an empty block on top of the chain head, sealed by the given
+// validator. Different proposers result in different proposal digests.
+func (tm *testerMachine) proposal(validator string) (*types.Block, common.Hash) {
+	parent := tm.chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	if err := tm.engine.Prepare(tm.chain, header); err != nil {
+		tm.t.Fatalf("failed to prepare header: %v", err)
+	}
+	statedb, err := tm.chain.StateAt(parent.Root())
+	if err != nil {
+		tm.t.Fatalf("failed to retrieve parent state: %v", err)
+	}
+	block, err := tm.engine.Finalize(tm.chain, header, statedb, nil, nil, nil)
+	if err != nil {
+		tm.t.Fatalf("failed to finalize block: %v", err)
+	}
+	header = block.Header()
+	tm.accounts.sign(header, validator)
+
+	digest, _ := proposalHash(header)
+	return block.WithSeal(header), digest
+}
+
+// sign fills in the sender and signatures of a message of the given validator,
+// defaulting to the sequence currently agreed upon.
+func (tm *testerMachine) sign(validator string, msg *message) *message {
+	if msg.Sequence == 0 {
+		msg.Sequence = tm.machine.sequence
+	}
+	msg.Address = tm.accounts.address(validator)
+	if msg.Code == msgCommit {
+		msg.CommittedSeal, _ = crypto.Sign(commitHash(msg.Digest), tm.accounts.key(validator))
+	}
+	msg.Signature, _ = crypto.Sign(msg.sigHash(), tm.accounts.key(validator))
+	if err := msg.verify(); err != nil {
+		tm.t.Fatalf("failed to sign message: %v", err)
+	}
+	return msg
+}
+
+// send feeds a message of the given validator to the state machine.
+func (tm *testerMachine) send(validator string, msg *message) {
+	tm.machine.handleMessage(tm.sign(validator, msg))
+}
+
+// preprepare sends the proposal of a validator for the given round.
+func (tm *testerMachine) preprepare(validator string, round uint64, block *types.Block) {
+	digest, _ := proposalHash(block.Header())
+	blob, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		tm.t.Fatalf("failed to encode proposal: %v", err)
+	}
+	tm.send(validator, &message{Code: msgPreprepare, Round: round, Digest: digest, Proposal: blob})
+}
+
+// certificate creates a round change of a validator asking for the given round,
+// carrying the prepares of the given validators for a proposal in an earlier
+// round.
+func (tm *testerMachine) certificate(validator string, round uint64, block *types.Block, prepared uint64, preparers []string) *message {
+	digest, _ := proposalHash(block.Header())
+	blob, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		tm.t.Fatalf("failed to encode proposal: %v", err)
+	}
+	msg := &message{Code: msgRoundChange, Round: round, Digest: digest, Proposal: blob}
+	for _, preparer := range preparers {
+		msg.Prepares = append(msg.Prepares, tm.sign(preparer, &message{Code: msgPrepare, Round: prepared, Digest: digest}))
+	}
+	return msg
+}
+
+// check verifies the round and the progress of the state machine within.
+func (tm *testerMachine) check(round uint64, step step) {
+	tm.t.Helper()
+	if tm.machine.round != round || tm.machine.step != step {
+		tm.t.Fatalf("state mismatch: have round %d step %d, want round %d step %d", tm.machine.round, tm.machine.step, round, step)
+	}
+}
+
+// Tests that a proposal passing the pre-prepare, prepare and commit steps gets
+// finalized with the committed seals of a quorum of validators.
+func TestMachineAgreement(t *testing.T) {
+	tester := newTesterMachine(t, 0)
+	local := tester.accounts.address(tester.local)
+	proposer := tester.proposer(0)
+	others := []string{tester.validators[2], tester.validators[3]}
+
+	block, digest := tester.proposal(proposer)
+	tester.engine.sealing = digest // Deliver the result instead of importing it
+
+	tester.preprepare(proposer, 0, block)
+	tester.check(0, stepPreprepared)
+	if tester.machine.digest != digest {
+		t.Fatalf("accepted digest mismatch: have %x, want %x", tester.machine.digest, digest)
+	}
+	if msg, ok := tester.machine.prepares[local]; !ok || msg.Digest != digest {
+		t.Fatalf("local prepare missing")
+	}
+	// The local node prepared, two more validators are needed for a quorum
+	tester.send(proposer, &message{Code: msgPrepare, Digest: digest})
+	tester.check(0, stepPreprepared)
+	tester.send(others[0], &message{Code: msgPrepare, Digest: digest})
+	tester.check(0, stepPrepared)
+
+	if tester.machine.locked.Hash() != block.Hash() || tester.machine.lockedDigest != digest {
+		t.Fatalf("prepared proposal not locked")
+	}
+	if msg, ok := tester.machine.commits[local]; !ok || msg.Digest != digest {
+		t.Fatalf("local commit missing")
+	}
+	// Commits for other proposals must not count towards the quorum
+	tester.send(proposer, &message{Code: msgCommit, Digest: common.Hash{0x01}})
+	tester.send(others[0], &message{Code: msgCommit, Digest: digest})
+	tester.check(0, stepPrepared)
+
+	tester.send(others[1], &message{Code: msgCommit, Digest: digest})
+	tester.check(0, stepCommitted)
+
+	select {
+	case committed := <-tester.engine.commitCh:
+		extra, err := ExtractIstanbulExtra(committed.Header())
+		if err != nil {
+			t.Fatalf("failed to extract committed seals: %v", err)
+		}
+		if have, want := len(extra.CommittedSeal), quorum(len(tester.validators)); have != want {
+			t.Fatalf("committed seal count mismatch: have %d, want %d", have, want)
+		}
+		if err := tester.engine.VerifySeal(tester.chain, committed.Header()); err != nil {
+			t.Fatalf("committed block failed seal verification: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("committed block not delivered")
+	}
+}
+
+// Tests that only the proposal of the round's proposer matching its digest is
+// accepted, and that proposals of future rounds are held back until reached.
+func TestMachinePreprepare(t *testing.T) {
+	tester := newTesterMachine(t, 0)
+	proposer := tester.proposer(0)
+	block, digest := tester.proposal(proposer)
+
+	// Proposals relayed by other validators or by non-validators are ignored
+	tester.preprepare(tester.validators[2], 0, block)
+	tester.preprepare(tester.validators[3], 0, block)
+	tester.preprepare("E", 0, block)
+	tester.check(0, stepNew)
+
+	// Proposals with a mismatching digest are ignored
+	blob, _ := rlp.EncodeToBytes(block)
+	tester.send(proposer, &message{Code: msgPreprepare, Digest: common.Hash{0x01}, Proposal: blob})
+	tester.check(0, stepNew)
+
+	// Proposals for future rounds are backlogged and accepted once reached
+	next := tester.proposer(1)
+	future, futureDigest := tester.proposal(next)
+	tester.preprepare(next, 1, future)
+	tester.check(0, stepNew)
+	if len(tester.machine.backlog) != 1 {
+		t.Fatalf("backlog size mismatch: have %d, want 1", len(tester.machine.backlog))
+	}
+	tester.machine.startRound(1)
+	tester.check(1, stepPreprepared)
+	if tester.machine.digest != futureDigest {
+		t.Fatalf("accepted digest mismatch: have %x, want %x", tester.machine.digest, futureDigest)
+	}
+	// The proposal of the previous round is stale by now
+	tester.preprepare(proposer, 0, block)
+	if tester.machine.digest == digest {
+		t.Fatalf("stale proposal accepted")
+	}
+}
+
+// Tests that round changes are requested on timeouts, joined once more than
+// the tolerated number of faulty validators ask for them and executed once a
+// quorum of validators did.
+func TestMachineRoundChange(t *testing.T) {
+	tester := newTesterMachine(t, 0)
+
+	tester.machine.handleTimeout()
+	tester.check(0, stepNew)
+	if tester.machine.sentRound != 1 {
+		t.Fatalf("round change not requested on timeout")
+	}
+	// Round changes for the current round or earlier are ignored
+	tester.send(tester.validators[1], &message{Code: msgRoundChange, Round: 0})
+	if len(tester.machine.roundChanges) != 1 {
+		t.Fatalf("stale round change recorded")
+	}
+	// A quorum including the local node moves on to the next round
+	tester.send(tester.validators[1], &message{Code: msgRoundChange, Round: 1})
+	tester.check(0, stepNew)
+	tester.send(tester.validators[2], &message{Code: msgRoundChange, Round: 1})
+	tester.check(1, stepNew)
+
+	// A single validator can't make the others skip rounds
+	tester.send(tester.validators[1], &message{Code: msgRoundChange, Round: 3})
+	tester.check(1, stepNew)
+	if tester.machine.sentRound != 1 {
+		t.Fatalf("round change joined too early: sent round %d", tester.machine.sentRound)
+	}
+	// Two validators can't be all faulty, join them which gives a quorum
+	tester.send(tester.validators[3], &message{Code: msgRoundChange, Round: 3})
+	if tester.machine.sentRound != 3 {
+		t.Fatalf("round change not joined: sent round %d", tester.machine.sentRound)
+	}
+	tester.check(3, stepNew)
+}
+
+// Tests that once a proposal is prepared, it is the only one accepted in later
+// rounds of the sequence and is proposed again by the local node.
+func TestMachineLockedProposal(t *testing.T) {
+	tester := newTesterMachine(t, 2) // Local node proposes round 1
+
+	// Prepare a proposal in round 0, locking it
+	proposer := tester.proposer(0)
+	block, digest := tester.proposal(proposer)
+
+	tester.preprepare(proposer, 0, block)
+	tester.send(proposer, &message{Code: msgPrepare, Digest: digest})
+	tester.send(tester.validators[3], &message{Code: msgPrepare, Digest: digest})
+	tester.check(0, stepPrepared)
+
+	// Have the local sealer hand over a different block in the meantime
+	request, _ := tester.proposal(tester.local)
+	tester.machine.handleRequest(request)
+
+	// Move on to round 1, where the local node needs to propose the locked block
+	tester.send(tester.validators[1], &message{Code: msgRoundChange, Round: 1})
+	tester.send(tester.validators[3], &message{Code: msgRoundChange, Round: 1})
+	tester.check(1, stepPreprepared)
+	if tester.machine.digest != digest {
+		t.Fatalf("locked proposal not proposed: have %x, want %x", tester.machine.digest, digest)
+	}
+	// Move on to round 2, where a remote validator proposes
+	tester.send(tester.validators[1], &message{Code: msgRoundChange, Round: 2})
+	tester.send(tester.validators[3], &message{Code: msgRoundChange, Round: 2})
+	tester.check(2, stepNew)
+
+	next := tester.proposer(2)
+	conflict, _ := tester.proposal(next)
+	tester.preprepare(next, 2, conflict)
+	tester.check(2, stepNew)
+
+	tester.preprepare(next, 2, block)
+	tester.check(2, stepPreprepared)
+	if tester.machine.digest != digest {
+		t.Fatalf("accepted digest mismatch: have %x, want %x", tester.machine.digest, digest)
+	}
+}
+
+// Tests that messages of non-validators are neither relayed nor backlogged.
+func TestMachineMessageSenders(t *testing.T) {
+	tester := newTesterMachine(t, 0)
+	validator := tester.validators[1]
+
+	// Future messages are only backlogged for validators
+	tester.send("E", &message{Code: msgPrepare, Sequence: 2})
+	tester.send("E", &message{Code: msgPrepare, Round: 1})
+	if len(tester.machine.backlog) != 0 {
+		t.Fatalf("non-validator messages backlogged: %d", len(tester.machine.backlog))
+	}
+	tester.send(validator, &message{Code: msgPrepare, Sequence: 2})
+	tester.send(validator, &message{Code: msgPrepare, Round: 1})
+	if len(tester.machine.backlog) != 2 {
+		t.Fatalf("backlog size mismatch: have %d, want 2", len(tester.machine.backlog))
+	}
+	// Only messages of validators about sequences not yet finalized are relayed
+	tests := []struct {
+		sender   string
+		sequence uint64
+		err      error
+	}{
+		{validator, 1, nil},
+		{validator, 5, nil},
+		{"E", 1, errUnauthorized},
+		{"E", 5, errUnauthorized},
+	}
+	for i, tt := range tests {
+		msg := tester.sign(tt.sender, &message{Code: msgPrepare, Sequence: tt.sequence})
+		if err := tester.engine.checkSender(tester.chain, msg); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	msg := tester.sign(validator, &message{Code: msgPrepare})
+	msg.Sequence = 0 // Genesis, signature doesn't matter as it's checked before
+	if err := tester.engine.checkSender(tester.chain, msg); err != errStaleMessage {
+		t.Errorf("stale message error mismatch: have %v, want %v", err, errStaleMessage)
+	}
+}
+
+// Tests that round changes carry the prepared certificate of the locked proposal,
+// that a certificate of a later round releases the lock in favor of its proposal
+// and that the proposer re-proposes the highest certified proposal.
+func TestMachinePreparedCertificate(t *testing.T) {
+	tester := newTesterMachine(t, 3) // Local node proposes round 2
+
+	// Prepare a proposal in round 0, locking it
+	proposer := tester.proposer(0)
+	block, digest := tester.proposal(proposer)
+
+	tester.preprepare(proposer, 0, block)
+	tester.send(proposer, &message{Code: msgPrepare, Digest: digest})
+	tester.send(tester.validators[2], &message{Code: msgPrepare, Digest: digest})
+	tester.check(0, stepPrepared)
+
+	if tester.machine.lockedRound != 0 || len(tester.machine.lockedPrepares) != quorum(len(tester.validators)) {
+		t.Fatalf("lock not certified: round %d, prepares %d", tester.machine.lockedRound, len(tester.machine.lockedPrepares))
+	}
+	// The prepares of the lock make up a certificate the others accept
+	blob, _ := rlp.EncodeToBytes(tester.machine.locked)
+	cert := &message{Code: msgRoundChange, Round: 1, Digest: tester.machine.lockedDigest, Proposal: blob, Prepares: tester.machine.lockedPrepares}
+	if _, round, err := tester.machine.verifyCertificate(cert); err != nil || round != 0 {
+		t.Fatalf("local certificate rejected: round %d, err %v", round, err)
+	}
+	// Have a different proposal prepared in round 1 by a quorum without the local node
+	other, otherDigest := tester.proposal(tester.proposer(1))
+	preparers := []string{tester.validators[0], tester.validators[1], tester.validators[2]}
+
+	// Invalid certificates neither count as a round change nor touch the lock
+	invalid := []*message{
+		tester.certificate(tester.validators[0], 2, other, 1, preparers[:2]),                                      // No quorum
+		tester.certificate(tester.validators[0], 2, other, 2, preparers),                                          // Not an earlier round
+		tester.certificate(tester.validators[0], 2, other, 1, []string{preparers[0], preparers[0], preparers[1]}), // Duplicate preparer
+		tester.certificate(tester.validators[0], 2, other, 1, []string{preparers[0], preparers[1], "E"}),          // Non-validator preparer
+	}
+	mismatch := tester.certificate(tester.validators[0], 2, other, 1, preparers)
+	mismatch.Digest = digest
+	invalid = append(invalid, mismatch)
+
+	for i, msg := range invalid {
+		tester.machine.handleMessage(tester.sign(tester.validators[0], msg))
+		if len(tester.machine.roundChanges[2]) != 0 {
+			t.Fatalf("invalid certificate %d: round change recorded", i)
+		}
+		if tester.machine.lockedDigest != digest {
+			t.Fatalf("invalid certificate %d: lock released", i)
+		}
+	}
+	// A valid certificate of a later round replaces the lock
+	tester.machine.handleMessage(tester.sign(tester.validators[0], tester.certificate(tester.validators[0], 2, other, 1, preparers)))
+	if tester.machine.lockedDigest != otherDigest || tester.machine.lockedRound != 1 {
+		t.Fatalf("lock not released: have %x round %d, want %x round 1", tester.machine.lockedDigest, tester.machine.lockedRound, otherDigest)
+	}
+	// A certificate of an earlier round doesn't take the lock back
+	tester.machine.handleMessage(tester.sign(tester.validators[2], tester.certificate(tester.validators[2], 2, block, 0, preparers)))
+	if tester.machine.lockedDigest != otherDigest {
+		t.Fatalf("lock taken over by an earlier certificate")
+	}
+	// Once the round starts, the local proposer re-proposes the certified proposal
+	tester.check(2, stepPreprepared)
+	if tester.machine.digest != otherDigest {
+		t.Fatalf("certified proposal not proposed: have %x, want %x", tester.machine.digest, otherDigest)
+	}
+}
+
+// Tests that validators collecting the committed seals of different quorums
+// still import the block under the same hash.
+func TestMachineCommittedHash(t *testing.T) {
+	accounts := newTesterAccountPool()
+	testers := make([]*testerMachine, 4)
+	for i := range testers {
+		testers[i] = newTesterPeer(t, accounts, i)
+	}
+	proposer := testers[0].proposer(0)
+	block, digest := testers[0].proposal(proposer)
+
+	for i, tester := range testers {
+		tester.preprepare(proposer, 0, block)
+		for _, validator := range tester.validators {
+			if validator != tester.local {
+				tester.send(validator, &message{Code: msgPrepare, Digest: digest})
+			}
+		}
+		tester.check(0, stepPrepared)
+
+		// Every validator sees a different quorum of commits, including its own
+		for j := 1; j < quorum(len(tester.validators)); j++ {
+			tester.send(tester.validators[(i+j)%len(tester.validators)], &message{Code: msgCommit, Digest: digest})
+		}
+		tester.check(0, stepCommitted)
+	}
+	seals := make(map[string]struct{})
+	for i, tester := range testers {
+		deadline := time.Now().Add(time.Second)
+		for tester.chain.CurrentBlock().NumberU64() != 1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("validator %d: committed block not imported", i)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		head := tester.chain.CurrentBlock()
+		if head.Hash() != block.Hash() {
+			t.Errorf("validator %d: block hash mismatch: have %x, want %x", i, head.Hash(), block.Hash())
+		}
+		extra, err := ExtractIstanbulExtra(head.Header())
+		if err != nil {
+			t.Fatalf("validator %d: failed to extract committed seals: %v", i, err)
+		}
+		blob, _ := rlp.EncodeToBytes(extra.CommittedSeal)
+		seals[string(blob)] = struct{}{}
+	}
+	if len(seals) != len(testers) {
+		t.Errorf("committed seal set count mismatch: have %d, want %d", len(seals), len(testers))
+	}
+}
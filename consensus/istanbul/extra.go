@@ -0,0 +1,135 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+// errInvalidExtraData is returned if the extra-data of a header doesn't contain
+// a valid RLP encoded Istanbul section after the vanity prefix.
+var errInvalidExtraData = errors.New("invalid istanbul extra-data")
+
+// IstanbulExtra is the consensus data carried in every header's extra-data after
+// the fixed vanity prefix.
+type IstanbulExtra struct {
+	Validators    []common.Address // Validator set in charge of the block, ascending
+	Seal          []byte           // Signature of the proposer over the sealing hash
+	CommittedSeal [][]byte         // Signatures of the validators committing to the block
+}
+
+// ExtractIstanbulExtra retrieves the Istanbul consensus data from the extra-data
+// section of a header.
+func ExtractIstanbulExtra(header *types.Header) (*IstanbulExtra, error) {
+	if len(header.Extra) < extraVanity {
+		return nil, errMissingVanity
+	}
+	extra := new(IstanbulExtra)
+	if err := rlp.DEWHodeBytes(header.Extra[extraVanity:], extra); err != nil {
+		return nil, errInvalidExtraData
+	}
+	return extra, nil
+}
+
+// PrepareExtra assembles the extra-data of a header with the given vanity and
+// validator set, leaving room for the seals to be filled in later. It can also
+// be used to create the extra-data of an Istanbul genesis block.
+func PrepareExtra(vanity []byte, validators []common.Address) ([]byte, error) {
+	if len(vanity) < extraVanity {
+		vanity = append(vanity, bytes.Repeat([]byte{0x00}, extraVanity-len(vanity))...)
+	}
+	payload, err := rlp.EncodeToBytes(&IstanbulExtra{
+		Validators:    validators,
+		Seal:          []byte{},
+		CommittedSeal: [][]byte{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(vanity[:extraVanity:extraVanity], payload...), nil
+}
+
+// writeExtra replaces the Istanbul section of the header's extra-data.
+func writeExtra(header *types.Header, extra *IstanbulExtra) error {
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return err
+	}
+	header.Extra = append(header.Extra[:extraVanity:extraVanity], payload...)
+	return nil
+}
+
+// filteredHeader returns a copy of the header with the committed seals, and
+// optionally the proposer seal, stripped from the extra-data.
+func filteredHeader(header *types.Header, keepSeal bool) (*types.Header, error) {
+	cpy := types.CopyHeader(header)
+	extra, err := ExtractIstanbulExtra(cpy)
+	if err != nil {
+		return nil, err
+	}
+	if !keepSeal {
+		extra.Seal = []byte{}
+	}
+	extra.CommittedSeal = [][]byte{}
+	if err := writeExtra(cpy, extra); err != nil {
+		return nil, err
+	}
+	return cpy, nil
+}
+
+// sigHash returns the hash signed by the proposer of a block: the hash of the
+// header without any of the seals.
+func sigHash(header *types.Header) (common.Hash, error) {
+	cpy, err := filteredHeader(header, false)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return cpy.Hash(), nil
+}
+
+// proposalHash returns the digest validators reach agreement on: the hash of
+// the header including the proposer seal, but without the committed seals. It
+// is the same as the hash of the finalized block, whichever seals it carries.
+func proposalHash(header *types.Header) (common.Hash, error) {
+	cpy, err := filteredHeader(header, true)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return cpy.Hash(), nil
+}
+
+// commitHash returns the hash signed by a validator to commit to a proposal.
+func commitHash(proposal common.Hash) []byte {
+	return crypto.Keccak256(proposal.Bytes(), []byte{byte(msgCommit)})
+}
+
+// recoverAddress extracts the DEWH account address that signed the hash.
+func recoverAddress(hash []byte, sig []byte) (common.Address, error) {
+	pubkey, err := crypto.Ecrecover(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
@@ -0,0 +1,767 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package istanbul implements the Istanbul byzantine fault tolerant consensus
+// engine, a proof-of-authority scheme with immediate block finality.
+//
+// A block is proposed by one of the validators and only becomes part of the
+// chain once more than two thirds of the validator set agreed on it through
+// the pre-prepare, prepare and commit steps exchanged over a dedicated p2p
+// sub-protocol. The signatures of the committing validators are stored in the
+// header, so that any node can verify the finality of a block on import.
+package istanbul
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/accounts"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/consensus/misc"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/state"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/core/vm"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	checkpointInterval = 1024 // Number of blocks after which to save the vote snapshot to the database
+	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
+	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+	inmemoryMessages   = 4096 // Number of recent consensus messages to remember for gossip
+)
+
+// Istanbul BFT protocol constants.
+var (
+	epochLength    = uint64(30000) // Default number of blocks after which to checkpoint and reset the pending votes
+	requestTimeout = uint64(10000) // Default timeout of the first round in milliseconds
+
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for validator vanity
+
+	nonceAuthVote = hexutil.MustDEWHode("0xffffffffffffffff") // Magic nonce number to vote on adding a new validator
+	nonceDropVote = hexutil.MustDEWHode("0x0000000000000000") // Magic nonce number to vote on removing a validator.
+
+	// IstanbulDigest is the mix digest of every Istanbul block, identifying the
+	// headers as produced by this consensus engine.
+	IstanbulDigest = types.IstanbulDigest
+
+	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
+
+	defaultDifficulty = big.NewInt(1) // Block difficulty, the chain with the most finalized blocks wins
+)
+
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when the list of validators is requested for a
+	// block that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidCheckpointBeneficiary is returned if a checkpoint/epoch transition
+	// block has a beneficiary set to non-zeroes.
+	errInvalidCheckpointBeneficiary = errors.New("beneficiary in checkpoint block non-zero")
+
+	// errInvalidVote is returned if a nonce value is something else that the two
+	// allowed constants of 0x00..0 or 0xff..f.
+	errInvalidVote = errors.New("vote nonce not 0x00..0 or 0xff..f")
+
+	// errInvalidCheckpointVote is returned if a checkpoint/epoch transition block
+	// has a vote nonce set to non-zeroes.
+	errInvalidCheckpointVote = errors.New("vote nonce in checkpoint block non-zero")
+
+	// errMissingVanity is returned if a block's extra-data section is shorter than
+	// 32 bytes, which is required to store the validator vanity.
+	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
+
+	// errInvalidValidators is returned if the validator list in a block's
+	// extra-data doesn't match the validator set the block was produced by.
+	errInvalidValidators = errors.New("invalid validator list")
+
+	// errInvalidCommittedSeals is returned if a block doesn't carry the committed
+	// seals of a quorum of its validators.
+	errInvalidCommittedSeals = errors.New("invalid committed seals")
+
+	// errInvalidMixDigest is returned if a block's mix digest isn't the Istanbul
+	// digest.
+	errInvalidMixDigest = errors.New("invalid istanbul mix digest")
+
+	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// ErrInvalidTimestamp is returned if the timestamp of a block is lower than
+	// the previous block's timestamp + the minimum block period.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errInvalidVotingChain is returned if a validator set is attempted to be
+	// modified via out-of-range or non-contiguous headers.
+	errInvalidVotingChain = errors.New("invalid voting chain")
+
+	// errUnauthorized is returned if a header is proposed by a non-validator.
+	errUnauthorized = errors.New("unauthorized")
+
+	// errStopped is returned if a block is attempted to be sealed while the
+	// consensus engine isn't running.
+	errStopped = errors.New("istanbul engine not started")
+)
+
+// SignerFn is a signer callback function to request a hash to be signed by a
+// backing account.
+type SignerFn func(accounts.Account, []byte) ([]byte, error)
+
+// Chain is the blockchain the consensus engine finalizes blocks into. Beside the
+// header access needed by all engines, validators need to follow the chain head
+// and to import blocks committed on the proposal of other validators.
+type Chain interface {
+	consensus.ChainReader
+
+	// SubscribeChainHeadEvent subscribes to head changes of the chain.
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+
+	// InsertChain imports a batch of blocks into the chain.
+	InsertChain(chain types.Blocks) (int, error)
+
+	// Validator returns the block validator of the chain.
+	Validator() core.Validator
+
+	// Processor returns the state processor of the chain.
+	Processor() core.Processor
+
+	// StateAt returns a mutable state based on a particular point in time.
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// ecrecover extracts the DEWH account address of the proposer of a header.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	// If the signature's already cached, return that
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address.(common.Address), nil
+	}
+	// Retrieve the proposer seal from the header extra-data
+	extra, err := ExtractIstanbulExtra(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sighash, err := sigHash(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	proposer, err := recoverAddress(sighash.Bytes(), extra.Seal)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sigcache.Add(hash, proposer)
+	return proposer, nil
+}
+
+// Istanbul is the byzantine fault tolerant proof-of-authority consensus engine.
+type Istanbul struct {
+	config *params.IstanbulConfig // Consensus engine configuration parameters
+	db     ethdb.Database         // Database to store and retrieve snapshot checkpoints
+
+	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
+	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+
+	proposals map[common.Address]bool // Current list of proposals we are pushing
+
+	signer common.Address // DEWH address of the signing key
+	signFn SignerFn       // Signer function to authorize hashes with
+	lock   sync.RWMutex   // Protects the signer and proposal fields
+
+	peers    *peerSet      // Validator peers connected via the consensus protocol
+	messages *lru.ARCCache // Recently seen consensus messages to stop gossip loops
+
+	core     *machine          // Consensus state machine, nil if the engine is stopped
+	sealing  common.Hash       // Proposal digest the local sealer is waiting on
+	commitCh chan *types.Block // Channel to hand committed local proposals to the sealer
+	coreLock sync.RWMutex      // Protects the state machine and sealing fields
+}
+
+// New creates an Istanbul BFT consensus engine with the initial validators set
+// to the ones in the genesis block.
+func New(config *params.IstanbulConfig, db ethdb.Database) *Istanbul {
+	// Set any missing consensus parameters to their defaults
+	conf := *config
+	if conf.Epoch == 0 {
+		conf.Epoch = epochLength
+	}
+	if conf.RequestTimeout == 0 {
+		conf.RequestTimeout = requestTimeout
+	}
+	// Allocate the caches and create the engine
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	signatures, _ := lru.NewARC(inmemorySignatures)
+	messages, _ := lru.NewARC(inmemoryMessages)
+
+	return &Istanbul{
+		config:     &conf,
+		db:         db,
+		recents:    recents,
+		signatures: signatures,
+		proposals:  make(map[common.Address]bool),
+		peers:      newPeerSet(),
+		messages:   messages,
+		commitCh:   make(chan *types.Block, 1),
+	}
+}
+
+// Author implements consensus.Engine, returning the DEWH address recovered
+// from the proposer seal in the header's extra-data section.
+func (sb *Istanbul) Author(header *types.Header) (common.Address, error) {
+	return ecrecover(header, sb.signatures)
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules. The
+// committed seals are always checked as they are the proof of finality.
+func (sb *Istanbul) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	return sb.verifyHeader(chain, header, nil, true)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (sb *Istanbul) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := sb.verifyHeader(chain, header, headers[:i], true)
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
+// caller may optionally pass in a batch of parents (ascending order) to avoid
+// looking those up from the database. Proposals still being agreed upon are
+// verified with committed set to false, skipping the committed seal checks.
+func (sb *Istanbul) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header, committed bool) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+
+	// Don't waste time checking blocks from the future
+	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
+		return consensus.ErrFutureBlock
+	}
+	// Checkpoint blocks need to enforce zero beneficiary
+	checkpoint := (number % sb.config.Epoch) == 0
+	if checkpoint && header.Coinbase != (common.Address{}) {
+		return errInvalidCheckpointBeneficiary
+	}
+	// Nonces must be 0x00..0 or 0xff..f, zeroes enforced on checkpoints
+	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) {
+		return errInvalidVote
+	}
+	if checkpoint && !bytes.Equal(header.Nonce[:], nonceDropVote) {
+		return errInvalidCheckpointVote
+	}
+	// Check that the extra-data contains the vanity and a valid Istanbul section
+	if _, err := ExtractIstanbulExtra(header); err != nil {
+		return err
+	}
+	// Ensure that the mix digest marks the block as an Istanbul one
+	if header.MixDigest != IstanbulDigest {
+		return errInvalidMixDigest
+	}
+	// Ensure that the block doesn't contain any uncles which are meaningless in BFT
+	if header.UncleHash != uncleHash {
+		return errInvalidUncleHash
+	}
+	// Ensure that the block's difficulty is meaningful
+	if number > 0 && (header.Difficulty == nil || header.Difficulty.Cmp(defaultDifficulty) != 0) {
+		return errInvalidDifficulty
+	}
+	// If all checks passed, validate any special fields for hard forks
+	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
+		return err
+	}
+	// All basic checks passed, verify cascading fields
+	return sb.verifyCascadingFields(chain, header, parents, committed)
+}
+
+// verifyCascadingFields verifies all the header fields that are not standalone,
+// rather depend on a batch of previous headers. The caller may optionally pass
+// in a batch of parents (ascending order) to avoid looking those up from the
+// database. This is useful for concurrently verifying a batch of new headers.
+func (sb *Istanbul) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header, committed bool) error {
+	// The genesis block is the always valid dead-end
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	// Ensure that the block's timestamp isn't too close to it's parent
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Time.Uint64()+sb.config.Period > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	// Retrieve the snapshot needed to verify this header and cache it
+	snap, err := sb.snapshot(chain, number-1, header.ParentHash, parents)
+	if err != nil {
+		return err
+	}
+	// Every block lists the validator set it was produced by
+	extra, err := ExtractIstanbulExtra(header)
+	if err != nil {
+		return err
+	}
+	validators := snap.validators()
+	if len(extra.Validators) != len(validators) {
+		return errInvalidValidators
+	}
+	for i, validator := range validators {
+		if extra.Validators[i] != validator {
+			return errInvalidValidators
+		}
+	}
+	// All basic checks passed, verify the seals and return
+	if err := sb.verifyProposer(header, snap); err != nil {
+		return err
+	}
+	if committed {
+		return verifyCommittedSeals(header, snap)
+	}
+	return nil
+}
+
+// snapshot retrieves the validator snapshot at a given point in time.
+func (sb *Istanbul) snapshot(chain consensus.ChainReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	// Search for a snapshot in memory or on disk for checkpoints
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := sb.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint snapshot can be found, use that
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(sb.config, sb.signatures, sb.db, hash); err == nil {
+				log.Trace("Loaded voting snapshot from disk", "number", number, "hash", hash)
+				snap = s
+				break
+			}
+		}
+		// If we're at block zero, make a snapshot
+		if number == 0 {
+			genesis := chain.GetHeaderByNumber(0)
+			if err := sb.VerifyHeader(chain, genesis, false); err != nil {
+				return nil, err
+			}
+			extra, err := ExtractIstanbulExtra(genesis)
+			if err != nil {
+				return nil, err
+			}
+			snap = newSnapshot(sb.config, sb.signatures, 0, genesis.Hash(), extra.Validators)
+			if err := snap.store(sb.db); err != nil {
+				return nil, err
+			}
+			log.Trace("Stored genesis voting snapshot to disk")
+			break
+		}
+		// No snapshot for this header, gather the header and move backward
+		var header *types.Header
+		if len(parents) > 0 {
+			// If we have explicit parents, pick from there (enforced)
+			header = parents[len(parents)-1]
+			if header.Hash() != hash || header.Number.Uint64() != number {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parents = parents[:len(parents)-1]
+		} else {
+			// No explicit parents (or no more left), reach out to the database
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+	// Previous snapshot found, apply any pending headers on top of it
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	sb.recents.Add(snap.Hash, snap)
+
+	// If we've generated a new checkpoint snapshot, save to disk
+	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
+		if err = snap.store(sb.db); err != nil {
+			return nil, err
+		}
+		log.Trace("Stored voting snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+	}
+	return snap, err
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (sb *Istanbul) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errors.New("uncles not allowed")
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine, checking whether the proposer seal and
+// the committed seals contained in the header satisfy the consensus protocol
+// requirements.
+func (sb *Istanbul) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	// Verifying the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	// Retrieve the snapshot needed to verify this header and cache it
+	snap, err := sb.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	if err := sb.verifyProposer(header, snap); err != nil {
+		return err
+	}
+	return verifyCommittedSeals(header, snap)
+}
+
+// verifyProposer checks that the header was proposed by one of the validators.
+func (sb *Istanbul) verifyProposer(header *types.Header, snap *Snapshot) error {
+	proposer, err := ecrecover(header, sb.signatures)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Validators[proposer]; !ok {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// verifyCommittedSeals checks that a quorum of distinct validators signed the
+// commitment to the header's proposal.
+func verifyCommittedSeals(header *types.Header, snap *Snapshot) error {
+	extra, err := ExtractIstanbulExtra(header)
+	if err != nil {
+		return err
+	}
+	digest, err := proposalHash(header)
+	if err != nil {
+		return err
+	}
+	hash := commitHash(digest)
+
+	committers := make(map[common.Address]struct{})
+	for _, seal := range extra.CommittedSeal {
+		committer, err := recoverAddress(hash, seal)
+		if err != nil {
+			return errInvalidCommittedSeals
+		}
+		if _, ok := snap.Validators[committer]; !ok {
+			return errInvalidCommittedSeals
+		}
+		if _, dup := committers[committer]; dup {
+			return errInvalidCommittedSeals
+		}
+		committers[committer] = struct{}{}
+	}
+	if len(committers) < quorum(len(snap.Validators)) {
+		return errInvalidCommittedSeals
+	}
+	return nil
+}
+
+// verifyProposal checks whether a block proposed for agreement is valid, fully
+// executing it on top of its parent. The committed seals are not yet present.
+func (sb *Istanbul) verifyProposal(chain Chain, block *types.Block) error {
+	if err := sb.verifyHeader(chain, block.Header(), nil, false); err != nil {
+		return err
+	}
+	if err := chain.Validator().ValidateBody(block); err != nil {
+		return err
+	}
+	parent := chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return err
+	}
+	receipts, _, usedGas, err := chain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		return err
+	}
+	return chain.Validator().ValidateState(block, parent, statedb, receipts, usedGas)
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of the
+// header for running the transactions on top.
+func (sb *Istanbul) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	// Votes are carried in the coinbase and nonce, clear them until one is picked
+	header.Coinbase = common.Address{}
+	header.Nonce = types.BlockNonce{}
+
+	number := header.Number.Uint64()
+	// Assemble the voting snapshot to check which votes make sense
+	snap, err := sb.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	// If the block isn't a checkpoint, vote on a random pending proposal
+	if number%sb.config.Epoch != 0 {
+		sb.lock.RLock()
+
+		// Gather all the proposals that make sense voting on
+		addresses := make([]common.Address, 0, len(sb.proposals))
+		for address, authorize := range sb.proposals {
+			if snap.validVote(address, authorize) {
+				addresses = append(addresses, address)
+			}
+		}
+		// If there's pending proposals, cast a vote on them
+		if len(addresses) > 0 {
+			header.Coinbase = addresses[rand.Intn(len(addresses))]
+			if sb.proposals[header.Coinbase] {
+				copy(header.Nonce[:], nonceAuthVote)
+			} else {
+				copy(header.Nonce[:], nonceDropVote)
+			}
+		}
+		sb.lock.RUnlock()
+	}
+	header.Difficulty = new(big.Int).Set(defaultDifficulty)
+	header.MixDigest = IstanbulDigest
+
+	// Ensure the extra data lists the current validators and has room for the seals
+	extra, err := PrepareExtra(header.Extra, snap.validators())
+	if err != nil {
+		return err
+	}
+	header.Extra = extra
+
+	// Ensure the timestamp has the correct delay
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(sb.config.Period))
+	if header.Time.Int64() < time.Now().Unix() {
+		header.Time = big.NewInt(time.Now().Unix())
+	}
+	return nil
+}
+
+// Finalize implements consensus.Engine, ensuring no uncles are set, nor block
+// rewards given, and returns the final block.
+func (sb *Istanbul) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	// No block rewards in PoA, so the state remains as is and uncles are dropped
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	// Assemble and return the final block for sealing
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// Authorize injects a private key into the consensus engine to propose blocks
+// and sign consensus messages with.
+func (sb *Istanbul) Authorize(signer common.Address, signFn SignerFn) {
+	sb.lock.Lock()
+	defer sb.lock.Unlock()
+
+	sb.signer = signer
+	sb.signFn = signFn
+}
+
+// Seal implements consensus.Engine, proposing the block to the validator set
+// and waiting until a quorum of them committed to it.
+func (sb *Istanbul) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	header := block.Header()
+
+	// Sealing the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, errUnknownBlock
+	}
+	sb.coreLock.RLock()
+	m := sb.core
+	sb.coreLock.RUnlock()
+	if m == nil {
+		return nil, errStopped
+	}
+	// Don't hold the signer fields for the entire sealing procedure
+	sb.lock.RLock()
+	signer, signFn := sb.signer, sb.signFn
+	sb.lock.RUnlock()
+
+	// Bail out if we're not a validator of the block
+	snap, err := sb.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, authorized := snap.Validators[signer]; !authorized {
+		return nil, errUnauthorized
+	}
+	// Sign the proposal and wait for its time
+	sighash, err := sigHash(header)
+	if err != nil {
+		return nil, err
+	}
+	seal, err := signFn(accounts.Account{Address: signer}, sighash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	extra, err := ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	extra.Seal = seal
+	if err := writeExtra(header, extra); err != nil {
+		return nil, err
+	}
+	block = block.WithSeal(header)
+
+	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()) // nolint: gosimple
+	log.Trace("Waiting for slot to propose", "delay", common.PrettyDuration(delay))
+
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	// Hand the proposal over to the validators and wait for it to be committed
+	digest, err := proposalHash(header)
+	if err != nil {
+		return nil, err
+	}
+	sb.coreLock.Lock()
+	sb.sealing = digest
+	sb.coreLock.Unlock()
+
+	m.propose(block)
+	for {
+		select {
+		case result := <-sb.commitCh:
+			if hash, err := proposalHash(result.Header()); err == nil && hash == digest {
+				return result, nil
+			}
+		case <-stop:
+			return nil, nil
+		}
+	}
+}
+
+// commit is called by the state machine once a quorum of validators committed
+// to a block. Blocks proposed by the local sealer are handed back to it, others
+// are imported into the chain directly.
+func (sb *Istanbul) commit(chain Chain, block *types.Block, digest common.Hash) {
+	sb.coreLock.RLock()
+	local := sb.sealing == digest
+	sb.coreLock.RUnlock()
+
+	if local {
+		select {
+		case sb.commitCh <- block:
+		default:
+		}
+		return
+	}
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		log.Warn("Failed to import committed block", "number", block.Number(), "hash", block.Hash(), "err", err)
+	}
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm. Istanbul blocks are
+// final, so every block has the same difficulty of 1.
+func (sb *Istanbul) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
+	return new(big.Int).Set(defaultDifficulty)
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to allow
+// controlling the validator voting.
+func (sb *Istanbul) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "istanbul",
+		Version:   "1.0",
+		Service:   &API{chain: chain, istanbul: sb},
+		Public:    false,
+	}}
+}
+
+// Start launches the consensus state machine, following the head of the given
+// chain and taking part in the agreement if the local signer is a validator.
+func (sb *Istanbul) Start(chain Chain) error {
+	sb.coreLock.Lock()
+	defer sb.coreLock.Unlock()
+
+	if sb.core != nil {
+		return errors.New("istanbul engine already started")
+	}
+	sb.core = newMachine(sb, chain)
+	sb.core.start()
+	return nil
+}
+
+// Stop terminates the consensus state machine.
+func (sb *Istanbul) Stop() error {
+	sb.coreLock.Lock()
+	m := sb.core
+	sb.core = nil
+	sb.coreLock.Unlock()
+
+	if m == nil {
+		return errStopped
+	}
+	m.stop()
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+// Consensus message codes exchanged between validators.
+const (
+	msgPreprepare uint64 = iota
+	msgPrepare
+	msgCommit
+	msgRoundChange
+)
+
+var (
+	// errInvalidMessageSigner is returned if the signature of a consensus message
+	// doesn't match its claimed sender.
+	errInvalidMessageSigner = errors.New("message not signed by sender")
+
+	// errStaleMessage is returned if a consensus message is about a sequence
+	// that is already finalized in the local chain.
+	errStaleMessage = errors.New("stale consensus message")
+
+	// errInvalidCertificate is returned if the prepared certificate of a round
+	// change doesn't prove a quorum prepared its proposal.
+	errInvalidCertificate = errors.New("invalid prepared certificate")
+)
+
+// message is a signed consensus message of a validator about a given view.
+type message struct {
+	Code          uint64         // Consensus step the message belongs to
+	Sequence      uint64         // Block number the message is about
+	Round         uint64         // Round within the sequence the message is about
+	Digest        common.Hash    // Proposal hash the message is about (if any)
+	Proposal      []byte         // RLP encoded proposed or locked block (pre-prepare and round change)
+	Prepares      []*message     // Prepares certifying the locked block (round change only)
+	CommittedSeal []byte         // Signature committing to the digest (commit only)
+	Address       common.Address // Validator sending the message
+	Signature     []byte         // Signature of the sender over the above fields
+}
+
+// String implements fmt.Stringer.
+func (m *message) String() string {
+	names := []string{"PRE-PREPARE", "PREPARE", "COMMIT", "ROUND-CHANGE"}
+	name := fmt.Sprintf("UNKNOWN(%d)", m.Code)
+	if m.Code < uint64(len(names)) {
+		name = names[m.Code]
+	}
+	return fmt.Sprintf("%s{seq: %d, round: %d, from: %x}", name, m.Sequence, m.Round, m.Address)
+}
+
+// sigHash returns the hash the sender signs to authenticate the message.
+func (m *message) sigHash() []byte {
+	blob, _ := rlp.EncodeToBytes([]interface{}{
+		m.Code,
+		m.Sequence,
+		m.Round,
+		m.Digest,
+		m.Proposal,
+		m.Prepares,
+		m.CommittedSeal,
+		m.Address,
+	})
+	return crypto.Keccak256(blob)
+}
+
+// hash returns the identifier of the message used for gossip deduplication.
+func (m *message) hash() common.Hash {
+	blob, _ := rlp.EncodeToBytes(m)
+	return crypto.Keccak256Hash(blob)
+}
+
+// verify checks that the message is signed by its claimed sender and, for
+// commits, that the committed seal belongs to the sender too.
+func (m *message) verify() error {
+	signer, err := recoverAddress(m.sigHash(), m.Signature)
+	if err != nil {
+		return err
+	}
+	if signer != m.Address {
+		return errInvalidMessageSigner
+	}
+	if m.Code == msgCommit {
+		signer, err := recoverAddress(commitHash(m.Digest), m.CommittedSeal)
+		if err != nil {
+			return err
+		}
+		if signer != m.Address {
+			return errInvalidCommittedSeals
+		}
+	}
+	return nil
+}
+
+// block decodes the proposal carried by a pre-prepare or round change message.
+func (m *message) block() (*types.Block, error) {
+	block := new(types.Block)
+	if err := rlp.DEWHodeBytes(m.Proposal, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/p2p"
+	mapset "github.com/DEWHkarep/golang-set"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	protocolName    = "istanbul"
+	protocolVersion = 1
+	protocolLength  = 1
+
+	consensusMsg = 0x00 // Signed consensus message of a validator
+
+	maxMessageSize   = 10 * 1024 * 1024 // Maximum cap on the size of a consensus message
+	maxKnownMessages = 4096             // Maximum message hashes to keep in the known list (prevent DOS)
+)
+
+// peer is a remote node connected via the consensus protocol.
+type peer struct {
+	*p2p.Peer
+	rw    p2p.MsgReadWriter
+	known mapset.Set // Set of message hashes known to be known by this peer
+}
+
+// markMessage marks a message as known for the peer, ensuring that it will never
+// be propagated to this particular peer.
+func (p *peer) markMessage(hash common.Hash) {
+	// If we reached the memory allowance, drop a previously known message hash
+	for p.known.Cardinality() >= maxKnownMessages {
+		p.known.Pop()
+	}
+	p.known.Add(hash)
+}
+
+// peerSet is the set of peers speaking the consensus protocol.
+type peerSet struct {
+	peers map[string]*peer
+	lock  sync.RWMutex
+}
+
+// newPeerSet creates a new peer set to track the consensus peers.
+func newPeerSet() *peerSet {
+	return &peerSet{peers: make(map[string]*peer)}
+}
+
+// register injects a new peer into the working set.
+func (ps *peerSet) register(p *peer) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	ps.peers[p.ID().String()] = p
+}
+
+// unregister removes a remote peer from the working set.
+func (ps *peerSet) unregister(p *peer) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.peers, p.ID().String())
+}
+
+// peersWithoutMessage retrieves a list of peers that do not have a given message
+// in their set of known hashes.
+func (ps *peerSet) peersWithoutMessage(hash common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if !p.known.Contains(hash) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Protocols returns the p2p sub-protocol used by the validators to exchange the
+// consensus messages. Messages are flooded to all peers speaking it, so that
+// validators not directly connected still reach agreement.
+func (sb *Istanbul) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    protocolName,
+		Version: protocolVersion,
+		Length:  protocolLength,
+		Run:     sb.runPeer,
+	}}
+}
+
+// runPeer is the lifecycle of a consensus protocol peer, verifying, relaying
+// and delivering its messages to the state machine until disconnected.
+func (sb *Istanbul) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	cp := &peer{Peer: p, rw: rw, known: mapset.NewSet()}
+
+	sb.peers.register(cp)
+	defer sb.peers.unregister(cp)
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > maxMessageSize {
+			msg.Discard()
+			return fmt.Errorf("message too large: %v > %v", msg.Size, maxMessageSize)
+		}
+		if msg.Code != consensusMsg {
+			msg.Discard()
+			return fmt.Errorf("invalid message code: %v", msg.Code)
+		}
+		cmsg := new(message)
+		if err := msg.DEWHode(cmsg); err != nil {
+			return fmt.Errorf("invalid consensus message: %v", err)
+		}
+		hash := cmsg.hash()
+		cp.markMessage(hash)
+
+		// Skip anything already processed, verify and relay the rest
+		if _, seen := sb.messages.Get(hash); seen {
+			continue
+		}
+		if err := cmsg.verify(); err != nil {
+			return fmt.Errorf("invalid consensus message signature: %v", err)
+		}
+		// Only relay messages of the validators, without a chain to resolve the
+		// validator set from the message can't be checked and isn't relayed
+		sb.coreLock.RLock()
+		m := sb.core
+		sb.coreLock.RUnlock()
+		if m == nil {
+			continue
+		}
+		if err := sb.checkSender(m.chain, cmsg); err != nil {
+			cp.Log().Trace("Dropping consensus message", "msg", cmsg, "err", err)
+			continue
+		}
+		sb.gossip(cmsg)
+		m.deliver(cmsg)
+	}
+}
+
+// checkSender verifies that a consensus message is about a sequence not yet
+// finalized locally and that its sender is a validator of the latest snapshot.
+// Messages for sequences beyond the next one are checked against the same
+// snapshot, as the validator set they belong to isn't known yet.
+func (sb *Istanbul) checkSender(chain consensus.ChainReader, msg *message) error {
+	head := chain.CurrentHeader()
+	if head == nil {
+		return errUnknownBlock
+	}
+	if msg.Sequence <= head.Number.Uint64() {
+		return errStaleMessage
+	}
+	snap, err := sb.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Validators[msg.Address]; !ok {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// gossip sends a consensus message to all the peers not yet knowing about it.
+func (sb *Istanbul) gossip(msg *message) {
+	hash := msg.hash()
+	sb.messages.Add(hash, struct{}{})
+
+	for _, p := range sb.peers.peersWithoutMessage(hash) {
+		p.markMessage(hash)
+		go func(p *peer) {
+			if err := p2p.Send(p.rw, consensusMsg, msg); err != nil {
+				p.Log().Debug("Failed to send consensus message", "err", err)
+			}
+		}(p)
+	}
+}
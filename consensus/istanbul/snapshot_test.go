@@ -0,0 +1,316 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/rawdb"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+type testerVote struct {
+	validator string
+	voted     string
+	auth      bool
+}
+
+// testerAccountPool is a pool to maintain currently active tester accounts,
+// mapped from textual names used in the tests below to actual DEWH private
+// keys capable of signing headers.
+type testerAccountPool struct {
+	accounts map[string]*ecdsa.PrivateKey
+}
+
+func newTesterAccountPool() *testerAccountPool {
+	return &testerAccountPool{
+		accounts: make(map[string]*ecdsa.PrivateKey),
+	}
+}
+
+func (ap *testerAccountPool) key(account string) *ecdsa.PrivateKey {
+	// Ensure we have a persistent key for the account
+	if ap.accounts[account] == nil {
+		ap.accounts[account], _ = crypto.GenerateKey()
+	}
+	return ap.accounts[account]
+}
+
+func (ap *testerAccountPool) address(account string) common.Address {
+	return crypto.PubkeyToAddress(ap.key(account).PublicKey)
+}
+
+func (ap *testerAccountPool) addresses(accounts []string) []common.Address {
+	addrs := make([]common.Address, len(accounts))
+	for i, account := range accounts {
+		addrs[i] = ap.address(account)
+	}
+	sort.Sort(addresses(addrs))
+	return addrs
+}
+
+// sign embeds the proposer seal of the given validator into the header.
+func (ap *testerAccountPool) sign(header *types.Header, validator string) {
+	hash, _ := sigHash(header)
+	seal, _ := crypto.Sign(hash.Bytes(), ap.key(validator))
+
+	extra, _ := ExtractIstanbulExtra(header)
+	extra.Seal = seal
+	writeExtra(header, extra)
+}
+
+// commit embeds the committed seals of the given validators into the header.
+func (ap *testerAccountPool) commit(header *types.Header, validators []string) {
+	digest, _ := proposalHash(header)
+
+	extra, _ := ExtractIstanbulExtra(header)
+	for _, validator := range validators {
+		seal, _ := crypto.Sign(commitHash(digest), ap.key(validator))
+		extra.CommittedSeal = append(extra.CommittedSeal, seal)
+	}
+	writeExtra(header, extra)
+}
+
+// testerChainReader implements consensus.ChainReader to access the genesis
+// block. All other methods and requests will panic.
+type testerChainReader struct {
+	db ethdb.Database
+}
+
+func (r *testerChainReader) Config() *params.ChainConfig                 { return params.TestChainConfig }
+func (r *testerChainReader) CurrentHeader() *types.Header                { panic("not supported") }
+func (r *testerChainReader) GetHeader(common.Hash, uint64) *types.Header { panic("not supported") }
+func (r *testerChainReader) GetBlock(common.Hash, uint64) *types.Block   { panic("not supported") }
+func (r *testerChainReader) GetHeaderByHash(common.Hash) *types.Header   { panic("not supported") }
+func (r *testerChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number == 0 {
+		return rawdb.ReadHeader(r.db, rawdb.ReadCanonicalHash(r.db, 0), 0)
+	}
+	panic("not supported")
+}
+
+// newTesterGenesis commits an Istanbul genesis block with the given validators.
+func newTesterGenesis(t *testing.T, validators []common.Address) ethdb.Database {
+	extra, err := PrepareExtra(nil, validators)
+	if err != nil {
+		t.Fatalf("failed to create genesis extra-data: %v", err)
+	}
+	genesis := &core.Genesis{
+		Config:    params.TestChainConfig,
+		ExtraData: extra,
+		Mixhash:   IstanbulDigest,
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.MustCommit(db)
+	return db
+}
+
+// Tests that the Istanbul section of the extra-data survives a round trip and
+// that the seals don't influence the hashes signed by the validators.
+func TestExtraData(t *testing.T) {
+	accounts := newTesterAccountPool()
+	validators := accounts.addresses([]string{"A", "B", "C"})
+
+	extra, err := PrepareExtra([]byte("vanity"), validators)
+	if err != nil {
+		t.Fatalf("failed to prepare extra-data: %v", err)
+	}
+	header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(1), Extra: extra}
+
+	sighash, _ := sigHash(header)
+	accounts.sign(header, "A")
+	if hash, _ := sigHash(header); hash != sighash {
+		t.Errorf("proposer seal changed the signing hash: have %x, want %x", hash, sighash)
+	}
+	digest, _ := proposalHash(header)
+	accounts.commit(header, []string{"A", "B"})
+	if hash, _ := proposalHash(header); hash != digest {
+		t.Errorf("committed seals changed the proposal hash: have %x, want %x", hash, digest)
+	}
+	parsed, err := ExtractIstanbulExtra(header)
+	if err != nil {
+		t.Fatalf("failed to extract extra-data: %v", err)
+	}
+	if len(parsed.Validators) != len(validators) {
+		t.Fatalf("validator count mismatch: have %d, want %d", len(parsed.Validators), len(validators))
+	}
+	for i, validator := range validators {
+		if parsed.Validators[i] != validator {
+			t.Errorf("validator %d mismatch: have %x, want %x", i, parsed.Validators[i], validator)
+		}
+	}
+	if len(parsed.CommittedSeal) != 2 {
+		t.Errorf("committed seal count mismatch: have %d, want %d", len(parsed.CommittedSeal), 2)
+	}
+	if proposer, err := ecrecover(header, newTesterEngine().signatures); err != nil || proposer != accounts.address("A") {
+		t.Errorf("proposer mismatch: have %x, want %x (err %v)", proposer, accounts.address("A"), err)
+	}
+}
+
+func newTesterEngine() *Istanbul {
+	return New(&params.IstanbulConfig{}, ethdb.NewMemDatabase())
+}
+
+// Tests that blocks are only accepted as final with a quorum of distinct
+// committed seals of the validators.
+func TestCommittedSeals(t *testing.T) {
+	tests := []struct {
+		validators []string
+		committers []string
+		valid      bool
+	}{
+		{validators: []string{"A"}, committers: []string{"A"}, valid: true},
+		{validators: []string{"A"}, committers: nil, valid: false},
+		{validators: []string{"A", "B", "C", "D"}, committers: []string{"A", "B", "C"}, valid: true},
+		{validators: []string{"A", "B", "C", "D"}, committers: []string{"A", "B"}, valid: false},
+		{validators: []string{"A", "B", "C", "D"}, committers: []string{"A", "B", "B"}, valid: false},
+		{validators: []string{"A", "B", "C", "D"}, committers: []string{"A", "B", "E"}, valid: false},
+	}
+	for i, tt := range tests {
+		accounts := newTesterAccountPool()
+		validators := accounts.addresses(tt.validators)
+
+		extra, _ := PrepareExtra(nil, validators)
+		header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(1), Extra: extra}
+		accounts.sign(header, tt.validators[0])
+		accounts.commit(header, tt.committers)
+
+		snap := newSnapshot(&params.IstanbulConfig{Epoch: epochLength}, nil, 0, common.Hash{}, validators)
+		if err := verifyCommittedSeals(header, snap); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+// Tests that proposers rotate with the block number and the round.
+func TestProposerRotation(t *testing.T) {
+	validators := []common.Address{{0x01}, {0x02}, {0x03}}
+
+	if have := proposer(validators, 1, 0); have != validators[1] {
+		t.Errorf("sequence 1 round 0: have %x, want %x", have, validators[1])
+	}
+	if have := proposer(validators, 1, 2); have != validators[0] {
+		t.Errorf("sequence 1 round 2: have %x, want %x", have, validators[0])
+	}
+	for n, want := range map[int]int{1: 1, 3: 2, 4: 3, 6: 4, 7: 5} {
+		if have := quorum(n); have != want {
+			t.Errorf("quorum of %d: have %d, want %d", n, have, want)
+		}
+	}
+}
+
+// Tests that validator voting is evaluated correctly.
+func TestVoting(t *testing.T) {
+	tests := []struct {
+		epoch      uint64
+		validators []string
+		votes      []testerVote
+		results    []string
+	}{
+		{
+			// Single validator, no votes cast
+			validators: []string{"A"},
+			votes:      []testerVote{{validator: "A"}},
+			results:    []string{"A"},
+		}, {
+			// Single validator, voting to add another
+			validators: []string{"A"},
+			votes:      []testerVote{{validator: "A", voted: "B", auth: true}},
+			results:    []string{"A", "B"},
+		}, {
+			// Two validators, adding a third needs both votes
+			validators: []string{"A", "B"},
+			votes: []testerVote{
+				{validator: "A", voted: "C", auth: true},
+				{validator: "A", voted: "C", auth: true},
+			},
+			results: []string{"A", "B"},
+		}, {
+			// Three validators, removing one needs two votes
+			validators: []string{"A", "B", "C"},
+			votes: []testerVote{
+				{validator: "A", voted: "C"},
+				{validator: "B", voted: "C"},
+			},
+			results: []string{"A", "B"},
+		}, {
+			// Pending votes are discarded at epoch transitions
+			epoch:      3,
+			validators: []string{"A", "B", "C"},
+			votes: []testerVote{
+				{validator: "A", voted: "D", auth: true},
+				{validator: "B"},
+				{validator: "C"},
+				{validator: "B", voted: "D", auth: true},
+			},
+			results: []string{"A", "B", "C"},
+		},
+	}
+	for i, tt := range tests {
+		accounts := newTesterAccountPool()
+		db := newTesterGenesis(t, accounts.addresses(tt.validators))
+
+		// Assemble a chain of headers from the cast votes
+		current := accounts.addresses(tt.validators)
+		headers := make([]*types.Header, len(tt.votes))
+		for j, vote := range tt.votes {
+			extra, _ := PrepareExtra(nil, current)
+			headers[j] = &types.Header{
+				Number:    big.NewInt(int64(j) + 1),
+				Time:      big.NewInt(int64(j) * 15),
+				Extra:     extra,
+				MixDigest: IstanbulDigest,
+			}
+			if vote.voted != "" {
+				headers[j].Coinbase = accounts.address(vote.voted)
+			}
+			if j > 0 {
+				headers[j].ParentHash = headers[j-1].Hash()
+			}
+			if vote.auth {
+				copy(headers[j].Nonce[:], nonceAuthVote)
+			}
+			accounts.sign(headers[j], vote.validator)
+		}
+		head := headers[len(headers)-1]
+
+		engine := New(&params.IstanbulConfig{Epoch: tt.epoch}, db)
+		snap, err := engine.snapshot(&testerChainReader{db: db}, head.Number.Uint64(), head.Hash(), headers)
+		if err != nil {
+			t.Errorf("test %d: failed to create voting snapshot: %v", i, err)
+			continue
+		}
+		result, want := snap.validators(), accounts.addresses(tt.results)
+		if len(result) != len(want) {
+			t.Errorf("test %d: validators mismatch: have %x, want %x", i, result, want)
+			continue
+		}
+		for j := range result {
+			if result[j] != want[j] {
+				t.Errorf("test %d, validator %d: validator mismatch: have %x, want %x", i, j, result[j], want[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+pragma solidity 0.4.24;
+
+/// @title CheckpointOracle is a registry of light client checkpoints.
+/// @notice A checkpoint is the set of post-processed trie roots (CHT and
+/// BloomTrie) of a chain section. New checkpoints are only accepted with the
+/// signatures of a threshold of the trusted admins.
+contract CheckpointOracle {
+    // Emitted for every admin signature accepted for a new checkpoint
+    event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, uint8 v, bytes32 r, bytes32 s);
+
+    // Admins allowed to sign and publish checkpoints
+    mapping(address => bool) isAdmin;
+    address[] adminList;
+
+    // Latest registered checkpoint and the block it was registered in
+    uint64 sectionIndex;
+    bytes32 sectionHead;
+    bytes32 chtRoot;
+    bytes32 bloomTrieRoot;
+    uint height;
+
+    // Chain parameters limiting which checkpoints are acceptable
+    uint sectionSize;
+    uint processConfirms;
+    uint signatureThreshold;
+
+    /// @notice Creates the oracle
+    ///
+    /// @param _adminlist addresses allowed to sign and publish checkpoints
+    /// @param _sectionSize number of blocks in a checkpoint section
+    /// @param _processConfirms confirmations needed before a section is processed
+    /// @param _threshold number of admin signatures required for a checkpoint
+    constructor(address[] _adminlist, uint _sectionSize, uint _processConfirms, uint _threshold) public {
+        require(_threshold > 0 && _threshold <= _adminlist.length);
+
+        for (uint i = 0; i < _adminlist.length; i++) {
+            isAdmin[_adminlist[i]] = true;
+            adminList.push(_adminlist[i]);
+        }
+        sectionSize = _sectionSize;
+        processConfirms = _processConfirms;
+        signatureThreshold = _threshold;
+    }
+
+    /// @notice Retrieves the latest registered checkpoint
+    function latestCheckpoint() public view returns (uint64, bytes32, bytes32, bytes32, uint) {
+        return (sectionIndex, sectionHead, chtRoot, bloomTrieRoot, height);
+    }
+
+    /// @notice Retrieves the list of admins
+    function admins() public view returns (address[]) {
+        return adminList;
+    }
+
+    /// @notice Retrieves the signature threshold of new checkpoints
+    function threshold() public view returns (uint) {
+        return signatureThreshold;
+    }
+
+    /// @notice Registers a new checkpoint
+    ///
+    /// @param _recentNumber number of a recent block, protecting against replays on forks
+    /// @param _recentHash hash of the recent block
+    /// @param _sectionIndex index of the checkpoint section
+    /// @param _checkpoint section head hash, CHT root and BloomTrie root of the section
+    /// @param _v signature parameters v of the admin signatures
+    /// @param _r signature parameters r of the admin signatures
+    /// @param _s signature parameters s of the admin signatures
+    /// The signatures are calculated on the EIP-191 version 0 hash of the oracle
+    /// address and the checkpoint hash, and must be sorted by signer address.
+    function setCheckpoint(
+        uint _recentNumber,
+        bytes32 _recentHash,
+        uint64 _sectionIndex,
+        bytes32[3] _checkpoint,
+        uint8[] _v,
+        bytes32[] _r,
+        bytes32[] _s
+    ) public returns (bool) {
+        // Only admins may publish checkpoints
+        require(isAdmin[msg.sender]);
+
+        // Ensure the transaction is not replayed on a different fork
+        require(blockhash(_recentNumber) == _recentHash);
+
+        // Ensure the signatures are well formed and sufficient
+        require(_v.length == _r.length && _v.length == _s.length);
+        require(_v.length >= signatureThreshold);
+
+        // Reject checkpoints for sections not yet processed
+        require(block.number >= (_sectionIndex + 1) * sectionSize + processConfirms);
+
+        // Reject checkpoints older than the current one, allowing the very first
+        // checkpoint to be for section 0
+        require(_sectionIndex > sectionIndex || (_sectionIndex == 0 && height == 0));
+
+        // Reject invalid checkpoints
+        require(_checkpoint[0] != 0 && _checkpoint[1] != 0 && _checkpoint[2] != 0);
+
+        bytes32 checkpointHash = keccak256(abi.encodePacked(_sectionIndex, _checkpoint));
+        bytes32 signedHash = keccak256(abi.encodePacked(byte(0x19), byte(0), address(this), checkpointHash));
+
+        // Signers must be sorted in strictly ascending order, so that a single
+        // admin cannot be counted twice
+        address lastSigner = address(0);
+        for (uint i = 0; i < _v.length; i++) {
+            address signer = ecrecover(signedHash, _v[i], _r[i], _s[i]);
+            require(isAdmin[signer]);
+            require(uint256(signer) > uint256(lastSigner));
+            lastSigner = signer;
+
+            emit NewCheckpointVote(_sectionIndex, checkpointHash, _v[i], _r[i], _s[i]);
+        }
+        sectionIndex = _sectionIndex;
+        sectionHead = _checkpoint[0];
+        chtRoot = _checkpoint[1];
+        bloomTrieRoot = _checkpoint[2];
+        height = block.number;
+
+        return true;
+    }
+}
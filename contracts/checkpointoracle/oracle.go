@@ -0,0 +1,152 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package checkpointoracle is a wrapper of the checkpoint oracle contract, an
+// on-chain registry of light client checkpoints approved by a set of admins.
+package checkpointoracle
+
+// The binding is generated with solc 0.4.24, the version pinned by the contract.
+//go:generate abigen --sol contract/oracle.sol --pkg contract --out contract/oracle.go
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/DEWH/go-DEWH/accounts/abi/bind"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle/contract"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+var (
+	errInvalidSignature = errors.New("invalid checkpoint signature")
+	errDuplicateSigner  = errors.New("duplicate checkpoint signer")
+)
+
+// CheckpointOracle is a Go wrapper around an on-chain checkpoint oracle contract.
+type CheckpointOracle struct {
+	address  common.Address
+	contract *contract.CheckpointOracle
+}
+
+// NewCheckpointOracle binds checkpoint contract and returns a registrar instance.
+func NewCheckpointOracle(contractAddr common.Address, backend bind.ContractBackend) (*CheckpointOracle, error) {
+	c, err := contract.NewCheckpointOracle(contractAddr, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{address: contractAddr, contract: c}, nil
+}
+
+// ContractAddr returns the address of the oracle contract.
+func (oracle *CheckpointOracle) ContractAddr() common.Address {
+	return oracle.address
+}
+
+// Contract returns the underlying contract instance.
+func (oracle *CheckpointOracle) Contract() *contract.CheckpointOracle {
+	return oracle.contract
+}
+
+// LatestCheckpoint retrieves the latest checkpoint registered in the oracle and
+// the number of the block it was registered in. If no checkpoint was registered
+// yet, the returned block number is zero.
+func (oracle *CheckpointOracle) LatestCheckpoint(opts *bind.CallOpts) (*params.TrustedCheckpoint, uint64, error) {
+	index, head, chtRoot, bloomRoot, height, err := oracle.contract.LatestCheckpoint(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	cp := &params.TrustedCheckpoint{
+		SectionIndex: index,
+		SectionHead:  head,
+		CHTRoot:      chtRoot,
+		BloomRoot:    bloomRoot,
+	}
+	return cp, height.Uint64(), nil
+}
+
+// RegisterCheckpoint publishes a checkpoint approved by the given admin signatures
+// into the oracle. The signatures are 65 byte [R || S || V] signatures of the
+// SigningHash, as produced by crypto.Sign, in any order.
+//
+// The recent block number and hash are used by the contract to make sure the
+// transaction is not replayed on a different fork.
+func (oracle *CheckpointOracle) RegisterCheckpoint(opts *bind.TransactOpts, cp *params.TrustedCheckpoint, recentNumber *big.Int, recentHash common.Hash, sigs [][]byte) (*types.Transaction, error) {
+	v, r, s, err := splitSignatures(SigningHash(oracle.address, cp), sigs)
+	if err != nil {
+		return nil, err
+	}
+	roots := [3][32]byte{cp.SectionHead, cp.CHTRoot, cp.BloomRoot}
+	return oracle.contract.SetCheckpoint(opts, recentNumber, recentHash, cp.SectionIndex, roots, v, r, s)
+}
+
+// SigningHash returns the hash admins need to sign to approve a checkpoint in the
+// oracle at the given address. It is the EIP-191 version 0 (data with intended
+// validator) hash of the checkpoint hash.
+func SigningHash(contractAddr common.Address, cp *params.TrustedCheckpoint) common.Hash {
+	hash := cp.Hash()
+	return crypto.Keccak256Hash([]byte{0x19, 0x00}, contractAddr[:], hash[:])
+}
+
+// RecoverSigner returns the admin address that produced the given checkpoint
+// signature.
+func RecoverSigner(hash common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		return common.Address{}, errInvalidSignature
+	}
+	pubkey, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// splitSignatures sorts the signatures by their signers, as required by the
+// contract, and splits them into the v, r and s components.
+func splitSignatures(hash common.Hash, sigs [][]byte) ([]uint8, [][32]byte, [][32]byte, error) {
+	type signature struct {
+		signer common.Address
+		sig    []byte
+	}
+	signatures := make([]signature, 0, len(sigs))
+	for _, sig := range sigs {
+		signer, err := RecoverSigner(hash, sig)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		signatures = append(signatures, signature{signer, sig})
+	}
+	sort.Slice(signatures, func(i, j int) bool {
+		return bytes.Compare(signatures[i].signer[:], signatures[j].signer[:]) < 0
+	})
+	var (
+		v    = make([]uint8, len(signatures))
+		r, s = make([][32]byte, len(signatures)), make([][32]byte, len(signatures))
+	)
+	for i, sig := range signatures {
+		if i > 0 && sig.signer == signatures[i-1].signer {
+			return nil, nil, nil, errDuplicateSigner
+		}
+		copy(r[i][:], sig.sig[:32])
+		copy(s[i][:], sig.sig[32:64])
+		v[i] = sig.sig[64] + 27 // Yellow paper v for ecrecover
+	}
+	return v, r, s, nil
+}
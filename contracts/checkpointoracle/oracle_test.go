@@ -0,0 +1,245 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/accounts/abi/bind"
+	"github.com/DEWH/go-DEWH/accounts/abi/bind/backends"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle/contract"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+var testCheckpoint = &params.TrustedCheckpoint{
+	SectionIndex: 1,
+	SectionHead:  common.HexToHash("0x01"),
+	CHTRoot:      common.HexToHash("0x02"),
+	BloomRoot:    common.HexToHash("0x03"),
+}
+
+// Tests that admin signatures are ordered by signer as the contract requires and
+// that malformed or duplicate signatures are rejected.
+func TestSplitSignatures(t *testing.T) {
+	hash := SigningHash(common.HexToAddress("0xdeadbeef"), testCheckpoint)
+
+	var sigs [][]byte
+	for i := 0; i < 5; i++ {
+		key, _ := crypto.GenerateKey()
+		sig, err := crypto.Sign(hash[:], key)
+		if err != nil {
+			t.Fatalf("failed to sign checkpoint: %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	v, r, s, err := splitSignatures(hash, sigs)
+	if err != nil {
+		t.Fatalf("failed to split signatures: %v", err)
+	}
+	var last common.Address
+	for i := range v {
+		if v[i] != 27 && v[i] != 28 {
+			t.Errorf("signature %d: invalid v %d", i, v[i])
+		}
+		sig := append(append(append([]byte{}, r[i][:]...), s[i][:]...), v[i]-27)
+		signer, err := RecoverSigner(hash, sig)
+		if err != nil {
+			t.Fatalf("signature %d: failed to recover signer: %v", i, err)
+		}
+		if i > 0 && bytes.Compare(signer[:], last[:]) <= 0 {
+			t.Errorf("signature %d: signer %x not after %x", i, signer, last)
+		}
+		last = signer
+	}
+	if _, _, _, err := splitSignatures(hash, append(sigs, sigs[2])); err != errDuplicateSigner {
+		t.Errorf("duplicate signer error mismatch: have %v, want %v", err, errDuplicateSigner)
+	}
+	if _, _, _, err := splitSignatures(hash, append(sigs, sigs[2][:64])); err != errInvalidSignature {
+		t.Errorf("short signature error mismatch: have %v, want %v", err, errInvalidSignature)
+	}
+}
+
+// sortKeys orders the given keys by their addresses, the order in which the
+// contract requires the signatures.
+func sortKeys(keys []*ecdsa.PrivateKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := crypto.PubkeyToAddress(keys[i].PublicKey), crypto.PubkeyToAddress(keys[j].PublicKey)
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+}
+
+// Tests that the oracle contract only registers checkpoints for processed and
+// newer sections, sent by an admin on the right chain and signed by a threshold
+// of distinct admins in ascending order.
+func TestCheckpointRegistration(t *testing.T) {
+	var (
+		keys     = make([]*ecdsa.PrivateKey, 3)
+		admins   = make([]common.Address, len(keys))
+		alloc    = make(core.GenesisAlloc)
+		funds    = new(big.Int).Lsh(common.Big1, 64)
+		outsider *ecdsa.PrivateKey
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sortKeys(keys)
+	for i, key := range keys {
+		admins[i] = crypto.PubkeyToAddress(key.PublicKey)
+		alloc[admins[i]] = core.GenesisAccount{Balance: funds}
+	}
+	outsider, _ = crypto.GenerateKey()
+	alloc[crypto.PubkeyToAddress(outsider.PublicKey)] = core.GenesisAccount{Balance: funds}
+
+	backend := backends.NewSimulatedBackend(alloc)
+	genesis := (&core.Genesis{Config: params.AllEthashProtocolChanges, Alloc: alloc}).ToBlock(nil)
+
+	// Deploy an oracle with 4 block sections, 2 confirmations and a threshold of 2
+	addr, _, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(keys[0]), backend, admins, big.NewInt(4), big.NewInt(2), big.NewInt(2))
+	if err != nil {
+		t.Fatalf("failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(addr, backend)
+	if err != nil {
+		t.Fatalf("failed to bind oracle: %v", err)
+	}
+	if have, err := oracle.Contract().Admins(nil); err != nil || len(have) != len(admins) {
+		t.Fatalf("admin list mismatch: have %v (%v), want %v", have, err, admins)
+	} else {
+		for i := range have {
+			if have[i] != admins[i] {
+				t.Errorf("admin %d mismatch: have %x, want %x", i, have[i], admins[i])
+			}
+		}
+	}
+	if have, err := oracle.Contract().Threshold(nil); err != nil || have.Uint64() != 2 {
+		t.Errorf("threshold mismatch: have %v (%v), want %d", have, err, 2)
+	}
+	// register sends a checkpoint signed by the given keys in the given order and
+	// reports whether the contract accepted it
+	register := func(sender *ecdsa.PrivateKey, cp *params.TrustedCheckpoint, recent common.Hash, signers ...*ecdsa.PrivateKey) bool {
+		hash := SigningHash(addr, cp)
+
+		var (
+			v    = make([]uint8, len(signers))
+			r, s = make([][32]byte, len(signers)), make([][32]byte, len(signers))
+		)
+		for i, key := range signers {
+			sig, err := crypto.Sign(hash[:], key)
+			if err != nil {
+				t.Fatalf("failed to sign checkpoint: %v", err)
+			}
+			copy(r[i][:], sig[:32])
+			copy(s[i][:], sig[32:64])
+			v[i] = sig[64] + 27
+		}
+		opts := bind.NewKeyedTransactor(sender)
+		opts.GasLimit = 1000000 // Gas estimation would refuse the failing calls
+
+		roots := [3][32]byte{cp.SectionHead, cp.CHTRoot, cp.BloomRoot}
+		tx, err := oracle.Contract().SetCheckpoint(opts, common.Big0, recent, cp.SectionIndex, roots, v, r, s)
+		if err != nil {
+			t.Fatalf("failed to send checkpoint: %v", err)
+		}
+		backend.Commit()
+
+		receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			t.Fatalf("failed to retrieve receipt: %v", err)
+		}
+		return receipt.Status == types.ReceiptStatusSuccessful
+	}
+	checkpoint := func(index uint64) *params.TrustedCheckpoint {
+		return &params.TrustedCheckpoint{
+			SectionIndex: index,
+			SectionHead:  common.BytesToHash([]byte{byte(index), 0x01}),
+			CHTRoot:      common.BytesToHash([]byte{byte(index), 0x02}),
+			BloomRoot:    common.BytesToHash([]byte{byte(index), 0x03}),
+		}
+	}
+	// Section 0 is processed at block 6, which is the second block from now
+	if register(keys[0], checkpoint(0), genesis.Hash(), keys[0], keys[1]) {
+		t.Fatalf("checkpoint of unprocessed section accepted")
+	}
+	for i := 0; i < 3; i++ {
+		backend.Commit()
+	}
+	invalid := checkpoint(0)
+	invalid.BloomRoot = common.Hash{}
+
+	mixed := []*ecdsa.PrivateKey{keys[0], outsider}
+	sortKeys(mixed)
+
+	tests := []struct {
+		name    string
+		sender  *ecdsa.PrivateKey
+		cp      *params.TrustedCheckpoint
+		recent  common.Hash
+		signers []*ecdsa.PrivateKey
+		want    bool
+	}{
+		{"below threshold", keys[0], checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[0]}, false},
+		{"duplicate signer", keys[0], checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[0], keys[0]}, false},
+		{"unsorted signers", keys[0], checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[1], keys[0]}, false},
+		{"non-admin signer", keys[0], checkpoint(0), genesis.Hash(), mixed, false},
+		{"non-admin sender", outsider, checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[0], keys[1]}, false},
+		{"foreign chain", keys[0], checkpoint(0), common.Hash{0x01}, []*ecdsa.PrivateKey{keys[0], keys[1]}, false},
+		{"empty root", keys[0], invalid, genesis.Hash(), []*ecdsa.PrivateKey{keys[0], keys[1]}, false},
+		{"first section", keys[0], checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[0], keys[1]}, true},
+		{"same section", keys[1], checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[1], keys[2]}, false},
+		{"unprocessed section", keys[0], checkpoint(10), genesis.Hash(), []*ecdsa.PrivateKey{keys[0], keys[1]}, false},
+		{"next section", keys[2], checkpoint(1), genesis.Hash(), keys, true},
+		{"older section", keys[0], checkpoint(0), genesis.Hash(), []*ecdsa.PrivateKey{keys[0], keys[1]}, false},
+	}
+	for _, tt := range tests {
+		if have := register(tt.sender, tt.cp, tt.recent, tt.signers...); have != tt.want {
+			t.Errorf("%s: acceptance mismatch: have %v, want %v", tt.name, have, tt.want)
+		}
+	}
+	cp, height, err := oracle.LatestCheckpoint(nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve latest checkpoint: %v", err)
+	}
+	if height == 0 || cp.Hash() != checkpoint(1).Hash() {
+		t.Errorf("latest checkpoint mismatch: have %+v at %d, want %+v", cp, height, checkpoint(1))
+	}
+	// Every signature of the accepted checkpoints must have been announced
+	votes, err := oracle.Contract().FilterNewCheckpointVote(&bind.FilterOpts{}, nil)
+	if err != nil {
+		t.Fatalf("failed to filter votes: %v", err)
+	}
+	var count int
+	for votes.Next() {
+		if want := checkpoint(votes.Event.Index).Hash(); votes.Event.CheckpointHash != want {
+			t.Errorf("vote %d: checkpoint hash mismatch: have %x, want %x", count, votes.Event.CheckpointHash, want)
+		}
+		count++
+	}
+	if count != 2+len(keys) {
+		t.Errorf("vote count mismatch: have %d, want %d", count, 2+len(keys))
+	}
+}
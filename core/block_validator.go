@@ -101,9 +101,11 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 	return nil
 }
 
-// CalcGasLimit computes the gas limit of the next block after parent.
+// CalcGasLimit computes the gas limit of the next block after parent. It aims
+// to keep the gas limit within the gasFloor and gasCeil range, a zero gasCeil
+// meaning no upper bound.
 // This is miner strategy, not consensus protocol.
-func CalcGasLimit(parent *types.Block) uint64 {
+func CalcGasLimit(parent *types.Block, gasFloor, gasCeil uint64) uint64 {
 	// contrib = (parentGasUsed * 3 / 2) / 1024
 	contrib := (parent.GasUsed() + parent.GasUsed()/2) / params.GasLimitBoundDivisor
 
@@ -121,12 +123,17 @@ func CalcGasLimit(parent *types.Block) uint64 {
 	if limit < params.MinGasLimit {
 		limit = params.MinGasLimit
 	}
-	// however, if we're now below the target (TargetGasLimit) we increase the
-	// limit as much as we can (parentGasLimit / 1024 -1)
-	if limit < params.TargetGasLimit {
+	// however, if we're now outside the allowed range we move the limit
+	// towards it as much as we can (parentGasLimit / 1024 -1)
+	if limit < gasFloor {
 		limit = parent.GasLimit() + DEWHay
-		if limit > params.TargetGasLimit {
-			limit = params.TargetGasLimit
+		if limit > gasFloor {
+			limit = gasFloor
+		}
+	} else if gasCeil != 0 && limit > gasCeil {
+		limit = parent.GasLimit() - DEWHay
+		if limit < gasCeil {
+			limit = gasCeil
 		}
 	}
 	return limit
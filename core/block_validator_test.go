@@ -197,3 +197,25 @@ func testHeaderConcurrentAbortion(t *testing.T, threads int) {
 		t.Errorf("verification count too large: have %d, want below %d", verified, 2*threads)
 	}
 }
+
+// Tests that the gas limit is moved towards the configured floor and ceiling.
+func TestCalcGasLimit(t *testing.T) {
+	tests := []struct {
+		parentLimit, parentUsed uint64
+		floor, ceil             uint64
+		want                    uint64
+	}{
+		{5000000, 0, 4000000, 0, 4995119},             // within range, decaying
+		{5000000, 0, 6000000, 0, 5004881},             // below floor, raising
+		{5000000, 0, 4995500, 0, 4995500},             // below floor, capped at floor
+		{5000000, 5000000, 3000000, 0, 5002443},       // no ceiling, growing with usage
+		{5000000, 5000000, 3000000, 4000000, 4995119}, // above ceiling, lowering
+		{5000000, 5000000, 3000000, 5001000, 5001000}, // above ceiling, capped at ceiling
+	}
+	for i, tt := range tests {
+		parent := types.NewBlockWithHeader(&types.Header{GasLimit: tt.parentLimit, GasUsed: tt.parentUsed})
+		if have := CalcGasLimit(parent, tt.floor, tt.ceil); have != tt.want {
+			t.Errorf("test %d: gas limit mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
@@ -24,6 +24,81 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// TxChangeKind is the kind of change a transaction went through in the pool.
+type TxChangeKind uint8
+
+const (
+	TxPromoted TxChangeKind = iota // moved from the future queue to the executable set
+	TxReplaced                     // replaced by another transaction with the same nonce
+	TxDropped                      // removed from the pool without being mined
+)
+
+func (k TxChangeKind) String() string {
+	switch k {
+	case TxPromoted:
+		return "promoted"
+	case TxReplaced:
+		return "replaced"
+	case TxDropped:
+		return "dropped"
+	default:
+		return "unknown"
+	}
+}
+
+// TxDropReason is the reason a transaction was removed from the pool.
+type TxDropReason uint8
+
+const (
+	TxDropNone           TxDropReason = iota // not dropped
+	TxDropUnderpriced                        // priced out by better paying transactions
+	TxDropReplaced                           // replaced by a transaction with the same nonce
+	TxDropNonceTooLow                        // nonce already used by a mined transaction
+	TxDropLifetime                           // queued for longer than the allowed lifetime
+	TxDropCapacity                           // exceeded the account or global slot limits
+	TxDropUnpayable                          // not enough funds or gas allowance anymore
+	TxDropPrivateExpired                     // private transaction not mined in time
+	TxDropRemoved                            // removed manually by the node operator
+)
+
+func (r TxDropReason) String() string {
+	switch r {
+	case TxDropNone:
+		return ""
+	case TxDropUnderpriced:
+		return "underpriced"
+	case TxDropReplaced:
+		return "replaced"
+	case TxDropNonceTooLow:
+		return "nonce too low"
+	case TxDropLifetime:
+		return "lifetime expired"
+	case TxDropCapacity:
+		return "capacity"
+	case TxDropUnpayable:
+		return "unpayable"
+	case TxDropPrivateExpired:
+		return "private expired"
+	case TxDropRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// TxChange describes a single change of a transaction in the pool.
+type TxChange struct {
+	Tx     *types.Transaction
+	From   common.Address
+	Kind   TxChangeKind
+	Reason TxDropReason       // Reason of the removal for dropped and replaced transactions
+	By     *types.Transaction // Replacement transaction for replaced ones
+}
+
+// TxChangeEvent is posted when transactions are promoted, replaced or dropped in
+// the transaction pool.
+type TxChangeEvent struct{ Changes []TxChange }
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
@@ -0,0 +1,281 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkid implements EIP-2124 fork identifiers, a compact summary of the
+// chain configuration used to reject incompatible peers early.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+// timestampThreshold is the Frontier launch timestamp, above which announced next
+// forks are interpreted as timestamps instead of block numbers.
+const timestampThreshold = 1438269973
+
+var (
+	// ErrRemoteStale is returned by the validator if a remote fork checksum is a
+	// subset of our already applied forks, but the announced next fork block is
+	// not on our already passed chain.
+	ErrRemoteStale = errors.New("remote needs update")
+
+	// ErrLocalIncompatibleOrStale is returned by the validator if a remote fork
+	// checksum does not match any local checksum variation, signalling that the
+	// two chains have diverged in the past at some point (possibly at genesis).
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// Blockchain defines all necessary methods to build a forkID.
+type Blockchain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// Genesis retrieves the chain's genesis block.
+	Genesis() *types.Block
+
+	// CurrentHeader retrieves the current head header of the canonical chain.
+	CurrentHeader() *types.Header
+}
+
+// ID is a fork identifier as defined by EIP-2124.
+type ID struct {
+	Hash [4]byte // CRC32 checksum of the genesis block and passed fork block numbers and timestamps
+	Next uint64  // Block number or timestamp of the next upcoming fork, or 0 if no forks are known
+}
+
+// Filter is a fork id filter to validate a remotely advertised ID.
+type Filter func(id ID) error
+
+// NewID calculates the fork ID from the chain config and the current head.
+func NewID(chain Blockchain) ID {
+	var (
+		genesis = chain.Genesis()
+		head    = chain.CurrentHeader()
+	)
+	return newID(chain.Config(), genesis.Hash(), genesis.Time().Uint64(), head.Number.Uint64(), head.Time.Uint64())
+}
+
+// newID is the internal version of NewID, which takes extracted values as its
+// arguments instead of a chain. The reason is to allow testing the IDs without
+// having to simulate an entire blockchain.
+func newID(config *params.ChainConfig, genesis common.Hash, genesisTime, head, time uint64) ID {
+	// Calculate the starting checksum from the genesis hash
+	hash := crc32.ChecksumIEEE(genesis[:])
+
+	// Calculate the current fork checksum and the next fork block or timestamp
+	forksByBlock, forksByTime := gatherForks(config, genesisTime)
+	for _, fork := range forksByBlock {
+		if fork <= head {
+			// Fork already passed, checksum the previous hash and the fork number
+			hash = checksumUpdate(hash, fork)
+			continue
+		}
+		return ID{Hash: checksumToBytes(hash), Next: fork}
+	}
+	for _, fork := range forksByTime {
+		if fork <= time {
+			// Fork already passed, checksum the previous hash and the fork timestamp
+			hash = checksumUpdate(hash, fork)
+			continue
+		}
+		return ID{Hash: checksumToBytes(hash), Next: fork}
+	}
+	return ID{Hash: checksumToBytes(hash), Next: 0}
+}
+
+// NewFilter creates a filter that returns if a fork ID should be rejected or not
+// based on the local chain's status.
+func NewFilter(chain Blockchain) Filter {
+	genesis := chain.Genesis()
+	return newFilter(chain.Config(), genesis.Hash(), genesis.Time().Uint64(), func() (uint64, uint64) {
+		head := chain.CurrentHeader()
+		return head.Number.Uint64(), head.Time.Uint64()
+	})
+}
+
+// newFilter is the internal version of NewFilter, taking closures as its
+// arguments instead of a chain. The reason is to allow testing it without
+// having to simulate an entire blockchain.
+func newFilter(config *params.ChainConfig, genesis common.Hash, genesisTime uint64, headfn func() (uint64, uint64)) Filter {
+	// Calculate all the valid fork hash and fork next combos, block based forks
+	// always preceding time based ones
+	var (
+		forksByBlock, forksByTime = gatherForks(config, genesisTime)
+		forks                     = append(append([]uint64{}, forksByBlock...), forksByTime...)
+		sums                      = make([][4]byte, len(forks)+1) // 0th is the genesis
+	)
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	// Add a sentinel fork that will never be passed
+	forks = append(forks, math.MaxUint64)
+
+	return func(id ID) error {
+		// Run the fork checksum validation ruleset:
+		//   1. If local and remote FORK_CSUM matches, compare local head to FORK_NEXT.
+		//        The two nodes are in the same fork state currently. They might know
+		//        of differing future forks, but that's not relevant until the fork
+		//        triggers (might be postponed, nodes might be updated to match).
+		//      1a. A remotely announced but remotely not passed block is already passed
+		//          locally, disconnect, since the chains are incompatible.
+		//      1b. No remotely announced fork; or not yet passed locally, connect.
+		//   2. If the remote FORK_CSUM is a subset of the local past forks and the
+		//      remote FORK_NEXT matches with the locally following fork block number,
+		//      connect.
+		//        Remote node is currently syncing. It might eventually diverge from
+		//        us, but at this current point in time we don't have enough information.
+		//   3. If the remote FORK_CSUM is a superset of the local past forks and can
+		//      be completed with locally known future forks, connect.
+		//        Local node is currently syncing. It might eventually diverge from
+		//        the remote, but at this current point in time we don't have enough
+		//        information.
+		//   4. Reject in all other cases.
+		head, time := headfn()
+		for i, fork := range forks {
+			// If our head is beyond this fork, continue to the next (we have a dummy
+			// fork of maxuint64 as the last item to always fail this check eventually).
+			passed := head >= fork
+			if i >= len(forksByBlock) {
+				passed = time >= fork
+			}
+			if passed {
+				continue
+			}
+			// Found the first unpassed fork block, check if our current state matches
+			// the remote checksum (rule #1).
+			if sums[i] == id.Hash {
+				// Fork checksum matched, check if a remote future fork block already passed
+				// locally without the local node being aware of it (rule #1a).
+				if id.Next > 0 && (head >= id.Next || (id.Next > timestampThreshold && time >= id.Next)) {
+					return ErrLocalIncompatibleOrStale
+				}
+				// Haven't passed locally a remote-only fork, accept the connection (rule #1b).
+				return nil
+			}
+			// The local and remote nodes are in different forks currently, check if the
+			// remote checksum is a subset of our local forks (rule #2).
+			for j := 0; j < i; j++ {
+				if sums[j] == id.Hash {
+					// Remote checksum is a subset, validate based on the announced next fork
+					if forks[j] != id.Next {
+						return ErrRemoteStale
+					}
+					return nil
+				}
+			}
+			// Remote chain is not a subset of our local one, check if it's a superset by
+			// any chance, signalling that we're simply out of sync (rule #3).
+			for j := i + 1; j < len(sums); j++ {
+				if sums[j] == id.Hash {
+					// Remote checksum is a superset, ignore upcoming forks
+					return nil
+				}
+			}
+			// No exact, subset or superset match. We are on differing chains, reject.
+			return ErrLocalIncompatibleOrStale
+		}
+		log.Error("Impossible fork ID validation", "id", id)
+		return nil // Something's very wrong, accept rather than reject
+	}
+}
+
+// checksumUpdate calculates the next IEEE CRC32 checksum based on the previous
+// one and a fork block number (equivalent to CRC32(original-blob || fork)).
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a uint32 checksum into a [4]byte array.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
+
+// gatherForks gathers all the known forks and creates two sorted lists out of
+// them, one for the block number based forks and one for the timestamp based
+// forks. Besides the fork blocks of the chain config, the starting blocks of the
+// reward schedule eras are forks too, since they change the issuance rules.
+func gatherForks(config *params.ChainConfig, genesisTime uint64) ([]uint64, []uint64) {
+	var forksByBlock, forksByTime []uint64
+
+	// Gather all the fork block numbers and timestamps via reflection
+	kind := reflect.TypeOf(params.ChainConfig{})
+	conf := reflect.ValueOf(config).Elem()
+
+	for i := 0; i < kind.NumField(); i++ {
+		// Fetch the next field and skip non-fork rules
+		field := kind.Field(i)
+		if field.Type != reflect.TypeOf(new(big.Int)) {
+			continue
+		}
+		rule := conf.Field(i).Interface().(*big.Int)
+		if rule == nil {
+			continue
+		}
+		// Extract the fork rule block number or timestamp and aggregate it
+		switch {
+		case strings.HasSuffix(field.Name, "Block"):
+			forksByBlock = append(forksByBlock, rule.Uint64())
+		case strings.HasSuffix(field.Name, "Time"):
+			// Time based forks active at genesis are treated like block 0 ones
+			if rule.Uint64() > genesisTime {
+				forksByTime = append(forksByTime, rule.Uint64())
+			}
+		}
+	}
+	if config.Rewards != nil {
+		for _, era := range config.Rewards.Eras {
+			if era.Block != nil {
+				forksByBlock = append(forksByBlock, era.Block.Uint64())
+			}
+		}
+	}
+	return uniqueForks(forksByBlock), uniqueForks(forksByTime)
+}
+
+// uniqueForks sorts the given fork switches to permit chronological XOR and
+// drops duplicates applying multiple forks, as well as any forks applied at
+// genesis.
+func uniqueForks(forks []uint64) []uint64 {
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+
+	var unique []uint64
+	for _, fork := range forks {
+		if fork == 0 || (len(unique) > 0 && unique[len(unique)-1] == fork) {
+			continue
+		}
+		unique = append(unique, fork)
+	}
+	return unique
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+// Tests that fork IDs are properly calculated for the canonical networks.
+func TestCreation(t *testing.T) {
+	type testcase struct {
+		head uint64
+		want ID
+	}
+	tests := []struct {
+		config  *params.ChainConfig
+		genesis common.Hash
+		cases   []testcase
+	}{
+		// Mainnet test cases
+		{
+			params.MainnetChainConfig,
+			params.MainnetGenesisHash,
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},       // Unsynced
+				{1149999, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}}, // Last Frontier block
+				{1150000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}}, // First Homestead block
+				{1919999, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}}, // Last Homestead block
+				{1920000, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}}, // First DAO block
+				{2462999, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}}, // Last DAO block
+				{2463000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}}, // First Tangerine block
+				{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}}, // Last Tangerine block
+				{2675000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}}, // First Spurious block
+				{4369999, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}}, // Last Spurious block
+				{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}},       // First Byzantium block
+				{7000000, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}},       // Future Byzantium block
+			},
+		},
+		// Ropsten test cases
+		{
+			params.TestnetChainConfig,
+			params.TestnetGenesisHash,
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0x30c7ddbc), Next: 10}},            // Unsynced, last Frontier, Homestead and first Tangerine block
+				{9, ID{Hash: checksumToBytes(0x30c7ddbc), Next: 10}},            // Last Tangerine block
+				{10, ID{Hash: checksumToBytes(0x63760190), Next: 1700000}},      // First Spurious block
+				{1699999, ID{Hash: checksumToBytes(0x63760190), Next: 1700000}}, // Last Spurious block
+				{1700000, ID{Hash: checksumToBytes(0x3ea159c7), Next: 0}},       // First Byzantium block
+				{4000000, ID{Hash: checksumToBytes(0x3ea159c7), Next: 0}},       // Future Byzantium block
+			},
+		},
+		// Rinkeby test cases
+		{
+			params.RinkebyChainConfig,
+			common.HexToHash("0x6341fd3daf94b748c72ced5a5b26028f2474f5f00d824504e4fa37a75767e177"),
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0x3b8e0691), Next: 1}},             // Unsynced, last Frontier block
+				{1, ID{Hash: checksumToBytes(0x60949295), Next: 2}},             // First and last Homestead block
+				{2, ID{Hash: checksumToBytes(0x8bde40dd), Next: 3}},             // First and last Tangerine block
+				{3, ID{Hash: checksumToBytes(0xcb3a64bb), Next: 1035301}},       // First Spurious block
+				{1035300, ID{Hash: checksumToBytes(0xcb3a64bb), Next: 1035301}}, // Last Spurious block
+				{1035301, ID{Hash: checksumToBytes(0x8d748b57), Next: 0}},       // First Byzantium block
+				{3000000, ID{Hash: checksumToBytes(0x8d748b57), Next: 0}},       // Future Byzantium block
+			},
+		},
+	}
+	for i, tt := range tests {
+		for j, ttt := range tt.cases {
+			if have := newID(tt.config, tt.genesis, 0, ttt.head, 0); have != ttt.want {
+				t.Errorf("test %d, case %d: fork ID mismatch: have %x, want %x", i, j, have, ttt.want)
+			}
+		}
+	}
+}
+
+// Tests that reward schedule eras are treated as forks.
+func TestRewardEraForks(t *testing.T) {
+	config := &params.ChainConfig{
+		HomesteadBlock: big.NewInt(10),
+		ByzantiumBlock: big.NewInt(30),
+		Rewards: &params.RewardConfig{Eras: []*params.RewardEra{
+			{Block: big.NewInt(0), Reward: big.NewInt(5)},
+			{Block: big.NewInt(20), Reward: big.NewInt(3)},
+			{Block: big.NewInt(30), Reward: big.NewInt(2)},
+		}},
+	}
+	if have, _ := gatherForks(config, 0); !reflect.DeepEqual(have, []uint64{10, 20, 30}) {
+		t.Errorf("fork list mismatch: have %v, want %v", have, []uint64{10, 20, 30})
+	}
+	if id := newID(config, common.Hash{}, 0, 15, 0); id.Next != 20 {
+		t.Errorf("next fork mismatch: have %d, want %d", id.Next, 20)
+	}
+}
+
+// Tests that timestamp based forks are checksummed after the block based ones and
+// are validated against the local head time.
+func TestTimestampForks(t *testing.T) {
+	var (
+		genesis     = common.HexToHash("0x01")
+		genesisTime = uint64(1500000000)
+		switchTime  = uint64(1600000000)
+		config      = &params.ChainConfig{
+			HomesteadBlock:     big.NewInt(10),
+			ConstantinopleTime: new(big.Int).SetUint64(switchTime),
+		}
+	)
+	// Time forks at or before genesis are not forks at all
+	if _, times := gatherForks(config, switchTime); len(times) != 0 {
+		t.Errorf("genesis time fork gathered: %v", times)
+	}
+	before := newID(config, genesis, genesisTime, 5, genesisTime)
+	if before.Next != 10 {
+		t.Errorf("next fork mismatch before homestead: have %d, want %d", before.Next, 10)
+	}
+	homestead := newID(config, genesis, genesisTime, 10, switchTime-1)
+	if homestead.Next != switchTime {
+		t.Errorf("next fork mismatch before switch time: have %d, want %d", homestead.Next, switchTime)
+	}
+	after := newID(config, genesis, genesisTime, 11, switchTime)
+	if after.Next != 0 || after.Hash == homestead.Hash {
+		t.Errorf("fork ID not updated at switch time: have %x, previous %x", after, homestead)
+	}
+	tests := []struct {
+		head, time uint64
+		id         ID
+		err        error
+	}{
+		// Local and remote at the same time fork
+		{20, switchTime, after, nil},
+		// Remote not yet at the time fork but aware of it, accept
+		{20, switchTime, homestead, nil},
+		// Remote not yet at the time fork and unaware of it, reject
+		{20, switchTime, ID{Hash: homestead.Hash, Next: 0}, ErrRemoteStale},
+		// Local not yet at the time fork, remote is, accept
+		{20, switchTime - 1, after, nil},
+		// Remote announces a time fork already passed locally without our knowledge, reject
+		{20, switchTime - 1, ID{Hash: homestead.Hash, Next: switchTime - 10}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newFilter(config, genesis, genesisTime, func() (uint64, uint64) { return tt.head, tt.time })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that IDs are properly RLP encoded (specifically important because we
+// use uint32 to store the hash, but we need to encode it as [4]byte).
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want []byte
+	}{
+		{ID{Hash: checksumToBytes(0), Next: 0}, common.Hex2Bytes("c6840000000080")},
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0xBADDCAFE}, common.Hex2Bytes("ca84deadbeef84baddcafe")},
+		{ID{Hash: checksumToBytes(math.MaxUint32), Next: math.MaxUint64}, common.Hex2Bytes("ce84ffffffff88ffffffffffffffff")},
+	}
+	for i, tt := range tests {
+		have, err := rlp.EncodeToBytes(tt.id)
+		if err != nil {
+			t.Errorf("test %d: failed to encode forkid: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: RLP mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that fork ID validation accepts and rejects remote IDs as expected.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local is mainnet Byzantium, remote announces the same. No future fork is announced.
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, nil},
+
+		// Local is mainnet Byzantium, remote announces the same. Remote also announces a next fork
+		// at a block not yet reached locally, accept.
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: math.MaxUint64}, nil},
+
+		// Local is mainnet Spurious, remote announces the same. Remote also announces Byzantium.
+		{2675000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}, nil},
+
+		// Local is mainnet Byzantium, remote announces the same. Remote also announces a next fork
+		// at a block already passed locally. Remote has diverged, reject.
+		{5000000, ID{Hash: checksumToBytes(0xa00bc324), Next: 4500000}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Byzantium, remote is Spurious and is aware of Byzantium. Remote is
+		// simply out of sync, accept.
+		{4370000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}, nil},
+
+		// Local is mainnet Byzantium, remote is Spurious and is not aware of Byzantium. Remote
+		// needs a software update, reject.
+		{4370000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, ErrRemoteStale},
+
+		// Local is mainnet Byzantium, remote is Spurious but announces a different next fork.
+		// Remote needs a software update, reject.
+		{4370000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4400000}, ErrRemoteStale},
+
+		// Local is mainnet Spurious, remote is Byzantium. Local is out of sync, accept.
+		{2675000, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, nil},
+
+		// Local is mainnet Byzantium, remote is Rinkeby Byzantium. Different genesis, reject.
+		{4370000, ID{Hash: checksumToBytes(0x8d748b57), Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newFilter(params.MainnetChainConfig, params.MainnetGenesisHash, 0, func() (uint64, uint64) { return tt.head, 0 })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
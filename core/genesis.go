@@ -154,6 +154,11 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil {
+		if err := genesis.Config.Validate(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
@@ -193,11 +198,28 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
-	height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	headHash := rawdb.ReadHeadHeaderHash(db)
+	height := rawdb.ReadHeaderNumber(db, headHash)
 	if height == nil {
 		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
 	}
-	compatErr := storedcfg.CheckCompatible(newcfg, *height)
+	head := rawdb.ReadHeader(db, headHash, *height)
+	if head == nil {
+		return newcfg, stored, fmt.Errorf("missing head header")
+	}
+	compatErr := storedcfg.CheckCompatible(newcfg, *height, head.Time.Uint64())
+	if compatErr != nil && compatErr.RewindToTime != 0 {
+		// Time based fork mismatch, rewind to the last block before the timestamp
+		// unless a block based mismatch already rewinds further
+		for head.Number.Sign() > 0 && head.Time.Uint64() > compatErr.RewindToTime {
+			if head = rawdb.ReadHeader(db, head.ParentHash, head.Number.Uint64()-1); head == nil {
+				return newcfg, stored, fmt.Errorf("missing ancestor header while resolving %v", compatErr)
+			}
+		}
+		if compatErr.RewindTo == 0 || head.Number.Uint64() < compatErr.RewindTo {
+			compatErr.RewindTo = head.Number.Uint64()
+		}
+	}
 	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
 		return newcfg, stored, compatErr
 	}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/core/types"
@@ -178,3 +179,125 @@ func (journal *txJournal) close() error {
 	}
 	return err
 }
+
+// remoteTx is an entry of the remote transaction journal, storing the time the
+// transaction was first seen alongside it to allow filtering out stale ones.
+type remoteTx struct {
+	Time uint64 // Unix timestamp of the transaction's arrival
+	Tx   *types.Transaction
+}
+
+// remoteJournal is a periodically regenerated dump of the remote transactions
+// in the pool, allowing nodes to restart without losing their pool content.
+// Contrary to the local journal, it is not appended to on every insertion as
+// remote transactions churn way too much for that.
+type remoteJournal struct {
+	path  string        // Filesystem path to store the transactions at
+	limit int           // Maximum number of transactions to store
+	age   time.Duration // Maximum age of transactions to reload
+
+	seen map[common.Hash]uint64 // Original arrival times of the reloaded transactions
+}
+
+// newRemoteJournal creates a new remote transaction journal.
+func newRemoteJournal(path string, limit uint64, age time.Duration) *remoteJournal {
+	return &remoteJournal{
+		path:  path,
+		limit: int(limit),
+		age:   age,
+		seen:  make(map[common.Hash]uint64),
+	}
+}
+
+// load parses a remote transaction journal dump from disk, loading all the not
+// yet stale transactions into the specified pool.
+func (journal *remoteJournal) load(add func([]*types.Transaction) []error) error {
+	// Skip the parsing if the journal file doesn't exist at all
+	if _, err := os.Stat(journal.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(journal.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream  = rlp.NewStream(input, 0)
+		cutoff  = uint64(time.Now().Add(-journal.age).Unix())
+		failure error
+		batch   types.Transactions
+
+		total, stale, dropped int
+	)
+	loadBatch := func(txs types.Transactions) {
+		for _, err := range add(txs) {
+			if err != nil {
+				log.Trace("Failed to add journaled remote transaction", "err", err)
+				dropped++
+			}
+		}
+	}
+	for {
+		entry := new(remoteTx)
+		if err = stream.DEWHode(entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			if batch.Len() > 0 {
+				loadBatch(batch)
+			}
+			break
+		}
+		total++
+
+		if entry.Time < cutoff {
+			stale++
+			continue
+		}
+		journal.seen[entry.Tx.Hash()] = entry.Time
+
+		if batch = append(batch, entry.Tx); batch.Len() > 1024 {
+			loadBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	log.Info("Loaded remote transaction journal", "transactions", total, "stale", stale, "dropped", dropped)
+
+	return failure
+}
+
+// dump regenerates the journal from the given transactions, storing at most the
+// configured number of them in the given order.
+func (journal *remoteJournal) dump(txs types.Transactions) error {
+	if len(txs) > journal.limit {
+		txs = txs[:journal.limit]
+	}
+	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	// Reloaded transactions retain their original arrival time, the rest of the
+	// remembered times can be forgotten
+	seen := make(map[common.Hash]uint64)
+	for _, tx := range txs {
+		entry := &remoteTx{Time: uint64(tx.Time().Unix()), Tx: tx}
+		if arrival, ok := journal.seen[tx.Hash()]; ok {
+			entry.Time, seen[tx.Hash()] = arrival, arrival
+		}
+		if err = rlp.Encode(replacement, entry); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	journal.seen = seen
+
+	if err = replacement.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(journal.path+".new", journal.path); err != nil {
+		return err
+	}
+	log.Info("Regenerated remote transaction journal", "transactions", len(txs))
+	return nil
+}
@@ -136,6 +136,15 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	PrivateLifetime uint64 // Number of blocks private transactions are kept before being dropped
+
+	RemoteJournal      string        // Journal of remote transactions to survive node restarts (disabled if empty)
+	RemoteJournalLimit uint64        // Maximum number of remote transactions to journal
+	RemoteJournalAge   time.Duration // Maximum age of journaled remote transactions to reload
+
+	PrioritySenders []common.Address `toml:",omitempty"` // Senders mined first and exempt from the global slot limits
+	NoGossipSenders []common.Address `toml:",omitempty"` // Senders whose transactions are never broadcast
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -153,6 +162,11 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	PrivateLifetime: 25,
+
+	RemoteJournalLimit: 5120,
+	RemoteJournalAge:   3 * time.Hour,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -171,6 +185,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.RemoteJournal != "" && conf.RemoteJournalLimit < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal limit", "provided", conf.RemoteJournalLimit, "updated", DefaultTxPoolConfig.RemoteJournalLimit)
+		conf.RemoteJournalLimit = DefaultTxPoolConfig.RemoteJournalLimit
+	}
+	if conf.PrivateLifetime < 1 {
+		log.Warn("Sanitizing invalid txpool private lifetime", "provided", conf.PrivateLifetime, "updated", DefaultTxPoolConfig.PrivateLifetime)
+		conf.PrivateLifetime = DefaultTxPoolConfig.PrivateLifetime
+	}
 	return conf
 }
 
@@ -187,6 +209,7 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	changeFeed   event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -197,14 +220,24 @@ type TxPool struct {
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals   *accountSet    // Set of local transaction to exempt from eviction rules
+	priority *accountSet    // Set of senders mined first and exempt from the global limits
+	noGossip *accountSet    // Set of senders whose transactions are never broadcast
+	journal  *txJournal     // Journal of local transaction to back up to disk
+	remotes  *remoteJournal // Journal of remote transactions to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
+	private map[common.Hash]uint64       // Private transactions and the block number they expire at
+	changes []TxChange                   // Transaction changes not yet flushed
+
+	changeQueue [][]TxChange  // Flushed change batches not yet sent to subscribers
+	changeLock  sync.Mutex    // Protects the change queue, independent of the pool lock
+	changeWake  chan struct{} // Notification channel for the change dispatcher
+	changeQuit  chan struct{} // Termination channel for the change dispatcher
 
 	wg sync.WaitGroup // for shutdown sync
 
@@ -227,10 +260,15 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
 		all:         newTxLookup(),
+		private:     make(map[common.Hash]uint64),
+		changeWake:  make(chan struct{}, 1),
+		changeQuit:  make(chan struct{}),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	pool.priority = newAccountSet(pool.signer, config.PrioritySenders...)
+	pool.noGossip = newAccountSet(pool.signer, config.NoGossipSenders...)
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -245,12 +283,21 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote transaction journaling is enabled, reload and revalidate them
+	if config.RemoteJournal != "" {
+		pool.remotes = newRemoteJournal(config.RemoteJournal, config.RemoteJournalLimit, config.RemoteJournalAge)
+
+		if err := pool.remotes.load(pool.AddRemotes); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
-	// Start the event loop and return
-	pool.wg.Add(1)
+	// Start the event loop and the change dispatcher and return
+	pool.wg.Add(2)
 	go pool.loop()
+	go pool.changeLoop()
 
 	return pool
 }
@@ -319,9 +366,11 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash(), true)
+						pool.dropped(addr, tx, TxDropLifetime)
 					}
 				}
 			}
+			pool.flushChanges()
 			pool.mu.Unlock()
 
 		// Handle local transaction journal rotation
@@ -333,6 +382,15 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remotes != nil {
+				pool.mu.RLock()
+				txs := pool.remote()
+				pool.mu.RUnlock()
+
+				if err := pool.remotes.dump(txs); err != nil {
+					log.Warn("Failed to regenerate remote tx journal", "err", err)
+				}
+			}
 		}
 	}
 }
@@ -350,7 +408,10 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
-	var reinject types.Transactions
+	var (
+		reinject types.Transactions
+		mined    types.Transactions
+	)
 
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
@@ -394,6 +455,11 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 				}
 			}
 			reinject = types.TxDifference(discarded, included)
+			mined = included
+		}
+	} else if newHead != nil {
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			mined = block.Transactions()
 		}
 	}
 	// Initialize the internal state to the current head
@@ -418,7 +484,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// any transactions that have been included in the block or
 	// have been invalidated because of another transaction (e.g.
 	// higher gas price)
-	pool.demoteUnexecutables()
+	pool.demoteUnexecutables(mined)
 
 	// Update all accounts to the latest known pending nonce
 	for addr, list := range pool.pending {
@@ -428,6 +494,26 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Check the queue and move transactions over to the pending if possible
 	// or remove those that have become invalid
 	pool.promoteExecutables(nil)
+
+	// Drop private transactions which weren't mined in time, and forget about
+	// the ones already gone from the pool
+	number := newHead.Number.Uint64()
+	for hash, expiry := range pool.private {
+		if pool.all.Get(hash) == nil {
+			delete(pool.private, hash)
+			continue
+		}
+		if number >= expiry {
+			log.Trace("Removing expired private transaction", "hash", hash)
+			tx := pool.all.Get(hash)
+			from, _ := types.Sender(pool.signer, tx) // already validated
+
+			pool.removeTx(hash, true)
+			pool.dropped(from, tx, TxDropPrivateExpired)
+			delete(pool.private, hash)
+		}
+	}
+	pool.flushChanges()
 }
 
 // Stop terminates the transaction pool.
@@ -437,11 +523,21 @@ func (pool *TxPool) Stop() {
 
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
+	close(pool.changeQuit)
 	pool.wg.Wait()
 
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.remotes != nil {
+		pool.mu.RLock()
+		txs := pool.remote()
+		pool.mu.RUnlock()
+
+		if err := pool.remotes.dump(txs); err != nil {
+			log.Warn("Failed to regenerate remote tx journal", "err", err)
+		}
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -451,6 +547,71 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxChangeEvent registers a subscription of TxChangeEvent, reporting the
+// promotion, replacement and removal of pooled transactions.
+func (pool *TxPool) SubscribeTxChangeEvent(ch chan<- TxChangeEvent) event.Subscription {
+	return pool.scope.Track(pool.changeFeed.Subscribe(ch))
+}
+
+// dropped records the removal of a transaction for the change subscribers.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) dropped(from common.Address, tx *types.Transaction, reason TxDropReason) {
+	pool.changes = append(pool.changes, TxChange{Tx: tx, From: from, Kind: TxDropped, Reason: reason})
+}
+
+// replaced records the replacement of a transaction for the change subscribers.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) replaced(from common.Address, old, tx *types.Transaction) {
+	pool.changes = append(pool.changes, TxChange{Tx: old, From: from, Kind: TxReplaced, Reason: TxDropReplaced, By: tx})
+}
+
+// flushChanges queues the recorded transaction changes for the subscribers.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) flushChanges() {
+	if len(pool.changes) == 0 {
+		return
+	}
+	pool.changeLock.Lock()
+	pool.changeQueue = append(pool.changeQueue, pool.changes)
+	pool.changeLock.Unlock()
+
+	pool.changes = nil
+	select {
+	case pool.changeWake <- struct{}{}:
+	default:
+	}
+}
+
+// changeLoop sends the flushed transaction changes to the subscribers batch by
+// batch, so they observe them in the order they happened without the pool ever
+// blocking on a slow subscriber.
+func (pool *TxPool) changeLoop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case <-pool.changeWake:
+		case <-pool.changeQuit:
+			return
+		}
+		for {
+			pool.changeLock.Lock()
+			if len(pool.changeQueue) == 0 {
+				pool.changeLock.Unlock()
+				break
+			}
+			batch := pool.changeQueue[0]
+			pool.changeQueue = pool.changeQueue[1:]
+			pool.changeLock.Unlock()
+
+			pool.changeFeed.Send(TxChangeEvent{batch})
+		}
+	}
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -467,8 +628,12 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 
 	pool.gasPrice = price
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
+		from, _ := types.Sender(pool.signer, tx) // already validated
 		pool.removeTx(tx.Hash(), false)
+		pool.dropped(from, tx, TxDropUnderpriced)
 	}
+	pool.flushChanges()
+
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
@@ -520,6 +685,81 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the data content of the transaction pool for a single
+// account, returning its pending as well as queued transactions sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var pending, queued types.Transactions
+	if list := pool.pending[addr]; list != nil {
+		pending = list.Flatten()
+	}
+	if list := pool.queue[addr]; list != nil {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
+// SenderStats retrieves the number of pending and queued transactions of every
+// account with transactions in the pool.
+func (pool *TxPool) SenderStats() (map[common.Address]int, map[common.Address]int) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending := make(map[common.Address]int)
+	for addr, list := range pool.pending {
+		pending[addr] = list.Len()
+	}
+	queued := make(map[common.Address]int)
+	for addr, list := range pool.queue {
+		queued[addr] = list.Len()
+	}
+	return pending, queued
+}
+
+// RemoveTransaction evicts a single transaction from the pool. Any subsequent
+// pending transactions of the same account are moved back to the future queue.
+func (pool *TxPool) RemoveTransaction(hash common.Hash) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx := pool.all.Get(hash)
+	if tx == nil {
+		return fmt.Errorf("unknown transaction: %x", hash)
+	}
+	from, _ := types.Sender(pool.signer, tx) // already validated
+
+	pool.removeTx(hash, true)
+	pool.dropped(from, tx, TxDropRemoved)
+	delete(pool.private, hash)
+
+	pool.flushChanges()
+	log.Info("Removed pooled transaction", "hash", hash, "from", from, "nonce", tx.Nonce())
+	return nil
+}
+
+// FlushQueue evicts all non-executable transactions of an account from the pool,
+// returning the number of transactions removed.
+func (pool *TxPool) FlushQueue(addr common.Address) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	list := pool.queue[addr]
+	if list == nil {
+		return 0
+	}
+	txs := list.Flatten()
+	for _, tx := range txs {
+		pool.removeTx(tx.Hash(), true)
+		pool.dropped(addr, tx, TxDropRemoved)
+		delete(pool.private, tx.Hash())
+	}
+	pool.flushChanges()
+	log.Info("Flushed queued transactions", "from", addr, "count", len(txs))
+	return len(txs)
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -541,15 +781,44 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	txs := make(map[common.Address]types.Transactions)
 	for addr := range pool.locals.accounts {
 		if pending := pool.pending[addr]; pending != nil {
-			txs[addr] = append(txs[addr], pending.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(pending.Flatten())...)
 		}
 		if queued := pool.queue[addr]; queued != nil {
-			txs[addr] = append(txs[addr], queued.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(queued.Flatten())...)
 		}
 	}
 	return txs
 }
 
+// remote retrieves all currently known remote transactions eligible for being
+// journaled, executable ones first, each account's transactions sorted by nonce.
+// Private transactions are never journaled.
+func (pool *TxPool) remote() types.Transactions {
+	var txs types.Transactions
+	for _, lists := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for addr, list := range lists {
+			if !pool.locals.contains(addr) {
+				txs = append(txs, pool.public(list.Flatten())...)
+			}
+		}
+	}
+	return txs
+}
+
+// public filters out the private transactions from a list.
+func (pool *TxPool) public(txs types.Transactions) types.Transactions {
+	if len(pool.private) == 0 {
+		return txs
+	}
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if _, ok := pool.private[tx.Hash()]; !ok {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -619,17 +888,21 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
-		if !local && pool.priced.Underpriced(tx, pool.locals) {
+		exempt := pool.exempt()
+		if !local && pool.priced.Underpriced(tx, exempt) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), exempt)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
+
+			from, _ := types.Sender(pool.signer, tx) // already validated
 			pool.removeTx(tx.Hash(), false)
+			pool.dropped(from, tx, TxDropUnderpriced)
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
@@ -645,6 +918,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed()
+			pool.replaced(from, old, tx)
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all.Add(tx)
@@ -692,6 +966,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.replaced(from, old, tx)
 		queuedReplaceCounter.Inc(1)
 	}
 	if pool.all.Get(hash) == nil {
@@ -708,6 +983,10 @@ func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
+	// Private transactions must not leak, not even into the journal
+	if _, ok := pool.private[tx.Hash()]; ok {
+		return
+	}
 	if err := pool.journal.insert(tx); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
@@ -729,6 +1008,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
 		pool.priced.Removed()
+		pool.dropped(addr, tx, TxDropUnderpriced)
 
 		pendingDiscardCounter.Inc(1)
 		return false
@@ -737,6 +1017,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.replaced(addr, old, tx)
 
 		pendingReplaceCounter.Inc(1)
 	}
@@ -780,6 +1061,102 @@ func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
 	return pool.addTxs(txs, false)
 }
 
+// AddPrivate enqueues a single transaction into the pool if it is valid, marking
+// it as private: it is only made available to the local miner and never relayed
+// to the network. Full pricing constraints apply, and the transaction is dropped
+// if it is not mined within the configured number of blocks.
+func (pool *TxPool) AddPrivate(tx *types.Transaction) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	hash := tx.Hash()
+	if _, ok := pool.private[hash]; ok || pool.all.Get(hash) != nil {
+		return fmt.Errorf("known transaction: %x", hash)
+	}
+	pool.private[hash] = pool.chain.CurrentBlock().NumberU64() + pool.config.PrivateLifetime
+
+	replace, err := pool.add(tx, false)
+	defer pool.flushChanges()
+
+	if err != nil {
+		delete(pool.private, hash)
+		return err
+	}
+	if !replace {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		pool.promoteExecutables([]common.Address{from})
+	}
+	return nil
+}
+
+// IsPrivate returns whether a transaction was submitted privately, and as such
+// must not be propagated to the network.
+func (pool *TxPool) IsPrivate(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.private[hash]
+	return ok
+}
+
+// SetPrioritySenders replaces the set of senders whose transactions are mined
+// first and are exempt from the global slot limits.
+func (pool *TxPool) SetPrioritySenders(addrs []common.Address) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.priority = newAccountSet(pool.signer, addrs...)
+}
+
+// PrioritySenders retrieves the senders whose transactions are mined first and
+// are exempt from the global slot limits.
+func (pool *TxPool) PrioritySenders() []common.Address {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.priority.flatten()
+}
+
+// SetNoGossipSenders replaces the set of senders whose transactions are never
+// broadcast to the network.
+func (pool *TxPool) SetNoGossipSenders(addrs []common.Address) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.noGossip = newAccountSet(pool.signer, addrs...)
+}
+
+// NoGossipSenders retrieves the senders whose transactions are never broadcast
+// to the network.
+func (pool *TxPool) NoGossipSenders() []common.Address {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.noGossip.flatten()
+}
+
+// IsNoGossip returns whether a transaction was sent by an account whose
+// transactions must never be broadcast to the network.
+func (pool *TxPool) IsNoGossip(tx *types.Transaction) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.noGossip.containsTx(tx)
+}
+
+// exempt returns the set of accounts exempt from the global pool limits, being
+// the local and the priority senders.
+func (pool *TxPool) exempt() *accountSet {
+	if len(pool.priority.accounts) == 0 {
+		return pool.locals
+	}
+	set := newAccountSet(pool.signer, pool.locals.flatten()...)
+	for addr := range pool.priority.accounts {
+		set.add(addr)
+	}
+	return set
+}
+
 // addTx enqueues a single transaction into the pool if it is valid.
 func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 	pool.mu.Lock()
@@ -787,6 +1164,8 @@ func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 
 	// Try to inject the transaction and update any state
 	replace, err := pool.add(tx, local)
+	defer pool.flushChanges()
+
 	if err != nil {
 		return err
 	}
@@ -813,6 +1192,8 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) []error {
 	dirty := make(map[common.Address]struct{})
 	errs := make([]error, len(txs))
 
+	defer pool.flushChanges()
+
 	for i, tx := range txs {
 		var replace bool
 		if replace, errs[i] = pool.add(tx, local); errs[i] == nil && !replace {
@@ -926,6 +1307,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed old queued transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.dropped(addr, tx, TxDropNonceTooLow)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -934,6 +1316,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.dropped(addr, tx, TxDropUnpayable)
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -942,6 +1325,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if pool.promoteTx(addr, hash, tx) {
 				log.Trace("Promoting queued transaction", "hash", hash)
 				promoted = append(promoted, tx)
+				pool.changes = append(pool.changes, TxChange{Tx: tx, From: addr, Kind: TxPromoted})
 			}
 		}
 		// Drop all transactions over the allowed limit
@@ -950,6 +1334,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
 				pool.priced.Removed()
+				pool.dropped(addr, tx, TxDropCapacity)
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -974,7 +1359,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
+			if !pool.locals.contains(addr) && !pool.priority.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
 				spammers.Push(addr, float32(list.Len()))
 			}
 		}
@@ -999,6 +1384,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							hash := tx.Hash()
 							pool.all.Remove(hash)
 							pool.priced.Removed()
+							pool.dropped(offenders[i], tx, TxDropCapacity)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
@@ -1021,6 +1407,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						hash := tx.Hash()
 						pool.all.Remove(hash)
 						pool.priced.Removed()
+						pool.dropped(addr, tx, TxDropCapacity)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
@@ -1043,7 +1430,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		// Sort all accounts with queued transactions by heartbeat
 		addresses := make(addressesByHeartbeat, 0, len(pool.queue))
 		for addr := range pool.queue {
-			if !pool.locals.contains(addr) { // don't drop locals
+			if !pool.locals.contains(addr) && !pool.priority.contains(addr) { // don't drop locals and priority senders
 				addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 			}
 		}
@@ -1060,6 +1447,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash(), true)
+					pool.dropped(addr.address, tx, TxDropCapacity)
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -1069,6 +1457,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash(), true)
+				pool.dropped(addr.address, txs[i], TxDropCapacity)
 				drop--
 				queuedRateLimitCounter.Inc(1)
 			}
@@ -1078,8 +1467,13 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 
 // demoteUnexecutables removes invalid and processed transactions from the pools
 // executable/pending queue and any subsequent transactions that become unexecutable
-// are moved back into the future queue.
-func (pool *TxPool) demoteUnexecutables() {
+// are moved back into the future queue. The given transactions are known to have
+// been mined, their removal is not reported as a drop.
+func (pool *TxPool) demoteUnexecutables(mined types.Transactions) {
+	included := make(map[common.Hash]struct{}, len(mined))
+	for _, tx := range mined {
+		included[tx.Hash()] = struct{}{}
+	}
 	// Iterate over all accounts and demote any non-executable transactions
 	for addr, list := range pool.pending {
 		nonce := pool.currentState.GetNonce(addr)
@@ -1090,6 +1484,9 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed old pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			if _, ok := included[hash]; !ok {
+				pool.dropped(addr, tx, TxDropNonceTooLow)
+			}
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1098,6 +1495,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.dropped(addr, tx, TxDropUnpayable)
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
@@ -1141,12 +1539,16 @@ type accountSet struct {
 }
 
 // newAccountSet creates a new address set with an associated signer for sender
-// derivations.
-func newAccountSet(signer types.Signer) *accountSet {
-	return &accountSet{
+// derivations, optionally prefilled with a list of addresses.
+func newAccountSet(signer types.Signer, addrs ...common.Address) *accountSet {
+	as := &accountSet{
 		accounts: make(map[common.Address]struct{}),
 		signer:   signer,
 	}
+	for _, addr := range addrs {
+		as.add(addr)
+	}
+	return as
 }
 
 // contains checks if a given address is contained within the set.
@@ -1169,6 +1571,15 @@ func (as *accountSet) add(addr common.Address) {
 	as.accounts[addr] = struct{}{}
 }
 
+// flatten returns the list of addresses within this set.
+func (as *accountSet) flatten() []common.Address {
+	accounts := make([]common.Address, 0, len(as.accounts))
+	for addr := range as.accounts {
+		accounts = append(accounts, addr)
+	}
+	return accounts
+}
+
 // txLookup is used internally by TxPool to track transactions while allowing lookup without
 // mutex contention.
 //
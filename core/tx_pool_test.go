@@ -33,6 +33,7 @@ import (
 	"github.com/DEWH/go-DEWH/ethdb"
 	"github.com/DEWH/go-DEWH/event"
 	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rlp"
 )
 
 // testTxPoolConfig is a transaction pool configuration without stateful disk
@@ -1680,6 +1681,377 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that remote transactions are journaled to disk if requested, and that on
+// startup they are revalidated and filtered by age.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the remote journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject transaction into the journal
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.RemoteJournal = journal
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Add a few remote transactions along with a local and a private one
+	pool.AddRemotes([]*types.Transaction{
+		transaction(0, 100000, keys[0]),
+		transaction(1, 100000, keys[0]),
+		transaction(3, 100000, keys[1]),
+	})
+	if err := pool.AddLocal(transaction(0, 100000, keys[2])); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddPrivate(transaction(0, 100000, keys[3])); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 1 {
+		t.Fatalf("transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 4, 1)
+	}
+	// Terminate the old pool, bump a nonce, and ensure only the still valid
+	// remote transactions survive the restart
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(keys[0].PublicKey), 1)
+
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	pool.Stop()
+
+	// Ensure the journal is capped and stale transactions are not reloaded
+	stale := &remoteTx{Time: uint64(time.Now().Add(-2 * config.RemoteJournalAge).Unix()), Tx: transaction(1, 100000, keys[0])}
+	fresh := &remoteTx{Time: uint64(time.Now().Unix()), Tx: transaction(0, 100000, keys[2])}
+
+	output, err := os.Create(journal)
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+	rlp.Encode(output, stale)
+	rlp.Encode(output, fresh)
+	output.Close()
+
+	config.RemoteJournalLimit = 1
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 1, 0)
+	}
+	if pool.Get(fresh.Tx.Hash()) == nil {
+		t.Fatalf("fresh transaction not reloaded")
+	}
+	pool.AddRemote(transaction(1, 100000, keys[2]))
+	pool.Stop()
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("capped transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 1, 0)
+	}
+	pool.Stop()
+}
+
+// Tests that promotions, replacements and drops are reported to change subscribers
+// along with the reason of the removal.
+func TestTransactionChangeEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	changes := make(chan TxChangeEvent, 32)
+	sub := pool.SubscribeTxChangeEvent(changes)
+	defer sub.Unsubscribe()
+
+	expect := func(tx *types.Transaction, kind TxChangeKind, reason TxDropReason, by *types.Transaction) {
+		t.Helper()
+
+		select {
+		case ev := <-changes:
+			if len(ev.Changes) != 1 {
+				t.Fatalf("change count mismatch: have %d, want 1", len(ev.Changes))
+			}
+			change := ev.Changes[0]
+			if change.Tx != tx || change.From != from || change.Kind != kind || change.Reason != reason || change.By != by {
+				t.Fatalf("change mismatch: have %x %v %q, want %x %v %q", change.Tx.Hash(), change.Kind, change.Reason, tx.Hash(), kind, reason)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v event not fired", kind)
+		}
+	}
+	// Add a transaction, replace it and ensure both events are reported
+	tx0 := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.AddRemote(tx0); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	expect(tx0, TxPromoted, TxDropNone, nil)
+
+	tx1 := pricedTransaction(0, 100000, big.NewInt(2), key)
+	if err := pool.AddRemote(tx1); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	expect(tx0, TxReplaced, TxDropReplaced, tx1)
+
+	// Raise the price limit and ensure the drop is reported
+	pool.SetGasPrice(big.NewInt(3))
+	expect(tx1, TxDropped, TxDropUnderpriced, nil)
+
+	// Queue a transaction, invalidate it and ensure the drop is reported
+	tx2 := pricedTransaction(2, 100000, big.NewInt(3), key)
+	if err := pool.AddRemote(tx2); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	pool.currentState.SetNonce(from, 3)
+	pool.lockedReset(nil, nil)
+	expect(tx2, TxDropped, TxDropNonceTooLow, nil)
+
+	select {
+	case ev := <-changes:
+		t.Fatalf("unexpected change: %v", ev.Changes[0].Kind)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// Tests that change events are delivered in the order the changes happened, even
+// if the subscriber only starts reading after a burst of them.
+func TestTransactionChangeEventOrdering(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, new(big.Int).Lsh(big.NewInt(1), 64))
+
+	changes := make(chan TxChangeEvent)
+	sub := pool.SubscribeTxChangeEvent(changes)
+	defer sub.Unsubscribe()
+
+	// Replace a transaction a number of times without consuming the events
+	var txs []*types.Transaction
+	for i := 0; i < 16; i++ {
+		tx := pricedTransaction(0, 100000, big.NewInt(int64(100<<uint(i))), key)
+		if err := pool.AddRemote(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	for i := range txs {
+		kind, tx := TxPromoted, txs[0]
+		if i > 0 {
+			kind, tx = TxReplaced, txs[i-1]
+		}
+		select {
+		case ev := <-changes:
+			if len(ev.Changes) != 1 {
+				t.Fatalf("change %d: count mismatch: have %d, want 1", i, len(ev.Changes))
+			}
+			if change := ev.Changes[0]; change.Kind != kind || change.Tx != tx {
+				t.Fatalf("change %d out of order: have %v %x, want %v %x", i, change.Kind, change.Tx.Hash(), kind, tx.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("change %d not fired", i)
+		}
+	}
+}
+
+// Tests that the transactions of a single account can be inspected and that they
+// can be evicted manually.
+func TestTransactionManualEviction(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	// Add three executable and two gapped transactions
+	var txs types.Transactions
+	for _, nonce := range []uint64{0, 1, 2, 4, 5} {
+		tx := transaction(nonce, 100000, key)
+		if err := pool.AddRemote(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+		txs = append(txs, tx)
+	}
+	pending, queued := pool.ContentFrom(from)
+	if len(pending) != 3 || len(queued) != 2 {
+		t.Fatalf("content mismatch: have %d/%d, want %d/%d", len(pending), len(queued), 3, 2)
+	}
+	if pending, queued := pool.ContentFrom(common.Address{}); len(pending) != 0 || len(queued) != 0 {
+		t.Fatalf("content of unknown account not empty: %d/%d", len(pending), len(queued))
+	}
+	// Remove a pending transaction, subsequent ones must be demoted
+	if err := pool.RemoveTransaction(txs[1].Hash()); err != nil {
+		t.Fatalf("failed to remove transaction: %v", err)
+	}
+	if err := pool.RemoveTransaction(txs[1].Hash()); err == nil {
+		t.Fatalf("removed unknown transaction")
+	}
+	pendings, queues := pool.SenderStats()
+	if pendings[from] != 1 || queues[from] != 3 {
+		t.Fatalf("sender stats mismatch: have %d/%d, want %d/%d", pendings[from], queues[from], 1, 3)
+	}
+	// Flush the queue and ensure only the executable transaction remains
+	if n := pool.FlushQueue(from); n != 3 {
+		t.Fatalf("flushed transaction count mismatch: have %d, want %d", n, 3)
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("transaction count mismatch: have %d/%d, want %d/%d", pending, queued, 1, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that priority senders are exempt from the global slot limits and that
+// the special sender lists can be changed at runtime.
+func TestTransactionSenderLists(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	priority, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	config := testTxPoolConfig
+	config.GlobalSlots = config.AccountSlots
+	config.PrioritySenders = []common.Address{crypto.PubkeyToAddress(priority.PublicKey)}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// Overflow the global limit with both accounts
+	var txs types.Transactions
+	for _, key := range []*ecdsa.PrivateKey{priority, remote} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+		for nonce := uint64(0); nonce < 2*config.AccountSlots; nonce++ {
+			txs = append(txs, transaction(nonce, 100000, key))
+		}
+	}
+	pool.AddRemotes(txs)
+
+	pendings, _ := pool.SenderStats()
+	if have, want := pendings[crypto.PubkeyToAddress(priority.PublicKey)], int(2*config.AccountSlots); have != want {
+		t.Errorf("priority sender pending mismatch: have %d, want %d", have, want)
+	}
+	if have, want := pendings[crypto.PubkeyToAddress(remote.PublicKey)], int(config.AccountSlots); have != want {
+		t.Errorf("remote sender pending mismatch: have %d, want %d", have, want)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Update the sender lists and check the changes are picked up
+	if pool.IsNoGossip(txs[0]) {
+		t.Errorf("transaction of gossiped sender reported as no-gossip")
+	}
+	pool.SetNoGossipSenders([]common.Address{crypto.PubkeyToAddress(priority.PublicKey)})
+	if !pool.IsNoGossip(txs[0]) {
+		t.Errorf("transaction of no-gossip sender not reported")
+	}
+	pool.SetPrioritySenders(nil)
+	if senders := pool.PrioritySenders(); len(senders) != 0 {
+		t.Errorf("priority senders not cleared: %v", senders)
+	}
+}
+
+// Tests that private transactions are executable locally but are never written to
+// the journal, and that they are dropped if not mined in time.
+func TestTransactionPrivateLifetime(t *testing.T) {
+	t.Parallel()
+
+	// Create a journaling pool dropping private transactions after two blocks
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	file.Close()
+	os.Remove(journal)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+	config.PrivateLifetime = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// Add a public local and a private transaction from the same account
+	public, private := transaction(0, 100000, key), transaction(1, 100000, key)
+	if err := pool.AddLocal(public); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddPrivate(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if err := pool.AddPrivate(private); err == nil {
+		t.Fatalf("duplicate private transaction accepted")
+	}
+	if pool.IsPrivate(public.Hash()) || !pool.IsPrivate(private.Hash()) {
+		t.Fatalf("privacy mismatch: public %v, private %v", pool.IsPrivate(public.Hash()), pool.IsPrivate(private.Hash()))
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	pool.mu.RLock()
+	locals := pool.local()
+	pool.mu.RUnlock()
+
+	if txs := locals[crypto.PubkeyToAddress(key.PublicKey)]; len(txs) != 1 || txs[0] != public {
+		t.Fatalf("local transactions mismatch: have %d, want public only", len(txs))
+	}
+	// Ensure the private transaction survives until its lifetime is up
+	pool.lockedReset(nil, &types.Header{Number: big.NewInt(1), GasLimit: 1000000})
+	if pool.Get(private.Hash()) == nil {
+		t.Fatalf("private transaction dropped before expiry")
+	}
+	pool.lockedReset(nil, &types.Header{Number: big.NewInt(2), GasLimit: 1000000})
+	if pool.Get(private.Hash()) != nil || pool.IsPrivate(private.Hash()) {
+		t.Fatalf("private transaction not dropped after expiry")
+	}
+	if pool.Get(public.Hash()) == nil {
+		t.Fatalf("public transaction dropped")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
@@ -1757,7 +2129,7 @@ func benchmarkPendingDemotion(b *testing.B, size int) {
 	// Benchmark the speed of pool validation
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		pool.demoteUnexecutables()
+		pool.demoteUnexecutables(nil)
 	}
 }
 
@@ -97,8 +97,13 @@ type headerMarshaling struct {
 }
 
 // Hash returns the block hash of the header, which is simply the keccak256 hash of its
-// RLP encoding.
+// RLP encoding. The committed seals of Istanbul headers are not part of it.
 func (h *Header) Hash() common.Hash {
+	if h.MixDigest == IstanbulDigest {
+		if filtered := istanbulFilteredHeader(h); filtered != nil {
+			return rlpHash(filtered)
+		}
+	}
 	return rlpHash(h)
 }
 
@@ -0,0 +1,63 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+// IstanbulDigest is the mix digest of every Istanbul block, identifying the
+// headers as produced by the Istanbul BFT consensus engine.
+var IstanbulDigest = common.HexToHash("0x63746963616c2062797a616e74696e65206661756c7420746f6c6572616e6365")
+
+// istanbulExtraVanity is the number of extra-data prefix bytes of an Istanbul
+// header reserved for validator vanity.
+const istanbulExtraVanity = 32
+
+// istanbulExtra mirrors the consensus data of an Istanbul header's extra-data.
+type istanbulExtra struct {
+	Validators    []common.Address
+	Seal          []byte
+	CommittedSeal [][]byte
+}
+
+// istanbulFilteredHeader returns a copy of an Istanbul header without the
+// committed seals, or nil if the header doesn't carry any. The committed seals
+// are whichever quorum of validators a node collected, so they are kept out of
+// the block hash to have all nodes agree on it.
+func istanbulFilteredHeader(h *Header) *Header {
+	if len(h.Extra) < istanbulExtraVanity {
+		return nil
+	}
+	extra := new(istanbulExtra)
+	if err := rlp.DEWHodeBytes(h.Extra[istanbulExtraVanity:], extra); err != nil {
+		return nil
+	}
+	if len(extra.CommittedSeal) == 0 {
+		return nil
+	}
+	extra.CommittedSeal = [][]byte{}
+
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return nil
+	}
+	cpy := *h
+	cpy.Extra = append(h.Extra[:istanbulExtraVanity:istanbulExtraVanity], payload...)
+	return &cpy
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/common/hexutil"
@@ -37,6 +38,7 @@ var (
 
 type Transaction struct {
 	data txdata
+	time time.Time // time first seen locally, used for arrival ordering
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -101,7 +103,7 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{data: d, time: time.Now()}
 }
 
 // ChainId returns which chain id this transaction was signed for (if at all)
@@ -134,6 +136,7 @@ func (tx *Transaction) DEWHodeRLP(s *rlp.Stream) error {
 	err := s.DEWHode(&tx.data)
 	if err == nil {
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		tx.time = time.Now()
 	}
 
 	return err
@@ -163,7 +166,7 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if !crypto.ValidateSignatureValues(V, DEWH.R, DEWH.S, false) {
 		return ErrInvalidSig
 	}
-	*tx = Transaction{data: DEWH}
+	*tx = Transaction{data: DEWH, time: time.Now()}
 	return nil
 }
 
@@ -174,6 +177,10 @@ func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amo
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+// Time returns the time when the transaction was first created or received by
+// the local node.
+func (tx *Transaction) Time() time.Time { return tx.time }
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -235,7 +242,7 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, time: tx.time}
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	return cpy, nil
 }
@@ -25,9 +25,11 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/consensus/ethash"
 	"github.com/DEWH/go-DEWH/core"
 	"github.com/DEWH/go-DEWH/core/rawdb"
 	"github.com/DEWH/go-DEWH/core/state"
@@ -70,16 +72,12 @@ func (api *PublicDEWHAPI) Hashrate() hexutil.Uint64 {
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
-	e     *DEWH
-	agent *miner.RemoteAgent
+	e *DEWH
 }
 
 // NewPublicMinerAPI create a new PublicMinerAPI instance.
 func NewPublicMinerAPI(e *DEWH) *PublicMinerAPI {
-	agent := miner.NewRemoteAgent(e.BlockChain(), e.Engine())
-	e.Miner().Register(agent)
-
-	return &PublicMinerAPI{e, agent}
+	return &PublicMinerAPI{e}
 }
 
 // Mining returns an indication if this node is currently mining.
@@ -90,7 +88,11 @@ func (api *PublicMinerAPI) Mining() bool {
 // SubmitWork can be used by external miner to submit their POW solution. It returns an indication if the work was
 // accepted. Note, this is not an indication if the provided work was valid!
 func (api *PublicMinerAPI) SubmitWork(nonce types.BlockNonce, solution, digest common.Hash) bool {
-	return api.agent.SubmitWork(nonce, digest, solution)
+	engine, ok := api.e.Engine().(*ethash.Ethash)
+	if !ok {
+		return false
+	}
+	return engine.SubmitWork(nonce, solution, digest) == nil
 }
 
 // GetWork returns a work package for external miner. The work package consists of 3 strings
@@ -98,12 +100,16 @@ func (api *PublicMinerAPI) SubmitWork(nonce types.BlockNonce, solution, digest c
 // result[1], 32 bytes hex encoded seed hash used for DAG
 // result[2], 32 bytes hex encoded boundary condition ("target"), 2^256/difficulty
 func (api *PublicMinerAPI) GetWork() ([3]string, error) {
+	engine, ok := api.e.Engine().(*ethash.Ethash)
+	if !ok {
+		return [3]string{}, errors.New("remote mining requires ethash")
+	}
 	if !api.e.IsMining() {
 		if err := api.e.StartMining(false); err != nil {
 			return [3]string{}, err
 		}
 	}
-	work, err := api.agent.GetWork()
+	work, err := engine.GetWork()
 	if err != nil {
 		return work, fmt.Errorf("mining not ready: %v", err)
 	}
@@ -114,8 +120,11 @@ func (api *PublicMinerAPI) GetWork() ([3]string, error) {
 // hash rate of all miners which submit work through this node. It accepts the miner hash rate and an identifier which
 // must be unique between nodes.
 func (api *PublicMinerAPI) SubmitHashrate(hashrate hexutil.Uint64, id common.Hash) bool {
-	api.agent.SubmitHashrate(id, uint64(hashrate))
-	return true
+	engine, ok := api.e.Engine().(*ethash.Ethash)
+	if !ok {
+		return false
+	}
+	return engine.SubmitHashRate(uint64(hashrate), id) == nil
 }
 
 // PrivateMinerAPI provides private RPC methods to control the miner.
@@ -196,11 +205,47 @@ func (api *PrivateMinerAPI) SetEtherbase(etherbase common.Address) bool {
 	return true
 }
 
+// SetGasLimit sets the range the gas limit of mined blocks is moved towards: it
+// is raised while below floor and lowered while above ceil, zero meaning no ceiling.
+func (api *PrivateMinerAPI) SetGasLimit(floor hexutil.Uint64, ceil hexutil.Uint64) (bool, error) {
+	if err := api.e.Miner().SetGasLimit(uint64(floor), uint64(ceil)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetRecommitInterval sets the interval in milliseconds at which the block being
+// sealed is rebuilt with newly arrived transactions, zero disables rebuilding.
+func (api *PrivateMinerAPI) SetRecommitInterval(interval int) {
+	api.e.Miner().SetRecommitInterval(time.Duration(interval) * time.Millisecond)
+}
+
 // GetHashrate returns the current hashrate of the miner.
 func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return uint64(api.e.miner.HashRate())
 }
 
+// SendBundle submits a list of RLP encoded signed transactions to be included
+// into the given block atomically: in order, at the top of the block and only if
+// none of them fails. The returned hash identifies the bundle for status queries.
+func (api *PrivateMinerAPI) SendBundle(encodedTxs []hexutil.Bytes, blockNumber hexutil.Uint64) (common.Hash, error) {
+	txs := make(types.Transactions, len(encodedTxs))
+	for i, encodedTx := range encodedTxs {
+		tx := new(types.Transaction)
+		if err := rlp.DEWHodeBytes(encodedTx, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		txs[i] = tx
+	}
+	return api.e.Miner().SendBundle(txs, uint64(blockNumber))
+}
+
+// GetBundleStatus returns whether a submitted bundle is still pending, was
+// included, or was dropped from its target block.
+func (api *PrivateMinerAPI) GetBundleStatus(hash common.Hash) (miner.BundleStatus, error) {
+	return api.e.Miner().BundleStatus(hash)
+}
+
 // PrivateAdminAPI is the collection of DEWH full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -294,6 +339,48 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// RemoveTransaction evicts a transaction from the local transaction pool. Any
+// later transactions of the same account are moved back to the future queue.
+func (api *PrivateAdminAPI) RemoveTransaction(hash common.Hash) (bool, error) {
+	if err := api.eth.TxPool().RemoveTransaction(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FlushQueue evicts all non-executable transactions of an account from the local
+// transaction pool, returning the number of transactions removed.
+func (api *PrivateAdminAPI) FlushQueue(addr common.Address) hexutil.Uint {
+	return hexutil.Uint(api.eth.TxPool().FlushQueue(addr))
+}
+
+// TxPoolSenderLists is the set of senders treated specially by the transaction pool.
+type TxPoolSenderLists struct {
+	Priority []common.Address `json:"priority"` // senders mined first and exempt from the global slot limits
+	NoGossip []common.Address `json:"noGossip"` // senders whose transactions are never broadcast
+}
+
+// TxPoolSenderLists retrieves the senders treated specially by the transaction pool.
+func (api *PrivateAdminAPI) TxPoolSenderLists() TxPoolSenderLists {
+	return TxPoolSenderLists{
+		Priority: api.eth.TxPool().PrioritySenders(),
+		NoGossip: api.eth.TxPool().NoGossipSenders(),
+	}
+}
+
+// SetTxPoolPriority replaces the senders whose transactions are mined first and
+// are exempt from the global slot limits of the transaction pool.
+func (api *PrivateAdminAPI) SetTxPoolPriority(senders []common.Address) bool {
+	api.eth.TxPool().SetPrioritySenders(senders)
+	return true
+}
+
+// SetTxPoolNoGossip replaces the senders whose transactions are never broadcast.
+func (api *PrivateAdminAPI) SetTxPoolNoGossip(senders []common.Address) bool {
+	api.eth.TxPool().SetNoGossipSenders(senders)
+	return true
+}
+
 // PublicDebugAPI is the collection of DEWH full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
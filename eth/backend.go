@@ -30,7 +30,9 @@ import (
 	"github.com/DEWH/go-DEWH/common/hexutil"
 	"github.com/DEWH/go-DEWH/consensus"
 	"github.com/DEWH/go-DEWH/consensus/clique"
+	"github.com/DEWH/go-DEWH/consensus/dpos"
 	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/consensus/istanbul"
 	"github.com/DEWH/go-DEWH/core"
 	"github.com/DEWH/go-DEWH/core/bloombits"
 	"github.com/DEWH/go-DEWH/core/rawdb"
@@ -56,6 +58,7 @@ type LesServer interface {
 	Stop()
 	Protocols() []p2p.Protocol
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
+	APIs() []rpc.API
 }
 
 // DEWH implements the DEWH full node service.
@@ -124,7 +127,7 @@ func New(ctx *node.ServiceContext, config *Config) (*DEWH, error) {
 		chainConfig:    chainConfig,
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
-		engine:         CreateConsensusEngine(ctx, &config.Ethash, chainConfig, chainDb),
+		engine:         CreateConsensusEngine(ctx, &config.Ethash, config.MinerNotify, chainConfig, chainDb),
 		shutdownChan:   make(chan bool),
 		networkID:      config.NetworkId,
 		gasPrice:       config.GasPrice,
@@ -133,6 +136,9 @@ func New(ctx *node.ServiceContext, config *Config) (*DEWH, error) {
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 	}
 
+	if clique, ok := eth.engine.(*clique.Clique); ok {
+		clique.SetAutoDrop(config.CliqueAutoDrop)
+	}
 	log.Info("Initialising DEWH protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
 	if !config.SkipBcVersionCheck {
@@ -161,6 +167,9 @@ func New(ctx *node.ServiceContext, config *Config) (*DEWH, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.chainConfig, eth.blockchain)
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
@@ -169,6 +178,20 @@ func New(ctx *node.ServiceContext, config *Config) (*DEWH, error) {
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
 
+	ordering, err := miner.NewOrderingPolicy(config.MinerTxOrdering)
+	if err != nil {
+		return nil, err
+	}
+	eth.miner.SetTxOrdering(ordering)
+	eth.miner.SetRecommitInterval(config.MinerRecommit)
+	gasFloor := config.MinerGasFloor
+	if gasFloor == 0 {
+		gasFloor = params.TargetGasLimit
+	}
+	if err := eth.miner.SetGasLimit(gasFloor, config.MinerGasCeil); err != nil {
+		return nil, err
+	}
+
 	eth.APIBackend = &EthAPIBackend{eth, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -209,11 +232,19 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Data
 }
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an DEWH service
-func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chainConfig *params.ChainConfig, db ethdb.Database) consensus.Engine {
+func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, notify []string, chainConfig *params.ChainConfig, db ethdb.Database) consensus.Engine {
 	// If proof-of-authority is requested, set it up
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
+	// If byzantine fault tolerance is requested, set it up
+	if chainConfig.Istanbul != nil {
+		return istanbul.New(chainConfig.Istanbul, db)
+	}
+	// If delegated proof-of-stake is requested, set it up
+	if chainConfig.Dpos != nil {
+		return dpos.New(chainConfig.Dpos, db)
+	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
 	case ethash.ModeFake:
@@ -233,7 +264,7 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chai
 			DatasetDir:     config.DatasetDir,
 			DatasetsInMem:  config.DatasetsInMem,
 			DatasetsOnDisk: config.DatasetsOnDisk,
-		})
+		}, notify)
 		engine.SetThreads(-1) // Disable CPU mining
 		return engine
 	}
@@ -247,6 +278,11 @@ func (s *DEWH) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append any APIs exposed explicitly by the les server
+	if s.lesServer != nil {
+		apis = append(apis, s.lesServer.APIs()...)
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -346,6 +382,22 @@ func (s *DEWH) StartMining(local bool) error {
 		}
 		clique.Authorize(eb, wallet.SignHash)
 	}
+	if istanbul, ok := s.engine.(*istanbul.Istanbul); ok {
+		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+		if wallet == nil || err != nil {
+			log.Error("Etherbase account unavailable locally", "err", err)
+			return fmt.Errorf("validator missing: %v", err)
+		}
+		istanbul.Authorize(eb, wallet.SignHash)
+	}
+	if dpos, ok := s.engine.(*dpos.Dpos); ok {
+		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+		if wallet == nil || err != nil {
+			log.Error("Etherbase account unavailable locally", "err", err)
+			return fmt.Errorf("producer missing: %v", err)
+		}
+		dpos.Authorize(eb, wallet.SignHash)
+	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
 		// mechanism introduced to speed sync times. CPU mining on mainnet is ludicrous
@@ -375,10 +427,14 @@ func (s *DEWH) Downloader() *downloader.Downloader { return s.protocolManager.do
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *DEWH) Protocols() []p2p.Protocol {
+	protos := s.protocolManager.SubProtocols
+	if istanbul, ok := s.engine.(*istanbul.Istanbul); ok {
+		protos = append(protos, istanbul.Protocols()...)
+	}
 	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+		return protos
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	return append(protos, s.lesServer.Protocols()...)
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
@@ -403,6 +459,12 @@ func (s *DEWH) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Start following the chain with the BFT state machine if configured
+	if istanbul, ok := s.engine.(*istanbul.Istanbul); ok {
+		if err := istanbul.Start(s.blockchain); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -410,6 +472,12 @@ func (s *DEWH) Start(srvr *p2p.Server) error {
 // DEWH protocol.
 func (s *DEWH) Stop() error {
 	s.bloomIndexer.Close()
+	if istanbul, ok := s.engine.(*istanbul.Istanbul); ok {
+		istanbul.Stop()
+	}
+	if ethash, ok := s.engine.(*ethash.Ethash); ok {
+		ethash.Close()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -35,7 +35,9 @@ import (
 
 // DefaultConfig contains default settings for use on the DEWH main net.
 var DefaultConfig = Config{
-	SyncMode: downloader.FastSync,
+	SyncMode:      downloader.FastSync,
+	MinerRecommit: 3 * time.Second,
+	MinerGasFloor: params.GenesisGasLimit,
 	Ethash: ethash.Config{
 		CacheDir:       "ethash",
 		CachesInMem:    2,
@@ -52,8 +54,10 @@ var DefaultConfig = Config{
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
-		Blocks:     20,
-		Percentile: 60,
+		Blocks:        20,
+		Percentile:    60,
+		Mode:          gasprice.ModeLowest,
+		ConfirmBlocks: 3,
 	},
 }
 
@@ -87,6 +91,9 @@ type Config struct {
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// Checkpoint oracle consulted by light clients for new trusted checkpoints
+	LightOracle common.Address `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
@@ -95,14 +102,22 @@ type Config struct {
 	TrieTimeout        time.Duration
 
 	// Mining-related options
-	Etherbase    common.Address `toml:",omitempty"`
-	MinerThreads int            `toml:",omitempty"`
-	ExtraData    []byte         `toml:",omitempty"`
-	GasPrice     *big.Int
+	Etherbase       common.Address `toml:",omitempty"`
+	MinerThreads    int            `toml:",omitempty"`
+	MinerTxOrdering string         `toml:",omitempty"` // Transaction ordering policy: price (default), fifo or fair
+	MinerRecommit   time.Duration  // Interval to rebuild the block being sealed at, 0 to disable
+	MinerGasFloor   uint64         // Gas limit to raise mined blocks towards
+	MinerGasCeil    uint64         // Gas limit to lower mined blocks towards, 0 for no ceiling
+	MinerNotify     []string       `toml:",omitempty"` // HTTP URLs to notify of new work packages
+	ExtraData       []byte         `toml:",omitempty"`
+	GasPrice        *big.Int
 
 	// Ethash options
 	Ethash ethash.Config
 
+	// Clique options
+	CliqueAutoDrop uint64 `toml:",omitempty"` // Missed in-turn blocks per epoch after which to propose dropping a signer
+
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
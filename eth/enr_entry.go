@@ -0,0 +1,62 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"io"
+
+	"github.com/DEWH/go-DEWH/core/forkid"
+	"github.com/DEWH/go-DEWH/rlp"
+)
+
+// ethEntry is the "eth" ENR entry which advertises the eth protocol in the node
+// record. Discovery (v4) doesn't exchange node records, so the entry is only
+// visible through admin_nodeInfo and remote peers are never filtered on it when
+// dialing; incompatible peers are rejected by the fork ID check of the eth/64
+// handshake instead.
+type ethEntry struct {
+	ForkID forkid.ID // Fork identifier per EIP-2124
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ethEntry) ENRKey() string {
+	return "eth"
+}
+
+// headENREntry is the "eth" entry of the local node record. It's encoded on
+// demand so the record always advertises the fork ID of the current head.
+type headENREntry func() ethEntry
+
+// ENRKey implements enr.Entry.
+func (f headENREntry) ENRKey() string {
+	return "eth"
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (f headENREntry) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, f())
+}
+
+// newENREntry creates the "eth" node record entry of the given chain.
+func newENREntry(chain forkid.Blockchain) headENREntry {
+	return func() ethEntry {
+		return ethEntry{ForkID: forkid.NewID(chain)}
+	}
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/DEWH/go-DEWH/core/types"
 	"github.com/DEWH/go-DEWH/ethdb"
 	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/internal/ethapi"
+	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/rpc"
 )
 
@@ -133,9 +135,21 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 	return pendingTxSub.ID
 }
 
+// PendingTxFilterCriteria are the options of a pending transaction subscription.
+type PendingTxFilterCriteria struct {
+	FullTx      bool             `json:"fullTx"`      // deliver full transaction objects instead of hashes
+	From        []common.Address `json:"from"`        // accepted senders, empty matches any sender
+	To          []common.Address `json:"to"`          // accepted recipients, empty matches any recipient
+	MinGasPrice *hexutil.Big     `json:"minGasPrice"` // lowest accepted gas price
+}
+
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
 // enters the transaction pool and was signed from one of the transactions this nodes manages.
-func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+//
+// The optional criteria restrict the notifications to transactions sent from or to
+// specific addresses or paying at least a minimum gas price. If fullTx is set, the
+// notifications carry the full transaction objects instead of only their hashes.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context, crit *PendingTxFilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -143,6 +157,38 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 
 	rpcSub := notifier.CreateSubscription()
 
+	if crit != nil {
+		query := PendingTxQuery{From: crit.From, To: crit.To}
+		if crit.MinGasPrice != nil {
+			query.MinGasPrice = crit.MinGasPrice.ToInt()
+		}
+		go func(fullTx bool) {
+			txs := make(chan []*types.Transaction, 128)
+			pendingTxSub := api.events.SubscribeFilteredPendingTxs(query, txs)
+
+			for {
+				select {
+				case batch := <-txs:
+					for _, tx := range batch {
+						if fullTx {
+							notifier.Notify(rpcSub.ID, ethapi.NewRPCPendingTransaction(tx))
+						} else {
+							notifier.Notify(rpcSub.ID, tx.Hash())
+						}
+					}
+				case <-rpcSub.Err():
+					pendingTxSub.Unsubscribe()
+					return
+				case <-notifier.Closed():
+					pendingTxSub.Unsubscribe()
+					return
+				}
+			}
+		}(crit.FullTx)
+
+		return rpcSub, nil
+	}
+
 	go func() {
 		txHashes := make(chan []common.Hash, 128)
 		pendingTxSub := api.events.SubscribePendingTxs(txHashes)
@@ -234,11 +280,20 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+//
+// If the criteria start at a past block number and leave the end open, the matching
+// logs of the canonical chain since that block are delivered first, followed by the
+// live logs. Logs of blocks that are reorged out are delivered again with the
+// removed flag set.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	// Subscriptions starting at a past block replay the logs since then first
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 && (crit.ToBlock == nil || crit.ToBlock.Int64() == rpc.LatestBlockNumber.Int64()) {
+		return api.logsFromBlock(notifier, crit)
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
@@ -271,6 +326,122 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	return rpcSub, nil
 }
 
+// logReplayBatch is the number of blocks whose logs are searched at once when
+// replaying the history of a log subscription.
+const logReplayBatch = 2048
+
+// logReplay is the outcome of replaying historical logs.
+type logReplay struct {
+	blocks map[common.Hash]bool // blocks whose logs were delivered
+	err    error
+}
+
+// logsFromBlock creates a log subscription which first delivers the matching logs
+// of the canonical chain from the requested block up to the current head, and then
+// continues with live logs. Live logs arriving during the replay are held back and
+// reconciled with the replayed ones, so that every log is delivered exactly once
+// and logs are only reported as removed if they were delivered before.
+func (api *PublicFilterAPI) logsFromBlock(notifier *rpc.Notifier, crit FilterCriteria) (*rpc.Subscription, error) {
+	head, _ := api.backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if head == nil {
+		return nil, errors.New("unknown head block")
+	}
+	var (
+		rpcSub      = notifier.CreateSubscription()
+		matchedLogs = make(chan []*types.Log)
+		number      = head.Number.Uint64()
+	)
+	// Subscribe to live logs before replaying, so that nothing falls in between
+	logsSub, err := api.events.SubscribeLogs(DEWH.FilterQuery(crit), matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		replayed := make(chan logReplay, 1)
+		go func() {
+			blocks, err := api.replayLogs(ctx, notifier, rpcSub, crit, number)
+			replayed <- logReplay{blocks, err}
+		}()
+
+		var (
+			queue     [][]*types.Log
+			replaying = true
+		)
+		for {
+			select {
+			case logs := <-matchedLogs:
+				if replaying {
+					queue = append(queue, logs)
+					continue
+				}
+				for _, log := range logs {
+					notifier.Notify(rpcSub.ID, log)
+				}
+			case res := <-replayed:
+				// A partial history is useless to the client, terminate the subscription
+				// and let it know why instead of going silent
+				if res.err != nil {
+					log.Debug("Failed to replay historical logs", "from", crit.FromBlock, "to", number, "err", res.err)
+					notifier.Fail(rpcSub.ID, res.err)
+					logsSub.Unsubscribe()
+					return
+				}
+				// Reorgs during the replay might have been seen by it or not. Drop the
+				// live logs of blocks which were already replayed, and the removals of
+				// blocks which never were.
+				for _, logs := range queue {
+					for _, log := range logs {
+						if log.BlockNumber <= number && log.Removed != res.blocks[log.BlockHash] {
+							continue
+						}
+						notifier.Notify(rpcSub.ID, log)
+					}
+				}
+				queue, replaying = nil, false
+
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				logsSub.Unsubscribe()
+				return
+			case <-notifier.Closed(): // connection dropped
+				logsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// replayLogs delivers the logs matching the criteria between the start block of
+// the criteria and the given head, once the subscription was activated.
+func (api *PublicFilterAPI) replayLogs(ctx context.Context, notifier *rpc.Notifier, rpcSub *rpc.Subscription, crit FilterCriteria, head uint64) (map[common.Hash]bool, error) {
+	select {
+	case <-rpcSub.Activated():
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	blocks := make(map[common.Hash]bool)
+	for begin := crit.FromBlock.Uint64(); begin <= head; begin += logReplayBatch {
+		end := begin + logReplayBatch - 1
+		if end > head {
+			end = head
+		}
+		logs, err := NewRangeFilter(api.backend, int64(begin), int64(end), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			blocks[log.BlockHash] = true
+			notifier.Notify(rpcSub.ID, log)
+		}
+	}
+	return blocks, nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as DEWH.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria DEWH.FilterQuery
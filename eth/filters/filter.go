@@ -27,10 +27,12 @@ import (
 	"github.com/DEWH/go-DEWH/core/types"
 	"github.com/DEWH/go-DEWH/ethdb"
 	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/params"
 	"github.com/DEWH/go-DEWH/rpc"
 )
 
 type Backend interface {
+	ChainConfig() *params.ChainConfig
 	ChainDb() ethdb.Database
 	EventMux() *event.TypeMux
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
@@ -318,6 +320,28 @@ Logs:
 	return ret
 }
 
+// filterTxs creates a slice of transactions matching the given criteria. The
+// signer must be the transaction pool's, so the senders it cached are reused.
+func filterTxs(txs []*types.Transaction, crit PendingTxQuery, signer types.Signer) []*types.Transaction {
+	var ret []*types.Transaction
+	for _, tx := range txs {
+		if crit.MinGasPrice != nil && tx.GasPrice().Cmp(crit.MinGasPrice) < 0 {
+			continue
+		}
+		if len(crit.To) > 0 && (tx.To() == nil || !includes(crit.To, *tx.To())) {
+			continue
+		}
+		if len(crit.From) > 0 {
+			from, err := types.Sender(signer, tx)
+			if err != nil || !includes(crit.From, from) {
+				continue
+			}
+		}
+		ret = append(ret, tx)
+	}
+	return ret
+}
+
 func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
 	if len(addresses) > 0 {
 		var included bool
@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -48,8 +49,8 @@ const (
 	PendingLogsSubscription
 	// MinedAndPendingLogsSubscription queries for logs in mined and pending blocks.
 	MinedAndPendingLogsSubscription
-	// PendingTransactionsSubscription queries tx hashes for pending
-	// transactions entering the pending state
+	// PendingTransactionsSubscription queries tx hashes or full transactions
+	// for pending transactions entering the pending state
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
@@ -74,13 +75,23 @@ var (
 	ErrInvalidSubscriptionID = errors.New("invalid id")
 )
 
+// PendingTxQuery restricts a pending transaction subscription to transactions
+// matching all of its non-empty criteria.
+type PendingTxQuery struct {
+	From        []common.Address // accepted senders, empty matches any sender
+	To          []common.Address // accepted recipients, empty matches any recipient
+	MinGasPrice *big.Int         // lowest accepted gas price, nil matches any price
+}
+
 type subscription struct {
 	id        rpc.ID
 	typ       Type
 	created   time.Time
 	logsCrit  DEWH.FilterQuery
+	txsCrit   PendingTxQuery
 	logs      chan []*types.Log
 	hashes    chan []common.Hash
+	txs       chan []*types.Transaction // full transactions, hashes are delivered if nil
 	headers   chan *types.Header
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
@@ -93,6 +104,7 @@ type EventSystem struct {
 	backend   Backend
 	lightMode bool
 	lastHead  *types.Header
+	signer    types.Signer // Signer of the transaction pool, to hit its sender cache
 
 	// Subscriptions
 	txsSub        event.Subscription         // Subscription for new transaction event
@@ -121,6 +133,7 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 		mux:       mux,
 		backend:   backend,
 		lightMode: lightMode,
+		signer:    types.NewEIP155Signer(backend.ChainConfig().ChainID),
 		install:   make(chan *subscription),
 		uninstall: make(chan *subscription),
 		txsCh:     make(chan core.NewTxsEvent, txChanSize),
@@ -314,6 +327,24 @@ func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscript
 	return es.subscribe(sub)
 }
 
+// SubscribeFilteredPendingTxs creates a subscription that writes the transactions
+// entering the transaction pool which match the given criteria.
+func (es *EventSystem) SubscribeFilteredPendingTxs(crit PendingTxQuery, txs chan []*types.Transaction) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       PendingTransactionsSubscription,
+		created:   time.Now(),
+		txsCrit:   crit,
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		txs:       txs,
+		headers:   make(chan *types.Header),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 type filterIndex map[Type]map[rpc.ID]*subscription
 
 // broadcast event to filters that match criteria.
@@ -353,7 +384,13 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 			hashes = append(hashes, tx.Hash())
 		}
 		for _, f := range filters[PendingTransactionsSubscription] {
-			f.hashes <- hashes
+			if f.txs == nil {
+				f.hashes <- hashes
+				continue
+			}
+			if matchedTxs := filterTxs(e.Txs, f.txsCrit, es.signer); len(matchedTxs) > 0 {
+				f.txs <- matchedTxs
+			}
 		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
@@ -18,6 +18,8 @@ package filters
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -32,6 +34,7 @@ import (
 	"github.com/DEWH/go-DEWH/core/bloombits"
 	"github.com/DEWH/go-DEWH/core/rawdb"
 	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
 	"github.com/DEWH/go-DEWH/ethdb"
 	"github.com/DEWH/go-DEWH/event"
 	"github.com/DEWH/go-DEWH/params"
@@ -48,6 +51,10 @@ type testBackend struct {
 	chainFeed  *event.Feed
 }
 
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
 func (b *testBackend) ChainDb() ethdb.Database {
 	return b.db
 }
@@ -273,6 +280,79 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestFilteredPendingTxSubscription tests that pending transaction subscriptions
+// only deliver the transactions matching their sender, recipient and gas price
+// criteria.
+func TestFilteredPendingTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = ethdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key1.PublicKey)
+		to1     = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		to2     = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		signer  = types.HomesteadSigner{}
+	)
+	sign := func(key *ecdsa.PrivateKey, tx *types.Transaction) *types.Transaction {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	transactions := []*types.Transaction{
+		sign(key1, types.NewTransaction(0, to1, new(big.Int), 21000, big.NewInt(10), nil)),
+		sign(key1, types.NewTransaction(1, to2, new(big.Int), 21000, big.NewInt(10), nil)),
+		sign(key2, types.NewTransaction(0, to1, new(big.Int), 21000, big.NewInt(10), nil)),
+		sign(key1, types.NewTransaction(2, to1, new(big.Int), 21000, big.NewInt(1), nil)),
+		sign(key1, types.NewContractCreation(3, new(big.Int), 21000, big.NewInt(10), nil)),
+	}
+	tests := []struct {
+		crit PendingTxQuery
+		want []*types.Transaction
+	}{
+		{PendingTxQuery{}, transactions},
+		{PendingTxQuery{From: []common.Address{sender}}, []*types.Transaction{transactions[0], transactions[1], transactions[3], transactions[4]}},
+		{PendingTxQuery{To: []common.Address{to1}}, []*types.Transaction{transactions[0], transactions[2], transactions[3]}},
+		{PendingTxQuery{MinGasPrice: big.NewInt(5)}, []*types.Transaction{transactions[0], transactions[1], transactions[2], transactions[4]}},
+		{PendingTxQuery{From: []common.Address{sender}, To: []common.Address{to1}, MinGasPrice: big.NewInt(5)}, []*types.Transaction{transactions[0]}},
+	}
+	channels := make([]chan []*types.Transaction, len(tests))
+	for i, tt := range tests {
+		channels[i] = make(chan []*types.Transaction, 1)
+		sub := api.events.SubscribeFilteredPendingTxs(tt.crit, channels[i])
+		defer sub.Unsubscribe()
+	}
+	txFeed.Send(core.NewTxsEvent{Txs: transactions})
+
+	for i, tt := range tests {
+		select {
+		case txs := <-channels[i]:
+			if len(txs) != len(tt.want) {
+				t.Errorf("test %d: invalid number of transactions, want %d, got %d", i, len(tt.want), len(txs))
+				continue
+			}
+			for j := range txs {
+				if txs[j].Hash() != tt.want[j].Hash() {
+					t.Errorf("test %d: txs[%d] invalid, want %x, got %x", i, j, tt.want[j].Hash(), txs[j].Hash())
+				}
+			}
+		case <-time.After(time.Second):
+			t.Errorf("test %d: no transactions delivered", i)
+		}
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
@@ -614,3 +694,258 @@ func TestPendingLogsSubscription(t *testing.T) {
 		}
 	}
 }
+
+// TestLogsSubscriptionFromBlock tests that log subscriptions starting at a past
+// block first replay the historical logs and then continue with live ones.
+func TestLogsSubscriptionFromBlock(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = ethdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		other = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		topic = common.BytesToHash([]byte("topic"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		if i == 1 || i == 4 || i == 7 {
+			address := addr
+			if i == 7 {
+				address = other
+			}
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: address, Topics: []common.Hash{topic}, BlockNumber: uint64(i + 1)}}
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	logs := make(chan *types.Log, 10)
+	crit := map[string]interface{}{"fromBlock": "0x1", "address": []common.Address{addr}}
+	sub, err := client.EthSubscribe(context.Background(), logs, "logs", crit)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	logsFeed.Send([]*types.Log{
+		{Address: addr, Topics: []common.Hash{topic}, BlockNumber: 11},
+		{Address: other, Topics: []common.Hash{topic}, BlockNumber: 11},
+	})
+
+	for i, want := range []uint64{2, 5, 11} {
+		select {
+		case log := <-logs:
+			if log.BlockNumber != want || log.Address != addr {
+				t.Fatalf("log %d: have block %d address %x, want block %d address %x", i, log.BlockNumber, log.Address, want, addr)
+			}
+		case err := <-sub.Err():
+			t.Fatal("subscription failed:", err)
+		case <-time.After(time.Second):
+			t.Fatalf("log %d: not delivered", i)
+		}
+	}
+}
+
+// replayBackend wraps a test backend, holding back log retrievals until released
+// and failing them if an error is set.
+type replayBackend struct {
+	*testBackend
+	release chan struct{}
+	err     error
+}
+
+func (b *replayBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.testBackend.GetLogs(ctx, hash)
+}
+
+// newReplayBackend creates a backend with a chain of 10 blocks, of which blocks 2
+// and 5 contain a log of addr.
+func newReplayBackend(addr common.Address, topic common.Hash) (*replayBackend, []*types.Block) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		if i == 1 || i == 4 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}, BlockNumber: uint64(i + 1)}}
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	for i, block := range chain {
+		for _, receipt := range receipts[i] {
+			for _, log := range receipt.Logs {
+				log.BlockHash = block.Hash()
+			}
+		}
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return &replayBackend{testBackend: backend, release: make(chan struct{})}, chain
+}
+
+// flushLogEvents makes sure that all log events sent before were processed by the
+// event system, by pushing more non-matching events than its channels buffer.
+func flushLogEvents(backend *testBackend) {
+	filler := []*types.Log{{Address: common.HexToAddress("0xffffffffffffffffffffffffffffffffffffffff")}}
+	for i := 0; i < logsChanSize+2; i++ {
+		backend.logsFeed.Send(filler)
+	}
+	for i := 0; i < rmLogsChanSize+2; i++ {
+		backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: filler})
+	}
+}
+
+// TestLogsSubscriptionFromBlockFailure tests that a failing replay of historical
+// logs terminates the subscription with the error.
+func TestLogsSubscriptionFromBlockFailure(t *testing.T) {
+	t.Parallel()
+
+	var (
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		topic = common.BytesToHash([]byte("topic"))
+	)
+	backend, _ := newReplayBackend(addr, topic)
+	backend.err = errors.New("logs unavailable")
+	close(backend.release)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	logs := make(chan *types.Log, 10)
+	crit := map[string]interface{}{"fromBlock": "0x1", "address": []common.Address{addr}}
+	sub, err := client.EthSubscribe(context.Background(), logs, "logs", crit)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log delivered: %v", log)
+	case err := <-sub.Err():
+		if err == nil || err.Error() != backend.err.Error() {
+			t.Fatalf("subscription error mismatch: have %v, want %v", err, backend.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not terminated")
+	}
+}
+
+// TestLogsSubscriptionFromBlockReorg tests that a reorg arriving while historical
+// logs are replayed is reconciled with the replayed logs: logs are delivered once
+// and only logs which were delivered before are reported as removed.
+func TestLogsSubscriptionFromBlockReorg(t *testing.T) {
+	t.Parallel()
+
+	var (
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		topic = common.BytesToHash([]byte("topic"))
+	)
+	backend, chain := newReplayBackend(addr, topic)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	logs := make(chan *types.Log, 10)
+	crit := map[string]interface{}{"fromBlock": "0x1", "address": []common.Address{addr}}
+	sub, err := client.EthSubscribe(context.Background(), logs, "logs", crit)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	// While the replay is held back, block 5 is reorged out for a new block 5 and
+	// a block the replay never saw is reorged out too. The replay still sees the
+	// old chain, as does the re-announcement of block 2.
+	var (
+		old5   = &types.Log{Address: addr, Topics: []common.Hash{topic}, BlockNumber: 5, BlockHash: chain[4].Hash(), Removed: true}
+		new5   = &types.Log{Address: addr, Topics: []common.Hash{topic}, BlockNumber: 5, BlockHash: common.Hash{0x05}}
+		side6  = &types.Log{Address: addr, Topics: []common.Hash{topic}, BlockNumber: 6, BlockHash: common.Hash{0x06}, Removed: true}
+		dup2   = &types.Log{Address: addr, Topics: []common.Hash{topic}, BlockNumber: 2, BlockHash: chain[1].Hash()}
+		live11 = &types.Log{Address: addr, Topics: []common.Hash{topic}, BlockNumber: 11, BlockHash: common.Hash{0x11}}
+	)
+	backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: []*types.Log{old5, side6}})
+	backend.logsFeed.Send([]*types.Log{new5, dup2})
+	flushLogEvents(backend.testBackend)
+	close(backend.release)
+
+	type delivery struct {
+		hash    common.Hash
+		removed bool
+	}
+	receive := func(n int) map[delivery]int {
+		delivered := make(map[delivery]int)
+		for i := 0; i < n; i++ {
+			select {
+			case log := <-logs:
+				delivered[delivery{log.BlockHash, log.Removed}]++
+			case err := <-sub.Err():
+				t.Fatal("subscription failed:", err)
+			case <-time.After(time.Second):
+				t.Fatalf("log %d of %d not delivered", i, n)
+			}
+		}
+		return delivered
+	}
+	// The replay comes first and in order
+	for i, want := range []common.Hash{chain[1].Hash(), chain[4].Hash()} {
+		if have := receive(1); have[delivery{want, false}] != 1 {
+			t.Fatalf("replayed log %d mismatch: have %v, want %x", i, have, want)
+		}
+	}
+	// The reconciled reorg follows, the order of removals and additions depends
+	// on the event system
+	want := map[delivery]int{{old5.BlockHash, true}: 1, {new5.BlockHash, false}: 1}
+	if have := receive(2); !reflect.DeepEqual(have, want) {
+		t.Fatalf("reorg logs mismatch: have %v, want %v", have, want)
+	}
+	// Live logs continue after the handoff, nothing else is delivered in between
+	backend.logsFeed.Send([]*types.Log{live11})
+	if have := receive(1); have[delivery{live11.BlockHash, false}] != 1 {
+		t.Fatalf("live log mismatch: have %v, want %x", have, live11.BlockHash)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/DEWH/go-DEWH/rpc"
+)
+
+const (
+	// maxFeeHistory is the maximum number of blocks a fee history can be
+	// requested for.
+	maxFeeHistory = 1024
+
+	// maxFeeHistoryPercentiles is the maximum number of percentiles a fee
+	// history can be requested for.
+	maxFeeHistoryPercentiles = 100
+
+	// feeHistoryWorkers is the number of blocks processed concurrently.
+	feeHistoryWorkers = 8
+)
+
+var (
+	errInvalidPercentile = errors.New("invalid percentile")
+	errInvalidBlockCount = errors.New("invalid block count")
+)
+
+// blockFees holds the fee statistics of a single block.
+type blockFees struct {
+	gasUsedRatio float64
+	prices       []*big.Int // gas prices at the requested percentiles
+	empty        bool       // whether the block contains no transactions
+	err          error
+}
+
+// txGasAndPrice is a transaction's gas usage and price, used for calculating
+// gas weighted percentiles.
+type txGasAndPrice struct {
+	gasUsed uint64
+	price   *big.Int
+}
+
+type txsByPrice []txGasAndPrice
+
+func (t txsByPrice) Len() int           { return len(t) }
+func (t txsByPrice) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t txsByPrice) Less(i, j int) bool { return t[i].price.Cmp(t[j].price) < 0 }
+
+// processBlock calculates the gas used ratio of a block and the gas prices paid
+// at the given percentiles, weighted by the gas used by each transaction. The
+// receipts are only retrieved if percentiles are requested.
+func (gpo *Oracle) processBlock(ctx context.Context, number uint64, percentiles []float64) (fees blockFees) {
+	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+	if block == nil {
+		if err == nil {
+			err = fmt.Errorf("block #%d not found", number)
+		}
+		return blockFees{err: err}
+	}
+	if block.GasLimit() > 0 {
+		fees.gasUsedRatio = float64(block.GasUsed()) / float64(block.GasLimit())
+	}
+	fees.empty = len(block.Transactions()) == 0
+	if len(percentiles) == 0 {
+		return fees
+	}
+	fees.prices = make([]*big.Int, len(percentiles))
+	if fees.empty {
+		for i := range fees.prices {
+			fees.prices[i] = new(big.Int)
+		}
+		return fees
+	}
+	receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return blockFees{err: err}
+	}
+	if len(receipts) != len(block.Transactions()) {
+		return blockFees{err: fmt.Errorf("receipt count mismatch in block #%d: have %d, want %d", number, len(receipts), len(block.Transactions()))}
+	}
+	txs := make(txsByPrice, len(receipts))
+	for i, tx := range block.Transactions() {
+		txs[i] = txGasAndPrice{gasUsed: receipts[i].GasUsed, price: tx.GasPrice()}
+	}
+	sort.Stable(txs)
+
+	var (
+		idx     = 0
+		sumUsed = txs[0].gasUsed
+	)
+	for i, p := range percentiles {
+		threshold := uint64(float64(block.GasUsed()) * p / 100)
+		for sumUsed < threshold && idx < len(txs)-1 {
+			idx++
+			sumUsed += txs[idx].gasUsed
+		}
+		fees.prices[i] = txs[idx].price
+	}
+	return fees
+}
+
+// processBlocks runs processBlock on a range of blocks with a bounded number of
+// concurrent workers, returning the results in block order.
+func (gpo *Oracle) processBlocks(ctx context.Context, oldest uint64, count int, percentiles []float64) []blockFees {
+	var (
+		results = make([]blockFees, count)
+		next    = make(chan int)
+		done    = make(chan struct{})
+	)
+	for i := 0; i < feeHistoryWorkers && i < count; i++ {
+		go func() {
+			for idx := range next {
+				results[idx] = gpo.processBlock(ctx, oldest+uint64(idx), percentiles)
+			}
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		next <- i
+	}
+	close(next)
+	for i := 0; i < feeHistoryWorkers && i < count; i++ {
+		<-done
+	}
+	return results
+}
+
+// FeeHistory returns the fee statistics of blockCount blocks ending with lastBlock:
+// the number of the oldest returned block, the gas prices paid at the requested
+// percentiles (weighted by gas used) and the gas used ratio of each block. Less
+// blocks than requested are returned if the chain isn't long enough.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	if blockCount < 1 || blockCount > maxFeeHistory {
+		return nil, nil, nil, errInvalidBlockCount
+	}
+	if len(percentiles) > maxFeeHistoryPercentiles {
+		return nil, nil, nil, fmt.Errorf("%v: more than %d requested", errInvalidPercentile, maxFeeHistoryPercentiles)
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, nil, nil, fmt.Errorf("%v: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, nil, nil, fmt.Errorf("%v: #%d:%f > #%d:%f", errInvalidPercentile, i-1, percentiles[i-1], i, p)
+		}
+	}
+	// Resolve the requested range, the pending block is not considered
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil {
+		return nil, nil, nil, err
+	}
+	last := head.Number.Uint64()
+	if lastBlock >= 0 && uint64(lastBlock) < last {
+		last = uint64(lastBlock)
+	}
+	if uint64(blockCount) > last+1 {
+		blockCount = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blockCount)
+
+	var (
+		prices = make([][]*big.Int, blockCount)
+		ratios = make([]float64, blockCount)
+	)
+	for i, fees := range gpo.processBlocks(ctx, oldest, blockCount, percentiles) {
+		if fees.err != nil {
+			return nil, nil, nil, fees.err
+		}
+		prices[i], ratios[i] = fees.prices, fees.gasUsedRatio
+	}
+	if len(percentiles) == 0 {
+		prices = nil
+	}
+	return new(big.Int).SetUint64(oldest), prices, ratios, nil
+}
+
+// SuggestPriceFor recommends a gas price for a transaction to be included within
+// the given number of blocks, paying at least the given gas weighted percentile
+// of the prices in those blocks. The estimate is based on the last configured
+// number of blocks: the price returned would have met the percentile in at least
+// one out of every confirmBlocks of them.
+func (gpo *Oracle) SuggestPriceFor(ctx context.Context, percentile float64, confirmBlocks int) (*big.Int, error) {
+	if percentile < 0 || percentile > 100 {
+		return nil, fmt.Errorf("%v: %f", errInvalidPercentile, percentile)
+	}
+	if confirmBlocks < 1 {
+		return nil, fmt.Errorf("invalid confirmation target: %d", confirmBlocks)
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil {
+		return nil, err
+	}
+	key := priceKey{percentile, confirmBlocks}
+
+	gpo.cacheLock.RLock()
+	if gpo.weightedHead == head.Hash() {
+		if price, ok := gpo.weightedPrices[key]; ok {
+			gpo.cacheLock.RUnlock()
+			return price, nil
+		}
+	}
+	gpo.cacheLock.RUnlock()
+
+	// Collect the percentile price of the recent non-empty blocks
+	count := gpo.checkBlocks
+	if uint64(count) > head.Number.Uint64() {
+		count = int(head.Number.Uint64())
+	}
+	var prices []*big.Int
+	if count > 0 {
+		for _, fees := range gpo.processBlocks(ctx, head.Number.Uint64()+1-uint64(count), count, []float64{percentile}) {
+			if fees.err != nil {
+				return nil, fees.err
+			}
+			if !fees.empty {
+				prices = append(prices, fees.prices[0])
+			}
+		}
+	}
+	price := gpo.defaultPrice
+	if len(prices) > 0 {
+		sort.Sort(bigIntArray(prices))
+
+		// Pick the lowest price meeting the percentile in one of every confirmBlocks blocks
+		price = prices[(len(prices)+confirmBlocks-1)/confirmBlocks-1]
+	}
+	if price == nil {
+		return nil, errors.New("no gas price data available")
+	}
+	if price.Cmp(maxPrice) > 0 {
+		price = new(big.Int).Set(maxPrice)
+	}
+	gpo.cacheLock.Lock()
+	if gpo.weightedHead != head.Hash() {
+		gpo.weightedHead = head.Hash()
+		gpo.weightedPrices = make(map[priceKey]*big.Int)
+	}
+	gpo.weightedPrices[key] = price
+	gpo.cacheLock.Unlock()
+
+	return price, nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/internal/ethapi"
+	"github.com/DEWH/go-DEWH/rpc"
+)
+
+const testGasLimit = 1000000
+
+// testTx is a transaction of a test block, paying price for gas.
+type testTx struct {
+	price int64
+	gas   uint64
+}
+
+// testBackend is a fake chain serving the blocks and receipts needed by the
+// oracle. All other backend methods panic.
+type testBackend struct {
+	ethapi.Backend
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+}
+
+// newTestBackend creates a chain with an empty genesis block followed by one
+// block for each of the given transaction lists.
+func newTestBackend(blocks ...[]testTx) *testBackend {
+	backend := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	backend.blocks = append(backend.blocks, types.NewBlockWithHeader(&types.Header{Number: new(big.Int), GasLimit: testGasLimit}))
+
+	for i, txs := range blocks {
+		var (
+			header   = &types.Header{Number: big.NewInt(int64(i + 1)), GasLimit: testGasLimit}
+			body     []*types.Transaction
+			receipts types.Receipts
+		)
+		for nonce, tx := range txs {
+			header.GasUsed += tx.gas
+			body = append(body, types.NewTransaction(uint64(nonce), common.Address{}, new(big.Int), tx.gas, big.NewInt(tx.price), nil))
+			receipts = append(receipts, &types.Receipt{CumulativeGasUsed: header.GasUsed, GasUsed: tx.gas})
+		}
+		block := types.NewBlock(header, body, nil, receipts)
+		backend.blocks = append(backend.blocks, block)
+		backend.receipts[block.Hash()] = receipts
+	}
+	return backend
+}
+
+func (b *testBackend) block(number rpc.BlockNumber) *types.Block {
+	if number < 0 {
+		return b.blocks[len(b.blocks)-1]
+	}
+	if int(number) >= len(b.blocks) {
+		return nil
+	}
+	return b.blocks[number]
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if block := b.block(number); block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	return b.block(number), nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func bigs(values ...int64) []*big.Int {
+	res := make([]*big.Int, len(values))
+	for i, v := range values {
+		res[i] = big.NewInt(v)
+	}
+	return res
+}
+
+func TestProcessBlock(t *testing.T) {
+	backend := newTestBackend(
+		// Sorted by price the cumulative gas used is 50000, 79000 and 100000
+		[]testTx{{3, 21000}, {1, 50000}, {2, 29000}},
+		nil,
+		[]testTx{{7, 21000}},
+	)
+	oracle := NewOracle(backend, Config{Blocks: 1})
+
+	tests := []struct {
+		number      uint64
+		percentiles []float64
+		prices      []*big.Int
+		ratio       float64
+		empty       bool
+	}{
+		{number: 1, percentiles: nil, prices: nil, ratio: 0.1},
+		{number: 1, percentiles: []float64{0}, prices: bigs(1), ratio: 0.1},
+		{number: 1, percentiles: []float64{100}, prices: bigs(3), ratio: 0.1},
+		{number: 1, percentiles: []float64{0, 50, 51, 79, 80, 100}, prices: bigs(1, 1, 2, 2, 3, 3), ratio: 0.1},
+		{number: 2, percentiles: nil, prices: nil, empty: true},
+		{number: 2, percentiles: []float64{0, 50, 100}, prices: bigs(0, 0, 0), empty: true},
+		{number: 3, percentiles: []float64{0, 100}, prices: bigs(7, 7), ratio: 0.021},
+	}
+	for i, tt := range tests {
+		fees := oracle.processBlock(context.Background(), tt.number, tt.percentiles)
+		if fees.err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, fees.err)
+			continue
+		}
+		if !reflect.DeepEqual(fees.prices, tt.prices) {
+			t.Errorf("test %d: prices mismatch: have %v, want %v", i, fees.prices, tt.prices)
+		}
+		if fees.gasUsedRatio != tt.ratio {
+			t.Errorf("test %d: gas used ratio mismatch: have %v, want %v", i, fees.gasUsedRatio, tt.ratio)
+		}
+		if fees.empty != tt.empty {
+			t.Errorf("test %d: empty flag mismatch: have %v, want %v", i, fees.empty, tt.empty)
+		}
+	}
+	// Blocks past the head must fail instead of returning empty statistics
+	if fees := oracle.processBlock(context.Background(), 4, nil); fees.err == nil {
+		t.Errorf("missing block: no error returned")
+	}
+}
+
+func TestFeeHistory(t *testing.T) {
+	backend := newTestBackend(
+		[]testTx{{1, 21000}},
+		[]testTx{{2, 21000}, {4, 42000}},
+		nil,
+		[]testTx{{3, 21000}},
+	)
+	tests := []struct {
+		count       int
+		last        rpc.BlockNumber
+		percentiles []float64
+
+		oldest int64
+		prices [][]*big.Int
+		ratios []float64
+		fail   bool
+	}{
+		// Invalid requests
+		{count: 0, last: rpc.LatestBlockNumber, fail: true},
+		{count: maxFeeHistory + 1, last: rpc.LatestBlockNumber, fail: true},
+		{count: 1, last: rpc.LatestBlockNumber, percentiles: []float64{-1}, fail: true},
+		{count: 1, last: rpc.LatestBlockNumber, percentiles: []float64{101}, fail: true},
+		{count: 1, last: rpc.LatestBlockNumber, percentiles: []float64{50, 10}, fail: true},
+		{count: 1, last: rpc.LatestBlockNumber, percentiles: make([]float64, maxFeeHistoryPercentiles+1), fail: true},
+
+		// Ranges ending at the head, the pending block is treated as latest
+		{count: 1, last: rpc.LatestBlockNumber, oldest: 4, ratios: []float64{0.021}},
+		{count: 1, last: rpc.PendingBlockNumber, oldest: 4, ratios: []float64{0.021}},
+		{count: 2, last: rpc.LatestBlockNumber, percentiles: []float64{0, 100}, oldest: 3,
+			prices: [][]*big.Int{bigs(0, 0), bigs(3, 3)}, ratios: []float64{0, 0.021}},
+
+		// Ranges ending before the head
+		{count: 2, last: 2, percentiles: []float64{0, 50, 100}, oldest: 1,
+			prices: [][]*big.Int{bigs(1, 1, 1), bigs(2, 4, 4)}, ratios: []float64{0.021, 0.063}},
+		{count: 1, last: 0, percentiles: []float64{50}, oldest: 0,
+			prices: [][]*big.Int{bigs(0)}, ratios: []float64{0}},
+
+		// Ranges reaching past the head or beyond the genesis are truncated
+		{count: 1, last: 100, oldest: 4, ratios: []float64{0.021}},
+		{count: 3, last: 100, oldest: 2, ratios: []float64{0.063, 0, 0.021}},
+		{count: 10, last: 1, oldest: 0, ratios: []float64{0, 0.021}},
+		{count: maxFeeHistory, last: rpc.LatestBlockNumber, oldest: 0, ratios: []float64{0, 0.021, 0.063, 0, 0.021}},
+	}
+	for i, tt := range tests {
+		oracle := NewOracle(backend, Config{Blocks: 1})
+
+		oldest, prices, ratios, err := oracle.FeeHistory(context.Background(), tt.count, tt.last, tt.percentiles)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if oldest.Int64() != tt.oldest {
+			t.Errorf("test %d: oldest block mismatch: have %v, want %v", i, oldest, tt.oldest)
+		}
+		if !reflect.DeepEqual(prices, tt.prices) {
+			t.Errorf("test %d: prices mismatch: have %v, want %v", i, prices, tt.prices)
+		}
+		if !reflect.DeepEqual(ratios, tt.ratios) {
+			t.Errorf("test %d: ratios mismatch: have %v, want %v", i, ratios, tt.ratios)
+		}
+	}
+}
+
+func TestSuggestPriceFor(t *testing.T) {
+	// Six non-empty blocks paying 1..6 in shuffled order, with an empty block
+	// that must not count towards the confirmation target.
+	backend := newTestBackend(
+		[]testTx{{4, 21000}},
+		[]testTx{{1, 21000}},
+		nil,
+		[]testTx{{6, 21000}},
+		[]testTx{{2, 21000}},
+		[]testTx{{5, 21000}},
+		[]testTx{{3, 21000}},
+	)
+	tests := []struct {
+		blocks     int
+		percentile float64
+		confirm    int
+		price      int64
+		fail       bool
+	}{
+		// Invalid requests
+		{blocks: 7, percentile: -1, confirm: 1, fail: true},
+		{blocks: 7, percentile: 101, confirm: 1, fail: true},
+		{blocks: 7, percentile: 50, confirm: 0, fail: true},
+
+		// The lowest price included in one of every confirm blocks
+		{blocks: 7, percentile: 50, confirm: 1, price: 6},
+		{blocks: 7, percentile: 50, confirm: 2, price: 3},
+		{blocks: 7, percentile: 50, confirm: 3, price: 2},
+		{blocks: 7, percentile: 50, confirm: 4, price: 2},
+		{blocks: 7, percentile: 50, confirm: 6, price: 1},
+		{blocks: 7, percentile: 50, confirm: 100, price: 1},
+
+		// Only the most recent blocks are considered, never the genesis
+		{blocks: 2, percentile: 50, confirm: 1, price: 5},
+		{blocks: 2, percentile: 50, confirm: 2, price: 3},
+		{blocks: 4, percentile: 0, confirm: 4, price: 2},
+		{blocks: 100, percentile: 100, confirm: 3, price: 2},
+	}
+	for i, tt := range tests {
+		oracle := NewOracle(backend, Config{Blocks: tt.blocks, Mode: ModeWeighted})
+
+		price, err := oracle.SuggestPriceFor(context.Background(), tt.percentile, tt.confirm)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if price.Int64() != tt.price {
+			t.Errorf("test %d: price mismatch: have %v, want %v", i, price, tt.price)
+		}
+		// Repeated queries at the same head must be served from the cache
+		if cached, _ := oracle.SuggestPriceFor(context.Background(), tt.percentile, tt.confirm); cached != price {
+			t.Errorf("test %d: cached price mismatch: have %v, want %v", i, cached, price)
+		}
+	}
+}
+
+func TestSuggestPriceForEmptyBlocks(t *testing.T) {
+	backend := newTestBackend(nil, nil, nil)
+
+	// Without any transactions the configured default is returned
+	oracle := NewOracle(backend, Config{Blocks: 3, Mode: ModeWeighted, Default: big.NewInt(42)})
+	if price, err := oracle.SuggestPriceFor(context.Background(), 50, 1); err != nil || price.Int64() != 42 {
+		t.Errorf("default price mismatch: have %v/%v, want 42/nil", price, err)
+	}
+	// Without a default no price can be suggested
+	oracle = NewOracle(backend, Config{Blocks: 3, Mode: ModeWeighted})
+	if price, err := oracle.SuggestPriceFor(context.Background(), 50, 1); err == nil {
+		t.Errorf("expected error, got price %v", price)
+	}
+	// A chain only consisting of the genesis block has no data either
+	oracle = NewOracle(newTestBackend(), Config{Blocks: 3, Mode: ModeWeighted, Default: big.NewInt(42)})
+	if price, err := oracle.SuggestPriceFor(context.Background(), 50, 1); err != nil || price.Int64() != 42 {
+		t.Errorf("genesis only price mismatch: have %v/%v, want 42/nil", price, err)
+	}
+}
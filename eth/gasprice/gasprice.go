@@ -25,16 +25,34 @@ import (
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/core/types"
 	"github.com/DEWH/go-DEWH/internal/ethapi"
+	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/params"
 	"github.com/DEWH/go-DEWH/rpc"
 )
 
 var maxPrice = big.NewInt(500 * params.Shannon)
 
+const (
+	// ModeLowest suggests a percentile of the lowest prices paid in recent blocks.
+	ModeLowest = "lowest"
+
+	// ModeWeighted suggests the price paying at least the gas weighted percentile
+	// of recent blocks within the configured number of confirmation blocks.
+	ModeWeighted = "weighted"
+)
+
 type Config struct {
-	Blocks     int
-	Percentile int
-	Default    *big.Int `toml:",omitempty"`
+	Blocks        int
+	Percentile    int
+	Mode          string   `toml:",omitempty"`
+	ConfirmBlocks int      `toml:",omitempty"`
+	Default       *big.Int `toml:",omitempty"`
+}
+
+// priceKey identifies a cached percentile price suggestion.
+type priceKey struct {
+	percentile    float64
+	confirmBlocks int
 }
 
 // Oracle recommends gas prices based on the content of recent
@@ -46,8 +64,14 @@ type Oracle struct {
 	cacheLock sync.RWMutex
 	fetchLock sync.Mutex
 
+	weightedHead   common.Hash           // head the weighted prices were calculated for
+	weightedPrices map[priceKey]*big.Int // weighted price suggestions at weightedHead
+	defaultPrice   *big.Int
+
 	checkBlocks, maxEmpty, maxBlocks int
 	percentile                       int
+	weighted                         bool
+	confirmBlocks                    int
 }
 
 // NewOracle returns a new oracle.
@@ -63,18 +87,37 @@ func NewOracle(backend ethapi.Backend, params Config) *Oracle {
 	if percent > 100 {
 		percent = 100
 	}
+	confirm := params.ConfirmBlocks
+	if confirm < 1 {
+		confirm = 1
+	}
+	weighted := false
+	switch params.Mode {
+	case "", ModeLowest:
+	case ModeWeighted:
+		weighted = true
+	default:
+		log.Warn("Unknown gas price oracle mode, using lowest", "mode", params.Mode)
+	}
 	return &Oracle{
-		backend:     backend,
-		lastPrice:   params.Default,
-		checkBlocks: blocks,
-		maxEmpty:    blocks / 2,
-		maxBlocks:   blocks * 5,
-		percentile:  percent,
+		backend:        backend,
+		lastPrice:      params.Default,
+		defaultPrice:   params.Default,
+		weightedPrices: make(map[priceKey]*big.Int),
+		checkBlocks:    blocks,
+		maxEmpty:       blocks / 2,
+		maxBlocks:      blocks * 5,
+		percentile:     percent,
+		weighted:       weighted,
+		confirmBlocks:  confirm,
 	}
 }
 
 // SuggestPrice returns the recommended gas price.
 func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	if gpo.weighted {
+		return gpo.SuggestPriceFor(ctx, float64(gpo.percentile), gpo.confirmBlocks)
+	}
 	gpo.cacheLock.RLock()
 	lastHead := gpo.lastHead
 	lastPrice := gpo.lastPrice
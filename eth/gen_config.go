@@ -4,6 +4,7 @@ package eth
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/common/hexutil"
@@ -20,16 +21,23 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		LightServ               int            `toml:",omitempty"`
+		LightPeers              int            `toml:",omitempty"`
+		LightOracle             common.Address `toml:",omitempty"`
+		SkipBcVersionCheck      bool           `toml:"-"`
+		DatabaseHandles         int            `toml:"-"`
 		DatabaseCache           int
 		Etherbase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
-		ExtraData               hexutil.Bytes  `toml:",omitempty"`
+		MinerTxOrdering         string         `toml:",omitempty"`
+		MinerRecommit           time.Duration
+		MinerGasFloor           uint64
+		MinerGasCeil            uint64
+		MinerNotify             []string      `toml:",omitempty"`
+		ExtraData               hexutil.Bytes `toml:",omitempty"`
 		GasPrice                *big.Int
 		Ethash                  ethash.Config
+		CliqueAutoDrop          uint64 `toml:",omitempty"`
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
@@ -41,14 +49,21 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
+	enc.LightOracle = c.LightOracle
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.Etherbase = c.Etherbase
 	enc.MinerThreads = c.MinerThreads
+	enc.MinerTxOrdering = c.MinerTxOrdering
+	enc.MinerRecommit = c.MinerRecommit
+	enc.MinerGasFloor = c.MinerGasFloor
+	enc.MinerGasCeil = c.MinerGasCeil
+	enc.MinerNotify = c.MinerNotify
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
 	enc.Ethash = c.Ethash
+	enc.CliqueAutoDrop = c.CliqueAutoDrop
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
@@ -61,16 +76,23 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		LightServ               *int            `toml:",omitempty"`
+		LightPeers              *int            `toml:",omitempty"`
+		LightOracle             *common.Address `toml:",omitempty"`
+		SkipBcVersionCheck      *bool           `toml:"-"`
+		DatabaseHandles         *int            `toml:"-"`
 		DatabaseCache           *int
 		Etherbase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
-		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
+		MinerTxOrdering         *string         `toml:",omitempty"`
+		MinerRecommit           *time.Duration
+		MinerGasFloor           *uint64
+		MinerGasCeil            *uint64
+		MinerNotify             []string       `toml:",omitempty"`
+		ExtraData               *hexutil.Bytes `toml:",omitempty"`
 		GasPrice                *big.Int
 		Ethash                  *ethash.Config
+		CliqueAutoDrop          *uint64 `toml:",omitempty"`
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
@@ -95,6 +117,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if DEWH.LightPeers != nil {
 		c.LightPeers = *DEWH.LightPeers
 	}
+	if DEWH.LightOracle != nil {
+		c.LightOracle = *DEWH.LightOracle
+	}
 	if DEWH.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *DEWH.SkipBcVersionCheck
 	}
@@ -110,6 +135,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if DEWH.MinerThreads != nil {
 		c.MinerThreads = *DEWH.MinerThreads
 	}
+	if DEWH.MinerTxOrdering != nil {
+		c.MinerTxOrdering = *DEWH.MinerTxOrdering
+	}
+	if DEWH.MinerRecommit != nil {
+		c.MinerRecommit = *DEWH.MinerRecommit
+	}
+	if DEWH.MinerGasFloor != nil {
+		c.MinerGasFloor = *DEWH.MinerGasFloor
+	}
+	if DEWH.MinerGasCeil != nil {
+		c.MinerGasCeil = *DEWH.MinerGasCeil
+	}
+	if DEWH.MinerNotify != nil {
+		c.MinerNotify = DEWH.MinerNotify
+	}
 	if DEWH.ExtraData != nil {
 		c.ExtraData = *DEWH.ExtraData
 	}
@@ -119,6 +159,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if DEWH.Ethash != nil {
 		c.Ethash = *DEWH.Ethash
 	}
+	if DEWH.CliqueAutoDrop != nil {
+		c.CliqueAutoDrop = *DEWH.CliqueAutoDrop
+	}
 	if DEWH.TxPool != nil {
 		c.TxPool = *DEWH.TxPool
 	}
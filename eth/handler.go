@@ -30,6 +30,7 @@ import (
 	"github.com/DEWH/go-DEWH/consensus"
 	"github.com/DEWH/go-DEWH/consensus/misc"
 	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/forkid"
 	"github.com/DEWH/go-DEWH/core/types"
 	"github.com/DEWH/go-DEWH/eth/downloader"
 	"github.com/DEWH/go-DEWH/eth/fetcher"
@@ -38,6 +39,7 @@ import (
 	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/p2p"
 	"github.com/DEWH/go-DEWH/p2p/discover"
+	"github.com/DEWH/go-DEWH/p2p/enr"
 	"github.com/DEWH/go-DEWH/params"
 	"github.com/DEWH/go-DEWH/rlp"
 )
@@ -72,6 +74,7 @@ type ProtocolManager struct {
 	txpool      txPool
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
+	forkFilter  forkid.Filter // Fork ID filter, constant across the lifetime of the node
 	maxPeers    int
 
 	downloader *downloader.Downloader
@@ -106,6 +109,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txpool:      txpool,
 		blockchain:  blockchain,
 		chainconfig: config,
+		forkFilter:  forkid.NewFilter(blockchain),
 		peers:       newPeerSet(),
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
@@ -117,6 +121,12 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
+	if mode == downloader.FastSync && config.Dpos != nil {
+		// Fast sync only retrieves the state trie, without the election context
+		// tries of the pivot block dpos can't finalize any block on top
+		log.Warn("Election contexts not synced, fast sync disabled on dpos")
+		mode = downloader.FullSync
+	}
 	if mode == downloader.FastSync {
 		manager.fastSync = uint32(1)
 	}
@@ -153,6 +163,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 				}
 				return nil
 			},
+			Attributes: []enr.Entry{newENREntry(blockchain)},
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
@@ -263,7 +274,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		number  = head.Number.Uint64()
 		td      = pm.blockchain.GetTd(hash, number)
 	)
-	if err := p.Handshake(pm.networkID, td, hash, genesis.Hash()); err != nil {
+	if err := p.Handshake(pm.networkID, td, hash, genesis.Hash(), forkid.NewID(pm.blockchain), pm.forkFilter); err != nil {
 		p.Log().Debug("DEWH handshake failed", "err", err)
 		return err
 	}
@@ -751,11 +762,23 @@ func (pm *ProtocolManager) minedBroadcastLoop() {
 	}
 }
 
+// publicTxs filters out the private transactions of the local pool and those of
+// the no-gossip senders, which must never be propagated to the network.
+func (pm *ProtocolManager) publicTxs(txs types.Transactions) types.Transactions {
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if !pm.txpool.IsPrivate(tx.Hash()) && !pm.txpool.IsNoGossip(tx) {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
 func (pm *ProtocolManager) txBroadcastLoop() {
 	for {
 		select {
 		case event := <-pm.txsCh:
-			pm.BroadcastTxs(event.Txs)
+			pm.BroadcastTxs(pm.publicTxs(event.Txs))
 
 		// Err() channel will be closed when unsubscribing.
 		case <-pm.txsSub.Err():
@@ -25,6 +25,7 @@ import (
 
 	mapset "github.com/DEWHkarep/golang-set"
 	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/forkid"
 	"github.com/DEWH/go-DEWH/core/types"
 	"github.com/DEWH/go-DEWH/p2p"
 	"github.com/DEWH/go-DEWH/rlp"
@@ -328,13 +329,28 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. Since eth/64 the fork IDs
+// are exchanged too and the remote one is validated against the local chain.
+func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter forkid.Filter) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
-	var status statusData // safe to read after two values have been received from errc
 
+	var ( // safe to read after two values have been received from errc
+		status   statusData
+		status64 statusData64
+	)
 	go func() {
+		if p.version >= eth64 {
+			errc <- p2p.Send(p.rw, StatusMsg, &statusData64{
+				ProtocolVersion: uint32(p.version),
+				NetworkId:       network,
+				TD:              td,
+				CurrentBlock:    head,
+				GenesisBlock:    genesis,
+				ForkID:          forkID,
+			})
+			return
+		}
 		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkId:       network,
@@ -344,6 +360,10 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		})
 	}()
 	go func() {
+		if p.version >= eth64 {
+			errc <- p.readStatus64(network, &status64, genesis, forkFilter)
+			return
+		}
 		errc <- p.readStatus(network, &status, genesis)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
@@ -358,33 +378,70 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 			return p2p.DiscReadTimeout
 		}
 	}
-	p.td, p.head = status.TD, status.CurrentBlock
+	if p.version >= eth64 {
+		p.td, p.head = status64.TD, status64.CurrentBlock
+	} else {
+		p.td, p.head = status.TD, status.CurrentBlock
+	}
 	return nil
 }
 
 func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
-	msg, err := p.rw.ReadMsg()
+	msg, err := p.readStatusMsg()
 	if err != nil {
 		return err
 	}
-	if msg.Code != StatusMsg {
-		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	// DEWHode the handshake and make sure everything matches
+	if err := msg.DEWHode(&status); err != nil {
+		return errResp(ErrDEWHode, "msg %v: %v", msg, err)
 	}
-	if msg.Size > ProtocolMaxMsgSize {
-		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	return p.checkStatus(network, genesis, status.NetworkId, status.ProtocolVersion, status.GenesisBlock)
+}
+
+func (p *peer) readStatus64(network uint64, status *statusData64, genesis common.Hash, forkFilter forkid.Filter) (err error) {
+	msg, err := p.readStatusMsg()
+	if err != nil {
+		return err
 	}
 	// DEWHode the handshake and make sure everything matches
 	if err := msg.DEWHode(&status); err != nil {
 		return errResp(ErrDEWHode, "msg %v: %v", msg, err)
 	}
-	if status.GenesisBlock != genesis {
-		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	if err := p.checkStatus(network, genesis, status.NetworkId, status.ProtocolVersion, status.GenesisBlock); err != nil {
+		return err
+	}
+	if err := forkFilter(status.ForkID); err != nil {
+		return errResp(ErrForkIDRejected, "%v", err)
+	}
+	return nil
+}
+
+// readStatusMsg reads the first message of the remote peer, making sure it's a
+// status message of acceptable size.
+func (p *peer) readStatusMsg() (p2p.Msg, error) {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	if msg.Code != StatusMsg {
+		return msg, errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return msg, errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	return msg, nil
+}
+
+// checkStatus verifies the version independent fields of a remote status message.
+func (p *peer) checkStatus(network uint64, genesis common.Hash, remoteNetwork uint64, remoteVersion uint32, remoteGenesis common.Hash) error {
+	if remoteGenesis != genesis {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", remoteGenesis[:8], genesis[:8])
 	}
-	if status.NetworkId != network {
-		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	if remoteNetwork != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", remoteNetwork, network)
 	}
-	if int(status.ProtocolVersion) != p.version {
-		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	if int(remoteVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", remoteVersion, p.version)
 	}
 	return nil
 }
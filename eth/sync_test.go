@@ -21,9 +21,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/vm"
 	"github.com/DEWH/go-DEWH/eth/downloader"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/event"
 	"github.com/DEWH/go-DEWH/p2p"
 	"github.com/DEWH/go-DEWH/p2p/discover"
+	"github.com/DEWH/go-DEWH/params"
 )
 
 // Tests that fast sync gets disabled as soon as a real block is successfully
@@ -53,3 +59,24 @@ func TestFastSyncDisabling(t *testing.T) {
 		t.Fatalf("fast sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that fast sync is never enabled on dpos chains, as the election contexts
+// are not part of the synced state.
+func TestFastSyncDisablingDpos(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Ethash, config.Dpos = nil, &params.DposConfig{}
+
+	var (
+		db            = ethdb.NewMemDatabase()
+		gspec         = &core.Genesis{Config: &config}
+		_             = gspec.MustCommit(db)
+		blockchain, _ = core.NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{})
+	)
+	pm, err := NewProtocolManager(&config, downloader.FastSync, DefaultConfig.NetworkId, new(event.TypeMux), &testTxPool{}, ethash.NewFaker(), blockchain, db)
+	if err != nil {
+		t.Fatalf("failed to create protocol manager: %v", err)
+	}
+	if atomic.LoadUint32(&pm.fastSync) == 1 {
+		t.Fatalf("fast sync enabled on dpos chain")
+	}
+}
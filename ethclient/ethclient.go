@@ -34,7 +34,8 @@ import (
 
 // Client defines typed wrappers for the DEWH RPC API.
 type Client struct {
-	c *rpc.Client
+	c         *rpc.Client
+	reconnect *rpc.ReconnectConfig // subscriptions survive connection loss if set
 }
 
 // Dial connects a client to the given URL.
@@ -52,7 +53,15 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 
 // NewClient creates a client that uses the given RPC client.
 func NewClient(c *rpc.Client) *Client {
-	return &Client{c}
+	return &Client{c: c}
+}
+
+// NewReconnectingClient creates a client that uses the given RPC client, keeping
+// its subscriptions established across connection failures. Subscriptions are
+// returned as *rpc.ReconnectingSubscription, whose Gaps channel reports every
+// interruption during which notifications might have been missed.
+func NewReconnectingClient(c *rpc.Client, config rpc.ReconnectConfig) *Client {
+	return &Client{c: c, reconnect: &config}
 }
 
 func (ec *Client) Close() {
@@ -310,7 +319,7 @@ func (ec *Client) SyncProgress(ctx context.Context) (*DEWH.SyncProgress, error)
 // SubscribeNewHead subscribes to notifications about the current blockchain head
 // on the given channel.
 func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (DEWH.Subscription, error) {
-	return ec.c.EthSubscribe(ctx, ch, "newHeads")
+	return ec.subscribe(ctx, ch, "newHeads")
 }
 
 // State Access
@@ -371,7 +380,20 @@ func (ec *Client) FilterLogs(ctx context.Context, q DEWH.FilterQuery) ([]types.L
 
 // SubscribeFilterLogs subscribes to the results of a streaming filter query.
 func (ec *Client) SubscribeFilterLogs(ctx context.Context, q DEWH.FilterQuery, ch chan<- types.Log) (DEWH.Subscription, error) {
-	return ec.c.EthSubscribe(ctx, ch, "logs", toFilterArg(q))
+	return ec.subscribe(ctx, ch, "logs", toFilterArg(q))
+}
+
+// subscribe registers an eth subscription, which is reinstated after connection
+// failures if the client was created by NewReconnectingClient.
+func (ec *Client) subscribe(ctx context.Context, channel interface{}, args ...interface{}) (DEWH.Subscription, error) {
+	if ec.reconnect == nil {
+		return ec.c.EthSubscribe(ctx, channel, args...)
+	}
+	sub, err := ec.c.ReconnectingSubscribe(ctx, *ec.reconnect, "eth", channel, args...)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
 }
 
 func toFilterArg(q DEWH.FilterQuery) interface{} {
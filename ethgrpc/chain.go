@@ -0,0 +1,342 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethgrpc
+
+import (
+	"context"
+
+	DEWH "github.com/DEWH/go-DEWH"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/rawdb"
+	"github.com/DEWH/go-DEWH/core/state"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/eth/filters"
+	"github.com/DEWH/go-DEWH/internal/ethapi"
+	"github.com/DEWH/go-DEWH/rlp"
+	"github.com/DEWH/go-DEWH/rpc"
+	"github.com/golang/protobuf/proto"
+)
+
+// serviceName is the fully qualified name of the service in chain.proto.
+const serviceName = "ethgrpc.Chain"
+
+// Backend is the chain access needed by the gRPC service, it is implemented by
+// both the full and the light client API backends.
+type Backend interface {
+	ethapi.Backend
+	filters.Backend
+}
+
+// chain implements the methods of the Chain service on top of a backend.
+type chain struct {
+	backend Backend
+	events  *filters.EventSystem
+}
+
+// newChain creates the Chain service and returns the HTTP handler serving it.
+func newChain(backend Backend, lightMode bool) (*chain, *server) {
+	c := &chain{
+		backend: backend,
+		events:  filters.NewEventSystem(backend.EventMux(), backend, lightMode),
+	}
+	return c, &server{
+		service: serviceName,
+		methods: map[string]*method{
+			"BlockNumber":           {request: func() proto.Message { return new(Empty) }, unary: c.blockNumber},
+			"GetHeader":             {request: func() proto.Message { return new(BlockRef) }, unary: c.getHeader},
+			"GetBlock":              {request: func() proto.Message { return new(BlockRef) }, unary: c.getBlock},
+			"GetReceipts":           {request: func() proto.Message { return new(BlockRef) }, unary: c.getReceipts},
+			"GetTransaction":        {request: func() proto.Message { return new(TransactionRequest) }, unary: c.getTransaction},
+			"GetTransactionReceipt": {request: func() proto.Message { return new(TransactionRequest) }, unary: c.getTransactionReceipt},
+			"GetAccount":            {request: func() proto.Message { return new(AccountRequest) }, unary: c.getAccount},
+			"GetStorageAt":          {request: func() proto.Message { return new(StorageRequest) }, unary: c.getStorageAt},
+			"GetLogs":               {request: func() proto.Message { return new(LogFilter) }, unary: c.getLogs},
+			"SendTransaction":       {request: func() proto.Message { return new(SendTransactionRequest) }, unary: c.sendTransaction},
+			"SubscribeNewHeads":     {request: func() proto.Message { return new(Empty) }, stream: c.subscribeNewHeads},
+			"SubscribeLogs":         {request: func() proto.Message { return new(LogFilter) }, stream: c.subscribeLogs},
+		},
+	}
+}
+
+// blockNumber resolves a block reference not selecting a block by hash into the
+// block number understood by the backend.
+func blockNumber(ref *BlockRef) rpc.BlockNumber {
+	switch ref.GetKind() {
+	case BlockRef_PENDING:
+		return rpc.PendingBlockNumber
+	case BlockRef_NUMBER:
+		return rpc.BlockNumber(ref.GetNumber())
+	default:
+		return rpc.LatestBlockNumber
+	}
+}
+
+// header resolves a block reference into a header of the canonical chain. A nil
+// reference selects the latest block, same as an empty one.
+func (c *chain) header(ctx context.Context, ref *BlockRef) (*types.Header, error) {
+	var (
+		header *types.Header
+		err    error
+	)
+	if ref.GetKind() == BlockRef_HASH {
+		header, err = c.backend.HeaderByHash(ctx, common.BytesToHash(ref.GetHash()))
+	} else {
+		header, err = c.backend.HeaderByNumber(ctx, blockNumber(ref))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, statusErrorf(NotFound, "block not found")
+	}
+	return header, nil
+}
+
+// state resolves a block reference into the state at the end of that block. Blocks
+// referenced by hash must be part of the canonical chain.
+func (c *chain) state(ctx context.Context, ref *BlockRef) (*state.StateDB, error) {
+	number := blockNumber(ref)
+	if ref.GetKind() == BlockRef_HASH {
+		header, err := c.header(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		canonical, err := c.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if err != nil {
+			return nil, err
+		}
+		if canonical == nil || canonical.Hash() != header.Hash() {
+			return nil, statusErrorf(FailedPrecondition, "block %x is not canonical", ref.GetHash())
+		}
+		number = rpc.BlockNumber(header.Number.Int64())
+	}
+	statedb, _, err := c.backend.StateAndHeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil {
+		return nil, statusErrorf(NotFound, "state not found")
+	}
+	return statedb, nil
+}
+
+func (c *chain) blockNumber(ctx context.Context, req proto.Message) (proto.Message, error) {
+	header, err := c.header(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockNumberReply{Number: header.Number.Uint64()}, nil
+}
+
+func (c *chain) getHeader(ctx context.Context, req proto.Message) (proto.Message, error) {
+	header, err := c.header(ctx, req.(*BlockRef))
+	if err != nil {
+		return nil, err
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &HeaderReply{Rlp: enc}, nil
+}
+
+func (c *chain) getBlock(ctx context.Context, req proto.Message) (proto.Message, error) {
+	ref := req.(*BlockRef)
+
+	var (
+		block *types.Block
+		err   error
+	)
+	if ref.GetKind() == BlockRef_HASH {
+		block, err = c.backend.GetBlock(ctx, common.BytesToHash(ref.GetHash()))
+	} else {
+		block, err = c.backend.BlockByNumber(ctx, blockNumber(ref))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, statusErrorf(NotFound, "block not found")
+	}
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockReply{Rlp: enc}, nil
+}
+
+func (c *chain) getReceipts(ctx context.Context, req proto.Message) (proto.Message, error) {
+	header, err := c.header(ctx, req.(*BlockRef))
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := c.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	reply := &ReceiptsReply{Receipts: make([]*Receipt, len(receipts))}
+	for i, receipt := range receipts {
+		reply.Receipts[i] = newReceipt(receipt)
+	}
+	return reply, nil
+}
+
+func (c *chain) getTransaction(ctx context.Context, req proto.Message) (proto.Message, error) {
+	hash := common.BytesToHash(req.(*TransactionRequest).Hash)
+
+	reply := new(TransactionReply)
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(c.backend.ChainDb(), hash)
+	if tx != nil {
+		reply.BlockHash, reply.BlockNumber, reply.Index = blockHash.Bytes(), blockNumber, index
+	} else if tx = c.backend.GetPoolTransaction(hash); tx == nil {
+		return nil, statusErrorf(NotFound, "transaction not found")
+	}
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	reply.Rlp = enc
+	return reply, nil
+}
+
+func (c *chain) getTransactionReceipt(ctx context.Context, req proto.Message) (proto.Message, error) {
+	hash := common.BytesToHash(req.(*TransactionRequest).Hash)
+
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(c.backend.ChainDb(), hash)
+	if tx == nil {
+		return nil, statusErrorf(NotFound, "transaction not found")
+	}
+	receipts, err := c.backend.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) <= int(index) {
+		return nil, statusErrorf(NotFound, "receipt not found")
+	}
+	return &ReceiptReply{
+		Receipt:     newReceipt(receipts[index]),
+		BlockHash:   blockHash.Bytes(),
+		BlockNumber: blockNumber,
+		Index:       index,
+	}, nil
+}
+
+func (c *chain) getAccount(ctx context.Context, req proto.Message) (proto.Message, error) {
+	args := req.(*AccountRequest)
+
+	statedb, err := c.state(ctx, args.Block)
+	if err != nil {
+		return nil, err
+	}
+	address := common.BytesToAddress(args.Address)
+	reply := &AccountReply{
+		Balance: statedb.GetBalance(address).Bytes(),
+		Nonce:   statedb.GetNonce(address),
+		Code:    statedb.GetCode(address),
+	}
+	return reply, statedb.Error()
+}
+
+func (c *chain) getStorageAt(ctx context.Context, req proto.Message) (proto.Message, error) {
+	args := req.(*StorageRequest)
+
+	statedb, err := c.state(ctx, args.Block)
+	if err != nil {
+		return nil, err
+	}
+	value := statedb.GetState(common.BytesToAddress(args.Address), common.BytesToHash(args.Key))
+	return &StorageReply{Value: value.Bytes()}, statedb.Error()
+}
+
+func (c *chain) getLogs(ctx context.Context, req proto.Message) (proto.Message, error) {
+	crit := req.(*LogFilter)
+
+	var filter *filters.Filter
+	if len(crit.BlockHash) > 0 {
+		filter = filters.NewBlockFilter(c.backend, common.BytesToHash(crit.BlockHash), toAddresses(crit.Addresses), toTopics(crit.Topics))
+	} else {
+		filter = filters.NewRangeFilter(c.backend, crit.FromBlock, crit.ToBlock, toAddresses(crit.Addresses), toTopics(crit.Topics))
+	}
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &LogsReply{Logs: newLogs(logs)}, nil
+}
+
+func (c *chain) sendTransaction(ctx context.Context, req proto.Message) (proto.Message, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DEWHodeBytes(req.(*SendTransactionRequest).Rlp, tx); err != nil {
+		return nil, statusErrorf(InvalidArgument, "invalid transaction: %v", err)
+	}
+	if err := c.backend.SendTx(ctx, tx); err != nil {
+		return nil, statusErrorf(FailedPrecondition, "%v", err)
+	}
+	return &SendTransactionReply{Hash: tx.Hash().Bytes()}, nil
+}
+
+func (c *chain) subscribeNewHeads(ctx context.Context, req proto.Message, send func(proto.Message) error) error {
+	headers := make(chan *types.Header)
+	sub := c.events.SubscribeNewHeads(headers)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case header := <-headers:
+			enc, err := rlp.EncodeToBytes(header)
+			if err != nil {
+				return err
+			}
+			if err := send(&HeaderReply{Rlp: enc}); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *chain) subscribeLogs(ctx context.Context, req proto.Message, send func(proto.Message) error) error {
+	crit := req.(*LogFilter)
+
+	logs := make(chan []*types.Log)
+	sub, err := c.events.SubscribeLogs(DEWH.FilterQuery{
+		Addresses: toAddresses(crit.Addresses),
+		Topics:    toTopics(crit.Topics),
+	}, logs)
+	if err != nil {
+		return statusErrorf(InvalidArgument, "%v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case batch := <-logs:
+			for _, log := range batch {
+				if err := send(newLog(log)); err != nil {
+					return err
+				}
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,765 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: chain.proto
+
+package ethgrpc
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type BlockRef_Kind int32
+
+const (
+	BlockRef_LATEST  BlockRef_Kind = 0
+	BlockRef_PENDING BlockRef_Kind = 1
+	BlockRef_NUMBER  BlockRef_Kind = 2
+	BlockRef_HASH    BlockRef_Kind = 3
+)
+
+var BlockRef_Kind_name = map[int32]string{
+	0: "LATEST",
+	1: "PENDING",
+	2: "NUMBER",
+	3: "HASH",
+}
+
+var BlockRef_Kind_value = map[string]int32{
+	"LATEST":  0,
+	"PENDING": 1,
+	"NUMBER":  2,
+	"HASH":    3,
+}
+
+func (x BlockRef_Kind) String() string {
+	return proto.EnumName(BlockRef_Kind_name, int32(x))
+}
+
+func (BlockRef_Kind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{1, 0}
+}
+
+type Empty struct {
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{0}
+}
+
+// BlockRef selects a block. The kind determines which of the other fields is
+// used, it defaults to the latest block so that both an empty and an omitted
+// reference select the current head.
+type BlockRef struct {
+	Hash   []byte        `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Number uint64        `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
+	Kind   BlockRef_Kind `protobuf:"varint,3,opt,name=kind,proto3,enum=ethgrpc.BlockRef_Kind" json:"kind,omitempty"`
+}
+
+func (m *BlockRef) Reset()         { *m = BlockRef{} }
+func (m *BlockRef) String() string { return proto.CompactTextString(m) }
+func (*BlockRef) ProtoMessage()    {}
+func (*BlockRef) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{1}
+}
+
+func (m *BlockRef) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *BlockRef) GetNumber() uint64 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+func (m *BlockRef) GetKind() BlockRef_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return BlockRef_LATEST
+}
+
+type BlockNumberReply struct {
+	Number uint64 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+}
+
+func (m *BlockNumberReply) Reset()         { *m = BlockNumberReply{} }
+func (m *BlockNumberReply) String() string { return proto.CompactTextString(m) }
+func (*BlockNumberReply) ProtoMessage()    {}
+func (*BlockNumberReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{2}
+}
+
+func (m *BlockNumberReply) GetNumber() uint64 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+type HeaderReply struct {
+	Rlp []byte `protobuf:"bytes,1,opt,name=rlp,proto3" json:"rlp,omitempty"`
+}
+
+func (m *HeaderReply) Reset()         { *m = HeaderReply{} }
+func (m *HeaderReply) String() string { return proto.CompactTextString(m) }
+func (*HeaderReply) ProtoMessage()    {}
+func (*HeaderReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{3}
+}
+
+func (m *HeaderReply) GetRlp() []byte {
+	if m != nil {
+		return m.Rlp
+	}
+	return nil
+}
+
+type BlockReply struct {
+	Rlp []byte `protobuf:"bytes,1,opt,name=rlp,proto3" json:"rlp,omitempty"`
+}
+
+func (m *BlockReply) Reset()         { *m = BlockReply{} }
+func (m *BlockReply) String() string { return proto.CompactTextString(m) }
+func (*BlockReply) ProtoMessage()    {}
+func (*BlockReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{4}
+}
+
+func (m *BlockReply) GetRlp() []byte {
+	if m != nil {
+		return m.Rlp
+	}
+	return nil
+}
+
+type Log struct {
+	Address     []byte   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Topics      [][]byte `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
+	Data        []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	BlockNumber uint64   `protobuf:"varint,4,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	TxHash      []byte   `protobuf:"bytes,5,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	TxIndex     uint32   `protobuf:"varint,6,opt,name=tx_index,json=txIndex,proto3" json:"tx_index,omitempty"`
+	BlockHash   []byte   `protobuf:"bytes,7,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	Index       uint32   `protobuf:"varint,8,opt,name=index,proto3" json:"index,omitempty"`
+	Removed     bool     `protobuf:"varint,9,opt,name=removed,proto3" json:"removed,omitempty"`
+}
+
+func (m *Log) Reset()         { *m = Log{} }
+func (m *Log) String() string { return proto.CompactTextString(m) }
+func (*Log) ProtoMessage()    {}
+func (*Log) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{5}
+}
+
+func (m *Log) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *Log) GetTopics() [][]byte {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+func (m *Log) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Log) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *Log) GetTxHash() []byte {
+	if m != nil {
+		return m.TxHash
+	}
+	return nil
+}
+
+func (m *Log) GetTxIndex() uint32 {
+	if m != nil {
+		return m.TxIndex
+	}
+	return 0
+}
+
+func (m *Log) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *Log) GetIndex() uint32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *Log) GetRemoved() bool {
+	if m != nil {
+		return m.Removed
+	}
+	return false
+}
+
+type Receipt struct {
+	PostState         []byte `protobuf:"bytes,1,opt,name=post_state,json=postState,proto3" json:"post_state,omitempty"`
+	Status            uint64 `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	CumulativeGasUsed uint64 `protobuf:"varint,3,opt,name=cumulative_gas_used,json=cumulativeGasUsed,proto3" json:"cumulative_gas_used,omitempty"`
+	Bloom             []byte `protobuf:"bytes,4,opt,name=bloom,proto3" json:"bloom,omitempty"`
+	Logs              []*Log `protobuf:"bytes,5,rep,name=logs,proto3" json:"logs,omitempty"`
+	TxHash            []byte `protobuf:"bytes,6,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	ContractAddress   []byte `protobuf:"bytes,7,opt,name=contract_address,json=contractAddress,proto3" json:"contract_address,omitempty"`
+	GasUsed           uint64 `protobuf:"varint,8,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+}
+
+func (m *Receipt) Reset()         { *m = Receipt{} }
+func (m *Receipt) String() string { return proto.CompactTextString(m) }
+func (*Receipt) ProtoMessage()    {}
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{6}
+}
+
+func (m *Receipt) GetPostState() []byte {
+	if m != nil {
+		return m.PostState
+	}
+	return nil
+}
+
+func (m *Receipt) GetStatus() uint64 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *Receipt) GetCumulativeGasUsed() uint64 {
+	if m != nil {
+		return m.CumulativeGasUsed
+	}
+	return 0
+}
+
+func (m *Receipt) GetBloom() []byte {
+	if m != nil {
+		return m.Bloom
+	}
+	return nil
+}
+
+func (m *Receipt) GetLogs() []*Log {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+func (m *Receipt) GetTxHash() []byte {
+	if m != nil {
+		return m.TxHash
+	}
+	return nil
+}
+
+func (m *Receipt) GetContractAddress() []byte {
+	if m != nil {
+		return m.ContractAddress
+	}
+	return nil
+}
+
+func (m *Receipt) GetGasUsed() uint64 {
+	if m != nil {
+		return m.GasUsed
+	}
+	return 0
+}
+
+type ReceiptsReply struct {
+	Receipts []*Receipt `protobuf:"bytes,1,rep,name=receipts,proto3" json:"receipts,omitempty"`
+}
+
+func (m *ReceiptsReply) Reset()         { *m = ReceiptsReply{} }
+func (m *ReceiptsReply) String() string { return proto.CompactTextString(m) }
+func (*ReceiptsReply) ProtoMessage()    {}
+func (*ReceiptsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{7}
+}
+
+func (m *ReceiptsReply) GetReceipts() []*Receipt {
+	if m != nil {
+		return m.Receipts
+	}
+	return nil
+}
+
+type TransactionRequest struct {
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *TransactionRequest) Reset()         { *m = TransactionRequest{} }
+func (m *TransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*TransactionRequest) ProtoMessage()    {}
+func (*TransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{8}
+}
+
+func (m *TransactionRequest) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+// TransactionReply carries a transaction and its position in the chain. Pending
+// transactions have no block hash set.
+type TransactionReply struct {
+	Rlp         []byte `protobuf:"bytes,1,opt,name=rlp,proto3" json:"rlp,omitempty"`
+	BlockHash   []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Index       uint64 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (m *TransactionReply) Reset()         { *m = TransactionReply{} }
+func (m *TransactionReply) String() string { return proto.CompactTextString(m) }
+func (*TransactionReply) ProtoMessage()    {}
+func (*TransactionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{9}
+}
+
+func (m *TransactionReply) GetRlp() []byte {
+	if m != nil {
+		return m.Rlp
+	}
+	return nil
+}
+
+func (m *TransactionReply) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *TransactionReply) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *TransactionReply) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+type ReceiptReply struct {
+	Receipt     *Receipt `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	BlockHash   []byte   `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber uint64   `protobuf:"varint,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Index       uint64   `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (m *ReceiptReply) Reset()         { *m = ReceiptReply{} }
+func (m *ReceiptReply) String() string { return proto.CompactTextString(m) }
+func (*ReceiptReply) ProtoMessage()    {}
+func (*ReceiptReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{10}
+}
+
+func (m *ReceiptReply) GetReceipt() *Receipt {
+	if m != nil {
+		return m.Receipt
+	}
+	return nil
+}
+
+func (m *ReceiptReply) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *ReceiptReply) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *ReceiptReply) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+type AccountRequest struct {
+	Address []byte    `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Block   *BlockRef `protobuf:"bytes,2,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (m *AccountRequest) Reset()         { *m = AccountRequest{} }
+func (m *AccountRequest) String() string { return proto.CompactTextString(m) }
+func (*AccountRequest) ProtoMessage()    {}
+func (*AccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{11}
+}
+
+func (m *AccountRequest) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *AccountRequest) GetBlock() *BlockRef {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type AccountReply struct {
+	Balance []byte `protobuf:"bytes,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	Nonce   uint64 `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Code    []byte `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (m *AccountReply) Reset()         { *m = AccountReply{} }
+func (m *AccountReply) String() string { return proto.CompactTextString(m) }
+func (*AccountReply) ProtoMessage()    {}
+func (*AccountReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{12}
+}
+
+func (m *AccountReply) GetBalance() []byte {
+	if m != nil {
+		return m.Balance
+	}
+	return nil
+}
+
+func (m *AccountReply) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *AccountReply) GetCode() []byte {
+	if m != nil {
+		return m.Code
+	}
+	return nil
+}
+
+type StorageRequest struct {
+	Address []byte    `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Key     []byte    `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Block   *BlockRef `protobuf:"bytes,3,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (m *StorageRequest) Reset()         { *m = StorageRequest{} }
+func (m *StorageRequest) String() string { return proto.CompactTextString(m) }
+func (*StorageRequest) ProtoMessage()    {}
+func (*StorageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{13}
+}
+
+func (m *StorageRequest) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *StorageRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *StorageRequest) GetBlock() *BlockRef {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type StorageReply struct {
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *StorageReply) Reset()         { *m = StorageReply{} }
+func (m *StorageReply) String() string { return proto.CompactTextString(m) }
+func (*StorageReply) ProtoMessage()    {}
+func (*StorageReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{14}
+}
+
+func (m *StorageReply) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// Topics lists the accepted alternatives at a single topic position, an empty
+// list matches any topic.
+type Topics struct {
+	Topics [][]byte `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+}
+
+func (m *Topics) Reset()         { *m = Topics{} }
+func (m *Topics) String() string { return proto.CompactTextString(m) }
+func (*Topics) ProtoMessage()    {}
+func (*Topics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{15}
+}
+
+func (m *Topics) GetTopics() [][]byte {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+// LogFilter selects logs of either a single block (block_hash) or of the block
+// range between from_block and to_block, where -1 denotes the latest block.
+// The range is ignored by log subscriptions.
+type LogFilter struct {
+	BlockHash []byte    `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	FromBlock int64     `protobuf:"varint,2,opt,name=from_block,json=fromBlock,proto3" json:"from_block,omitempty"`
+	ToBlock   int64     `protobuf:"varint,3,opt,name=to_block,json=toBlock,proto3" json:"to_block,omitempty"`
+	Addresses [][]byte  `protobuf:"bytes,4,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Topics    []*Topics `protobuf:"bytes,5,rep,name=topics,proto3" json:"topics,omitempty"`
+}
+
+func (m *LogFilter) Reset()         { *m = LogFilter{} }
+func (m *LogFilter) String() string { return proto.CompactTextString(m) }
+func (*LogFilter) ProtoMessage()    {}
+func (*LogFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{16}
+}
+
+func (m *LogFilter) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *LogFilter) GetFromBlock() int64 {
+	if m != nil {
+		return m.FromBlock
+	}
+	return 0
+}
+
+func (m *LogFilter) GetToBlock() int64 {
+	if m != nil {
+		return m.ToBlock
+	}
+	return 0
+}
+
+func (m *LogFilter) GetAddresses() [][]byte {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+func (m *LogFilter) GetTopics() []*Topics {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+type LogsReply struct {
+	Logs []*Log `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+}
+
+func (m *LogsReply) Reset()         { *m = LogsReply{} }
+func (m *LogsReply) String() string { return proto.CompactTextString(m) }
+func (*LogsReply) ProtoMessage()    {}
+func (*LogsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{17}
+}
+
+func (m *LogsReply) GetLogs() []*Log {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+type SendTransactionRequest struct {
+	Rlp []byte `protobuf:"bytes,1,opt,name=rlp,proto3" json:"rlp,omitempty"`
+}
+
+func (m *SendTransactionRequest) Reset()         { *m = SendTransactionRequest{} }
+func (m *SendTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*SendTransactionRequest) ProtoMessage()    {}
+func (*SendTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{18}
+}
+
+func (m *SendTransactionRequest) GetRlp() []byte {
+	if m != nil {
+		return m.Rlp
+	}
+	return nil
+}
+
+type SendTransactionReply struct {
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *SendTransactionReply) Reset()         { *m = SendTransactionReply{} }
+func (m *SendTransactionReply) String() string { return proto.CompactTextString(m) }
+func (*SendTransactionReply) ProtoMessage()    {}
+func (*SendTransactionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d4d91b2d037e7a44, []int{19}
+}
+
+func (m *SendTransactionReply) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("ethgrpc.BlockRef_Kind", BlockRef_Kind_name, BlockRef_Kind_value)
+	proto.RegisterType((*Empty)(nil), "ethgrpc.Empty")
+	proto.RegisterType((*BlockRef)(nil), "ethgrpc.BlockRef")
+	proto.RegisterType((*BlockNumberReply)(nil), "ethgrpc.BlockNumberReply")
+	proto.RegisterType((*HeaderReply)(nil), "ethgrpc.HeaderReply")
+	proto.RegisterType((*BlockReply)(nil), "ethgrpc.BlockReply")
+	proto.RegisterType((*Log)(nil), "ethgrpc.Log")
+	proto.RegisterType((*Receipt)(nil), "ethgrpc.Receipt")
+	proto.RegisterType((*ReceiptsReply)(nil), "ethgrpc.ReceiptsReply")
+	proto.RegisterType((*TransactionRequest)(nil), "ethgrpc.TransactionRequest")
+	proto.RegisterType((*TransactionReply)(nil), "ethgrpc.TransactionReply")
+	proto.RegisterType((*ReceiptReply)(nil), "ethgrpc.ReceiptReply")
+	proto.RegisterType((*AccountRequest)(nil), "ethgrpc.AccountRequest")
+	proto.RegisterType((*AccountReply)(nil), "ethgrpc.AccountReply")
+	proto.RegisterType((*StorageRequest)(nil), "ethgrpc.StorageRequest")
+	proto.RegisterType((*StorageReply)(nil), "ethgrpc.StorageReply")
+	proto.RegisterType((*Topics)(nil), "ethgrpc.Topics")
+	proto.RegisterType((*LogFilter)(nil), "ethgrpc.LogFilter")
+	proto.RegisterType((*LogsReply)(nil), "ethgrpc.LogsReply")
+	proto.RegisterType((*SendTransactionRequest)(nil), "ethgrpc.SendTransactionRequest")
+	proto.RegisterType((*SendTransactionReply)(nil), "ethgrpc.SendTransactionReply")
+}
+
+func init() { proto.RegisterFile("chain.proto", fileDescriptor_d4d91b2d037e7a44) }
+
+var fileDescriptor_d4d91b2d037e7a44 = []byte{
+	// 1025 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0x5f, 0x6f, 0xe3, 0x44,
+	0x10, 0xc7, 0x75, 0x12, 0x27, 0x13, 0x37, 0x75, 0xf7, 0xda, 0x9e, 0x1b, 0x28, 0x17, 0x2c, 0xa4,
+	0x0b, 0x11, 0x44, 0x47, 0x0e, 0xa4, 0x93, 0xf8, 0x23, 0xe5, 0xa0, 0x24, 0xa7, 0x2b, 0x11, 0x6c,
+	0x7a, 0xcf, 0x91, 0x63, 0xef, 0xa5, 0x56, 0x13, 0x6f, 0xb0, 0x37, 0x25, 0x95, 0x78, 0xe7, 0x9d,
+	0x37, 0x3e, 0x03, 0x9f, 0x0e, 0x3e, 0x01, 0xda, 0x3f, 0xfe, 0x93, 0xd8, 0xe5, 0x9e, 0x78, 0xf3,
+	0xcc, 0xec, 0xcc, 0xfe, 0x66, 0xe6, 0x37, 0xb3, 0x86, 0xa6, 0x77, 0xe3, 0x06, 0x61, 0x7f, 0x1d,
+	0x51, 0x46, 0x91, 0x41, 0xd8, 0xcd, 0x22, 0x5a, 0x7b, 0x8e, 0x01, 0xd5, 0xcb, 0xd5, 0x9a, 0xdd,
+	0x3b, 0x7f, 0x6a, 0x50, 0x7f, 0xb9, 0xa4, 0xde, 0x2d, 0x26, 0x6f, 0x11, 0x82, 0xca, 0x8d, 0x1b,
+	0xdf, 0xd8, 0x5a, 0x47, 0xeb, 0x9a, 0x58, 0x7c, 0xa3, 0x33, 0xa8, 0x85, 0x9b, 0xd5, 0x9c, 0x44,
+	0xf6, 0x41, 0x47, 0xeb, 0x56, 0xb0, 0x92, 0x50, 0x0f, 0x2a, 0xb7, 0x41, 0xe8, 0xdb, 0x7a, 0x47,
+	0xeb, 0xb6, 0x06, 0x67, 0x7d, 0x15, 0xb9, 0x9f, 0x04, 0xeb, 0xbf, 0x0e, 0x42, 0x1f, 0x8b, 0x33,
+	0xce, 0x97, 0x50, 0xe1, 0x12, 0x02, 0xa8, 0x5d, 0x0d, 0xaf, 0x2f, 0xa7, 0xd7, 0xd6, 0x7b, 0xa8,
+	0x09, 0xc6, 0x4f, 0x97, 0x93, 0xef, 0x5f, 0x4d, 0x46, 0x96, 0xc6, 0x0d, 0x93, 0x37, 0x3f, 0xbe,
+	0xbc, 0xc4, 0xd6, 0x01, 0xaa, 0x43, 0x65, 0x3c, 0x9c, 0x8e, 0x2d, 0xdd, 0xe9, 0x81, 0x25, 0xa2,
+	0x4d, 0xc4, 0x8d, 0x98, 0xac, 0x97, 0xf7, 0x39, 0x38, 0x5a, 0x1e, 0x8e, 0xf3, 0x04, 0x9a, 0x63,
+	0xe2, 0xfa, 0xc9, 0x31, 0x0b, 0xf4, 0x68, 0xb9, 0x56, 0x89, 0xf0, 0x4f, 0xe7, 0x43, 0x00, 0x05,
+	0xad, 0xdc, 0xfe, 0xb7, 0x06, 0xfa, 0x15, 0x5d, 0x20, 0x1b, 0x0c, 0xd7, 0xf7, 0x23, 0x12, 0xc7,
+	0xca, 0x9a, 0x88, 0xfc, 0x6a, 0x46, 0xd7, 0x81, 0x17, 0xdb, 0x07, 0x1d, 0xbd, 0x6b, 0x62, 0x25,
+	0xf1, 0xaa, 0xf9, 0x2e, 0x73, 0x45, 0x25, 0x4c, 0x2c, 0xbe, 0xd1, 0x47, 0x60, 0xce, 0xf9, 0x6d,
+	0x33, 0x05, 0xb6, 0x22, 0xc0, 0x36, 0xe7, 0x59, 0x3a, 0xe8, 0x31, 0x18, 0x6c, 0x3b, 0x13, 0xf5,
+	0xae, 0x0a, 0xcf, 0x1a, 0xdb, 0x8e, 0x79, 0xc5, 0xcf, 0xa1, 0xce, 0xb6, 0xb3, 0x20, 0xf4, 0xc9,
+	0xd6, 0xae, 0x75, 0xb4, 0xee, 0x21, 0x36, 0xd8, 0xf6, 0x15, 0x17, 0xd1, 0x05, 0x80, 0x0c, 0x2b,
+	0xdc, 0x0c, 0xe1, 0xd6, 0x10, 0x1a, 0xe1, 0x79, 0x02, 0x55, 0xe9, 0x56, 0x17, 0x6e, 0x52, 0xe0,
+	0x19, 0x45, 0x64, 0x45, 0xef, 0x88, 0x6f, 0x37, 0x3a, 0x5a, 0xb7, 0x8e, 0x13, 0xd1, 0xf9, 0xfd,
+	0x00, 0x0c, 0x4c, 0x3c, 0x12, 0xac, 0x19, 0x0f, 0xbd, 0xa6, 0x31, 0x9b, 0xc5, 0xcc, 0x65, 0x44,
+	0xa5, 0xde, 0xe0, 0x9a, 0x29, 0x57, 0xf0, 0xe4, 0xb9, 0x65, 0x13, 0x27, 0x34, 0x90, 0x12, 0xea,
+	0xc3, 0x23, 0x6f, 0xb3, 0xda, 0x2c, 0x5d, 0x16, 0xdc, 0x91, 0xd9, 0xc2, 0x8d, 0x67, 0x9b, 0x98,
+	0x48, 0x56, 0x54, 0xf0, 0x71, 0x66, 0x1a, 0xb9, 0xf1, 0x9b, 0x98, 0xf8, 0x1c, 0xe2, 0x7c, 0x49,
+	0xe9, 0x4a, 0x54, 0xc4, 0xc4, 0x52, 0x40, 0x1d, 0xa8, 0x2c, 0xe9, 0x22, 0xb6, 0xab, 0x1d, 0xbd,
+	0xdb, 0x1c, 0x98, 0x29, 0x99, 0xae, 0xe8, 0x02, 0x0b, 0x4b, 0xbe, 0x5a, 0xb5, 0x9d, 0x6a, 0x7d,
+	0x02, 0x96, 0x47, 0x43, 0x16, 0xb9, 0x1e, 0x9b, 0x25, 0x8d, 0x93, 0x85, 0x39, 0x4a, 0xf4, 0x43,
+	0xd5, 0xc0, 0x73, 0xa8, 0xa7, 0x00, 0xeb, 0x02, 0xa0, 0xb1, 0x90, 0xb0, 0x9c, 0x6f, 0xe0, 0x50,
+	0x15, 0x22, 0x96, 0x04, 0xf9, 0x14, 0xea, 0x91, 0x52, 0xd8, 0x9a, 0x40, 0x65, 0xa5, 0xa8, 0xd4,
+	0x49, 0x9c, 0x9e, 0x70, 0xba, 0x80, 0xae, 0x23, 0x37, 0x8c, 0x5d, 0x8f, 0x05, 0x34, 0xc4, 0xe4,
+	0x97, 0x0d, 0x89, 0x59, 0xd9, 0x38, 0x39, 0xbf, 0x81, 0xb5, 0x73, 0xb2, 0x94, 0x8c, 0x7b, 0x7d,
+	0x3e, 0xd8, 0xef, 0xf3, 0x3e, 0xbb, 0xf4, 0x22, 0xbb, 0x52, 0x2a, 0x48, 0xe6, 0x49, 0xc1, 0xf9,
+	0x43, 0x03, 0x33, 0x41, 0x2f, 0xae, 0xee, 0x71, 0x6e, 0x08, 0x59, 0x5c, 0x5f, 0x96, 0x65, 0x72,
+	0xe0, 0x7f, 0x03, 0x35, 0x85, 0xd6, 0xd0, 0xf3, 0xe8, 0x26, 0x64, 0x49, 0xe1, 0x1e, 0x9e, 0xc1,
+	0xa7, 0x82, 0x3e, 0xde, 0xad, 0xb8, 0xbe, 0x39, 0x38, 0x2e, 0xac, 0x1d, 0x2c, 0xed, 0x0e, 0x06,
+	0x33, 0x0d, 0xca, 0x13, 0xb5, 0xc1, 0x98, 0xbb, 0x4b, 0x37, 0xf4, 0x12, 0x6e, 0x27, 0x22, 0x07,
+	0x15, 0x52, 0xae, 0x97, 0xc4, 0x96, 0x02, 0xef, 0x9d, 0x47, 0x7d, 0x92, 0x0c, 0x35, 0xff, 0x76,
+	0x08, 0xb4, 0xa6, 0x8c, 0x46, 0xee, 0x82, 0xbc, 0x1b, 0xa8, 0x05, 0xfa, 0x2d, 0xb9, 0x57, 0x55,
+	0xe2, 0x9f, 0x19, 0x74, 0xfd, 0x1d, 0xd0, 0x3f, 0x06, 0x33, 0xbd, 0x86, 0x43, 0x3f, 0x81, 0xea,
+	0x9d, 0xbb, 0xdc, 0x24, 0xc0, 0xa5, 0xe0, 0x74, 0xa0, 0x76, 0x2d, 0xf7, 0x4f, 0xb6, 0x97, 0xb4,
+	0xfc, 0x5e, 0x72, 0xfe, 0xd2, 0xa0, 0x71, 0x45, 0x17, 0x3f, 0x04, 0x4b, 0x46, 0xa2, 0xbd, 0xee,
+	0x69, 0xfb, 0xdd, 0xbb, 0x00, 0x78, 0x1b, 0xd1, 0xd5, 0x2c, 0xab, 0xae, 0x8e, 0x1b, 0x5c, 0x23,
+	0xd0, 0x89, 0x9d, 0x44, 0x67, 0x19, 0x7e, 0x1d, 0x1b, 0x8c, 0x4a, 0xd3, 0x07, 0xd0, 0x50, 0x49,
+	0x93, 0xd8, 0xae, 0x08, 0x04, 0x99, 0x02, 0x3d, 0x4d, 0xc1, 0xc9, 0xd9, 0x3e, 0x4a, 0xd3, 0x96,
+	0xe8, 0x53, 0xb4, 0x9f, 0x09, 0xb0, 0x6a, 0xfa, 0x92, 0x7d, 0xa0, 0x3d, 0xb4, 0x0f, 0x9c, 0x1e,
+	0x9c, 0x4d, 0x49, 0xe8, 0x97, 0x4c, 0x5d, 0x71, 0xb5, 0xf7, 0xe0, 0xa4, 0x70, 0x96, 0xdf, 0x52,
+	0x32, 0x9f, 0x83, 0x7f, 0xaa, 0x50, 0xfd, 0x8e, 0xbf, 0x98, 0xe8, 0x05, 0x34, 0x73, 0xaf, 0x0f,
+	0x6a, 0xa5, 0x20, 0xc4, 0xc3, 0xd9, 0x3e, 0xdf, 0xed, 0x5f, 0xfe, 0x8d, 0xfa, 0x02, 0x1a, 0x23,
+	0xc2, 0xe4, 0x73, 0x84, 0x8a, 0x7d, 0x6e, 0x9f, 0xa4, 0xaa, 0xfc, 0x93, 0x35, 0x80, 0xfa, 0x88,
+	0x30, 0x59, 0xd3, 0x12, 0xa7, 0x47, 0xfb, 0x2a, 0xee, 0xf3, 0x02, 0x9a, 0x23, 0xc2, 0x92, 0xcd,
+	0x55, 0xe6, 0x76, 0xb6, 0x3f, 0xcf, 0xaa, 0xc2, 0x63, 0x68, 0x8d, 0x08, 0xcb, 0x95, 0x04, 0xbd,
+	0x9f, 0x75, 0xa6, 0x50, 0xd4, 0xf6, 0x79, 0xb9, 0x91, 0x47, 0x7a, 0x0d, 0xa7, 0xbb, 0x91, 0x92,
+	0x17, 0xe5, 0x3f, 0x03, 0x9e, 0x16, 0xf6, 0x8c, 0x08, 0xf6, 0x35, 0xc0, 0x88, 0x30, 0x35, 0xb9,
+	0xe8, 0x71, 0x7a, 0x68, 0x77, 0x41, 0xb4, 0x4f, 0x8b, 0x06, 0xee, 0xfd, 0x2d, 0x98, 0x23, 0xc2,
+	0xd4, 0xf0, 0x0c, 0xf3, 0xfe, 0xbb, 0x73, 0xdb, 0x3e, 0x2d, 0x1a, 0xb8, 0xff, 0xe7, 0x60, 0x8c,
+	0x08, 0xe3, 0x34, 0x44, 0x28, 0xcf, 0x39, 0x39, 0x42, 0xed, 0x1d, 0x9d, 0xaa, 0xe3, 0xcf, 0x70,
+	0xb4, 0xc7, 0x2d, 0xf4, 0x24, 0x0b, 0x5e, 0xca, 0xd0, 0xf6, 0xc5, 0xc3, 0x07, 0x78, 0xc8, 0xaf,
+	0xe0, 0x78, 0xba, 0x99, 0xc7, 0x5e, 0x14, 0xcc, 0xc9, 0x84, 0xfc, 0xca, 0x39, 0x12, 0x17, 0xe8,
+	0x57, 0xca, 0xa1, 0x67, 0x1a, 0x7a, 0x0e, 0x87, 0xa9, 0xf3, 0x83, 0x89, 0xec, 0x0c, 0xd4, 0x33,
+	0x6d, 0x5e, 0x13, 0x7f, 0x87, 0xcf, 0xff, 0x1d, 0x00, 0x96, 0xff, 0x80, 0x46, 0x2c, 0x0a, 0x00,
+	0x00,
+}
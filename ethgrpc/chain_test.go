@@ -0,0 +1,439 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethgrpc
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	DEWH "github.com/DEWH/go-DEWH"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/core/bloombits"
+	"github.com/DEWH/go-DEWH/core/state"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/core/vm"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/internal/ethapi"
+	"github.com/DEWH/go-DEWH/params"
+	"github.com/DEWH/go-DEWH/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(1000000000000000000)
+
+	// testLogger is a contract emitting a log with testTopic on every call.
+	testLogger = common.HexToAddress("0x1000")
+	testTopic  = common.HexToHash("0x1234")
+	testCode   = append(append([]byte{byte(vm.PUSH32)}, testTopic.Bytes()...), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG1))
+)
+
+// testBackend serves the chain service from a local blockchain. Methods not used
+// by the service are left to the embedded nil backend.
+type testBackend struct {
+	ethapi.Backend
+
+	db    ethdb.Database
+	chain *core.BlockChain
+	mux   *event.TypeMux
+	pool  map[common.Hash]*types.Transaction
+
+	txFeed     event.Feed
+	rmLogsFeed event.Feed
+	logsFeed   event.Feed
+	chainFeed  event.Feed
+}
+
+// newTestBackend creates a chain of n blocks, each calling the logger contract
+// once, along with a side chain block which is not canonical.
+func newTestBackend(t *testing.T, n int) (*testBackend, *types.Block) {
+	var (
+		db    = ethdb.NewMemDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				testAddr:   {Balance: testBalance},
+				testLogger: {Code: testCode, Balance: big.NewInt(0)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, n, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(testAddr), testLogger, big.NewInt(1), 100000, big.NewInt(1), nil), signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	side, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, err := chain.InsertChain(side); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	return &testBackend{
+		db:    db,
+		chain: chain,
+		mux:   new(event.TypeMux),
+		pool:  make(map[common.Hash]*types.Transaction),
+	}, side[0]
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
+func (b *testBackend) ChainDb() ethdb.Database          { return b.db }
+func (b *testBackend) EventMux() *event.TypeMux         { return b.mux }
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.chain.CurrentBlock().Header(), nil
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.chain.CurrentBlock(), nil
+	}
+	return b.chain.GetBlockByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	header, _ := b.HeaderByNumber(ctx, number)
+	if header == nil {
+		return nil, nil, nil
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	receipts := b.chain.GetReceiptsByHash(hash)
+
+	logs := make([][]*types.Log, len(receipts))
+	for i, receipt := range receipts {
+		logs[i] = receipt.Logs
+	}
+	return logs, nil
+}
+
+func (b *testBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	b.pool[tx.Hash()] = tx
+	return nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	return b.pool[hash]
+}
+
+func (b *testBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	return b.txFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return b.rmLogsFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return b.logsFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return b.chainFeed.Subscribe(ch)
+}
+
+func (b *testBackend) BloomStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, 0
+}
+
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {}
+
+// newTestChain starts a chain service on top of the test backend and returns a
+// client connected to it.
+func newTestChain(t *testing.T, backend *testBackend) (*httptest.Server, *Client) {
+	_, handler := newChain(backend, false)
+	httpsrv := serveHTTP2(handler)
+
+	client, err := DialWithClient(httpsrv.URL, httpsrv.Client())
+	if err != nil {
+		httpsrv.Close()
+		t.Fatalf("failed to dial test server: %v", err)
+	}
+	return httpsrv, client
+}
+
+// Tests that block references resolve to the same blocks regardless of whether
+// they are omitted, empty or explicit.
+func TestBlockRefResolution(t *testing.T) {
+	backend, side := newTestBackend(t, 4)
+	defer backend.chain.Stop()
+
+	httpsrv, client := newTestChain(t, backend)
+	defer httpsrv.Close()
+
+	var (
+		genesis = backend.chain.Genesis()
+		head    = backend.chain.CurrentBlock()
+	)
+	tests := []struct {
+		ref  *BlockRef
+		want common.Hash
+		code Code
+	}{
+		{&BlockRef{}, head.Hash(), OK},
+		{&BlockRef{Kind: BlockRef_LATEST, Number: 1}, head.Hash(), OK},
+		{&BlockRef{Kind: BlockRef_PENDING}, head.Hash(), OK},
+		{&BlockRef{Kind: BlockRef_NUMBER}, genesis.Hash(), OK},
+		{&BlockRef{Kind: BlockRef_NUMBER, Number: 2}, backend.chain.GetBlockByNumber(2).Hash(), OK},
+		{&BlockRef{Kind: BlockRef_NUMBER, Number: 5}, common.Hash{}, NotFound},
+		{&BlockRef{Kind: BlockRef_HASH, Hash: side.Hash().Bytes()}, side.Hash(), OK},
+		{&BlockRef{Kind: BlockRef_HASH, Hash: []byte{0x01}}, common.Hash{}, NotFound},
+	}
+	for i, tt := range tests {
+		header := new(HeaderReply)
+		err := client.conn.invoke(context.Background(), "GetHeader", tt.ref, header)
+		if code := toStatus(err).Code; code != tt.code {
+			t.Errorf("test %d: header status mismatch: have %d, want %d", i, code, tt.code)
+			continue
+		}
+		block := new(BlockReply)
+		err = client.conn.invoke(context.Background(), "GetBlock", tt.ref, block)
+		if code := toStatus(err).Code; code != tt.code {
+			t.Errorf("test %d: block status mismatch: have %d, want %d", i, code, tt.code)
+			continue
+		}
+		if tt.code != OK {
+			continue
+		}
+		if hash := crypto.Keccak256Hash(header.Rlp); hash != tt.want {
+			t.Errorf("test %d: header mismatch: have %x, want %x", i, hash, tt.want)
+		}
+	}
+	// The typed client must resolve the same way
+	if number, err := client.BlockNumber(context.Background()); err != nil || number != head.NumberU64() {
+		t.Errorf("block number mismatch: have %d (%v), want %d", number, err, head.NumberU64())
+	}
+	if block, err := client.BlockByNumber(context.Background(), nil); err != nil || block.Hash() != head.Hash() {
+		t.Errorf("latest block mismatch: have %v (%v), want %x", block, err, head.Hash())
+	}
+	if block, err := client.BlockByNumber(context.Background(), common.Big0); err != nil || block.Hash() != genesis.Hash() {
+		t.Errorf("genesis block mismatch: have %v (%v), want %x", block, err, genesis.Hash())
+	}
+	if _, err := client.HeaderByHash(context.Background(), common.Hash{0x01}); err != DEWH.NotFound {
+		t.Errorf("missing header error mismatch: have %v, want %v", err, DEWH.NotFound)
+	}
+}
+
+// Tests that account state is resolved at the referenced block and that only
+// canonical blocks are accepted by hash.
+func TestStateResolution(t *testing.T) {
+	backend, side := newTestBackend(t, 4)
+	defer backend.chain.Stop()
+
+	httpsrv, client := newTestChain(t, backend)
+	defer httpsrv.Close()
+
+	for number := uint64(0); number <= 4; number++ {
+		nonce, err := client.NonceAt(context.Background(), testAddr, new(big.Int).SetUint64(number))
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve nonce: %v", number, err)
+		}
+		if nonce != number {
+			t.Errorf("block %d: nonce mismatch: have %d, want %d", number, nonce, number)
+		}
+	}
+	if nonce, err := client.NonceAt(context.Background(), testAddr, nil); err != nil || nonce != 4 {
+		t.Errorf("latest nonce mismatch: have %d (%v), want 4", nonce, err)
+	}
+	if code, err := client.CodeAt(context.Background(), testLogger, nil); err != nil || string(code) != string(testCode) {
+		t.Errorf("code mismatch: have %x (%v), want %x", code, err, testCode)
+	}
+	// Canonical blocks are accepted by hash, side chain ones are rejected
+	reply := new(AccountReply)
+	req := &AccountRequest{Address: testAddr.Bytes(), Block: hashBlockRef(backend.chain.GetBlockByNumber(1).Hash())}
+	if err := client.conn.invoke(context.Background(), "GetAccount", req, reply); err != nil {
+		t.Fatalf("failed to retrieve account by canonical hash: %v", err)
+	}
+	if reply.Nonce != 1 {
+		t.Errorf("nonce mismatch by hash: have %d, want 1", reply.Nonce)
+	}
+	req.Block = hashBlockRef(side.Hash())
+	if err := client.conn.invoke(context.Background(), "GetAccount", req, reply); toStatus(err).Code != FailedPrecondition {
+		t.Errorf("side chain state error mismatch: have %v, want code %d", err, FailedPrecondition)
+	}
+}
+
+// Tests that transactions, receipts and logs are retrievable, both from the
+// chain and from the pool.
+func TestTransactionsAndLogs(t *testing.T) {
+	backend, _ := newTestBackend(t, 4)
+	defer backend.chain.Stop()
+
+	httpsrv, client := newTestChain(t, backend)
+	defer httpsrv.Close()
+
+	block := backend.chain.GetBlockByNumber(2)
+	blockHash := block.Hash()
+	receipts, err := client.BlockReceipts(context.Background(), block.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve receipts: %v", err)
+	}
+	if len(receipts) != 1 || len(receipts[0].Logs) != 1 || receipts[0].Logs[0].Topics[0] != testTopic {
+		t.Fatalf("receipts mismatch: have %v", receipts)
+	}
+	tx := block.Transactions()[0]
+	if have, pending, err := client.TransactionByHash(context.Background(), tx.Hash()); err != nil || pending || have.Hash() != tx.Hash() {
+		t.Errorf("transaction mismatch: have %v, pending %v (%v)", have, pending, err)
+	}
+	receipt, err := client.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve receipt: %v", err)
+	}
+	if receipt.TxHash != tx.Hash() || receipt.GasUsed != receipts[0].GasUsed {
+		t.Errorf("receipt mismatch: have %v, want %v", receipt, receipts[0])
+	}
+	// Logs are filtered by range, block hash, address and topic
+	tests := []struct {
+		query DEWH.FilterQuery
+		want  int
+	}{
+		{DEWH.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(4)}, 4},
+		{DEWH.FilterQuery{FromBlock: big.NewInt(2), ToBlock: big.NewInt(3)}, 2},
+		{DEWH.FilterQuery{FromBlock: big.NewInt(1)}, 4},
+		{DEWH.FilterQuery{BlockHash: &blockHash}, 1},
+		{DEWH.FilterQuery{FromBlock: big.NewInt(1), Addresses: []common.Address{testAddr}}, 0},
+		{DEWH.FilterQuery{FromBlock: big.NewInt(1), Topics: [][]common.Hash{{testTopic}}}, 4},
+		{DEWH.FilterQuery{FromBlock: big.NewInt(1), Topics: [][]common.Hash{{common.Hash{}}}}, 0},
+	}
+	for i, tt := range tests {
+		logs, err := client.FilterLogs(context.Background(), tt.query)
+		if err != nil {
+			t.Errorf("query %d: failed to filter logs: %v", i, err)
+			continue
+		}
+		if len(logs) != tt.want {
+			t.Errorf("query %d: log count mismatch: have %d, want %d", i, len(logs), tt.want)
+		}
+	}
+	// Sent transactions are retrievable as pending ones
+	pooled, _ := types.SignTx(types.NewTransaction(4, testLogger, big.NewInt(1), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, testKey)
+	if err := client.SendTransaction(context.Background(), pooled); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if have, pending, err := client.TransactionByHash(context.Background(), pooled.Hash()); err != nil || !pending || have.Hash() != pooled.Hash() {
+		t.Errorf("pending transaction mismatch: have %v, pending %v (%v)", have, pending, err)
+	}
+	if _, err := client.TransactionReceipt(context.Background(), pooled.Hash()); err != DEWH.NotFound {
+		t.Errorf("pending receipt error mismatch: have %v, want %v", err, DEWH.NotFound)
+	}
+}
+
+// Tests that new heads and logs are streamed to subscribers.
+func TestSubscriptions(t *testing.T) {
+	backend, _ := newTestBackend(t, 1)
+	defer backend.chain.Stop()
+
+	httpsrv, client := newTestChain(t, backend)
+	defer httpsrv.Close()
+
+	var (
+		heads = make(chan *types.Header, 1)
+		logs  = make(chan types.Log, 1)
+	)
+	headSub, err := client.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("failed to subscribe to heads: %v", err)
+	}
+	defer headSub.Unsubscribe()
+
+	logSub, err := client.SubscribeFilterLogs(context.Background(), DEWH.FilterQuery{Topics: [][]common.Hash{{testTopic}}}, logs)
+	if err != nil {
+		t.Fatalf("failed to subscribe to logs: %v", err)
+	}
+	defer logSub.Unsubscribe()
+
+	// The subscriptions are installed by the server asynchronously, keep feeding
+	// events until they arrive
+	var (
+		block   = backend.chain.CurrentBlock()
+		matched = &types.Log{Address: testLogger, Topics: []common.Hash{testTopic}, BlockNumber: 1}
+		ignored = &types.Log{Address: testLogger, Topics: []common.Hash{{}}, BlockNumber: 1}
+		timeout = time.After(5 * time.Second)
+		ticker  = time.NewTicker(10 * time.Millisecond)
+	)
+	defer ticker.Stop()
+
+	var head *types.Header
+	var log *types.Log
+	for head == nil || log == nil {
+		select {
+		case <-ticker.C:
+			if head == nil {
+				backend.chainFeed.Send(core.ChainEvent{Block: block, Hash: block.Hash()})
+			}
+			if log == nil {
+				backend.logsFeed.Send([]*types.Log{ignored, matched})
+			}
+		case h := <-heads:
+			head = h
+		case l := <-logs:
+			if log != nil {
+				continue
+			}
+			log = &l
+		case err := <-headSub.Err():
+			t.Fatalf("head subscription failed: %v", err)
+		case err := <-logSub.Err():
+			t.Fatalf("log subscription failed: %v", err)
+		case <-timeout:
+			t.Fatalf("subscriptions timed out: head %v, log %v", head, log)
+		}
+	}
+	if head.Hash() != block.Hash() {
+		t.Errorf("head mismatch: have %x, want %x", head.Hash(), block.Hash())
+	}
+	if log.Topics[0] != testTopic {
+		t.Errorf("log topic mismatch: have %x, want %x", log.Topics[0], testTopic)
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethgrpc
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	DEWH "github.com/DEWH/go-DEWH"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/rlp"
+	"github.com/DEWH/go-DEWH/rpc"
+	"github.com/golang/protobuf/proto"
+)
+
+// Client is a typed client of the Chain gRPC service, mirroring the API of
+// ethclient.Client.
+type Client struct {
+	conn *conn
+}
+
+// Dial connects a client to the gRPC endpoint at the given https URL.
+func Dial(rawurl string) (*Client, error) {
+	return DialWithClient(rawurl, new(http.Client))
+}
+
+// DialWithClient connects a client to the gRPC endpoint at the given https URL,
+// issuing calls through the given HTTP client. The client must be able to
+// negotiate HTTP/2, which is the default for TLS connections.
+func DialWithClient(rawurl string, client *http.Client) (*Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(u.String(), "/") + "/" + serviceName
+	return &Client{conn: &conn{client: client, endpoint: endpoint}}, nil
+}
+
+// call issues a unary call, converting missing results into DEWH.NotFound.
+func (c *Client) call(ctx context.Context, method string, req, reply proto.Message) error {
+	err := c.conn.invoke(ctx, method, req, reply)
+	if status, ok := err.(*StatusError); ok && status.Code == NotFound {
+		return DEWH.NotFound
+	}
+	return err
+}
+
+// toBlockRef converts a block number into a block reference, nil selecting the
+// latest block.
+func toBlockRef(number *big.Int) *BlockRef {
+	if number == nil {
+		return &BlockRef{Kind: BlockRef_LATEST}
+	}
+	return &BlockRef{Kind: BlockRef_NUMBER, Number: number.Uint64()}
+}
+
+// hashBlockRef creates a reference to the block with the given hash.
+func hashBlockRef(hash common.Hash) *BlockRef {
+	return &BlockRef{Kind: BlockRef_HASH, Hash: hash.Bytes()}
+}
+
+// BlockNumber returns the number of the most recent block.
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	reply := new(BlockNumberReply)
+	if err := c.call(ctx, "BlockNumber", new(Empty), reply); err != nil {
+		return 0, err
+	}
+	return reply.Number, nil
+}
+
+// HeaderByHash returns the block header with the given hash.
+func (c *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return c.header(ctx, hashBlockRef(hash))
+}
+
+// HeaderByNumber returns a block header from the current canonical chain. If number is
+// nil, the latest known header is returned.
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.header(ctx, toBlockRef(number))
+}
+
+func (c *Client) header(ctx context.Context, ref *BlockRef) (*types.Header, error) {
+	reply := new(HeaderReply)
+	if err := c.call(ctx, "GetHeader", ref, reply); err != nil {
+		return nil, err
+	}
+	header := new(types.Header)
+	if err := rlp.DEWHodeBytes(reply.Rlp, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// BlockByHash returns the given full block.
+func (c *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return c.block(ctx, hashBlockRef(hash))
+}
+
+// BlockByNumber returns a block from the current canonical chain. If number is nil, the
+// latest known block is returned.
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return c.block(ctx, toBlockRef(number))
+}
+
+func (c *Client) block(ctx context.Context, ref *BlockRef) (*types.Block, error) {
+	reply := new(BlockReply)
+	if err := c.call(ctx, "GetBlock", ref, reply); err != nil {
+		return nil, err
+	}
+	block := new(types.Block)
+	if err := rlp.DEWHodeBytes(reply.Rlp, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// BlockReceipts returns the receipts of all transactions in the given block.
+func (c *Client) BlockReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	reply := new(ReceiptsReply)
+	if err := c.call(ctx, "GetReceipts", hashBlockRef(hash), reply); err != nil {
+		return nil, err
+	}
+	receipts := make(types.Receipts, len(reply.Receipts))
+	for i, receipt := range reply.Receipts {
+		receipts[i] = toReceipt(receipt)
+	}
+	return receipts, nil
+}
+
+// TransactionByHash returns the transaction with the given hash.
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	reply := new(TransactionReply)
+	if err := c.call(ctx, "GetTransaction", &TransactionRequest{Hash: hash.Bytes()}, reply); err != nil {
+		return nil, false, err
+	}
+	tx = new(types.Transaction)
+	if err := rlp.DEWHodeBytes(reply.Rlp, tx); err != nil {
+		return nil, false, err
+	}
+	return tx, len(reply.BlockHash) == 0, nil
+}
+
+// TransactionReceipt returns the receipt of a transaction by transaction hash.
+// Note that the receipt is not available for pending transactions.
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	reply := new(ReceiptReply)
+	if err := c.call(ctx, "GetTransactionReceipt", &TransactionRequest{Hash: txHash.Bytes()}, reply); err != nil {
+		return nil, err
+	}
+	if reply.Receipt == nil {
+		return nil, DEWH.NotFound
+	}
+	return toReceipt(reply.Receipt), nil
+}
+
+func (c *Client) account(ctx context.Context, account common.Address, blockNumber *big.Int) (*AccountReply, error) {
+	reply := new(AccountReply)
+	if err := c.call(ctx, "GetAccount", &AccountRequest{Address: account.Bytes(), Block: toBlockRef(blockNumber)}, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// BalanceAt returns the wei balance of the given account.
+// The block number can be nil, in which case the balance is taken from the latest known block.
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	reply, err := c.account(ctx, account, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(reply.Balance), nil
+}
+
+// NonceAt returns the account nonce of the given account.
+// The block number can be nil, in which case the nonce is taken from the latest known block.
+func (c *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	reply, err := c.account(ctx, account, blockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return reply.Nonce, nil
+}
+
+// CodeAt returns the contract code of the given account.
+// The block number can be nil, in which case the code is taken from the latest known block.
+func (c *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	reply, err := c.account(ctx, account, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Code, nil
+}
+
+// StorageAt returns the value of key in the contract storage of the given account.
+// The block number can be nil, in which case the value is taken from the latest known block.
+func (c *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	req := &StorageRequest{Address: account.Bytes(), Key: key.Bytes(), Block: toBlockRef(blockNumber)}
+	reply := new(StorageReply)
+	if err := c.call(ctx, "GetStorageAt", req, reply); err != nil {
+		return nil, err
+	}
+	return common.LeftPadBytes(reply.Value, common.HashLength), nil
+}
+
+// toLogFilter converts a filter query into its wire representation.
+func toLogFilter(q DEWH.FilterQuery) *LogFilter {
+	filter := &LogFilter{
+		FromBlock: int64(rpc.LatestBlockNumber),
+		ToBlock:   int64(rpc.LatestBlockNumber),
+		Addresses: make([][]byte, len(q.Addresses)),
+		Topics:    make([]*Topics, len(q.Topics)),
+	}
+	if q.BlockHash != nil {
+		filter.BlockHash = q.BlockHash.Bytes()
+	}
+	if q.FromBlock != nil {
+		filter.FromBlock = q.FromBlock.Int64()
+	}
+	if q.ToBlock != nil {
+		filter.ToBlock = q.ToBlock.Int64()
+	}
+	for i, addr := range q.Addresses {
+		filter.Addresses[i] = addr.Bytes()
+	}
+	for i, alternatives := range q.Topics {
+		filter.Topics[i] = new(Topics)
+		for _, topic := range alternatives {
+			filter.Topics[i].Topics = append(filter.Topics[i].Topics, topic.Bytes())
+		}
+	}
+	return filter
+}
+
+// FilterLogs executes a filter query.
+func (c *Client) FilterLogs(ctx context.Context, q DEWH.FilterQuery) ([]types.Log, error) {
+	reply := new(LogsReply)
+	if err := c.call(ctx, "GetLogs", toLogFilter(q), reply); err != nil {
+		return nil, err
+	}
+	logs := make([]types.Log, len(reply.Logs))
+	for i, log := range reply.Logs {
+		logs[i] = *toLog(log)
+	}
+	return logs, nil
+}
+
+// SendTransaction injects a signed transaction into the pending pool for execution.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, "SendTransaction", &SendTransactionRequest{Rlp: data}, new(SendTransactionReply))
+}
+
+// SubscribeNewHead subscribes to notifications about the current blockchain head
+// on the given channel.
+func (c *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (DEWH.Subscription, error) {
+	return c.subscribe(ctx, "SubscribeNewHeads", new(Empty), func() proto.Message { return new(HeaderReply) }, func(msg proto.Message) (interface{}, error) {
+		header := new(types.Header)
+		if err := rlp.DEWHodeBytes(msg.(*HeaderReply).Rlp, header); err != nil {
+			return nil, err
+		}
+		return header, nil
+	}, func(v interface{}, quit <-chan struct{}) {
+		select {
+		case ch <- v.(*types.Header):
+		case <-quit:
+		}
+	})
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query.
+// The block range of the query is ignored, only new logs are delivered.
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q DEWH.FilterQuery, ch chan<- types.Log) (DEWH.Subscription, error) {
+	return c.subscribe(ctx, "SubscribeLogs", toLogFilter(q), func() proto.Message { return new(Log) }, func(msg proto.Message) (interface{}, error) {
+		return toLog(msg.(*Log)), nil
+	}, func(v interface{}, quit <-chan struct{}) {
+		select {
+		case ch <- *v.(*types.Log):
+		case <-quit:
+		}
+	})
+}
+
+// subscribe opens a server stream and forwards its converted messages until
+// either the stream ends or the subscription is cancelled. Only the opening of
+// the stream is bounded by ctx.
+func (c *Client) subscribe(ctx context.Context, method string, req proto.Message, alloc func() proto.Message, convert func(proto.Message) (interface{}, error), deliver func(interface{}, <-chan struct{})) (DEWH.Subscription, error) {
+	stream, err := c.conn.open(ctx, method, req)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-quit:
+				stream.close()
+			case <-done:
+			}
+		}()
+		defer stream.close()
+
+		for {
+			msg := alloc()
+			if err := stream.recv(msg); err != nil {
+				select {
+				case <-quit:
+					return nil
+				default:
+				}
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			v, err := convert(msg)
+			if err != nil {
+				return err
+			}
+			deliver(v, quit)
+		}
+	}), nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethgrpc
+
+import (
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+)
+
+// newLog converts a log event into its wire representation.
+func newLog(log *types.Log) *Log {
+	topics := make([][]byte, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Bytes()
+	}
+	return &Log{
+		Address:     log.Address.Bytes(),
+		Topics:      topics,
+		Data:        log.Data,
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash.Bytes(),
+		TxIndex:     uint32(log.TxIndex),
+		BlockHash:   log.BlockHash.Bytes(),
+		Index:       uint32(log.Index),
+		Removed:     log.Removed,
+	}
+}
+
+// toLog converts a wire log back into a log event.
+func toLog(log *Log) *types.Log {
+	topics := make([]common.Hash, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = common.BytesToHash(topic)
+	}
+	return &types.Log{
+		Address:     common.BytesToAddress(log.Address),
+		Topics:      topics,
+		Data:        log.Data,
+		BlockNumber: log.BlockNumber,
+		TxHash:      common.BytesToHash(log.TxHash),
+		TxIndex:     uint(log.TxIndex),
+		BlockHash:   common.BytesToHash(log.BlockHash),
+		Index:       uint(log.Index),
+		Removed:     log.Removed,
+	}
+}
+
+// newLogs converts a list of log events into their wire representation.
+func newLogs(logs []*types.Log) []*Log {
+	res := make([]*Log, len(logs))
+	for i, log := range logs {
+		res[i] = newLog(log)
+	}
+	return res
+}
+
+// toLogs converts a list of wire logs back into log events.
+func toLogs(logs []*Log) []*types.Log {
+	res := make([]*types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = toLog(log)
+	}
+	return res
+}
+
+// newReceipt converts a transaction receipt into its wire representation.
+func newReceipt(receipt *types.Receipt) *Receipt {
+	return &Receipt{
+		PostState:         receipt.PostState,
+		Status:            receipt.Status,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		Bloom:             receipt.Bloom.Bytes(),
+		Logs:              newLogs(receipt.Logs),
+		TxHash:            receipt.TxHash.Bytes(),
+		ContractAddress:   receipt.ContractAddress.Bytes(),
+		GasUsed:           receipt.GasUsed,
+	}
+}
+
+// toReceipt converts a wire receipt back into a transaction receipt.
+func toReceipt(receipt *Receipt) *types.Receipt {
+	return &types.Receipt{
+		PostState:         receipt.PostState,
+		Status:            receipt.Status,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		Bloom:             types.BytesToBloom(receipt.Bloom),
+		Logs:              toLogs(receipt.Logs),
+		TxHash:            common.BytesToHash(receipt.TxHash),
+		ContractAddress:   common.BytesToAddress(receipt.ContractAddress),
+		GasUsed:           receipt.GasUsed,
+	}
+}
+
+// toAddresses converts raw addresses into typed ones.
+func toAddresses(raw [][]byte) []common.Address {
+	addresses := make([]common.Address, len(raw))
+	for i, addr := range raw {
+		addresses[i] = common.BytesToAddress(addr)
+	}
+	return addresses
+}
+
+// toTopics converts the topic criteria of a log filter.
+func toTopics(raw []*Topics) [][]common.Hash {
+	topics := make([][]common.Hash, len(raw))
+	for i, alternatives := range raw {
+		for _, topic := range alternatives.Topics {
+			topics[i] = append(topics[i], common.BytesToHash(topic))
+		}
+	}
+	return topics
+}
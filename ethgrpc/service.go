@@ -0,0 +1,106 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ethgrpc exposes the chain and the transaction pool of a node over a
+// gRPC service with protobuf messages, as defined in chain.proto.
+package ethgrpc
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/DEWH/go-DEWH/eth"
+	"github.com/DEWH/go-DEWH/les"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/p2p"
+	"github.com/DEWH/go-DEWH/rpc"
+)
+
+//go:generate protoc --go_out=. chain.proto
+
+// Config contains the settings of the gRPC endpoint. gRPC runs on HTTP/2, which
+// the standard library only negotiates over TLS, so a certificate is required.
+type Config struct {
+	ListenAddr string // TCP address to listen on, e.g. localhost:8547
+	CertFile   string // TLS certificate of the endpoint
+	KeyFile    string // private key of the TLS certificate
+}
+
+// Service is a node.Service serving the Chain gRPC service.
+type Service struct {
+	config  Config
+	handler *server
+
+	listener net.Listener
+	server   *http.Server
+}
+
+// New creates a gRPC service on top of either a full or a light node.
+func New(config Config, ethServ *eth.DEWH, lesServ *les.LightDEWH) (*Service, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, errors.New("gRPC endpoint requires a TLS certificate and key")
+	}
+	var handler *server
+	switch {
+	case ethServ != nil:
+		_, handler = newChain(ethServ.APIBackend, false)
+	case lesServ != nil:
+		_, handler = newChain(lesServ.ApiBackend, true)
+	default:
+		return nil, errors.New("gRPC endpoint requires an DEWH service")
+	}
+	return &Service{config: config, handler: handler}, nil
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the gRPC service (nil as it doesn't use the devp2p overlay network).
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// gRPC service (nil as it doesn't provide any JSON-RPC APIs).
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting to accept gRPC connections.
+func (s *Service) Start(server *p2p.Server) error {
+	cert, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", s.config.ListenAddr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:   s.handler,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2"}},
+	}
+	go s.server.ServeTLS(listener, "", "")
+
+	log.Info("gRPC endpoint opened", "url", "https://"+listener.Addr().String())
+	return nil
+}
+
+// Stop implements node.Service, closing the endpoint and all open streams.
+func (s *Service) Stop() error {
+	if s.server != nil {
+		s.server.Close()
+		log.Info("gRPC endpoint closed", "url", "https://"+s.listener.Addr().String())
+	}
+	return nil
+}
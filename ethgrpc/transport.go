@@ -0,0 +1,425 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethgrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// This file implements the subset of the gRPC wire protocol needed by the chain
+// service (unary and server streaming calls of uncompressed protobuf messages)
+// on top of the HTTP/2 support of the standard library.
+
+const (
+	contentType    = "application/grpc"
+	maxMessageSize = 16 * 1024 * 1024
+)
+
+// Code is a gRPC status code.
+type Code uint32
+
+// Status codes defined by the gRPC protocol.
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+)
+
+// StatusError is the error returned by a failed gRPC call.
+type StatusError struct {
+	Code    Code
+	Message string
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("grpc error %d: %s", err.Code, err.Message)
+}
+
+// statusErrorf creates a status error with a formatted message.
+func statusErrorf(code Code, format string, args ...interface{}) error {
+	return &StatusError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// toStatus converts an arbitrary error into the status reported to the client.
+func toStatus(err error) *StatusError {
+	switch err {
+	case nil:
+		return &StatusError{Code: OK}
+	case context.Canceled:
+		return &StatusError{Code: Canceled, Message: err.Error()}
+	case context.DeadlineExceeded:
+		return &StatusError{Code: DeadlineExceeded, Message: err.Error()}
+	}
+	if status, ok := err.(*StatusError); ok {
+		return status
+	}
+	return &StatusError{Code: Unknown, Message: err.Error()}
+}
+
+// writeMessage writes a length-prefixed protobuf message.
+func writeMessage(w io.Writer, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var prefix [5]byte // compression flag and big-endian length
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readMessage reads a length-prefixed protobuf message. It returns io.EOF if the
+// stream ended cleanly before the message.
+func readMessage(r io.Reader, msg proto.Message) error {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return err
+	}
+	if prefix[0] != 0 {
+		return statusErrorf(Unimplemented, "compressed messages not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return statusErrorf(ResourceExhausted, "message too large (%d>%d)", size, maxMessageSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// encodeStatusMessage percent-encodes a status message for the grpc-message
+// trailer as mandated by the protocol.
+func encodeStatusMessage(msg string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		} else {
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// parseStatusMessage reverts encodeStatusMessage, leaving malformed escape
+// sequences untouched.
+func parseStatusMessage(msg string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(msg); i++ {
+		if msg[i] == '%' && i+2 < len(msg) {
+			if c, err := strconv.ParseUint(msg[i+1:i+3], 16, 8); err == nil {
+				buf.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		buf.WriteByte(msg[i])
+	}
+	return buf.String()
+}
+
+// timeoutUnits maps the unit suffixes of the grpc-timeout header to durations.
+var timeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// parseTimeout parses the value of a grpc-timeout header.
+func parseTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+	unit, ok := timeoutUnits[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	amount, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(amount) * unit, true
+}
+
+// encodeTimeout formats a duration as a grpc-timeout header value.
+func encodeTimeout(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "0n"
+	}
+	// The protocol allows at most 8 digits, pick the finest unit that fits
+	for _, unit := range []byte{'n', 'u', 'm', 'S', 'M'} {
+		if amount := timeout / timeoutUnits[unit]; amount < 1e8 {
+			return strconv.FormatInt(int64(amount), 10) + string(unit)
+		}
+	}
+	return strconv.FormatInt(int64(timeout/time.Hour), 10) + "H"
+}
+
+// method is a single procedure exposed by a server. Exactly one of unary and
+// stream is set.
+type method struct {
+	request func() proto.Message
+	unary   func(ctx context.Context, req proto.Message) (proto.Message, error)
+	stream  func(ctx context.Context, req proto.Message, send func(proto.Message) error) error
+}
+
+// server dispatches gRPC requests to the methods of a single service.
+type server struct {
+	service string
+	methods map[string]*method
+}
+
+// ServeHTTP implements http.Handler, serving gRPC calls over HTTP/2.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requires HTTP/2 POST requests", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := r.Header.Get("content-type"); ct != contentType && !strings.HasPrefix(ct, contentType+"+proto") {
+		http.Error(w, fmt.Sprintf("invalid content type, only %s is supported", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("content-type", contentType)
+
+	m := s.methods[strings.TrimPrefix(r.URL.Path, "/"+s.service+"/")]
+	if m == nil {
+		writeStatus(w, statusErrorf(Unimplemented, "unknown method %s", r.URL.Path))
+		return
+	}
+	ctx := r.Context()
+	if timeout, ok := parseTimeout(r.Header.Get("grpc-timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req := m.request()
+	if err := readMessage(r.Body, req); err != nil {
+		if _, ok := err.(*StatusError); !ok {
+			err = statusErrorf(InvalidArgument, "invalid request: %v", err)
+		}
+		writeStatus(w, err)
+		return
+	}
+	var err error
+	if m.unary != nil {
+		var reply proto.Message
+		if reply, err = m.unary(ctx, req); err == nil {
+			err = writeMessage(w, reply)
+		}
+	} else {
+		// Push the response headers right away, subscriptions might stay
+		// silent for a long time.
+		flusher, _ := w.(http.Flusher)
+		flush := func() {
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		flush()
+
+		err = m.stream(ctx, req, func(msg proto.Message) error {
+			if err := writeMessage(w, msg); err != nil {
+				return err
+			}
+			flush()
+			return nil
+		})
+	}
+	writeStatus(w, err)
+}
+
+// writeStatus sends the outcome of a call in the response trailers.
+func writeStatus(w http.ResponseWriter, err error) {
+	status := toStatus(err)
+
+	header := w.Header()
+	header.Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(int(status.Code)))
+	if status.Message != "" {
+		header.Set(http.TrailerPrefix+"Grpc-Message", encodeStatusMessage(status.Message))
+	}
+}
+
+// conn issues gRPC calls to the methods of a single service.
+type conn struct {
+	client   *http.Client
+	endpoint string // service URL prefix, e.g. https://localhost:8547/ethgrpc.Chain
+}
+
+// clientStream is the response stream of a single call.
+type clientStream struct {
+	resp   *http.Response
+	cancel context.CancelFunc
+}
+
+// open sends a request to the given method and waits for the response headers.
+// The context bounds the establishment of the stream only, the stream itself is
+// canceled by calling close.
+func (c *conn) open(ctx context.Context, method string, req proto.Message) (*clientStream, error) {
+	body := new(bytes.Buffer)
+	if err := writeMessage(body, req); err != nil {
+		return nil, err
+	}
+	hreq, err := http.NewRequest(http.MethodPost, c.endpoint+"/"+method, body)
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("content-type", contentType)
+	hreq.Header.Set("te", "trailers")
+	if deadline, ok := ctx.Deadline(); ok {
+		// Let the server know when to give up as well
+		hreq.Header.Set("grpc-timeout", encodeTimeout(time.Until(deadline)))
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	hreq = hreq.WithContext(streamCtx)
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := c.client.Do(hreq)
+		done <- result{resp, err}
+	}()
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		cancel()
+		if res = <-done; res.err == nil {
+			res.resp.Body.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		cancel()
+		return nil, res.err
+	}
+	if res.resp.StatusCode != http.StatusOK {
+		res.resp.Body.Close()
+		cancel()
+		return nil, statusErrorf(Unavailable, "unexpected HTTP status: %s", res.resp.Status)
+	}
+	return &clientStream{resp: res.resp, cancel: cancel}, nil
+}
+
+// invoke performs a unary call, waiting for the reply at most until the context
+// is canceled.
+func (c *conn) invoke(ctx context.Context, method string, req, reply proto.Message) error {
+	stream, err := c.open(ctx, method, req)
+	if err != nil {
+		return err
+	}
+	defer stream.close()
+
+	done := make(chan error, 1)
+	go func() {
+		err := stream.recv(reply)
+		if err == io.EOF {
+			err = statusErrorf(Internal, "missing reply")
+		}
+		if err == nil {
+			err = stream.finish()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		stream.close()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// recv reads the next message of the stream. At the end of the stream io.EOF
+// is returned if the call succeeded, or the error status of the call.
+func (s *clientStream) recv(msg proto.Message) error {
+	err := readMessage(s.resp.Body, msg)
+	if err == io.EOF {
+		if err := s.status(); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// finish consumes the remainder of the stream and returns the call status.
+func (s *clientStream) finish() error {
+	if _, err := io.Copy(ioutil.Discard, s.resp.Body); err != nil {
+		return err
+	}
+	return s.status()
+}
+
+// status returns the error reported by the server in the trailers, or in the
+// headers of a trailers-only response.
+func (s *clientStream) status() error {
+	value := s.resp.Trailer.Get("Grpc-Status")
+	msg := s.resp.Trailer.Get("Grpc-Message")
+	if value == "" {
+		value, msg = s.resp.Header.Get("Grpc-Status"), s.resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return statusErrorf(Internal, "invalid status %q", value)
+	}
+	if Code(code) == OK {
+		return nil
+	}
+	return &StatusError{Code: Code(code), Message: parseStatusMessage(msg)}
+}
+
+// close aborts the stream.
+func (s *clientStream) close() {
+	s.cancel()
+	s.resp.Body.Close()
+}
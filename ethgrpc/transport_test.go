@@ -0,0 +1,164 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethgrpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// newTestServer starts a server with a service counting up from the requested
+// block number.
+func newTestServer(t *testing.T) (*httptest.Server, *conn) {
+	srv := &server{
+		service: "test.Counter",
+		methods: map[string]*method{
+			"Next": {
+				request: func() proto.Message { return new(BlockRef) },
+				unary: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+					ref := req.(*BlockRef)
+					if ref.Kind != BlockRef_NUMBER {
+						return nil, statusErrorf(InvalidArgument, "100%% numbers only, not %v", ref.Kind)
+					}
+					return &BlockNumberReply{Number: ref.Number + 1}, nil
+				},
+			},
+			"Count": {
+				request: func() proto.Message { return new(BlockRef) },
+				stream: func(ctx context.Context, req proto.Message, send func(proto.Message) error) error {
+					for i := uint64(0); i < req.(*BlockRef).Number; i++ {
+						if err := send(&BlockNumberReply{Number: i}); err != nil {
+							return err
+						}
+					}
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+		},
+	}
+	httpsrv := serveHTTP2(srv)
+	return httpsrv, &conn{client: httpsrv.Client(), endpoint: httpsrv.URL + "/test.Counter"}
+}
+
+// serveHTTP2 starts a test server for a gRPC handler. The test server can't
+// negotiate HTTP/2 on older Go releases, so calls are carried over chunked
+// HTTP/1.1 responses, which support the same streamed bodies and trailers, and
+// are presented to the handler as HTTP/2 requests.
+func serveHTTP2(handler http.Handler) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+
+		// HTTP/1.1 only delivers trailers of chunked responses, which needs
+		// them to be announced upfront
+		w.Header().Set("trailer", "Grpc-Status, Grpc-Message")
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func TestUnaryCall(t *testing.T) {
+	httpsrv, c := newTestServer(t)
+	defer httpsrv.Close()
+
+	reply := new(BlockNumberReply)
+	if err := c.invoke(context.Background(), "Next", &BlockRef{Kind: BlockRef_NUMBER, Number: 41}, reply); err != nil {
+		t.Fatal("call failed:", err)
+	}
+	if reply.Number != 42 {
+		t.Fatalf("reply mismatch: have %d, want 42", reply.Number)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	httpsrv, c := newTestServer(t)
+	defer httpsrv.Close()
+
+	tests := []struct {
+		method  string
+		req     proto.Message
+		code    Code
+		message string
+	}{
+		{"Next", &BlockRef{Kind: BlockRef_HASH}, InvalidArgument, "100% numbers only, not HASH"},
+		{"Missing", &BlockRef{}, Unimplemented, "unknown method /test.Counter/Missing"},
+	}
+	for _, tt := range tests {
+		err := c.invoke(context.Background(), tt.method, tt.req, new(BlockNumberReply))
+		status, ok := err.(*StatusError)
+		if !ok {
+			t.Errorf("%s: expected status error, got %v", tt.method, err)
+			continue
+		}
+		if status.Code != tt.code || status.Message != tt.message {
+			t.Errorf("%s: status mismatch: have (%d, %q), want (%d, %q)", tt.method, status.Code, status.Message, tt.code, tt.message)
+		}
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	httpsrv, c := newTestServer(t)
+	defer httpsrv.Close()
+
+	stream, err := c.open(context.Background(), "Count", &BlockRef{Kind: BlockRef_NUMBER, Number: 3})
+	if err != nil {
+		t.Fatal("can't open stream:", err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		reply := new(BlockNumberReply)
+		if err := stream.recv(reply); err != nil {
+			t.Fatalf("message %d: receive failed: %v", i, err)
+		}
+		if reply.Number != i {
+			t.Fatalf("message %d: number mismatch: have %d", i, reply.Number)
+		}
+	}
+	// The server stalls the stream after the last message, cancellation must
+	// unblock the reader
+	done := make(chan error, 1)
+	go func() { done <- stream.recv(new(BlockNumberReply)) }()
+	stream.close()
+
+	select {
+	case err := <-done:
+		if err == nil || err == io.EOF {
+			t.Fatalf("expected error after close, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream not terminated by close")
+	}
+}
+
+func TestTimeoutEncoding(t *testing.T) {
+	tests := []time.Duration{0, time.Nanosecond, 90 * time.Millisecond, 5 * time.Second, 3 * time.Hour, 1000000 * time.Hour}
+	for _, timeout := range tests {
+		enc := encodeTimeout(timeout)
+		have, ok := parseTimeout(enc)
+		if !ok {
+			t.Errorf("%v: encoded timeout %q not parsable", timeout, enc)
+			continue
+		}
+		if have > timeout || timeout-have > timeout/1e7 {
+			t.Errorf("%v: timeout mismatch after round trip via %q: %v", timeout, enc, have)
+		}
+	}
+}
@@ -67,6 +67,45 @@ func (s *PublicDEWHAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 	return (*hexutil.Big)(price), err
 }
 
+// GasPriceFor returns a suggestion for a gas price paying at least the given
+// gas weighted percentile of recent blocks, for inclusion within the given
+// number of blocks.
+func (s *PublicDEWHAPI) GasPriceFor(ctx context.Context, percentile float64, confirmBlocks hexutil.Uint64) (*hexutil.Big, error) {
+	price, err := s.b.SuggestPriceFor(ctx, percentile, int(confirmBlocks))
+	return (*hexutil.Big)(price), err
+}
+
+// FeeHistoryResult is the fee statistics of a range of blocks.
+type FeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the gas used ratio of a range of blocks ending with lastBlock,
+// along with the gas prices paid at the requested percentiles of each block,
+// weighted by the gas used by its transactions.
+func (s *PublicDEWHAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint64, lastBlock rpc.BlockNumber, percentiles []float64) (*FeeHistoryResult, error) {
+	oldest, prices, ratios, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, percentiles)
+	if err != nil {
+		return nil, err
+	}
+	result := &FeeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: ratios,
+	}
+	if prices != nil {
+		result.Reward = make([][]*hexutil.Big, len(prices))
+		for i, block := range prices {
+			result.Reward[i] = make([]*hexutil.Big, len(block))
+			for j, price := range block {
+				result.Reward[i][j] = (*hexutil.Big)(price)
+			}
+		}
+	}
+	return result, nil
+}
+
 // ProtocolVersion returns the current DEWH protocol version this node supports
 func (s *PublicDEWHAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
@@ -118,7 +157,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -126,13 +165,52 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["queued"][account.Hex()] = dump
 	}
 	return content
 }
 
+// ContentFrom returns the transactions of a single account contained within the
+// transaction pool.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+	content := map[string]map[string]*RPCTransaction{
+		"pending": make(map[string]*RPCTransaction),
+		"queued":  make(map[string]*RPCTransaction),
+	}
+	pending, queue := s.b.TxPoolContentFrom(addr)
+
+	for _, tx := range pending {
+		content["pending"][fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
+	}
+	for _, tx := range queue {
+		content["queued"][fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
+	}
+	return content
+}
+
+// Senders returns the number of pending and queued transactions of each account
+// with transactions in the pool.
+func (s *PublicTxPoolAPI) Senders() map[string]map[string]hexutil.Uint {
+	pending, queue := s.b.TxPoolSenderStats()
+
+	senders := make(map[string]map[string]hexutil.Uint)
+	count := func(addr common.Address) map[string]hexutil.Uint {
+		if senders[addr.Hex()] == nil {
+			senders[addr.Hex()] = map[string]hexutil.Uint{"pending": 0, "queued": 0}
+		}
+		return senders[addr.Hex()]
+	}
+	for addr, n := range pending {
+		count(addr)["pending"] = hexutil.Uint(n)
+	}
+	for addr, n := range queue {
+		count(addr)["queued"] = hexutil.Uint(n)
+	}
+	return senders
+}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	pending, queue := s.b.Stats()
@@ -177,6 +255,70 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// RPCTxChange is a transaction pool change reported to subscribers.
+type RPCTxChange struct {
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      hexutil.Uint64 `json:"nonce"`
+	Change     string         `json:"change"`
+	Reason     string         `json:"reason,omitempty"`
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"`
+}
+
+// Changes creates a subscription that reports transactions being promoted to the
+// executable set, replaced or dropped from the pool, along with the reason of the
+// removal. If senders are given, only their transactions are reported.
+func (s *PublicTxPoolAPI) Changes(ctx context.Context, senders *[]common.Address) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var filter map[common.Address]bool
+	if senders != nil {
+		filter = make(map[common.Address]bool)
+		for _, addr := range *senders {
+			filter[addr] = true
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changes := make(chan core.TxChangeEvent, 128)
+		changesSub := s.b.SubscribeTxChangeEvent(changes)
+		defer changesSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-changes:
+				for _, change := range ev.Changes {
+					if filter != nil && !filter[change.From] {
+						continue
+					}
+					res := &RPCTxChange{
+						Hash:   change.Tx.Hash(),
+						From:   change.From,
+						Nonce:  hexutil.Uint64(change.Tx.Nonce()),
+						Change: change.Kind.String(),
+						Reason: change.Reason.String(),
+					}
+					if change.By != nil {
+						hash := change.By.Hash()
+						res.ReplacedBy = &hash
+					}
+					notifier.Notify(rpcSub.ID, res)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-changesSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -905,8 +1047,8 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
-// newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
-func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
+// NewRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
+func NewRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0)
 }
 
@@ -1018,7 +1160,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx)
+		return NewRPCPendingTransaction(tx)
 	}
 	// Transaction unknown, return as such
 	return nil
@@ -1241,6 +1383,21 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	return submitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateRawTransaction adds the signed transaction to the transaction pool
+// without announcing it to the network. It is only picked up by the local miner
+// and dropped if it isn't mined within the configured number of blocks.
+func (s *PublicTransactionPoolAPI) SendPrivateRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DEWHodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	if err := s.b.SendPrivateTx(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted private transaction", "fullhash", tx.Hash().Hex(), "recipient", tx.To())
+	return tx.Hash(), nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19DEWH Signed Message:\n" + len(message) + message).
 //
@@ -1320,7 +1477,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, exists := accounts[from]; exists {
-			transactions = append(transactions, newRPCPendingTransaction(tx))
+			transactions = append(transactions, NewRPCPendingTransaction(tx))
 		}
 	}
 	return transactions, nil
@@ -22,12 +22,16 @@ var Modules = map[string]string{
 	"chequebook": Chequebook_JS,
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
+	"dpos":       Dpos_JS,
 	"eth":        Eth_JS,
+	"ethash":     Ethash_JS,
+	"istanbul":   Istanbul_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
+	"stratum":    Stratum_JS,
 	"swarmfs":    SWARMFS_JS,
 	"txpool":     TxPool_JS,
 }
@@ -89,6 +93,12 @@ web3._extend({
 			call: 'clique_getSignersAtHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getSignerStatus',
+			call: 'clique_getSignerStatus',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'propose',
 			call: 'clique_propose',
@@ -99,12 +109,143 @@ web3._extend({
 			call: 'clique_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'proposeParam',
+			call: 'clique_proposeParam',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'discardParam',
+			call: 'clique_discardParam',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'proposals',
 			getter: 'clique_proposals'
 		}),
+		new web3._extend.Property({
+			name: 'paramProposals',
+			getter: 'clique_paramProposals'
+		}),
+	]
+});
+`
+
+const Dpos_JS = `
+web3._extend({
+	property: 'dpos',
+	methods: [
+		new web3._extend.Method({
+			name: 'getElection',
+			call: 'dpos_getElection',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getValidators',
+			call: 'dpos_getValidators',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getCandidates',
+			call: 'dpos_getCandidates',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getStake',
+			call: 'dpos_getStake',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getVote',
+			call: 'dpos_getVote',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+	]
+});
+`
+
+const Ethash_JS = `
+web3._extend({
+	property: 'ethash',
+	methods: [
+		new web3._extend.Method({
+			name: 'getWork',
+			call: 'ethash_getWork',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getHashrate',
+			call: 'ethash_getHashrate',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getHashrates',
+			call: 'ethash_getHashrates',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'submitWork',
+			call: 'ethash_submitWork',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'submitHashRate',
+			call: 'ethash_submitHashRate',
+			params: 2
+		}),
+	]
+});
+`
+
+const Istanbul_JS = `
+web3._extend({
+	property: 'istanbul',
+	methods: [
+		new web3._extend.Method({
+			name: 'getSnapshot',
+			call: 'istanbul_getSnapshot',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getSnapshotAtHash',
+			call: 'istanbul_getSnapshotAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getValidators',
+			call: 'istanbul_getValidators',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorsAtHash',
+			call: 'istanbul_getValidatorsAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'propose',
+			call: 'istanbul_propose',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'discard',
+			call: 'istanbul_discard',
+			params: 1
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'candidates',
+			getter: 'istanbul_candidates'
+		}),
 	]
 });
 `
@@ -134,6 +275,27 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'removeTransaction',
+			call: 'admin_removeTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'flushQueue',
+			call: 'admin_flushQueue',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'setTxPoolPriority',
+			call: 'admin_setTxPoolPriority',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setTxPoolNoGossip',
+			call: 'admin_setTxPoolNoGossip',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -173,6 +335,10 @@ web3._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'txPoolSenderLists',
+			getter: 'admin_txPoolSenderLists'
+		}),
 	]
 });
 `
@@ -400,6 +566,24 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'sendPrivateRawTransaction',
+			call: 'eth_sendPrivateRawTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'eth_feeHistory',
+			params: 3,
+			inputFormatter: [web3._extend.utils.toHex, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'gasPriceFor',
+			call: 'eth_gasPriceFor',
+			params: 2,
+			inputFormatter: [null, web3._extend.utils.toHex],
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
 		new web3._extend.Method({
 			name: 'resend',
 			call: 'eth_resend',
@@ -474,6 +658,17 @@ web3._extend({
 			call: 'miner_setExtra',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setGasLimit',
+			call: 'miner_setGasLimit',
+			params: 2,
+			inputFormatter: [web3._extend.utils.toHex, web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'setRecommitInterval',
+			call: 'miner_setRecommitInterval',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'setGasPrice',
 			call: 'miner_setGasPrice',
@@ -484,6 +679,17 @@ web3._extend({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
 		}),
+		new web3._extend.Method({
+			name: 'sendBundle',
+			call: 'miner_sendBundle',
+			params: 2,
+			inputFormatter: [null, web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'getBundleStatus',
+			call: 'miner_getBundleStatus',
+			params: 1
+		}),
 	],
 	properties: []
 });
@@ -581,6 +787,19 @@ web3._extend({
 });
 `
 
+const Stratum_JS = `
+web3._extend({
+	property: 'stratum',
+	methods: [],
+	properties: [
+		new web3._extend.Property({
+			name: 'workers',
+			getter: 'stratum_workers'
+		}),
+	]
+});
+`
+
 const SWARMFS_JS = `
 web3._extend({
 	property: 'swarmfs',
@@ -608,9 +827,20 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'contentFrom',
+			call: 'txpool_contentFrom',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+	],
 	properties:
 	[
+		new web3._extend.Property({
+			name: 'senders',
+			getter: 'txpool_senders'
+		}),
 		new web3._extend.Property({
 			name: 'content',
 			getter: 'txpool_content'
@@ -0,0 +1,54 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+
+	"github.com/DEWH/go-DEWH/params"
+)
+
+var errNoCheckpoint = errors.New("no local checkpoint available")
+
+// PrivateLightServerAPI provides an API to access the checkpoints generated by
+// a LES server, which checkpoint oracle admins sign and publish.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new LES server API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// LatestCheckpoint returns the most recent checkpoint generated locally.
+func (api *PrivateLightServerAPI) LatestCheckpoint() (*params.TrustedCheckpoint, error) {
+	cp := api.server.latestLocalCheckpoint()
+	if cp == nil {
+		return nil, errNoCheckpoint
+	}
+	return cp, nil
+}
+
+// GetCheckpoint returns the locally generated checkpoint of the given section.
+func (api *PrivateLightServerAPI) GetCheckpoint(index uint64) (*params.TrustedCheckpoint, error) {
+	cp := api.server.localCheckpoint(index)
+	if cp == nil {
+		return nil, errNoCheckpoint
+	}
+	return cp, nil
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/DEWH/go-DEWH/accounts"
+	"github.com/DEWH/go-DEWH/accounts/abi/bind"
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/common/hexutil"
 	"github.com/DEWH/go-DEWH/consensus"
@@ -67,6 +68,7 @@ type LightDEWH struct {
 
 	bloomRequests                              chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer, chtIndexer, bloomTrieIndexer *core.ChainIndexer
+	oracle                                     *checkpointOracle // Checkpoint oracle consultant, nil if no oracle is configured
 
 	ApiBackend *LesApiBackend
 
@@ -102,7 +104,7 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightDEWH, error) {
 		peers:            peers,
 		reqDist:          newRequestDistributor(peers, quitSync),
 		accountManager:   ctx.AccountManager,
-		engine:           eth.CreateConsensusEngine(ctx, &config.Ethash, chainConfig, chainDb),
+		engine:           eth.CreateConsensusEngine(ctx, &config.Ethash, nil, chainConfig, chainDb),
 		shutdownChan:     make(chan bool),
 		networkId:        config.NetworkId,
 		bloomRequests:    make(chan chan *bloombits.Retrieval),
@@ -119,6 +121,9 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightDEWH, error) {
 		return nil, err
 	}
 	leth.bloomIndexer.Start(leth.blockchain)
+	if config.LightOracle != (common.Address{}) {
+		leth.oracle = newCheckpointOracle(config.LightOracle, leth.blockchain, chainDb)
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -202,6 +207,16 @@ func (s *LightDEWH) APIs() []rpc.API {
 	}...)
 }
 
+// SetContractBackend sets the backend used to consult the checkpoint oracle
+// contract and starts looking for newly registered checkpoints. It's a noop if
+// no checkpoint oracle is configured.
+func (s *LightDEWH) SetContractBackend(backend bind.ContractBackend) error {
+	if s.oracle == nil {
+		return nil
+	}
+	return s.oracle.start(backend)
+}
+
 func (s *LightDEWH) ResetWithGenesisBlock(gb *types.Block) {
 	s.blockchain.ResetWithGenesisBlock(gb)
 }
@@ -235,6 +250,9 @@ func (s *LightDEWH) Start(srvr *p2p.Server) error {
 // Stop implements node.Service, terminating all internal goroutines used by the
 // DEWH protocol.
 func (s *LightDEWH) Stop() error {
+	if s.oracle != nil {
+		s.oracle.stop()
+	}
 	s.odr.Stop()
 	if s.bloomIndexer != nil {
 		s.bloomIndexer.Close()
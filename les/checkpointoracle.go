@@ -0,0 +1,140 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/accounts/abi/bind"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/light"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+const (
+	// checkpointPollInterval is the time between two consecutive lookups of the
+	// latest checkpoint registered in the oracle.
+	checkpointPollInterval = 10 * time.Minute
+
+	// checkpointTimeout is the maximum time allowed for a single oracle lookup.
+	checkpointTimeout = 30 * time.Second
+)
+
+// checkpointOracle periodically retrieves the latest checkpoint registered in the
+// on-chain checkpoint oracle and feeds it into the light chain if it's newer than
+// the one currently in use. The contract is consulted through the light client's
+// own (header chain verified) state, so the retrieved checkpoints are as trusted
+// as the chain itself.
+type checkpointOracle struct {
+	address common.Address
+	chain   *light.LightChain
+	db      ethdb.Database
+
+	latest  uint64 // Section index of the checkpoint currently in use
+	hasCurr bool   // Whether any checkpoint is in use at all
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newCheckpointOracle creates a checkpoint oracle consultant for the oracle
+// contract at the given address.
+func newCheckpointOracle(address common.Address, chain *light.LightChain, db ethdb.Database) *checkpointOracle {
+	oracle := &checkpointOracle{
+		address: address,
+		chain:   chain,
+		db:      db,
+		quit:    make(chan struct{}),
+	}
+	if cp := params.TrustedCheckpoints[chain.Genesis().Hash()]; cp != nil {
+		oracle.latest, oracle.hasCurr = cp.SectionIndex, true
+	}
+	if cp := light.ReadTrustedCheckpoint(db); cp != nil && (!oracle.hasCurr || cp.SectionIndex > oracle.latest) {
+		oracle.latest, oracle.hasCurr = cp.SectionIndex, true
+	}
+	return oracle
+}
+
+// start binds the oracle contract using the given backend and starts polling it
+// for new checkpoints.
+func (oracle *checkpointOracle) start(backend bind.ContractBackend) error {
+	contract, err := checkpointoracle.NewCheckpointOracle(oracle.address, backend)
+	if err != nil {
+		return err
+	}
+	log.Info("Consulting checkpoint oracle", "address", oracle.address)
+
+	oracle.wg.Add(1)
+	go oracle.loop(contract)
+	return nil
+}
+
+// stop terminates the oracle polling.
+func (oracle *checkpointOracle) stop() {
+	close(oracle.quit)
+	oracle.wg.Wait()
+}
+
+// loop periodically checks the oracle contract for new checkpoints.
+func (oracle *checkpointOracle) loop(contract *checkpointoracle.CheckpointOracle) {
+	defer oracle.wg.Done()
+
+	ticker := time.NewTicker(checkpointPollInterval)
+	defer ticker.Stop()
+
+	for {
+		oracle.update(contract)
+
+		select {
+		case <-ticker.C:
+		case <-oracle.quit:
+			return
+		}
+	}
+}
+
+// update retrieves the latest checkpoint from the oracle and, if it's newer than
+// the current one, injects it into the light chain and persists it for the next
+// startup.
+func (oracle *checkpointOracle) update(contract *checkpointoracle.CheckpointOracle) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+	defer cancel()
+
+	cp, height, err := contract.LatestCheckpoint(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		log.Debug("Failed to retrieve checkpoint from oracle", "err", err)
+		return
+	}
+	// Nothing to do if no checkpoint was registered yet or it's not newer
+	if height == 0 || (oracle.hasCurr && cp.SectionIndex <= oracle.latest) {
+		return
+	}
+	if cp.Empty() {
+		log.Warn("Invalid checkpoint in oracle", "section", cp.SectionIndex, "height", height)
+		return
+	}
+	light.WriteTrustedCheckpoint(oracle.db, cp)
+	oracle.chain.AddTrustedCheckpoint(cp)
+	oracle.latest, oracle.hasCurr = cp.SectionIndex, true
+
+	log.Info("Updated checkpoint from oracle", "section", cp.SectionIndex, "hash", cp.Hash(), "registered", height)
+}
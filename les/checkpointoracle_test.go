@@ -0,0 +1,96 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/accounts/abi/bind"
+	"github.com/DEWH/go-DEWH/accounts/abi/bind/backends"
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle"
+	"github.com/DEWH/go-DEWH/contracts/checkpointoracle/contract"
+	"github.com/DEWH/go-DEWH/core"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/ethdb"
+	"github.com/DEWH/go-DEWH/light"
+	"github.com/DEWH/go-DEWH/params"
+)
+
+// Tests that the checkpoint oracle consultant picks up the checkpoints registered
+// in the oracle contract and persists them for the next startup.
+func TestCheckpointOracleUpdate(t *testing.T) {
+	// Deploy an oracle with a single admin on a simulated chain
+	key, _ := crypto.GenerateKey()
+	admin := crypto.PubkeyToAddress(key.PublicKey)
+
+	alloc := core.GenesisAlloc{admin: {Balance: new(big.Int).Lsh(common.Big1, 64)}}
+	backend := backends.NewSimulatedBackend(alloc)
+	genesis := (&core.Genesis{Config: params.AllEthashProtocolChanges, Alloc: alloc}).ToBlock(nil)
+
+	addr, _, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(key), backend, []common.Address{admin}, big.NewInt(4), big.NewInt(2), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to deploy oracle: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		backend.Commit()
+	}
+	registrar, err := checkpointoracle.NewCheckpointOracle(addr, backend)
+	if err != nil {
+		t.Fatalf("failed to bind oracle: %v", err)
+	}
+	// Create a light chain consulting the oracle, without any checkpoint yet
+	db := ethdb.NewMemDatabase()
+	(&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+
+	chain, err := light.NewLightChain(NewLesOdr(db, nil, nil, nil, nil), params.TestChainConfig, ethash.NewFaker())
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	oracle := newCheckpointOracle(addr, chain, db)
+
+	oracle.update(registrar)
+	if oracle.hasCurr || light.ReadTrustedCheckpoint(db) != nil {
+		t.Fatalf("checkpoint picked up from empty oracle")
+	}
+	// Register a checkpoint and ensure it's picked up
+	cp := &params.TrustedCheckpoint{
+		SectionIndex: 0,
+		SectionHead:  common.HexToHash("0x01"),
+		CHTRoot:      common.HexToHash("0x02"),
+		BloomRoot:    common.HexToHash("0x03"),
+	}
+	hash := checkpointoracle.SigningHash(addr, cp)
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatalf("failed to sign checkpoint: %v", err)
+	}
+	if _, err := registrar.RegisterCheckpoint(bind.NewKeyedTransactor(key), cp, common.Big0, genesis.Hash(), [][]byte{sig}); err != nil {
+		t.Fatalf("failed to register checkpoint: %v", err)
+	}
+	backend.Commit()
+
+	oracle.update(registrar)
+	if !oracle.hasCurr || oracle.latest != cp.SectionIndex {
+		t.Errorf("checkpoint not picked up: have section %d (%v), want %d", oracle.latest, oracle.hasCurr, cp.SectionIndex)
+	}
+	if stored := light.ReadTrustedCheckpoint(db); stored == nil || stored.Hash() != cp.Hash() {
+		t.Errorf("persisted checkpoint mismatch: have %+v, want %+v", stored, cp)
+	}
+}
@@ -34,7 +34,9 @@ import (
 	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/p2p"
 	"github.com/DEWH/go-DEWH/p2p/discv5"
+	"github.com/DEWH/go-DEWH/params"
 	"github.com/DEWH/go-DEWH/rlp"
+	"github.com/DEWH/go-DEWH/rpc"
 )
 
 type LesServer struct {
@@ -130,6 +132,58 @@ func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
 	bloomIndexer.AddChildIndexer(s.bloomTrieIndexer)
 }
 
+// APIs returns the collection of RPC services the LES server offers.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// localCheckpoint returns the checkpoint of the given LES/2 section as generated
+// by the local CHT and BloomTrie indexers, or nil if it's not available yet.
+func (s *LesServer) localCheckpoint(index uint64) *params.TrustedCheckpoint {
+	var (
+		ratio               = uint64(light.CHTFrequencyClient / light.CHTFrequencyServer)
+		chtSections, _, _   = s.chtIndexer.Sections()
+		bloomSections, _, _ = s.bloomTrieIndexer.Sections()
+	)
+	if chtSections < (index+1)*ratio || bloomSections <= index {
+		return nil
+	}
+	// The CHT indexer still uses LES/1 sections, convert the index back
+	head := s.chtIndexer.SectionHead((index+1)*ratio - 1)
+	if head == (common.Hash{}) || head != s.bloomTrieIndexer.SectionHead(index) {
+		return nil
+	}
+	return &params.TrustedCheckpoint{
+		SectionIndex: index,
+		SectionHead:  head,
+		CHTRoot:      light.GetChtV2Root(s.protocolManager.chainDb, index, head),
+		BloomRoot:    light.GetBloomTrieRoot(s.protocolManager.chainDb, index, head),
+	}
+}
+
+// latestLocalCheckpoint returns the most recent checkpoint generated by the local
+// indexers, or nil if no section was processed yet.
+func (s *LesServer) latestLocalCheckpoint() *params.TrustedCheckpoint {
+	chtSections, _, _ := s.chtIndexer.Sections()
+	bloomSections, _, _ := s.bloomTrieIndexer.Sections()
+
+	sections := chtSections / (light.CHTFrequencyClient / light.CHTFrequencyServer)
+	if bloomSections < sections {
+		sections = bloomSections
+	}
+	if sections == 0 {
+		return nil
+	}
+	return s.localCheckpoint(sections - 1)
+}
+
 // Stop stops the LES service
 func (s *LesServer) Stop() {
 	s.chtIndexer.Close()
@@ -47,44 +47,12 @@ const (
 	HelperTrieProcessConfirmations = 256  // number of confirmations before a HelperTrie is generated
 )
 
-// trustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
-// the appropriate section index and head hash. It is used to start light syncing from this checkpoint
-// and avoid downloading the entire header chain while still being able to securely access old headers/logs.
-type trustedCheckpoint struct {
-	name                                string
-	sectionIdx                          uint64
-	sectionHead, chtRoot, bloomTrieRoot common.Hash
-}
-
-var (
-	mainnetCheckpoint = trustedCheckpoint{
-		name:          "mainnet",
-		sectionIdx:    179,
-		sectionHead:   common.HexToHash("ae778e455492db1183e566fa0c67f954d256fdd08618f6d5a393b0e24576d0ea"),
-		chtRoot:       common.HexToHash("646b338f9ca74d936225338916be53710ec84020b89946004a8605f04c817f16"),
-		bloomTrieRoot: common.HexToHash("d0f978f5dbc86e5bf931d8dd5b2ecbebbda6dc78f8896af6a27b46a3ced0ac25"),
-	}
-
-	ropstenCheckpoint = trustedCheckpoint{
-		name:          "ropsten",
-		sectionIdx:    107,
-		sectionHead:   common.HexToHash("e1988f95399debf45b873e065e5cd61b416ef2e2e5deec5a6f87c3127086e1ce"),
-		chtRoot:       common.HexToHash("15cba18e4de0ab1e95e202625199ba30147aec8b0b70384b66ebea31ba6a18e0"),
-		bloomTrieRoot: common.HexToHash("e00fa6389b2e597d9df52172cd8e936879eed0fca4fa59db99e2c8ed682562f2"),
-	}
-)
-
-// trustedCheckpoints associates each known checkpoint with the genesis hash of the chain it belongs to
-var trustedCheckpoints = map[common.Hash]trustedCheckpoint{
-	params.MainnetGenesisHash: mainnetCheckpoint,
-	params.TestnetGenesisHash: ropstenCheckpoint,
-}
-
 var (
 	ErrNoTrustedCht       = errors.New("No trusted canonical hash trie")
 	ErrNoTrustedBloomTrie = errors.New("No trusted bloom trie")
 	ErrNoHeader           = errors.New("Header not found")
 	chtPrefix             = []byte("chtRoot-") // chtPrefix + chtNum (uint64 big endian) -> trie root hash
+	trustedCheckpointKey  = []byte("LastTrustedCheckpoint")
 	ChtTablePrefix        = "cht-"
 )
 
@@ -117,6 +85,33 @@ func StoreChtRoot(db ethdb.Database, sectionIdx uint64, sectionHead, root common
 	db.Put(append(append(chtPrefix, encNumber[:]...), sectionHead.Bytes()...), root.Bytes())
 }
 
+// ReadTrustedCheckpoint retrieves the last trusted checkpoint obtained from the
+// checkpoint oracle, or nil if none was stored yet.
+func ReadTrustedCheckpoint(db ethdb.Database) *params.TrustedCheckpoint {
+	data, _ := db.Get(trustedCheckpointKey)
+	if len(data) == 0 {
+		return nil
+	}
+	cp := new(params.TrustedCheckpoint)
+	if err := rlp.DEWHodeBytes(data, cp); err != nil {
+		log.Error("Invalid trusted checkpoint RLP", "err", err)
+		return nil
+	}
+	return cp
+}
+
+// WriteTrustedCheckpoint stores a trusted checkpoint obtained from the checkpoint
+// oracle, to be used on the next startup.
+func WriteTrustedCheckpoint(db ethdb.Database, cp *params.TrustedCheckpoint) {
+	data, err := rlp.EncodeToBytes(cp)
+	if err != nil {
+		log.Crit("Failed to RLP encode trusted checkpoint", "err", err)
+	}
+	if err := db.Put(trustedCheckpointKey, data); err != nil {
+		log.Crit("Failed to store trusted checkpoint", "err", err)
+	}
+}
+
 // ChtIndexerBackend implements core.ChainIndexerBackend
 type ChtIndexerBackend struct {
 	diskdb               ethdb.Database
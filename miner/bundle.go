@@ -0,0 +1,262 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+)
+
+const (
+	// maxBundleTxs is the maximum number of transactions in a single bundle.
+	maxBundleTxs = 64
+
+	// maxPendingBundles is the maximum number of bundles waiting for inclusion.
+	maxPendingBundles = 1024
+
+	// maxBundleFuture is how many blocks ahead of the chain head a bundle may
+	// target.
+	maxBundleFuture = 128
+
+	// bundleStatusRetention is the number of blocks the status of a resolved
+	// bundle is kept queryable.
+	bundleStatusRetention = 256
+)
+
+var (
+	// ErrBundleEmpty is returned if a bundle without transactions is submitted.
+	ErrBundleEmpty = errors.New("empty bundle")
+
+	// ErrBundleTooLarge is returned if a bundle contains more transactions than
+	// permitted.
+	ErrBundleTooLarge = fmt.Errorf("bundle exceeds %d transactions", maxBundleTxs)
+
+	// ErrBundleKnown is returned if an identical bundle was already submitted.
+	ErrBundleKnown = errors.New("known bundle")
+
+	// ErrBundlePoolFull is returned if too many bundles await inclusion.
+	ErrBundlePoolFull = errors.New("bundle pool full")
+
+	// ErrBundleNotFound is returned when querying the status of an unknown or
+	// already forgotten bundle.
+	ErrBundleNotFound = errors.New("bundle not found")
+
+	// errBundleReverted is recorded if a bundle transaction failed execution.
+	errBundleReverted = errors.New("execution reverted")
+)
+
+// Bundle is a group of transactions that must be included in the given block
+// all together, in order and at the top of the block, or not at all.
+type Bundle struct {
+	Txs         types.Transactions
+	BlockNumber uint64
+
+	hash common.Hash
+}
+
+// NewBundle creates a bundle of transactions targeting a block number.
+func NewBundle(txs types.Transactions, number uint64) *Bundle {
+	blob := make([]byte, 8, 8+len(txs)*common.HashLength)
+	binary.BigEndian.PutUint64(blob, number)
+	for _, tx := range txs {
+		blob = append(blob, tx.Hash().Bytes()...)
+	}
+	hash := crypto.Keccak256Hash(blob)
+	return &Bundle{Txs: txs, BlockNumber: number, hash: hash}
+}
+
+// Hash returns the unique identifier of the bundle, derived from its target
+// block and transactions.
+func (b *Bundle) Hash() common.Hash {
+	return b.hash
+}
+
+// BundleState is the life cycle state of a submitted bundle.
+type BundleState int
+
+const (
+	BundlePending  BundleState = iota // waiting for its target block
+	BundleIncluded                    // included in its target block
+	BundleFailed                      // target block passed, bundle did not execute cleanly
+	BundleExpired                     // target block passed without the bundle
+)
+
+func (s BundleState) String() string {
+	switch s {
+	case BundlePending:
+		return "pending"
+	case BundleIncluded:
+		return "included"
+	case BundleFailed:
+		return "failed"
+	case BundleExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s BundleState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// BundleStatus reports what happened to a submitted bundle.
+type BundleStatus struct {
+	State       BundleState    `json:"state"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   *common.Hash   `json:"blockHash"`       // set if the bundle was included
+	Error       string         `json:"error,omitempty"` // last simulation failure
+}
+
+// bundlePool tracks the bundles awaiting inclusion as well as the status of the
+// recently resolved ones.
+type bundlePool struct {
+	pending map[uint64][]*Bundle          // bundles waiting for inclusion, by target block
+	status  map[common.Hash]*BundleStatus // status of pending and recently resolved bundles
+	count   int                           // number of pending bundles
+
+	lock sync.RWMutex
+}
+
+func newBundlePool() *bundlePool {
+	return &bundlePool{
+		pending: make(map[uint64][]*Bundle),
+		status:  make(map[common.Hash]*BundleStatus),
+	}
+}
+
+// add schedules a bundle for inclusion, given the current chain head.
+func (p *bundlePool) add(bundle *Bundle, head uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	switch {
+	case len(bundle.Txs) == 0:
+		return ErrBundleEmpty
+	case len(bundle.Txs) > maxBundleTxs:
+		return ErrBundleTooLarge
+	case bundle.BlockNumber <= head:
+		return fmt.Errorf("bundle target block #%d already mined", bundle.BlockNumber)
+	case bundle.BlockNumber > head+maxBundleFuture:
+		return fmt.Errorf("bundle target block #%d too far in the future", bundle.BlockNumber)
+	case p.count >= maxPendingBundles:
+		return ErrBundlePoolFull
+	}
+	if _, ok := p.status[bundle.Hash()]; ok {
+		return ErrBundleKnown
+	}
+	p.pending[bundle.BlockNumber] = append(p.pending[bundle.BlockNumber], bundle)
+	p.status[bundle.Hash()] = &BundleStatus{State: BundlePending, BlockNumber: hexutil.Uint64(bundle.BlockNumber)}
+	p.count++
+	return nil
+}
+
+// bundles returns the bundles targeting the given block, in submission order.
+func (p *bundlePool) bundles(number uint64) []*Bundle {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return append([]*Bundle(nil), p.pending[number]...)
+}
+
+// simulated records the outcome of applying a bundle on top of the pending state.
+func (p *bundlePool) simulated(hash common.Hash, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if status, ok := p.status[hash]; ok && status.State == BundlePending {
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Error = ""
+		}
+	}
+}
+
+// resolve finalizes the status of all bundles targeting the given new chain head
+// or any block before it, and forgets about bundles resolved long ago.
+func (p *bundlePool) resolve(block *types.Block) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	number := block.NumberU64()
+	for target, bundles := range p.pending {
+		if target > number {
+			continue
+		}
+		for _, bundle := range bundles {
+			status := p.status[bundle.Hash()]
+			switch {
+			case target == number && containsBundle(block, bundle):
+				hash := block.Hash()
+				status.State, status.BlockHash, status.Error = BundleIncluded, &hash, ""
+			case status.Error != "":
+				status.State = BundleFailed
+			default:
+				status.State = BundleExpired
+			}
+			p.count--
+		}
+		delete(p.pending, target)
+	}
+	for hash, status := range p.status {
+		if status.State != BundlePending && uint64(status.BlockNumber)+bundleStatusRetention < number {
+			delete(p.status, hash)
+		}
+	}
+}
+
+// get returns the status of a bundle.
+func (p *bundlePool) get(hash common.Hash) (BundleStatus, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	status, ok := p.status[hash]
+	if !ok {
+		return BundleStatus{}, false
+	}
+	return *status, true
+}
+
+// containsBundle checks whether the block contains all the transactions of the
+// bundle, consecutively and in order.
+func containsBundle(block *types.Block, bundle *Bundle) bool {
+	txs := block.Transactions()
+	for i, tx := range txs {
+		if tx.Hash() != bundle.Txs[0].Hash() {
+			continue
+		}
+		if len(txs)-i < len(bundle.Txs) {
+			return false
+		}
+		for j, btx := range bundle.Txs {
+			if txs[i+j].Hash() != btx.Hash() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
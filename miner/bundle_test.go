@@ -0,0 +1,120 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+)
+
+func newBundleTx(nonce uint64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{}, new(big.Int), 21000, big.NewInt(1), nil)
+}
+
+func newBundleBlock(number uint64, txs ...*types.Transaction) *types.Block {
+	header := &types.Header{Number: new(big.Int).SetUint64(number)}
+	return types.NewBlock(header, txs, nil, nil)
+}
+
+// Tests that bundles are validated against the chain head and pool limits.
+func TestBundlePoolAdd(t *testing.T) {
+	pool := newBundlePool()
+
+	tx := newBundleTx(0)
+	if err := pool.add(NewBundle(nil, 11), 10); err != ErrBundleEmpty {
+		t.Errorf("empty bundle: have %v, want %v", err, ErrBundleEmpty)
+	}
+	if err := pool.add(NewBundle(types.Transactions{tx}, 10), 10); err == nil {
+		t.Errorf("bundle targeting mined block accepted")
+	}
+	if err := pool.add(NewBundle(types.Transactions{tx}, 11+maxBundleFuture), 10); err == nil {
+		t.Errorf("bundle targeting far future block accepted")
+	}
+	if err := pool.add(NewBundle(types.Transactions{tx}, 11), 10); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if err := pool.add(NewBundle(types.Transactions{tx}, 11), 10); err != ErrBundleKnown {
+		t.Errorf("duplicate bundle: have %v, want %v", err, ErrBundleKnown)
+	}
+	// The same transactions may target a different block
+	if err := pool.add(NewBundle(types.Transactions{tx}, 12), 10); err != nil {
+		t.Errorf("failed to add bundle for other block: %v", err)
+	}
+	if bundles := pool.bundles(11); len(bundles) != 1 {
+		t.Errorf("bundle count mismatch: have %d, want 1", len(bundles))
+	}
+}
+
+// Tests that bundles get their final status once their target block is mined.
+func TestBundlePoolResolve(t *testing.T) {
+	pool := newBundlePool()
+
+	var (
+		txs      = []*types.Transaction{newBundleTx(0), newBundleTx(1), newBundleTx(2), newBundleTx(3)}
+		included = NewBundle(types.Transactions{txs[1], txs[2]}, 1)
+		failed   = NewBundle(types.Transactions{txs[3]}, 1)
+		expired  = NewBundle(types.Transactions{txs[2], txs[1]}, 1)
+		future   = NewBundle(types.Transactions{txs[0]}, 2)
+	)
+	for _, bundle := range []*Bundle{included, failed, expired, future} {
+		if err := pool.add(bundle, 0); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	pool.simulated(failed.Hash(), errors.New("execution reverted"))
+
+	block := newBundleBlock(1, txs[1], txs[2], txs[0])
+	pool.resolve(block)
+
+	tests := []struct {
+		bundle *Bundle
+		state  BundleState
+	}{
+		{included, BundleIncluded},
+		{failed, BundleFailed},
+		{expired, BundleExpired},
+		{future, BundlePending},
+	}
+	for i, tt := range tests {
+		status, ok := pool.get(tt.bundle.Hash())
+		if !ok {
+			t.Errorf("test %d: status not found", i)
+			continue
+		}
+		if status.State != tt.state {
+			t.Errorf("test %d: state mismatch: have %v, want %v", i, status.State, tt.state)
+		}
+	}
+	if status, _ := pool.get(included.Hash()); status.BlockHash == nil || *status.BlockHash != block.Hash() {
+		t.Errorf("included bundle block hash mismatch: have %v, want %x", status.BlockHash, block.Hash())
+	}
+	if pool.count != 1 {
+		t.Errorf("pending count mismatch: have %d, want 1", pool.count)
+	}
+	// Resolved bundles must be forgotten after the retention period
+	pool.resolve(newBundleBlock(2 + bundleStatusRetention))
+	if _, ok := pool.get(included.Hash()); ok {
+		t.Errorf("resolved bundle not forgotten")
+	}
+	if status, ok := pool.get(future.Hash()); !ok || status.State != BundleExpired {
+		t.Errorf("future bundle not expired: %v", status.State)
+	}
+}
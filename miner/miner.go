@@ -20,6 +20,7 @@ package miner
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/DEWH/go-DEWH/accounts"
 	"github.com/DEWH/go-DEWH/common"
@@ -115,7 +116,7 @@ func (self *Miner) Start(coinbase common.Address) {
 
 	log.Info("Starting mining operation")
 	self.worker.start()
-	self.worker.commitNewWork()
+	self.worker.commitNewWork(false)
 }
 
 func (self *Miner) Stop() {
@@ -162,6 +163,47 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetTxOrdering sets the policy deciding the order in which pending transactions
+// are included into newly mined blocks.
+func (self *Miner) SetTxOrdering(policy OrderingPolicy) {
+	self.worker.setOrdering(policy)
+}
+
+// SetGasLimit sets the range the gas limit of mined blocks is moved towards: it
+// is raised while below floor and lowered while above ceil. A zero ceil leaves
+// the gas limit unbounded above the floor.
+func (self *Miner) SetGasLimit(floor, ceil uint64) error {
+	if floor < params.MinGasLimit {
+		return fmt.Errorf("gas floor %d below minimum %d", floor, params.MinGasLimit)
+	}
+	if ceil != 0 && ceil < floor {
+		return fmt.Errorf("gas ceiling %d below floor %d", ceil, floor)
+	}
+	self.worker.setGasLimit(floor, ceil)
+	return nil
+}
+
+// SetRecommitInterval sets the interval at which the block being sealed is
+// rebuilt to include newly arrived transactions. Zero disables rebuilding.
+func (self *Miner) SetRecommitInterval(interval time.Duration) {
+	self.worker.setRecommitInterval(interval)
+}
+
+// SendBundle schedules a bundle of transactions for atomic inclusion into its
+// target block, returning the bundle hash to query its status with.
+func (self *Miner) SendBundle(txs types.Transactions, number uint64) (common.Hash, error) {
+	bundle := NewBundle(txs, number)
+	if err := self.worker.addBundle(bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
+// BundleStatus retrieves the status of a previously submitted bundle.
+func (self *Miner) BundleStatus(hash common.Hash) (BundleStatus, error) {
+	return self.worker.bundleStatus(hash)
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
@@ -0,0 +1,259 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+)
+
+// Names of the built-in transaction ordering policies.
+const (
+	PriceOrdering    = "price" // highest gas price first (default)
+	ArrivalOrdering  = "fifo"  // earliest arrival first
+	FairnessOrdering = "fair"  // round robin across senders
+)
+
+// TransactionSet is a set of pending transactions which the worker drains in the
+// order decided by an ordering policy. The transactions of every sender must be
+// returned in nonce order.
+type TransactionSet interface {
+	// Peek returns the next transaction to try, or nil if the set is exhausted.
+	Peek() *types.Transaction
+
+	// Shift replaces the current transaction with the next one from the same
+	// sender, called after the current one was included or skipped.
+	Shift()
+
+	// Pop drops the current transaction along with all further transactions of
+	// its sender, called if the sender cannot be included any more.
+	Pop()
+}
+
+// OrderingPolicy decides in which order the worker tries to include pending
+// transactions into the block it is sealing.
+type OrderingPolicy interface {
+	// Order creates the transaction set to drain from the nonce sorted pending
+	// transactions of each sender. The input map is reowned by the policy.
+	Order(signer types.Signer, pending map[common.Address]types.Transactions) TransactionSet
+}
+
+// NewOrderingPolicy creates one of the built-in ordering policies by name. The
+// priority senders of the transaction pool are put ahead of it by the worker.
+func NewOrderingPolicy(name string) (OrderingPolicy, error) {
+	switch name {
+	case "", PriceOrdering:
+		return priceOrdering{}, nil
+	case ArrivalOrdering:
+		return arrivalOrdering{}, nil
+	case FairnessOrdering:
+		return fairnessOrdering{}, nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q", name)
+	}
+}
+
+// priceOrdering is the default policy, maximizing the fees of the block by always
+// picking the best paying transaction among the senders.
+type priceOrdering struct{}
+
+func (priceOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions) TransactionSet {
+	return types.NewTransactionsByPriceAndNonce(signer, pending)
+}
+
+// arrivalOrdering picks transactions in the order they were first seen by the
+// local node, as far as the nonce order of their senders permits.
+type arrivalOrdering struct{}
+
+func (arrivalOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions) TransactionSet {
+	set := &txsByArrival{txs: pending, signer: signer}
+	for from, txs := range pending {
+		set.heads = append(set.heads, txs[0])
+		pending[from] = txs[1:]
+	}
+	heap.Init(&set.heads)
+	return set
+}
+
+// txsByArrival is a heap of the next transaction of each sender, ordered by
+// arrival time.
+type txsByArrival struct {
+	txs    map[common.Address]types.Transactions
+	heads  arrivalHeap
+	signer types.Signer
+}
+
+func (t *txsByArrival) Peek() *types.Transaction {
+	if len(t.heads) == 0 {
+		return nil
+	}
+	return t.heads[0]
+}
+
+func (t *txsByArrival) Shift() {
+	from, _ := types.Sender(t.signer, t.heads[0])
+	if txs := t.txs[from]; len(txs) > 0 {
+		t.heads[0], t.txs[from] = txs[0], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+func (t *txsByArrival) Pop() {
+	heap.Pop(&t.heads)
+}
+
+type arrivalHeap []*types.Transaction
+
+func (h arrivalHeap) Len() int { return len(h) }
+func (h arrivalHeap) Less(i, j int) bool {
+	if h[i].Time().Equal(h[j].Time()) {
+		return h[i].GasPrice().Cmp(h[j].GasPrice()) > 0
+	}
+	return h[i].Time().Before(h[j].Time())
+}
+func (h arrivalHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *arrivalHeap) Push(x interface{}) {
+	*h = append(*h, x.(*types.Transaction))
+}
+
+func (h *arrivalHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// fairnessOrdering includes transactions of all senders in turns, one transaction
+// per sender and round, so that no sender can crowd out the others by flooding the
+// pool. Within a round senders are ordered by the gas price of their first
+// transaction.
+type fairnessOrdering struct{}
+
+func (fairnessOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions) TransactionSet {
+	set := &txsRoundRobin{txs: pending}
+	for from := range pending {
+		set.senders = append(set.senders, from)
+	}
+	sort.Slice(set.senders, func(i, j int) bool {
+		return pending[set.senders[i]][0].GasPrice().Cmp(pending[set.senders[j]][0].GasPrice()) > 0
+	})
+	return set
+}
+
+// txsRoundRobin cycles through the senders, offering the next transaction of
+// each in turn.
+type txsRoundRobin struct {
+	txs     map[common.Address]types.Transactions
+	senders []common.Address // senders with transactions left, in turn order
+}
+
+func (t *txsRoundRobin) Peek() *types.Transaction {
+	if len(t.senders) == 0 {
+		return nil
+	}
+	return t.txs[t.senders[0]][0]
+}
+
+func (t *txsRoundRobin) Shift() {
+	from := t.senders[0]
+	t.senders = t.senders[1:]
+	if txs := t.txs[from][1:]; len(txs) > 0 {
+		t.txs[from] = txs
+		t.senders = append(t.senders, from)
+	} else {
+		delete(t.txs, from)
+	}
+}
+
+func (t *txsRoundRobin) Pop() {
+	delete(t.txs, t.senders[0])
+	t.senders = t.senders[1:]
+}
+
+// priorityOrdering includes the transactions of a whitelist of senders ahead of
+// all other transactions. Both groups are ordered by a wrapped policy.
+type priorityOrdering struct {
+	policy   OrderingPolicy
+	priority map[common.Address]struct{}
+}
+
+// NewPriorityOrdering creates a policy which orders the transactions of the given
+// senders ahead of all others, ordering both groups by the given policy.
+func NewPriorityOrdering(policy OrderingPolicy, senders []common.Address) OrderingPolicy {
+	priority := make(map[common.Address]struct{}, len(senders))
+	for _, addr := range senders {
+		priority[addr] = struct{}{}
+	}
+	return &priorityOrdering{policy: policy, priority: priority}
+}
+
+func (p *priorityOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions) TransactionSet {
+	prioritized := make(map[common.Address]types.Transactions)
+	for from, txs := range pending {
+		if _, ok := p.priority[from]; ok {
+			prioritized[from] = txs
+			delete(pending, from)
+		}
+	}
+	return &chainedTxs{sets: []TransactionSet{
+		p.policy.Order(signer, prioritized),
+		p.policy.Order(signer, pending),
+	}}
+}
+
+// chainedTxs drains a list of transaction sets one after the other.
+type chainedTxs struct {
+	sets []TransactionSet
+}
+
+// current returns the first set with transactions left.
+func (t *chainedTxs) current() TransactionSet {
+	for len(t.sets) > 0 && t.sets[0].Peek() == nil {
+		t.sets = t.sets[1:]
+	}
+	if len(t.sets) == 0 {
+		return nil
+	}
+	return t.sets[0]
+}
+
+func (t *chainedTxs) Peek() *types.Transaction {
+	if set := t.current(); set != nil {
+		return set.Peek()
+	}
+	return nil
+}
+
+func (t *chainedTxs) Shift() {
+	if set := t.current(); set != nil {
+		set.Shift()
+	}
+}
+
+func (t *chainedTxs) Pop() {
+	if set := t.current(); set != nil {
+		set.Pop()
+	}
+}
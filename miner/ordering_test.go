@@ -0,0 +1,149 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+)
+
+// orderingTester creates signed transactions in a known arrival order.
+type orderingTester struct {
+	t      *testing.T
+	signer types.Signer
+	keys   []*ecdsa.PrivateKey
+	txs    []*types.Transaction // all transactions in arrival order
+}
+
+func newOrderingTester(t *testing.T, accounts int) *orderingTester {
+	tester := &orderingTester{t: t, signer: types.HomesteadSigner{}}
+	for i := 0; i < accounts; i++ {
+		key, _ := crypto.GenerateKey()
+		tester.keys = append(tester.keys, key)
+	}
+	return tester
+}
+
+// add creates the next transaction of an account with the given gas price.
+func (o *orderingTester) add(account int, nonce uint64, price int64) {
+	tx := types.NewTransaction(nonce, common.Address{}, new(big.Int), 21000, big.NewInt(price), nil)
+	tx, err := types.SignTx(tx, o.signer, o.keys[account])
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	o.txs = append(o.txs, tx)
+	time.Sleep(time.Millisecond) // ensure distinct arrival times
+}
+
+// pending groups the transactions by sender, as returned by the pool.
+func (o *orderingTester) pending() map[common.Address]types.Transactions {
+	pending := make(map[common.Address]types.Transactions)
+	for _, tx := range o.txs {
+		from, _ := types.Sender(o.signer, tx)
+		pending[from] = append(pending[from], tx)
+	}
+	return pending
+}
+
+// check drains the transaction set and compares the order with the expected
+// indexes of the created transactions.
+func (o *orderingTester) check(policy OrderingPolicy, want []int) {
+	set := policy.Order(o.signer, o.pending())
+
+	var have []*types.Transaction
+	for tx := set.Peek(); tx != nil; tx = set.Peek() {
+		have = append(have, tx)
+		set.Shift()
+	}
+	if len(have) != len(want) {
+		o.t.Fatalf("transaction count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i, idx := range want {
+		if have[i] != o.txs[idx] {
+			o.t.Errorf("transaction %d: have nonce %d price %v, want nonce %d price %v",
+				i, have[i].Nonce(), have[i].GasPrice(), o.txs[idx].Nonce(), o.txs[idx].GasPrice())
+		}
+	}
+}
+
+func TestArrivalOrdering(t *testing.T) {
+	tester := newOrderingTester(t, 2)
+	tester.add(0, 0, 2) // 0
+	tester.add(1, 0, 5) // 1
+	tester.add(0, 1, 9) // 2
+	tester.add(1, 1, 1) // 3
+
+	tester.check(arrivalOrdering{}, []int{0, 1, 2, 3})
+	tester.check(priceOrdering{}, []int{1, 0, 2, 3})
+}
+
+func TestFairnessOrdering(t *testing.T) {
+	tester := newOrderingTester(t, 3)
+	tester.add(0, 0, 3) // 0
+	tester.add(0, 1, 3) // 1
+	tester.add(0, 2, 3) // 2
+	tester.add(1, 0, 2) // 3
+	tester.add(2, 0, 1) // 4
+	tester.add(2, 1, 1) // 5
+
+	tester.check(fairnessOrdering{}, []int{0, 3, 4, 1, 5, 2})
+}
+
+func TestPriorityOrdering(t *testing.T) {
+	tester := newOrderingTester(t, 3)
+	tester.add(0, 0, 9) // 0
+	tester.add(1, 0, 1) // 1
+	tester.add(2, 0, 5) // 2
+	tester.add(1, 1, 2) // 3
+
+	priority := crypto.PubkeyToAddress(tester.keys[1].PublicKey)
+	policy, err := NewOrderingPolicy(PriceOrdering)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tester.check(NewPriorityOrdering(policy, []common.Address{priority}), []int{1, 3, 0, 2})
+}
+
+func TestPopSkipsSender(t *testing.T) {
+	// Distinct prices make the first sender win under every policy, independent
+	// of the iteration order of the pending map.
+	tester := newOrderingTester(t, 2)
+	tester.add(0, 0, 3)
+	tester.add(0, 1, 2)
+	tester.add(1, 0, 1)
+
+	for _, policy := range []OrderingPolicy{priceOrdering{}, arrivalOrdering{}, fairnessOrdering{}} {
+		set := policy.Order(tester.signer, tester.pending())
+		if tx := set.Peek(); tx != tester.txs[0] {
+			t.Fatalf("%T: unexpected first transaction", policy)
+		}
+		set.Pop()
+		if tx := set.Peek(); tx != tester.txs[2] {
+			t.Fatalf("%T: popped sender not skipped", policy)
+		}
+		set.Shift()
+		if tx := set.Peek(); tx != nil {
+			t.Fatalf("%T: set not exhausted", policy)
+		}
+	}
+}
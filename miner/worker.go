@@ -27,6 +27,7 @@ import (
 	mapset "github.com/DEWHkarep/golang-set"
 	"github.com/DEWH/go-DEWH/common"
 	"github.com/DEWH/go-DEWH/consensus"
+	"github.com/DEWH/go-DEWH/consensus/clique"
 	"github.com/DEWH/go-DEWH/consensus/misc"
 	"github.com/DEWH/go-DEWH/core"
 	"github.com/DEWH/go-DEWH/core/state"
@@ -42,6 +43,10 @@ const (
 	resultQueueSize  = 10
 	miningLogAtDepth = 5
 
+	// pendingWorkLimit is the maximum number of works pushed for the same head
+	// that are tracked to resolve the results of remote sealers.
+	pendingWorkLimit = 16
+
 	// txChanSize is the size of channel listening to NewTxsEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
@@ -49,6 +54,12 @@ const (
 	chainHeadChanSize = 10
 	// chainSiDEWHhanSize is the size of channel listening to ChainSideEvent.
 	chainSiDEWHhanSize = 10
+
+	// DefaultRecommitInterval is the default interval at which the sealing block
+	// is rebuilt with newly arrived transactions.
+	DefaultRecommitInterval = 3 * time.Second
+	// minRecommitInterval is the minimal interval to rebuild the sealing block at.
+	minRecommitInterval = time.Second
 )
 
 // Agent can register themself with the worker
@@ -78,6 +89,7 @@ type Work struct {
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
+	fees     *big.Int // total transaction fees collected by the block
 
 	createdAt time.Time
 }
@@ -102,6 +114,7 @@ type worker struct {
 	chainHeadSub event.Subscription
 	chainSiDEWHh  chan core.ChainSideEvent
 	chainSideSub event.Subscription
+	recommitCh   chan struct{} // notifies the update loop of a recommit interval change
 	wg           sync.WaitGroup
 
 	agents map[Agent]struct{}
@@ -114,9 +127,18 @@ type worker struct {
 
 	coinbase common.Address
 	extra    []byte
+	ordering OrderingPolicy // decides the order in which pending transactions are included
+	bundles  *bundlePool    // transaction bundles to include atomically
+	recommit time.Duration  // interval to rebuild the sealing block at, 0 to disable
+	gasFloor uint64         // gas limit to raise mined blocks towards
+	gasCeil  uint64         // gas limit to lower mined blocks towards, 0 for no ceiling
 
 	currentMu sync.Mutex
 	current   *Work
+	best      *Work // most valuable work pushed to the agents for the current head
+
+	pendingMu    sync.Mutex
+	pendingWorks map[common.Hash]*Work // works pushed to the agents for the current head, by seal hash
 
 	snapshotMu    sync.RWMutex
 	snapshotBlock *types.Block
@@ -141,12 +163,18 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		txsCh:          make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:    make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSiDEWHh:    make(chan core.ChainSideEvent, chainSiDEWHhanSize),
+		recommitCh:     make(chan struct{}, 1),
 		chainDb:        eth.ChainDb(),
 		recv:           make(chan *Result, resultQueueSize),
 		chain:          eth.BlockChain(),
 		proc:           eth.BlockChain().Validator(),
 		possibleUncles: make(map[common.Hash]*types.Block),
+		pendingWorks:   make(map[common.Hash]*Work),
 		coinbase:       coinbase,
+		ordering:       priceOrdering{},
+		bundles:        newBundlePool(),
+		recommit:       DefaultRecommitInterval,
+		gasFloor:       params.TargetGasLimit,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
 	}
@@ -158,7 +186,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 	go worker.update()
 
 	go worker.wait()
-	worker.commitNewWork()
+	worker.commitNewWork(false)
 
 	return worker
 }
@@ -175,6 +203,73 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+func (self *worker) setOrdering(policy OrderingPolicy) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.ordering = policy
+}
+
+// txOrdering returns the configured ordering policy, putting the priority
+// senders of the transaction pool ahead of everyone else. The caller must
+// hold self.mu.
+func (self *worker) txOrdering() OrderingPolicy {
+	if senders := self.eth.TxPool().PrioritySenders(); len(senders) > 0 {
+		return NewPriorityOrdering(self.ordering, senders)
+	}
+	return self.ordering
+}
+
+// setGasLimit updates the range the gas limit of mined blocks is moved towards.
+func (self *worker) setGasLimit(floor, ceil uint64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.gasFloor, self.gasCeil = floor, ceil
+}
+
+// setRecommitInterval updates the interval at which the sealing block is rebuilt.
+// Intervals below the minimum are raised to it, zero disables rebuilding.
+func (self *worker) setRecommitInterval(interval time.Duration) {
+	if interval > 0 && interval < minRecommitInterval {
+		log.Warn("Sanitizing miner recommit interval", "provided", interval, "updated", minRecommitInterval)
+		interval = minRecommitInterval
+	}
+	self.mu.Lock()
+	self.recommit = interval
+	self.mu.Unlock()
+
+	select {
+	case self.recommitCh <- struct{}{}:
+	default:
+	}
+}
+
+// recommitInterval returns the interval at which the sealing block is rebuilt.
+func (self *worker) recommitInterval() time.Duration {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.recommit
+}
+
+// addBundle schedules a bundle for inclusion into its target block.
+func (self *worker) addBundle(bundle *Bundle) error {
+	signer := types.MakeSigner(self.config, new(big.Int).SetUint64(bundle.BlockNumber))
+	for i, tx := range bundle.Txs {
+		if _, err := types.Sender(signer, tx); err != nil {
+			return fmt.Errorf("transaction %d: %v", i, err)
+		}
+	}
+	return self.bundles.add(bundle, self.chain.CurrentBlock().NumberU64())
+}
+
+// bundleStatus retrieves the status of a submitted bundle.
+func (self *worker) bundleStatus(hash common.Hash) (BundleStatus, error) {
+	status, ok := self.bundles.get(hash)
+	if !ok {
+		return BundleStatus{}, ErrBundleNotFound
+	}
+	return status, nil
+}
+
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	if atomic.LoadInt32(&self.mining) == 0 {
 		// return a snapshot to avoid contention on currentMu mutex
@@ -246,12 +341,43 @@ func (self *worker) update() {
 	defer self.chainHeadSub.Unsubscribe()
 	defer self.chainSideSub.Unsubscribe()
 
+	// The recommit timer periodically rebuilds the block being sealed, so that
+	// transactions arriving after it was started can still make it in
+	recommit := time.NewTimer(0)
+	defer recommit.Stop()
+
+	resetRecommit := func() {
+		if !recommit.Stop() {
+			select {
+			case <-recommit.C:
+			default:
+			}
+		}
+		if interval := self.recommitInterval(); interval > 0 {
+			recommit.Reset(interval)
+		}
+	}
+	resetRecommit()
+
 	for {
 		// A real event arrived, process interesting content
 		select {
 		// Handle ChainHeadEvent
-		case <-self.chainHeadCh:
-			self.commitNewWork()
+		case ev := <-self.chainHeadCh:
+			self.bundles.resolve(ev.Block)
+			self.commitNewWork(false)
+			resetRecommit()
+
+		// Rebuild the sealing block if sealing is in progress
+		case <-recommit.C:
+			if atomic.LoadInt32(&self.mining) == 1 && atomic.LoadInt32(&self.atWork) > 0 {
+				self.commitNewWork(true)
+			}
+			resetRecommit()
+
+		// Restart the timer with the new recommit interval
+		case <-self.recommitCh:
+			resetRecommit()
 
 		// Handle ChainSideEvent
 		case ev := <-self.chainSiDEWHh:
@@ -267,20 +393,24 @@ func (self *worker) update() {
 			// already included in the current mining block. These transactions will
 			// be automatically eliminated.
 			if atomic.LoadInt32(&self.mining) == 0 {
+				self.mu.Lock()
+				ordering := self.txOrdering()
+				self.mu.Unlock()
+
 				self.currentMu.Lock()
 				txs := make(map[common.Address]types.Transactions)
 				for _, tx := range ev.Txs {
 					acc, _ := types.Sender(self.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
+				txset := ordering.Order(self.current.signer, txs)
 				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
 				self.updateSnapshot()
 				self.currentMu.Unlock()
 			} else {
 				// If we're mining, but nothing is being processed, wake on new transactions
-				if self.config.Clique != nil && self.config.Clique.Period == 0 {
-					self.commitNewWork()
+				if self.instantSealing() {
+					self.commitNewWork(false)
 				}
 			}
 
@@ -295,6 +425,21 @@ func (self *worker) update() {
 	}
 }
 
+// instantSealing returns whether the engine seals blocks as soon as there are
+// transactions to include, i.e. clique with the signers' voted period at zero.
+func (self *worker) instantSealing() bool {
+	engine, ok := self.engine.(*clique.Clique)
+	if !ok {
+		return false
+	}
+	period, err := engine.Period(self.chain, self.chain.CurrentHeader())
+	if err != nil {
+		log.Warn("Failed to retrieve block period", "err", err)
+		return false
+	}
+	return period == 0
+}
+
 func (self *worker) wait() {
 	for {
 		for result := range self.recv {
@@ -306,6 +451,13 @@ func (self *worker) wait() {
 			block := result.Block
 			work := result.Work
 
+			// Remote sealers may return a solution for an older work package
+			self.pendingMu.Lock()
+			if pending, ok := self.pendingWorks[block.HashNoNonce()]; ok {
+				work = pending
+			}
+			self.pendingMu.Unlock()
+
 			// Update the block hash in all logs since it is now available and not when the
 			// receipt/log of individual transactions were created.
 			for _, r := range work.receipts {
@@ -346,6 +498,27 @@ func (self *worker) push(work *Work) {
 	if atomic.LoadInt32(&self.mining) != 1 {
 		return
 	}
+	// Track the work to resolve sealing results, dropping any from older heads
+	self.pendingMu.Lock()
+	var (
+		oldestHash common.Hash
+		oldest     *Work
+	)
+	for hash, pending := range self.pendingWorks {
+		if pending.header.ParentHash != work.header.ParentHash {
+			delete(self.pendingWorks, hash)
+			continue
+		}
+		if oldest == nil || pending.createdAt.Before(oldest.createdAt) {
+			oldestHash, oldest = hash, pending
+		}
+	}
+	if len(self.pendingWorks) >= pendingWorkLimit {
+		delete(self.pendingWorks, oldestHash)
+	}
+	self.pendingWorks[work.Block.HashNoNonce()] = work
+	self.pendingMu.Unlock()
+
 	for agent := range self.agents {
 		atomic.AddInt32(&self.atWork, 1)
 		if ch := agent.Work(); ch != nil {
@@ -368,6 +541,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
+		fees:      new(big.Int),
 		createdAt: time.Now(),
 	}
 
@@ -386,7 +560,11 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	return nil
 }
 
-func (self *worker) commitNewWork() {
+// commitNewWork assembles a new block on top of the current chain head and hands
+// it to the sealing agents. If recommit is set, the block replaces one already
+// being sealed on the same parent and is only handed out if it collects more
+// fees than the most valuable block sealed so far.
+func (self *worker) commitNewWork(recommit bool) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 	self.uncleMu.Lock()
@@ -412,7 +590,7 @@ func (self *worker) commitNewWork() {
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent),
+		GasLimit:   core.CalcGasLimit(parent, self.gasFloor, self.gasCeil),
 		Extra:      self.extra,
 		Time:       big.NewInt(tstamp),
 	}
@@ -448,12 +626,20 @@ func (self *worker) commitNewWork() {
 	if self.config.DAOForkSupport && self.config.DAOForkBlock != nil && self.config.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(work.state)
 	}
+	// Bundles go to the top of the block, each either fully or not at all
+	for _, bundle := range self.bundles.bundles(header.Number.Uint64()) {
+		err := work.commitBundle(self.mux, bundle, self.chain, self.coinbase)
+		if err != nil {
+			log.Debug("Bundle excluded from block", "hash", bundle.Hash(), "err", err)
+		}
+		self.bundles.simulated(bundle.Hash(), err)
+	}
 	pending, err := self.eth.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	}
-	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
+	txs := self.txOrdering().Order(self.current.signer, pending)
 	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 
 	// compute uncles for the new block.
@@ -483,11 +669,23 @@ func (self *worker) commitNewWork() {
 		log.Error("Failed to finalize block for sealing", "err", err)
 		return
 	}
+	// Keep sealing the previous block if the rebuilt one isn't more valuable
+	best := self.best
+	if recommit && best != nil && best.header.ParentHash == header.ParentHash && work.fees.Cmp(best.fees) <= 0 {
+		log.Debug("Skipping less valuable recommit", "number", header.Number, "fees", work.fees, "best", best.fees)
+		self.current = best
+		return
+	}
 	// We only care about logging if we're actually mining.
 	if atomic.LoadInt32(&self.mining) == 1 {
-		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(tstart)))
+		if recommit {
+			log.Info("Recommit mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "fees", work.fees, "elapsed", common.PrettyDuration(time.Since(tstart)))
+		} else {
+			log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(tstart)))
+		}
 		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
 	}
+	self.best = work
 	self.push(work)
 	self.updateSnapshot()
 }
@@ -520,7 +718,7 @@ func (self *worker) updateSnapshot() {
 	self.snapshotState = self.current.state.Copy()
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
+func (env *Work) commitTransactions(mux *event.TypeMux, txs TransactionSet, bc *core.BlockChain, coinbase common.Address) {
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
 	}
@@ -605,6 +803,61 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 	}
 }
 
+// commitBundle applies all transactions of a bundle, reverting the work to its
+// previous state if any of them is invalid or fails execution.
+func (env *Work) commitBundle(mux *event.TypeMux, bundle *Bundle, bc *core.BlockChain, coinbase common.Address) error {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	var (
+		snap    = env.state.Snapshot()
+		gas     = env.gasPool.Gas()
+		gasUsed = env.header.GasUsed
+		txs     = len(env.txs)
+		logs    []*types.Log
+	)
+	for i, tx := range bundle.Txs {
+		var (
+			receipt *types.Receipt
+			err     error
+		)
+		if tx.Protected() && !env.config.IsEIP155(env.header.Number) {
+			err = fmt.Errorf("replay protected before EIP155")
+		} else {
+			env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount+i)
+			receipt, _, err = core.ApplyTransaction(env.config, bc, &coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, vm.Config{})
+			if err == nil && receipt.Status == types.ReceiptStatusFailed {
+				err = errBundleReverted
+			}
+		}
+		if err != nil {
+			env.state.RevertToSnapshot(snap)
+			env.gasPool = new(core.GasPool).AddGas(gas)
+			env.header.GasUsed = gasUsed
+			env.txs, env.receipts = env.txs[:txs], env.receipts[:txs]
+			return fmt.Errorf("transaction %d (%x): %v", i, tx.Hash(), err)
+		}
+		env.txs = append(env.txs, tx)
+		env.receipts = append(env.receipts, receipt)
+		logs = append(logs, receipt.Logs...)
+	}
+	for i, tx := range bundle.Txs {
+		env.collectFee(tx, env.receipts[txs+i])
+	}
+	env.tcount += len(bundle.Txs)
+
+	if len(logs) > 0 {
+		// make a copy, see commitTransactions for the reasoning
+		cpy := make([]*types.Log, len(logs))
+		for i, l := range logs {
+			cpy[i] = new(types.Log)
+			*cpy[i] = *l
+		}
+		go mux.Post(core.PendingLogsEvent{Logs: cpy})
+	}
+	return nil
+}
+
 func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) (error, []*types.Log) {
 	snap := env.state.Snapshot()
 
@@ -615,6 +868,13 @@ func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, c
 	}
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
+	env.collectFee(tx, receipt)
 
 	return nil, receipt.Logs
 }
+
+// collectFee adds the fee paid by an included transaction to the block's total.
+func (env *Work) collectFee(tx *types.Transaction, receipt *types.Receipt) {
+	fee := new(big.Int).SetUint64(receipt.GasUsed)
+	env.fees.Add(env.fees, fee.Mul(fee, tx.GasPrice()))
+}
@@ -18,7 +18,9 @@
 package p2p
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
@@ -31,8 +33,10 @@ import (
 	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/p2p/discover"
 	"github.com/DEWH/go-DEWH/p2p/discv5"
+	"github.com/DEWH/go-DEWH/p2p/enr"
 	"github.com/DEWH/go-DEWH/p2p/nat"
 	"github.com/DEWH/go-DEWH/p2p/netutil"
+	"github.com/DEWH/go-DEWH/rlp"
 )
 
 const (
@@ -162,6 +166,10 @@ type Server struct {
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
 
+	recordLock    sync.Mutex  // protects record and recordContent
+	record        *enr.Record // last signed node record of the local node
+	recordContent []byte      // unsigned content of record, to detect changes
+
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
 	peerOpDone chan struct{}
@@ -908,10 +916,11 @@ func (srv *Server) runPeer(p *Peer) {
 
 // NodeInfo represents a short summary of the information known about the host.
 type NodeInfo struct {
-	ID    string `json:"id"`    // Unique node identifier (also the encryption key)
-	Name  string `json:"name"`  // Name of the node, including client type, version, OS, custom data
-	Enode string `json:"enode"` // Enode URL for adding this peer from remote peers
-	IP    string `json:"ip"`    // IP address of the node
+	ID    string `json:"id"`            // Unique node identifier (also the encryption key)
+	Name  string `json:"name"`          // Name of the node, including client type, version, OS, custom data
+	Enode string `json:"enode"`         // Enode URL for adding this peer from remote peers
+	ENR   string `json:"enr,omitempty"` // DEWH Node Record
+	IP    string `json:"ip"`            // IP address of the node
 	Ports struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
@@ -936,6 +945,13 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
 
+	if record, err := srv.NodeRecord(); err == nil {
+		blob, _ := rlp.EncodeToBytes(record)
+		info.ENR = "enr:" + base64.RawURLEncoding.EncodeToString(blob)
+	} else {
+		srv.log.Warn("Failed to create node record", "err", err)
+	}
+
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
 		if _, ok := info.Protocols[proto.Name]; !ok {
@@ -949,6 +965,51 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	return info
 }
 
+// NodeRecord returns the signed node record of the local node, carrying its
+// endpoint and the entries advertised by the running protocols. The sequence
+// number of the record is bumped whenever its content changes.
+//
+// Note, the discovery protocols don't carry node records yet, so the record is
+// not seen by remote nodes and can't be used to filter them before dialing.
+func (srv *Server) NodeRecord() (*enr.Record, error) {
+	node := srv.Self()
+
+	var record enr.Record
+	if node.IP != nil && !node.IP.IsUnspecified() {
+		record.Set(enr.IP(node.IP))
+	}
+	if node.TCP != 0 {
+		record.Set(enr.TCP(node.TCP))
+	}
+	if node.UDP != 0 {
+		record.Set(enr.UDP(node.UDP))
+	}
+	for _, proto := range srv.Protocols {
+		for _, attr := range proto.Attributes {
+			record.Set(attr)
+		}
+	}
+	// Skip the sequence number when comparing against the last record
+	content, err := rlp.EncodeToBytes(record.AppendElements(nil)[1:])
+	if err != nil {
+		return nil, err
+	}
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+
+	if srv.record != nil && bytes.Equal(content, srv.recordContent) {
+		return srv.record, nil
+	}
+	if srv.record != nil {
+		record.SetSeq(srv.record.Seq() + 1)
+	}
+	if err := enr.SignV4(&record, srv.PrivateKey); err != nil {
+		return nil, err
+	}
+	srv.record, srv.recordContent = &record, content
+	return &record, nil
+}
+
 // PeersInfo returns an array of metadata objects describing connected peers.
 func (srv *Server) PeersInfo() []*PeerInfo {
 	// Gather all the generic and sub-protocol specific infos
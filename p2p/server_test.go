@@ -29,6 +29,7 @@ import (
 	"github.com/DEWH/go-DEWH/crypto/sha3"
 	"github.com/DEWH/go-DEWH/log"
 	"github.com/DEWH/go-DEWH/p2p/discover"
+	"github.com/DEWH/go-DEWH/p2p/enr"
 )
 
 func init() {
@@ -122,6 +123,55 @@ func TestServerListen(t *testing.T) {
 	}
 }
 
+// Tests that the node record carries the listener endpoint and the protocol
+// attributes, and is only re-signed with a higher sequence number on change.
+func TestServerNodeRecord(t *testing.T) {
+	value := uint(1)
+	srv := &Server{
+		Config: Config{
+			Name:        "test",
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			PrivateKey:  newkey(),
+			Protocols:   []Protocol{{Name: "test", Attributes: []enr.Entry{enr.WithEntry("test", &value)}}},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	first, err := srv.NodeRecord()
+	if err != nil {
+		t.Fatalf("failed to create node record: %v", err)
+	}
+	var (
+		tcp  enr.TCP
+		have uint
+	)
+	if err := first.Load(&tcp); err != nil || int(tcp) != srv.listener.Addr().(*net.TCPAddr).Port {
+		t.Errorf("tcp port mismatch: have %d (%v), want %d", tcp, err, srv.listener.Addr().(*net.TCPAddr).Port)
+	}
+	if err := first.Load(enr.WithEntry("test", &have)); err != nil || have != 1 {
+		t.Errorf("attribute mismatch: have %d (%v), want %d", have, err, 1)
+	}
+	if second, _ := srv.NodeRecord(); second != first {
+		t.Errorf("unchanged record re-signed: seq %d -> %d", first.Seq(), second.Seq())
+	}
+	value = 2
+	third, err := srv.NodeRecord()
+	if err != nil {
+		t.Fatalf("failed to update node record: %v", err)
+	}
+	if third.Seq() <= first.Seq() {
+		t.Errorf("sequence number not bumped: have %d, previous %d", third.Seq(), first.Seq())
+	}
+	if err := third.Load(enr.WithEntry("test", &have)); err != nil || have != 2 {
+		t.Errorf("updated attribute mismatch: have %d (%v), want %d", have, err, 2)
+	}
+}
+
 func TestServerDial(t *testing.T) {
 	// run a one-shot TCP server to handle the connection.
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
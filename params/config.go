@@ -17,10 +17,12 @@
 package params
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math/big"
 
 	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/crypto/sha3"
 )
 
 // Genesis hashes to enforce below configs on.
@@ -83,19 +85,76 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(EthashConfig), nil, nil, nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the DEWH core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000, ParamVoteBlock: big.NewInt(0)}, nil, nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(EthashConfig), nil, nil, nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
+var (
+	// MainnetTrustedCheckpoint contains the light client trusted checkpoint for the main network.
+	MainnetTrustedCheckpoint = &TrustedCheckpoint{
+		SectionIndex: 179,
+		SectionHead:  common.HexToHash("ae778e455492db1183e566fa0c67f954d256fdd08618f6d5a393b0e24576d0ea"),
+		CHTRoot:      common.HexToHash("646b338f9ca74d936225338916be53710ec84020b89946004a8605f04c817f16"),
+		BloomRoot:    common.HexToHash("d0f978f5dbc86e5bf931d8dd5b2ecbebbda6dc78f8896af6a27b46a3ced0ac25"),
+	}
+
+	// TestnetTrustedCheckpoint contains the light client trusted checkpoint for the Ropsten test network.
+	TestnetTrustedCheckpoint = &TrustedCheckpoint{
+		SectionIndex: 107,
+		SectionHead:  common.HexToHash("e1988f95399debf45b873e065e5cd61b416ef2e2e5deec5a6f87c3127086e1ce"),
+		CHTRoot:      common.HexToHash("15cba18e4de0ab1e95e202625199ba30147aec8b0b70384b66ebea31ba6a18e0"),
+		BloomRoot:    common.HexToHash("e00fa6389b2e597d9df52172cd8e936879eed0fca4fa59db99e2c8ed682562f2"),
+	}
+)
+
+// TrustedCheckpoints associates each known checkpoint with the genesis hash of
+// the chain it belongs to.
+var TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{
+	MainnetGenesisHash: MainnetTrustedCheckpoint,
+	TestnetGenesisHash: TestnetTrustedCheckpoint,
+}
+
+// TrustedCheckpoint represents a set of post-processed trie roots (CHT and
+// BloomTrie) associated with the appropriate section index and head hash. It is
+// used to start light syncing from this checkpoint and avoid downloading the
+// entire header chain while still being able to securely access old headers/logs.
+type TrustedCheckpoint struct {
+	SectionIndex uint64      `json:"sectionIndex"`
+	SectionHead  common.Hash `json:"sectionHead"`
+	CHTRoot      common.Hash `json:"chtRoot"`
+	BloomRoot    common.Hash `json:"bloomRoot"`
+}
+
+// Hash returns the hash of the checkpoint as registered in the checkpoint
+// oracle: keccak256(sectionIndex || sectionHead || chtRoot || bloomRoot).
+func (c *TrustedCheckpoint) Hash() common.Hash {
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], c.SectionIndex)
+
+	var h common.Hash
+	hasher := sha3.NewKeccak256()
+	hasher.Write(index[:])
+	hasher.Write(c.SectionHead[:])
+	hasher.Write(c.CHTRoot[:])
+	hasher.Write(c.BloomRoot[:])
+	hasher.Sum(h[:0])
+	return h
+}
+
+// Empty returns whether the checkpoint is missing any of its roots.
+func (c *TrustedCheckpoint) Empty() bool {
+	return c.SectionHead == (common.Hash{}) || c.CHTRoot == (common.Hash{}) || c.BloomRoot == (common.Hash{})
+}
+
 // ChainConfig is the core config which determines the blockchain settings.
 //
 // ChainConfig is stored in the database on a per block basis. This means
@@ -119,9 +178,17 @@ type ChainConfig struct {
 	ByzantiumBlock      *big.Int `json:"byzantiumBlock,omitempty"`      // Byzantium switch block (nil = no fork, 0 = already on byzantium)
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 
+	// Forks may alternatively be scheduled by header timestamp. If a switch time
+	// is set, it takes precedence over the switch block of the same fork.
+	ConstantinopleTime *big.Int `json:"constantinopleTime,omitempty"` // Constantinople switch time (nil = scheduled by block)
+
 	// Various consensus engines
-	Ethash *EthashConfig `json:"ethash,omitempty"`
-	Clique *CliqueConfig `json:"clique,omitempty"`
+	Ethash   *EthashConfig   `json:"ethash,omitempty"`
+	Clique   *CliqueConfig   `json:"clique,omitempty"`
+	Istanbul *IstanbulConfig `json:"istanbul,omitempty"`
+	Dpos     *DposConfig     `json:"dpos,omitempty"`
+
+	Rewards *RewardConfig `json:"rewards,omitempty"` // Block reward schedule (nil = Frontier/Byzantium defaults)
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -136,6 +203,8 @@ func (c *EthashConfig) String() string {
 type CliqueConfig struct {
 	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
 	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+
+	ParamVoteBlock *big.Int `json:"paramVoteBlock,omitempty"` // Block from which signers may vote on chain parameters (nil = never)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -143,6 +212,109 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// IsParamVote returns whether num is either equal to the block enabling chain
+// parameter votes or greater.
+func (c *CliqueConfig) IsParamVote(num *big.Int) bool {
+	return isForked(c.ParamVoteBlock, num)
+}
+
+// IstanbulConfig is the consensus engine configs for Byzantine fault tolerant
+// proof-of-authority sealing.
+type IstanbulConfig struct {
+	Period         uint64 `json:"period"`         // Number of seconds between blocks to enforce
+	Epoch          uint64 `json:"epoch"`          // Epoch length to reset votes and checkpoint
+	RequestTimeout uint64 `json:"requestTimeout"` // Milliseconds before a consensus round times out
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *IstanbulConfig) String() string {
+	return "istanbul"
+}
+
+// DposConfig is the consensus engine configs for delegated proof-of-stake based
+// sealing.
+type DposConfig struct {
+	Period     uint64 `json:"period"`     // Number of seconds in a block production slot
+	Epoch      uint64 `json:"epoch"`      // Number of slots between validator elections
+	Validators uint64 `json:"validators"` // Maximum number of elected block producers
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *DposConfig) String() string {
+	return "dpos"
+}
+
+// RewardConfig is the block issuance schedule applied by the proof-of-work
+// engine in place of the hard-coded Frontier and Byzantium rewards.
+type RewardConfig struct {
+	Eras []*RewardEra `json:"eras"` // Reward eras in ascending order of their first block
+}
+
+// RewardEra is the issuance in effect from its first block until the start of
+// the next era.
+type RewardEra struct {
+	Block           *big.Int        `json:"block"`                     // First block the era applies to
+	Reward          *big.Int        `json:"reward"`                    // Static block reward in wei
+	UncleDivisor    uint64          `json:"uncleDivisor"`              // Uncle reward shrinks by Reward/UncleDivisor per block of depth (0 = no uncle reward)
+	NephewDivisor   uint64          `json:"nephewDivisor"`             // Miner earns Reward/NephewDivisor per included uncle (0 = no inclusion reward)
+	Treasury        *common.Address `json:"treasury,omitempty"`        // Address receiving a share of the static block reward
+	TreasuryPercent uint64          `json:"treasuryPercent,omitempty"` // Percentage of the static block reward paid to the treasury
+}
+
+// Era returns the reward era active at block num, or nil if the schedule does
+// not cover it and the default rewards apply.
+func (c *RewardConfig) Era(num *big.Int) *RewardEra {
+	if c == nil {
+		return nil
+	}
+	var active *RewardEra
+	for _, era := range c.Eras {
+		if isForked(era.Block, num) && (active == nil || era.Block.Cmp(active.Block) > 0) {
+			active = era
+		}
+	}
+	return active
+}
+
+// Validate checks that the reward schedule is well formed.
+func (c *RewardConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for i, era := range c.Eras {
+		if era.Block == nil || era.Reward == nil {
+			return fmt.Errorf("reward era %d: missing block or reward", i)
+		}
+		if era.Reward.Sign() < 0 {
+			return fmt.Errorf("reward era %d: negative reward %v", i, era.Reward)
+		}
+		if i > 0 && era.Block.Cmp(c.Eras[i-1].Block) <= 0 {
+			return fmt.Errorf("reward era %d: block %v not after previous era block %v", i, era.Block, c.Eras[i-1].Block)
+		}
+		if era.TreasuryPercent > 100 {
+			return fmt.Errorf("reward era %d: treasury percentage %d above 100", i, era.TreasuryPercent)
+		}
+		if era.TreasuryPercent > 0 && era.Treasury == nil {
+			return fmt.Errorf("reward era %d: treasury percentage without treasury address", i)
+		}
+	}
+	return nil
+}
+
+// equal returns whether two reward eras define the same issuance.
+func (era *RewardEra) equal(other *RewardEra) bool {
+	if !configNumEqual(era.Block, other.Block) || !configNumEqual(era.Reward, other.Reward) {
+		return false
+	}
+	if era.UncleDivisor != other.UncleDivisor || era.NephewDivisor != other.NephewDivisor {
+		return false
+	}
+	if (era.Treasury == nil) != (other.Treasury == nil) || (era.Treasury != nil && *era.Treasury != *other.Treasury) {
+		return false
+	}
+	return era.TreasuryPercent == other.TreasuryPercent
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -151,10 +323,14 @@ func (c *ChainConfig) String() string {
 		engine = c.Ethash
 	case c.Clique != nil:
 		engine = c.Clique
+	case c.Istanbul != nil:
+		engine = c.Istanbul
+	case c.Dpos != nil:
+		engine = c.Dpos
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v ConstantinopleTime: %v Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -164,6 +340,7 @@ func (c *ChainConfig) String() string {
 		c.EIP158Block,
 		c.ByzantiumBlock,
 		c.ConstantinopleBlock,
+		c.ConstantinopleTime,
 		engine,
 	)
 }
@@ -198,20 +375,25 @@ func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isForked(c.ByzantiumBlock, num)
 }
 
-// IsConstantinople returns whether num is either equal to the Constantinople fork block or greater.
-func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
+// IsConstantinople returns whether the Constantinople fork is active for a block
+// with the given number and timestamp. A configured switch time takes precedence
+// over the switch block.
+func (c *ChainConfig) IsConstantinople(num, time *big.Int) bool {
+	if c.ConstantinopleTime != nil {
+		return isForked(c.ConstantinopleTime, time)
+	}
 	return isForked(c.ConstantinopleBlock, num)
 }
 
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
-func (c *ChainConfig) GasTable(num *big.Int) GasTable {
+func (c *ChainConfig) GasTable(num, time *big.Int) GasTable {
 	if num == nil {
 		return GasTableHomestead
 	}
 	switch {
-	case c.IsConstantinople(num):
+	case c.IsConstantinople(num, time):
 		return GasTableConstantinople
 	case c.IsEIP158(num):
 		return GasTableEIP158
@@ -222,25 +404,51 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	}
 }
 
-// CheckCompatible checks whether scheduled fork transitions have been imported
-// with a mismatching chain configuration.
-func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
-	bhead := new(big.Int).SetUint64(height)
+// Validate checks that the chain configuration is well formed.
+func (c *ChainConfig) Validate() error {
+	if c.ConstantinopleBlock != nil && c.ConstantinopleTime != nil {
+		return fmt.Errorf("Constantinople scheduled both at block %v and time %v", c.ConstantinopleBlock, c.ConstantinopleTime)
+	}
+	return c.Rewards.Validate()
+}
 
-	// Iterate checkCompatible to find the lowest conflict.
-	var lasterr *ConfigCompatError
+// CheckCompatible checks whether scheduled fork transitions have been imported
+// with a mismatching chain configuration. The height and time are the number
+// and timestamp of the local head block.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64, time uint64) *ConfigCompatError {
+	var (
+		bhead = new(big.Int).SetUint64(height)
+		btime = new(big.Int).SetUint64(time)
+	)
+	// Iterate checkCompatible to find the lowest conflict, separately for the
+	// block and the timestamp based forks.
+	var blockerr, timeerr, lasterr *ConfigCompatError
 	for {
-		err := c.checkCompatible(newcfg, bhead)
-		if err == nil || (lasterr != nil && err.RewindTo == lasterr.RewindTo) {
+		err := c.checkCompatible(newcfg, bhead, btime)
+		if err == nil || (lasterr != nil && err.RewindTo == lasterr.RewindTo && err.RewindToTime == lasterr.RewindToTime) {
 			break
 		}
 		lasterr = err
-		bhead.SetUint64(err.RewindTo)
+		if err.RewindToTime > 0 {
+			timeerr = err
+			btime.SetUint64(err.RewindToTime)
+		} else {
+			blockerr = err
+			bhead.SetUint64(err.RewindTo)
+		}
+	}
+	// If both kinds of forks conflict, report the block one but keep the time
+	// to rewind to as well, the caller rewinds to whichever is earlier.
+	if blockerr == nil {
+		return timeerr
+	}
+	if timeerr != nil {
+		blockerr.RewindToTime = timeerr.RewindToTime
 	}
-	return lasterr
+	return blockerr
 }
 
-func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int, time *big.Int) *ConfigCompatError {
 	if isForkIncompatible(c.HomesteadBlock, newcfg.HomesteadBlock, head) {
 		return newCompatError("Homestead fork block", c.HomesteadBlock, newcfg.HomesteadBlock)
 	}
@@ -268,16 +476,69 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
 		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
 	}
+	if isForkIncompatible(c.ConstantinopleTime, newcfg.ConstantinopleTime, time) {
+		return newTimestampCompatError("Constantinople fork timestamp", c.ConstantinopleTime, newcfg.ConstantinopleTime)
+	}
+	if isForkIncompatible(c.cliqueParamVoteBlock(), newcfg.cliqueParamVoteBlock(), head) {
+		return newCompatError("Clique parameter vote block", c.cliqueParamVoteBlock(), newcfg.cliqueParamVoteBlock())
+	}
+	if storedblock, newblock, ok := isRewardIncompatible(c.Rewards, newcfg.Rewards, head); ok {
+		return newCompatError("reward era", storedblock, newblock)
+	}
 	return nil
 }
 
+// cliqueParamVoteBlock returns the block enabling the clique chain parameter
+// votes, or nil if the chain doesn't use clique.
+func (c *ChainConfig) cliqueParamVoteBlock() *big.Int {
+	if c.Clique == nil {
+		return nil
+	}
+	return c.Clique.ParamVoteBlock
+}
+
+// isRewardIncompatible returns the starting blocks of the first pair of reward
+// eras that differ and are already active at head in either schedule.
+func isRewardIncompatible(r1, r2 *RewardConfig, head *big.Int) (*big.Int, *big.Int, bool) {
+	var e1, e2 []*RewardEra
+	if r1 != nil {
+		e1 = r1.Eras
+	}
+	if r2 != nil {
+		e2 = r2.Eras
+	}
+	for i := 0; i < len(e1) || i < len(e2); i++ {
+		var s1, s2 *RewardEra
+		if i < len(e1) {
+			s1 = e1[i]
+		}
+		if i < len(e2) {
+			s2 = e2[i]
+		}
+		switch {
+		case s1 == nil && isForked(s2.Block, head):
+			return nil, s2.Block, true
+		case s2 == nil && isForked(s1.Block, head):
+			return s1.Block, nil, true
+		case s1 == nil || s2 == nil:
+			return nil, nil, false
+		case !isForked(s1.Block, head) && !isForked(s2.Block, head):
+			return nil, nil, false
+		case !s1.equal(s2):
+			return s1.Block, s2.Block, true
+		}
+	}
+	return nil, nil, false
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
 	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
 }
 
-// isForked returns whether a fork scheduled at block s is active at the given head block.
+// isForked returns whether a fork scheduled at block (or timestamp) s is active
+// at the given head block (or timestamp).
 func isForked(s, head *big.Int) bool {
 	if s == nil || head == nil {
 		return false
@@ -299,30 +560,49 @@ func configNumEqual(x, y *big.Int) bool {
 // ChainConfig that would alter the past.
 type ConfigCompatError struct {
 	What string
-	// block numbers of the stored and new configurations
+	// block numbers (or timestamps) of the stored and new configurations
 	StoredConfig, NewConfig *big.Int
 	// the block number to which the local chain must be rewound to correct the error
 	RewindTo uint64
+	// the timestamp to which the local chain must be rewound, for time based forks
+	RewindToTime uint64
 }
 
 func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatError {
-	var rew *big.Int
-	switch {
-	case storedblock == nil:
-		rew = newblock
-	case newblock == nil || storedblock.Cmp(newblock) < 0:
-		rew = storedblock
-	default:
-		rew = newblock
-	}
-	err := &ConfigCompatError{what, storedblock, newblock, 0}
-	if rew != nil && rew.Sign() > 0 {
+	err := &ConfigCompatError{What: what, StoredConfig: storedblock, NewConfig: newblock}
+	if rew := rewindPoint(storedblock, newblock); rew != nil && rew.Sign() > 0 {
 		err.RewindTo = rew.Uint64() - 1
 	}
 	return err
 }
 
+func newTimestampCompatError(what string, storedtime, newtime *big.Int) *ConfigCompatError {
+	err := &ConfigCompatError{What: what, StoredConfig: storedtime, NewConfig: newtime}
+	if rew := rewindPoint(storedtime, newtime); rew != nil && rew.Sign() > 0 {
+		err.RewindToTime = rew.Uint64() - 1
+	}
+	return err
+}
+
+// rewindPoint returns the earlier of two fork switches, ignoring unset ones.
+func rewindPoint(stored, next *big.Int) *big.Int {
+	switch {
+	case stored == nil:
+		return next
+	case next == nil || stored.Cmp(next) < 0:
+		return stored
+	default:
+		return next
+	}
+}
+
 func (err *ConfigCompatError) Error() string {
+	if err.RewindToTime > 0 && err.RewindTo == 0 {
+		return fmt.Sprintf("mismatching %s in database (have timestamp %d, want timestamp %d, rewindto timestamp %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindToTime)
+	}
+	if err.RewindToTime > 0 {
+		return fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d, rewindto timestamp %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo, err.RewindToTime)
+	}
 	return fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo)
 }
 
@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/DEWH/go-DEWH/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,12 +72,201 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{Clique: &CliqueConfig{ParamVoteBlock: big.NewInt(30)}},
+			new:     &ChainConfig{Clique: &CliqueConfig{ParamVoteBlock: big.NewInt(40)}},
+			head:    20,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Clique: &CliqueConfig{ParamVoteBlock: big.NewInt(10)}},
+			new:    &ChainConfig{Clique: &CliqueConfig{}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Clique parameter vote block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    nil,
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
-		err := test.stored.CheckCompatible(test.new, test.head)
+		err := test.stored.CheckCompatible(test.new, test.head, 0)
 		if !reflect.DeepEqual(err, test.wantErr) {
 			t.Errorf("error mismatch:\nstored: %v\nnew: %v\nhead: %v\nerr: %v\nwant: %v", test.stored, test.new, test.head, err, test.wantErr)
 		}
 	}
 }
+
+func TestCheckCompatibleTimestamp(t *testing.T) {
+	tests := []struct {
+		stored, new *ChainConfig
+		head, time  uint64
+		wantErr     *ConfigCompatError
+	}{
+		// Future switch times may be freely changed
+		{
+			stored: &ChainConfig{ConstantinopleTime: big.NewInt(100)},
+			new:    &ChainConfig{ConstantinopleTime: big.NewInt(200)},
+			head:   10,
+			time:   50,
+		},
+		// Changing a passed switch time requires a rewind before it
+		{
+			stored: &ChainConfig{ConstantinopleTime: big.NewInt(100)},
+			new:    &ChainConfig{ConstantinopleTime: big.NewInt(200)},
+			head:   10,
+			time:   150,
+			wantErr: &ConfigCompatError{
+				What:         "Constantinople fork timestamp",
+				StoredConfig: big.NewInt(100),
+				NewConfig:    big.NewInt(200),
+				RewindToTime: 99,
+			},
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{ConstantinopleTime: big.NewInt(100)},
+			head:   10,
+			time:   150,
+			wantErr: &ConfigCompatError{
+				What:         "Constantinople fork timestamp",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(100),
+				RewindToTime: 99,
+			},
+		},
+		// Switching a passed block based fork to a time based one requires a rewind
+		// before both of them
+		{
+			stored: &ChainConfig{ConstantinopleBlock: big.NewInt(5)},
+			new:    &ChainConfig{ConstantinopleTime: big.NewInt(100)},
+			head:   10,
+			time:   150,
+			wantErr: &ConfigCompatError{
+				What:         "Constantinople fork block",
+				StoredConfig: big.NewInt(5),
+				NewConfig:    nil,
+				RewindTo:     4,
+				RewindToTime: 99,
+			},
+		},
+	}
+	for i, test := range tests {
+		err := test.stored.CheckCompatible(test.new, test.head, test.time)
+		if !reflect.DeepEqual(err, test.wantErr) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestIsConstantinopleTimestamp(t *testing.T) {
+	config := &ChainConfig{ConstantinopleTime: big.NewInt(100)}
+	if config.IsConstantinople(big.NewInt(1000), big.NewInt(99)) {
+		t.Errorf("fork active before switch time")
+	}
+	if !config.IsConstantinople(big.NewInt(0), big.NewInt(100)) {
+		t.Errorf("fork inactive at switch time")
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("time based config rejected: %v", err)
+	}
+	config.ConstantinopleBlock = big.NewInt(10)
+	if err := config.Validate(); err == nil {
+		t.Errorf("config scheduled both by block and time accepted")
+	}
+}
+
+func TestCheckCompatibleRewards(t *testing.T) {
+	treasury := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	schedule := func(eras ...*RewardEra) *ChainConfig {
+		return &ChainConfig{Rewards: &RewardConfig{Eras: eras}}
+	}
+	era := func(block, reward int64, percent uint64) *RewardEra {
+		e := &RewardEra{Block: big.NewInt(block), Reward: big.NewInt(reward), UncleDivisor: 8, NephewDivisor: 32}
+		if percent > 0 {
+			e.Treasury, e.TreasuryPercent = &treasury, percent
+		}
+		return e
+	}
+	tests := []struct {
+		stored, new *ChainConfig
+		head        uint64
+		wantErr     *ConfigCompatError
+	}{
+		// Identical schedules are compatible
+		{stored: schedule(era(0, 5, 0), era(10, 3, 10)), new: schedule(era(0, 5, 0), era(10, 3, 10)), head: 100},
+		// Future eras may be freely changed, added or removed
+		{stored: schedule(era(0, 5, 0), era(10, 3, 0)), new: schedule(era(0, 5, 0), era(20, 2, 5)), head: 9},
+		{stored: schedule(era(0, 5, 0)), new: schedule(era(0, 5, 0), era(10, 3, 0)), head: 9},
+		{stored: &ChainConfig{}, new: schedule(era(10, 3, 0)), head: 9},
+		// Changing an active era requires a rewind before its start
+		{
+			stored:  schedule(era(0, 5, 0), era(10, 3, 0)),
+			new:     schedule(era(0, 5, 0), era(10, 3, 10)),
+			head:    15,
+			wantErr: &ConfigCompatError{What: "reward era", StoredConfig: big.NewInt(10), NewConfig: big.NewInt(10), RewindTo: 9},
+		},
+		{
+			stored:  schedule(era(0, 5, 0), era(10, 3, 0)),
+			new:     schedule(era(0, 5, 0), era(20, 3, 0)),
+			head:    15,
+			wantErr: &ConfigCompatError{What: "reward era", StoredConfig: big.NewInt(10), NewConfig: big.NewInt(20), RewindTo: 9},
+		},
+		// Adding or removing an active era requires a rewind too
+		{
+			stored:  &ChainConfig{},
+			new:     schedule(era(10, 3, 0)),
+			head:    15,
+			wantErr: &ConfigCompatError{What: "reward era", StoredConfig: nil, NewConfig: big.NewInt(10), RewindTo: 9},
+		},
+		{
+			stored:  schedule(era(0, 5, 0), era(10, 3, 0)),
+			new:     schedule(era(0, 5, 0)),
+			head:    15,
+			wantErr: &ConfigCompatError{What: "reward era", StoredConfig: big.NewInt(10), NewConfig: nil, RewindTo: 9},
+		},
+	}
+	for i, test := range tests {
+		err := test.stored.CheckCompatible(test.new, test.head, 0)
+		if !reflect.DeepEqual(err, test.wantErr) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestRewardEra(t *testing.T) {
+	rewards := &RewardConfig{Eras: []*RewardEra{
+		{Block: big.NewInt(10), Reward: big.NewInt(5)},
+		{Block: big.NewInt(20), Reward: big.NewInt(3)},
+	}}
+	if err := rewards.Validate(); err != nil {
+		t.Fatalf("valid schedule rejected: %v", err)
+	}
+	tests := []struct {
+		number int64
+		want   *RewardEra
+	}{
+		{0, nil}, {9, nil}, {10, rewards.Eras[0]}, {19, rewards.Eras[0]}, {20, rewards.Eras[1]}, {1000, rewards.Eras[1]},
+	}
+	for _, test := range tests {
+		if era := rewards.Era(big.NewInt(test.number)); era != test.want {
+			t.Errorf("block %d: era mismatch: have %v, want %v", test.number, era, test.want)
+		}
+	}
+	if era := (*RewardConfig)(nil).Era(big.NewInt(10)); era != nil {
+		t.Errorf("nil schedule returned era %v", era)
+	}
+	invalid := []*RewardConfig{
+		{Eras: []*RewardEra{{Block: big.NewInt(0)}}},
+		{Eras: []*RewardEra{{Block: big.NewInt(10), Reward: big.NewInt(1)}, {Block: big.NewInt(10), Reward: big.NewInt(1)}}},
+		{Eras: []*RewardEra{{Block: big.NewInt(0), Reward: big.NewInt(1), TreasuryPercent: 10}}},
+		{Eras: []*RewardEra{{Block: big.NewInt(0), Reward: big.NewInt(1), Treasury: new(common.Address), TreasuryPercent: 101}}},
+	}
+	for i, rewards := range invalid {
+		if err := rewards.Validate(); err == nil {
+			t.Errorf("invalid schedule %d accepted", i)
+		}
+	}
+}
@@ -94,5 +94,13 @@ Subscriptions are deleted when:
  - the connection which was used to create the subscription is closed. This can be initiated
    by the client and server. The server will close the connection on a write error or when
    the queue of buffered notifications gets too big.
+
+Plain HTTP requests cannot carry notifications. HTTP clients which accept a server-sent
+event stream (text/event-stream) instead receive the response as the first event of a
+stream which stays open, followed by the notifications of the created subscription. The
+request is either POSTed as usual, or for EventSource style clients passed to a GET
+request through the method and (JSON encoded) params query parameters:
+ GET /?method=eth_subscribe&params=["newHeads"]
+The subscription is cancelled when the client closes the stream.
 */
 package rpc
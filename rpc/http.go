@@ -223,6 +223,12 @@ func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv *S
 
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Requests accepting an event stream get their response and all subsequent
+	// subscription notifications as server-sent events
+	if isSSERequest(r) {
+		srv.serveSSE(w, r)
+		return
+	}
 	// Permit dumb empty requests for remote health-checks (AWS)
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
 		return
@@ -266,9 +272,13 @@ func validateRequest(r *http.Request) (int, error) {
 }
 
 func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+	// Event streams opened through GET escape the browser preflight, check them
+	// against the same origins as everything else
+	handler := newSSEOriginHandler(allowedOrigins, srv)
+
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
-		return srv
+		return handler
 	}
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
@@ -276,7 +286,7 @@ func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
 		MaxAge:         600,
 		AllowedHeaders: []string{"*"},
 	})
-	return c.Handler(srv)
+	return c.Handler(handler)
 }
 
 // virtualHostHandler is a handler which validates the Host-header of incoming requests.
@@ -73,6 +73,17 @@ type jsonNotification struct {
 	Params  jsonSubscription `json:"params"`
 }
 
+type jsonSubscriptionError struct {
+	Subscription string    `json:"subscription"`
+	Error        jsonError `json:"error"`
+}
+
+type jsonErrNotification struct {
+	Version string                `json:"jsonrpc"`
+	Method  string                `json:"method"`
+	Params  jsonSubscriptionError `json:"params"`
+}
+
 // jsonCoDEWH reads and writes JSON-RPC messages to the underlying connection. It
 // also has support for parsing arguments and serializing (result) objects.
 type jsonCoDEWH struct {
@@ -341,6 +352,13 @@ func (c *jsonCoDEWH) CreateNotification(subid, namespace string, event interface
 		Params: jsonSubscription{Subscription: subid, Result: event}}
 }
 
+// CreateErrorNotification will create a JSON-RPC notification with the given subscription id and an error
+// as params, informing the client that the subscription was terminated by the server.
+func (c *jsonCoDEWH) CreateErrorNotification(subid, namespace string, err Error) interface{} {
+	return &jsonErrNotification{Version: jsonrpcVersion, Method: namespace + notificationMethodSuffix,
+		Params: jsonSubscriptionError{Subscription: subid, Error: jsonError{Code: err.ErrorCode(), Message: err.Error()}}}
+}
+
 // Write message to client
 func (c *jsonCoDEWH) Write(res interface{}) error {
 	c.encMu.Lock()
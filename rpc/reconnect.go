@@ -0,0 +1,220 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/log"
+)
+
+// ReconnectConfig defines how a reconnecting subscription redials the server
+// after its connection was lost.
+type ReconnectConfig struct {
+	MinBackoff time.Duration // delay before the first resubscription attempt
+	MaxBackoff time.Duration // upper bound of the exponentially growing delay between attempts
+}
+
+// DefaultReconnectConfig contains the reconnection settings to use when none
+// are explicitly configured.
+var DefaultReconnectConfig = ReconnectConfig{
+	MinBackoff: 100 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// SubscriptionGap reports that a reconnecting subscription was interrupted and
+// re-established. Notifications issued by the server between Lost and Restored
+// were not delivered.
+type SubscriptionGap struct {
+	Err      error     // error which interrupted the subscription
+	Lost     time.Time // time when the subscription failed
+	Restored time.Time // time when the subscription was re-established
+}
+
+// ReconnectingSubscription is a subscription which survives the loss of the
+// client connection. Whenever the underlying subscription fails, the client
+// redials the server with exponential backoff and reissues the original
+// subscribe request, delivering notifications to the same channel. Every
+// interruption is reported on the Gaps channel, allowing consumers to recover
+// the notifications they missed.
+type ReconnectingSubscription struct {
+	client    *Client
+	config    ReconnectConfig
+	namespace string
+	channel   interface{}
+	args      []interface{}
+
+	gaps      chan SubscriptionGap
+	err       chan error
+	unsub     chan struct{}
+	unsubOnce sync.Once
+	done      chan struct{}
+}
+
+// ReconnectingSubscribe registers a subscription just like Subscribe, but keeps
+// it established across connection failures using the given reconnection
+// settings. Only the initial subscription attempt is bounded by ctx.
+//
+// The subscription only ends with an error if the server refuses to reinstate
+// it after a reconnect. It ends without error if the client is closed.
+func (c *Client) ReconnectingSubscribe(ctx context.Context, config ReconnectConfig, namespace string, channel interface{}, args ...interface{}) (*ReconnectingSubscription, error) {
+	sub, err := c.Subscribe(ctx, namespace, channel, args...)
+	if err != nil {
+		return nil, err
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultReconnectConfig.MinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = config.MinBackoff
+	}
+	rsub := &ReconnectingSubscription{
+		client:    c,
+		config:    config,
+		namespace: namespace,
+		channel:   channel,
+		args:      args,
+		gaps:      make(chan SubscriptionGap, 1),
+		err:       make(chan error, 1),
+		unsub:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go rsub.loop(sub)
+	return rsub, nil
+}
+
+// Gaps returns a channel which receives a value every time the subscription was
+// re-established after a failure. Interruptions which happen while a previous
+// gap was not yet received are merged into it.
+func (sub *ReconnectingSubscription) Gaps() <-chan SubscriptionGap {
+	return sub.gaps
+}
+
+// Err returns the subscription error channel. It receives a value when the
+// subscription has ended for good: nil if the client was closed, or the error
+// of the server rejecting resubscription.
+//
+// The error channel is closed when Unsubscribe is called on the subscription.
+func (sub *ReconnectingSubscription) Err() <-chan error {
+	return sub.err
+}
+
+// Unsubscribe unsubscribes the notification and closes the error channel.
+// It can safely be called more than once.
+func (sub *ReconnectingSubscription) Unsubscribe() {
+	sub.unsubOnce.Do(func() {
+		close(sub.unsub)
+		<-sub.done
+		close(sub.err)
+	})
+}
+
+// loop waits for the active subscription to fail and reinstates it.
+func (sub *ReconnectingSubscription) loop(active *ClientSubscription) {
+	defer close(sub.done)
+
+	for {
+		select {
+		case err := <-active.Err():
+			if err == nil {
+				// The client was closed, there's nothing to reconnect
+				sub.err <- nil
+				return
+			}
+			lost := time.Now()
+			log.Debug("RPC subscription lost, resubscribing", "namespace", sub.namespace, "err", err)
+
+			var fail error
+			if active, fail = sub.resubscribe(); active == nil {
+				// Report the end of the subscription unless it was requested
+				if !sub.unsubscribed() {
+					sub.err <- fail
+				}
+				return
+			}
+			sub.reportGap(SubscriptionGap{Err: err, Lost: lost, Restored: time.Now()})
+
+		case <-sub.unsub:
+			active.Unsubscribe()
+			return
+		}
+	}
+}
+
+// resubscribe reissues the subscribe request with exponential backoff until
+// it succeeds. Transport errors are retried, nil is returned if the client
+// was closed, the subscription was cancelled or the server rejected it.
+func (sub *ReconnectingSubscription) resubscribe() (*ClientSubscription, error) {
+	wait := sub.config.MinBackoff
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-sub.unsub:
+			timer.Stop()
+			return nil, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+		active, err := sub.client.Subscribe(ctx, sub.namespace, sub.channel, sub.args...)
+		cancel()
+
+		switch {
+		case err == nil:
+			return active, nil
+		case err == ErrClientQuit:
+			return nil, nil
+		}
+		if _, ok := err.(Error); ok {
+			// The server is reachable but refuses the subscription
+			return nil, err
+		}
+		log.Trace("RPC resubscription failed", "namespace", sub.namespace, "err", err, "retry", wait)
+
+		if wait *= 2; wait > sub.config.MaxBackoff {
+			wait = sub.config.MaxBackoff
+		}
+	}
+}
+
+// reportGap delivers an interruption notice without blocking the subscription,
+// merging it with a pending one if the consumer did not pick that up yet.
+func (sub *ReconnectingSubscription) reportGap(gap SubscriptionGap) {
+	for {
+		select {
+		case sub.gaps <- gap:
+			return
+		default:
+		}
+		select {
+		case prev := <-sub.gaps:
+			gap.Lost = prev.Lost
+		default:
+		}
+	}
+}
+
+// unsubscribed reports whether Unsubscribe was called.
+func (sub *ReconnectingSubscription) unsubscribed() bool {
+	select {
+	case <-sub.unsub:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// PingService notifies subscribers once shortly after subscribing.
+type PingService struct{}
+
+func (s *PingService) Ping(ctx context.Context, val int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		// Give the server time to activate the subscription
+		time.Sleep(100 * time.Millisecond)
+		notifier.Notify(sub.ID, val)
+	}()
+	return sub, nil
+}
+
+func TestClientReconnectingSubscribe(t *testing.T) {
+	server := newTestServer("eth", new(PingService))
+	defer server.Stop()
+
+	// Create an in-process client which exposes the server end of its connections
+	conns := make(chan net.Conn, 10)
+	client, _ := newClient(context.Background(), func(context.Context) (net.Conn, error) {
+		p1, p2 := net.Pipe()
+		go server.ServeCoDEWH(NewJSONCoDEWH(p1), OptionMethodInvocation|OptionSubscriptions)
+		conns <- p1
+		return p2, nil
+	})
+	defer client.Close()
+
+	config := ReconnectConfig{MinBackoff: 10 * time.Millisecond, MaxBackoff: 100 * time.Millisecond}
+	nc := make(chan int)
+	sub, err := client.ReconnectingSubscribe(context.Background(), config, "eth", nc, "ping", 42)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	select {
+	case val := <-nc:
+		if val != 42 {
+			t.Fatalf("value mismatch: got %d, want 42", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification received")
+	}
+	// Drop the connection and wait for the subscription to be reinstated
+	(<-conns).Close()
+
+	select {
+	case gap := <-sub.Gaps():
+		if gap.Err == nil {
+			t.Error("gap without interruption error")
+		}
+		if gap.Restored.Before(gap.Lost) {
+			t.Errorf("gap restored (%v) before lost (%v)", gap.Restored, gap.Lost)
+		}
+	case err := <-sub.Err():
+		t.Fatal("subscription ended:", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not reinstated")
+	}
+	select {
+	case val := <-nc:
+		if val != 42 {
+			t.Fatalf("value mismatch after reconnect: got %d, want 42", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification received after reconnect")
+	}
+	sub.Unsubscribe()
+	if err, ok := <-sub.Err(); ok || err != nil {
+		t.Fatalf("Err not closed after explicit unsubscribe: %v", err)
+	}
+}
+
+func TestClientReconnectingSubscribeClose(t *testing.T) {
+	server := newTestServer("eth", new(PingService))
+	defer server.Stop()
+	client := DialInProc(server)
+
+	nc := make(chan int, 1)
+	sub, err := client.ReconnectingSubscribe(context.Background(), DefaultReconnectConfig, "eth", nc, "ping", 1)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	client.Close()
+
+	select {
+	case err := <-sub.Err():
+		if err != nil {
+			t.Fatalf("Err returned a non-nil error after client shutdown: %q", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not ended after client shutdown")
+	}
+	sub.Unsubscribe()
+}
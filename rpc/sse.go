@@ -0,0 +1,338 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sseContentType       = "text/event-stream"
+	sseKeepAliveInterval = 15 * time.Second
+)
+
+var errSSEStreamingUnsupported = errors.New("streaming not supported by the HTTP connection")
+
+// isSSERequest reports whether the HTTP request asks for its response to be
+// delivered as a server-sent event stream.
+func isSSERequest(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mt == sseContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// sseRequestMessage extracts the JSON-RPC request carried by an event stream
+// request. POST requests carry the message in the body like any other HTTP
+// request, GET requests (as issued by browser EventSource objects) carry the
+// method name and the JSON encoded parameter list in the URL query.
+func sseRequestMessage(r *http.Request) (json.RawMessage, int, error) {
+	switch r.Method {
+	case http.MethodPost:
+		if code, err := validateRequest(r); err != nil {
+			return nil, code, err
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestContentLength))
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return body, 0, nil
+
+	case http.MethodGet:
+		if r.ContentLength > 0 {
+			return nil, http.StatusBadRequest, errors.New("unexpected request body")
+		}
+		query := r.URL.Query()
+		req := jsonRequest{
+			Version: jsonrpcVersion,
+			Id:      json.RawMessage("1"),
+			Method:  query.Get("method"),
+		}
+		if req.Method == "" {
+			return nil, http.StatusBadRequest, errors.New("missing method in query")
+		}
+		// GET requests are not preflighted by browsers, so any site could issue
+		// them on behalf of the user. Only allow side effect free subscriptions.
+		if !strings.HasSuffix(req.Method, subscribeMethodSuffix) {
+			return nil, http.StatusMethodNotAllowed, fmt.Errorf("only subscriptions are allowed over GET, not %s", req.Method)
+		}
+		if params := query.Get("params"); params != "" {
+			req.Payload = json.RawMessage(params)
+		}
+		if len(req.Payload) > maxRequestContentLength {
+			err := fmt.Errorf("params too large (%d>%d)", len(req.Payload), maxRequestContentLength)
+			return nil, http.StatusRequestEntityTooLarge, err
+		}
+		msg, err := json.Marshal(&req)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return msg, 0, nil
+
+	default:
+		return nil, http.StatusMethodNotAllowed, errors.New("method not allowed")
+	}
+}
+
+// sseConn is the transport beneath a server-sent event stream. It hands the
+// single request of the HTTP exchange to the coDEWH and then keeps the stream
+// open, writing every response and notification as a separate event until
+// either side closes the connection.
+type sseConn struct {
+	request json.RawMessage // request message, nil once it was consumed
+
+	writeMu sync.Mutex    // guards out and flush
+	out     io.Writer     // event stream destination
+	flush   func() error  // pushes buffered events to the client
+	conn    net.Conn      // hijacked connection, nil if streamed through the ResponseWriter
+	gone    chan struct{} // closed when the client goes away
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newSSEConn starts an event stream response on w. Where possible the
+// underlying connection is hijacked so that the HTTP server's write timeout
+// does not cut off long lived subscriptions.
+func newSSEConn(w http.ResponseWriter, r *http.Request, request json.RawMessage) (*sseConn, error) {
+	c := &sseConn{
+		request: request,
+		gone:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	header := w.Header()
+	header.Set("content-type", sseContentType)
+	header.Set("cache-control", "no-cache")
+
+	if hijacker, ok := w.(http.Hijacker); ok && r.ProtoMajor == 1 {
+		conn, bufrw, err := hijacker.Hijack()
+		if err != nil {
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+		header.Set("connection", "close")
+
+		fmt.Fprintf(bufrw, "HTTP/1.1 %d %s\r\n", http.StatusOK, http.StatusText(http.StatusOK))
+		header.Write(bufrw)
+		bufrw.WriteString("\r\n")
+		bufrw.Flush()
+
+		c.out, c.flush, c.conn = bufrw, bufrw.Flush, conn
+
+		// The client never sends anything after the request, a read returning
+		// means that the connection was torn down.
+		go func(rd *bufio.ReadWriter) {
+			io.Copy(ioutil.Discard, rd)
+			close(c.gone)
+		}(bufrw)
+		return c, nil
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errSSEStreamingUnsupported
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c.out, c.flush = w, func() error { flusher.Flush(); return nil }
+	go func() {
+		<-r.Context().Done()
+		close(c.gone)
+	}()
+	return c, nil
+}
+
+// readRequest feeds the request of the exchange to the coDEWH. Subsequent calls
+// block until the stream is closed and report the end of the input.
+func (c *sseConn) readRequest(v interface{}) error {
+	if msg := c.request; msg != nil {
+		c.request = nil
+		return json.Unmarshal(msg, v)
+	}
+	select {
+	case <-c.gone:
+	case <-c.closed:
+	}
+	return io.EOF
+}
+
+// writeEvent sends the given message to the client as a single data event.
+func (c *sseConn) writeEvent(v interface{}) error {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("data: ")
+	buf.Write(msg)
+	buf.WriteString("\n\n")
+	return c.write(buf.Bytes())
+}
+
+// write pushes raw event stream data to the client.
+func (c *sseConn) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	select {
+	case <-c.closed:
+		return io.ErrClosedPipe
+	default:
+	}
+	if _, err := c.out.Write(data); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// keepAlive periodically sends a comment line to the client, which prevents
+// intermediate proxies from dropping idle streams and detects dead clients.
+func (c *sseConn) keepAlive() {
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write([]byte(": ping\n\n")); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.gone:
+			return
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// Read implements io.Reader, it is never used since requests are handed to the
+// coDEWH by readRequest.
+func (c *sseConn) Read(b []byte) (int, error) {
+	return 0, io.EOF
+}
+
+// Write implements io.Writer by sending the data unmodified to the client.
+func (c *sseConn) Write(b []byte) (int, error) {
+	if err := c.write(b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close terminates the event stream.
+func (c *sseConn) Close() error {
+	c.closeOnce.Do(func() {
+		// Tear down the connection first to unblock pending writes
+		if c.conn != nil {
+			c.conn.Close()
+		}
+		c.writeMu.Lock()
+		close(c.closed)
+		c.writeMu.Unlock()
+	})
+	return nil
+}
+
+// serveSSE serves a single JSON-RPC request over a server-sent event stream.
+// The response is delivered as the first event and notifications of any
+// subscription created by the request follow as separate events. The stream,
+// and with it all its subscriptions, lives until the client disconnects.
+func (srv *Server) serveSSE(w http.ResponseWriter, r *http.Request) {
+	msg, code, err := sseRequestMessage(r)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	conn, err := newSSEConn(w, r, msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	go conn.keepAlive()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
+	ctx = context.WithValue(ctx, "scheme", r.Proto)
+	ctx = context.WithValue(ctx, "local", r.Host)
+
+	coDEWH := NewCoDEWH(conn, conn.writeEvent, conn.readRequest)
+	defer coDEWH.Close()
+
+	srv.serveRequest(ctx, coDEWH, false, OptionMethodInvocation|OptionSubscriptions)
+}
+
+// sseOriginHandler rejects event stream GET requests issued from origins that
+// are not allowed to access the API. Browsers do not preflight these requests
+// like they do for JSON POSTs, so the CORS handler alone would let them through.
+type sseOriginHandler struct {
+	origins []string
+	next    http.Handler
+}
+
+func newSSEOriginHandler(allowedOrigins []string, next http.Handler) http.Handler {
+	origins := make([]string, len(allowedOrigins))
+	for i, origin := range allowedOrigins {
+		origins[i] = strings.ToLower(origin)
+	}
+	return &sseOriginHandler{origins: origins, next: next}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *sseOriginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && isSSERequest(r) {
+		if origin := r.Header.Get("origin"); origin != "" && !h.allowed(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// allowed reports whether the origin matches any of the allowed ones, using the
+// same rules as the CORS handler: "*" matches everything and a single wildcard
+// matches any substring of the origin.
+func (h *sseOriginHandler) allowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range h.origins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if i := strings.IndexByte(allowed, '*'); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSERequestDetection(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{contentType, false},
+		{sseContentType, true},
+		{"text/html, text/event-stream;q=0.9", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://url.com", nil)
+		req.Header.Set("accept", tt.accept)
+		if have := isSSERequest(req); have != tt.want {
+			t.Errorf("accept %q: have %v, want %v", tt.accept, have, tt.want)
+		}
+	}
+}
+
+// readSSEEvent reads the next data event from an event stream.
+func readSSEEvent(t *testing.T, stream *bufio.Reader, v interface{}) {
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), v); err != nil {
+				t.Fatalf("invalid event %q: %v", line, err)
+			}
+			return
+		}
+	}
+}
+
+func TestSSEMethodCall(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("eth", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_echo","params":[42]}`
+	req, _ := http.NewRequest(http.MethodPost, httpsrv.URL, strings.NewReader(body))
+	req.Header.Set("content-type", contentType)
+	req.Header.Set("accept", sseContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("content-type"); ct != sseContentType {
+		t.Fatalf("content type mismatch: have %q, want %q", ct, sseContentType)
+	}
+	var response jsonSuccessResponse
+	readSSEEvent(t, bufio.NewReader(resp.Body), &response)
+	if result, ok := response.Result.(float64); !ok || result != 42 {
+		t.Fatalf("result mismatch: have %v, want 42", response.Result)
+	}
+}
+
+// Tests that event streams requested through GET can only create subscriptions,
+// since browsers don't preflight them and any site could issue method calls.
+func TestSSEGetOnlySubscriptions(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("eth", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	tests := []struct {
+		method string
+		params string
+		code   int
+	}{
+		{"eth_echo", "[42]", http.StatusMethodNotAllowed},
+		{"eth_unsubscribe", `["0x1"]`, http.StatusMethodNotAllowed},
+		{"eth_subscribe", `["someSubscription",1,1]`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		query := url.Values{"method": {tt.method}, "params": {tt.params}}
+		req, _ := http.NewRequest(http.MethodGet, httpsrv.URL+"?"+query.Encode(), nil)
+		req.Header.Set("accept", sseContentType)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Errorf("method %s: status mismatch: have %d, want %d", tt.method, resp.StatusCode, tt.code)
+		}
+	}
+}
+
+// Tests that event streams requested through GET are subject to the same origin
+// restrictions as the rest of the HTTP API.
+func TestSSEGetOrigin(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("eth", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	tests := []struct {
+		allowed []string
+		origin  string
+		code    int
+	}{
+		{nil, "", http.StatusOK},
+		{nil, "http://evil.com", http.StatusForbidden},
+		{[]string{"http://good.com"}, "http://evil.com", http.StatusForbidden},
+		{[]string{"http://good.com"}, "http://GOOD.com", http.StatusOK},
+		{[]string{"http://*.good.com"}, "http://sub.good.com", http.StatusOK},
+		{[]string{"http://*.good.com"}, "http://good.com.evil.com", http.StatusForbidden},
+		{[]string{"*"}, "http://any.com", http.StatusOK},
+	}
+	query := url.Values{"method": {"eth_subscribe"}, "params": {`["someSubscription",1,1]`}}
+	for _, tt := range tests {
+		httpsrv := httptest.NewServer(newCorsHandler(server, tt.allowed))
+
+		req, _ := http.NewRequest(http.MethodGet, httpsrv.URL+"?"+query.Encode(), nil)
+		req.Header.Set("accept", sseContentType)
+		if tt.origin != "" {
+			req.Header.Set("origin", tt.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		httpsrv.Close()
+
+		if resp.StatusCode != tt.code {
+			t.Errorf("allowed %v, origin %q: status mismatch: have %d, want %d", tt.allowed, tt.origin, resp.StatusCode, tt.code)
+		}
+	}
+}
+
+func TestSSENotifications(t *testing.T) {
+	server := NewServer()
+	service := &NotificationTestService{}
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	n, val := 5, 12345
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["someSubscription",5,12345]}`
+	req, _ := http.NewRequest(http.MethodPost, httpsrv.URL, strings.NewReader(body))
+	req.Header.Set("content-type", contentType)
+	req.Header.Set("accept", sseContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	var response jsonSuccessResponse
+	readSSEEvent(t, stream, &response)
+	if _, ok := response.Result.(string); !ok {
+		t.Fatalf("expected subscription id, got %T", response.Result)
+	}
+	for i := 0; i < n; i++ {
+		var notification jsonNotification
+		readSSEEvent(t, stream, &notification)
+		if int(notification.Params.Result.(float64)) != val+i {
+			t.Fatalf("expected %d, got %v", val+i, notification.Params.Result)
+		}
+	}
+	resp.Body.Close() // causes notification unsubscribe callback to be called
+	time.Sleep(1 * time.Second)
+
+	if !service.wasUnsubCallbackCalled() {
+		t.Error("unsubscribe callback not called after closing the event stream")
+	}
+}
@@ -37,14 +37,23 @@ type ID string
 type Subscription struct {
 	ID        ID
 	namespace string
-	err       chan error // closed on unsubscribe
+	err       chan error    // closed on unsubscribe
+	activated chan struct{} // closed when notifications start to be delivered
 }
 
-// Err returns a channel that is closed when the client send an unsubscribe request.
+// Err returns a channel that is closed when the client send an unsubscribe request,
+// or when the subscription was terminated by the server through Notifier.Fail.
 func (s *Subscription) Err() <-chan error {
 	return s.err
 }
 
+// Activated returns a channel that is closed when the subscription ID was sent to
+// the client. Notifications sent before are dropped, callbacks which want to
+// deliver data right away should wait for it.
+func (s *Subscription) Activated() <-chan struct{} {
+	return s.activated
+}
+
 // notifierKey is used to store a notifier within the connection context.
 type notifierKey struct{}
 
@@ -78,7 +87,7 @@ func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
 // are dropped until the subscription is marked as active. This is done
 // by the RPC server after the subscription ID is send to the client.
 func (n *Notifier) CreateSubscription() *Subscription {
-	s := &Subscription{ID: NewID(), err: make(chan error)}
+	s := &Subscription{ID: NewID(), err: make(chan error), activated: make(chan struct{})}
 	n.subMu.Lock()
 	n.inactive[s.ID] = s
 	n.subMu.Unlock()
@@ -102,6 +111,32 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// Fail terminates a subscription because of a server side error. The client is
+// notified with the error and the subscription is dropped, closing its Err channel
+// like an unsubscribe request would.
+func (n *Notifier) Fail(id ID, err error) error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+
+	sub, active := n.active[id]
+	if !active {
+		return ErrSubscriptionNotFound
+	}
+	close(sub.err)
+	delete(n.active, id)
+
+	rpcErr, ok := err.(Error)
+	if !ok {
+		rpcErr = &callbackError{err.Error()}
+	}
+	notification := n.coDEWH.CreateErrorNotification(string(id), sub.namespace, rpcErr)
+	if err := n.coDEWH.Write(notification); err != nil {
+		n.coDEWH.Close()
+		return err
+	}
+	return nil
+}
+
 // Closed returns a channel that is closed when the RPC connection is closed.
 func (n *Notifier) Closed() <-chan interface{} {
 	return n.coDEWH.Closed()
@@ -131,5 +166,6 @@ func (n *Notifier) activate(id ID, namespace string) {
 		sub.namespace = namespace
 		n.active[id] = sub
 		delete(n.inactive, id)
+		close(sub.activated)
 	}
 }
@@ -108,6 +108,8 @@ type ServerCoDEWH interface {
 	CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{}
 	// Create notification response
 	CreateNotification(id, namespace string, event interface{}) interface{}
+	// Create notification terminating a subscription with an error
+	CreateErrorNotification(id, namespace string, err Error) interface{}
 	// Write msg to client.
 	Write(msg interface{}) error
 	// Close underlying data stream
@@ -0,0 +1,312 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package stratum
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/log"
+)
+
+const (
+	protocolVersion = "EthereumStratum/1.0.0"
+	maxRequestSize  = 16 * 1024 // Maximum size of a single request line
+)
+
+// Session errors, as defined by the Stratum protocol.
+var (
+	errUnknownMethod  = &stratumError{20, "Unsupported method"}
+	errInvalidParams  = &stratumError{20, "Invalid parameters"}
+	errUnauthorized   = &stratumError{24, "Unauthorized worker"}
+	errNotSubscribed  = &stratumError{25, "Not subscribed"}
+	errInvalidNonce   = &stratumError{20, "Invalid nonce"}
+	errInvalidRequest = &stratumError{20, "Invalid request"}
+)
+
+// stratumError is an error reported to the miners as a [code, message, null]
+// triplet.
+type stratumError struct {
+	code    int
+	message string
+}
+
+func (e *stratumError) Error() string { return e.message }
+
+// MarshalJSON implements json.Marshaler.
+func (e *stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.code, e.message, nil})
+}
+
+// request is a Stratum method call sent by a miner.
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// response is the reply to a miner's request.
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  *stratumError   `json:"error"`
+}
+
+// notification is a method call pushed to a miner.
+type notification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// session is a connection of a single mining rig, which may run multiple workers.
+type session struct {
+	service    *Service
+	conn       net.Conn
+	extranonce string // Hex encoded nonce prefix assigned to the session
+
+	subscribed bool
+	authorized map[string]bool // Workers authorized on this connection
+	lock       sync.Mutex      // Protects the fields above and the connection writes
+
+	jobs chan queuedJob // Latest job waiting to be pushed to the miner
+	quit chan struct{}  // Closed when the connection is dropped
+}
+
+// queuedJob is a job waiting to be pushed to a miner.
+type queuedJob struct {
+	job   *job
+	clean bool
+}
+
+func newSession(service *Service, conn net.Conn, nonce uint16) *session {
+	return &session{
+		service:    service,
+		conn:       conn,
+		extranonce: fmt.Sprintf("%04x", nonce),
+		authorized: make(map[string]bool),
+		jobs:       make(chan queuedJob, 1),
+		quit:       make(chan struct{}),
+	}
+}
+
+// handle serves the requests of the miner until the connection is dropped.
+func (s *session) handle() {
+	defer s.conn.Close()
+
+	log.Debug("Stratum miner connected", "addr", s.conn.RemoteAddr())
+	defer log.Debug("Stratum miner disconnected", "addr", s.conn.RemoteAddr())
+
+	scanner := bufio.NewScanner(s.conn)
+	scanner.Buffer(make([]byte, 1024), maxRequestSize)
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(sessionTimeout))
+		if !scanner.Scan() {
+			return
+		}
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			s.send(&response{ID: json.RawMessage("null"), Error: errInvalidRequest})
+			return
+		}
+		result, err := s.dispatch(&req)
+		res := &response{ID: req.ID, Result: result, Error: err}
+		if s.send(res) != nil {
+			return
+		}
+		// Hand out work to freshly subscribed or authorized miners
+		if err == nil && (req.Method == "mining.subscribe" || req.Method == "mining.authorize") {
+			s.lock.Lock()
+			ready := s.subscribed && len(s.authorized) > 0
+			s.lock.Unlock()
+
+			if ready {
+				s.send(&notification{Method: "mining.set_difficulty", Params: []interface{}{float64(s.service.config.Difficulty) / difficultyScale}})
+				if job := s.service.currentJob(); job != nil {
+					s.queueJob(job, true)
+				}
+			}
+		}
+	}
+}
+
+// dispatch executes a single request of the miner.
+func (s *session) dispatch(req *request) (interface{}, *stratumError) {
+	switch req.Method {
+	case "mining.subscribe":
+		s.lock.Lock()
+		s.subscribed = true
+		s.lock.Unlock()
+
+		return []interface{}{
+			[]string{"mining.notify", s.extranonce, protocolVersion},
+			s.extranonce,
+		}, nil
+
+	case "mining.extranonce.subscribe":
+		return true, nil
+
+	case "mining.authorize":
+		var worker, password string
+		if len(req.Params) < 2 || json.Unmarshal(req.Params[0], &worker) != nil || worker == "" ||
+			json.Unmarshal(req.Params[1], &password) != nil {
+			return nil, errInvalidParams
+		}
+		if !s.service.authorize(password) {
+			return nil, errUnauthorized
+		}
+		s.lock.Lock()
+		s.authorized[worker] = true
+		s.lock.Unlock()
+
+		s.service.ensureMining()
+		return true, nil
+
+	case "mining.submit":
+		var worker, jobID, suffix string
+		if len(req.Params) < 3 || json.Unmarshal(req.Params[0], &worker) != nil ||
+			json.Unmarshal(req.Params[1], &jobID) != nil || json.Unmarshal(req.Params[2], &suffix) != nil {
+			return nil, errInvalidParams
+		}
+		s.lock.Lock()
+		subscribed, authorized := s.subscribed, s.authorized[worker]
+		s.lock.Unlock()
+
+		if !subscribed {
+			return nil, errNotSubscribed
+		}
+		if !authorized {
+			return nil, errUnauthorized
+		}
+		nonce, err := s.nonce(suffix)
+		if err != nil {
+			return nil, errInvalidNonce
+		}
+		if err := s.service.submit(worker, jobID, nonce); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	case "eth_submitHashrate":
+		var rate hexutil.Uint64
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &rate) != nil {
+			return nil, errInvalidParams
+		}
+		s.lock.Lock()
+		workers := make([]string, 0, len(s.authorized))
+		for worker := range s.authorized {
+			workers = append(workers, worker)
+		}
+		s.lock.Unlock()
+
+		if len(workers) == 0 {
+			return nil, errUnauthorized
+		}
+		// The rate is reported per connection, attribute it evenly to the workers
+		for _, worker := range workers {
+			s.service.report(worker, uint64(rate)/uint64(len(workers)))
+		}
+		return true, nil
+
+	default:
+		return nil, errUnknownMethod
+	}
+}
+
+// nonce assembles the full nonce of a submitted share from the session's extra
+// nonce and the suffix searched by the miner.
+func (s *session) nonce(suffix string) (uint64, error) {
+	suffix = strings.TrimPrefix(suffix, "0x")
+	if len(s.extranonce)+len(suffix) != 16 {
+		return 0, fmt.Errorf("invalid nonce length %d", len(suffix))
+	}
+	return strconv.ParseUint(s.extranonce+suffix, 16, 64)
+}
+
+// queueJob schedules a job to be pushed to the miner without blocking. If the
+// miner lags behind, the job still waiting in the queue is stale and is dropped.
+func (s *session) queueJob(job *job, clean bool) {
+	for {
+		select {
+		case s.jobs <- queuedJob{job: job, clean: clean}:
+			return
+		default:
+		}
+		select {
+		case stale := <-s.jobs:
+			// The miner must still drop its work if the skipped job asked for it
+			clean = clean || stale.clean
+		default:
+		}
+	}
+}
+
+// push keeps writing the queued jobs to the miner until the connection is dropped.
+func (s *session) push() {
+	for {
+		select {
+		case queued := <-s.jobs:
+			if err := s.sendJob(queued.job, queued.clean); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// sendJob pushes a job to the miner if it's ready to receive work.
+func (s *session) sendJob(job *job, clean bool) error {
+	s.lock.Lock()
+	ready := s.subscribed && len(s.authorized) > 0
+	s.lock.Unlock()
+
+	if !ready {
+		return nil
+	}
+	return s.send(&notification{
+		Method: "mining.notify",
+		Params: []interface{}{
+			job.id,
+			hexutil.Encode(job.work.Seed[:])[2:],
+			hexutil.Encode(job.work.Hash[:])[2:],
+			clean,
+		},
+	})
+}
+
+// send writes a single message to the miner.
+func (s *session) send(msg interface{}) error {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err = s.conn.Write(append(blob, '\n'))
+	return err
+}
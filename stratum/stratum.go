@@ -0,0 +1,432 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package stratum implements an EthereumStratum/1.0.0 mining server, handing
+// out the ethash work packages of the local miner to remote mining rigs over
+// plain TCP connections and tracking the shares they submit.
+package stratum
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/common/hexutil"
+	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/core/types"
+	"github.com/DEWH/go-DEWH/crypto"
+	"github.com/DEWH/go-DEWH/eth"
+	"github.com/DEWH/go-DEWH/event"
+	"github.com/DEWH/go-DEWH/log"
+	"github.com/DEWH/go-DEWH/p2p"
+	"github.com/DEWH/go-DEWH/rpc"
+)
+
+const (
+	maxJobs         = 8                // Number of recent jobs to accept shares for
+	hashrateWindow  = 10 * time.Minute // Time window to estimate worker hashrates over
+	sessionTimeout  = 10 * time.Minute // Time after which idle connections are dropped
+	workChanSize    = 16               // Size of the channel listening to new work packages
+	difficultyScale = 1 << 32          // Hashes per unit of EthereumStratum difficulty
+)
+
+// Config contains the settings of the Stratum server.
+type Config struct {
+	ListenAddr string // TCP address to listen on, e.g. 0.0.0.0:8008
+	Difficulty uint64 // Share difficulty handed out to the miners, in hashes
+	Password   string // Password the workers must authorize with
+}
+
+// DefaultConfig contains the default settings of the Stratum server.
+var DefaultConfig = Config{
+	ListenAddr: ":8008",
+	Difficulty: difficultyScale,
+}
+
+// Backend wraps the miner controls the Stratum server needs.
+type Backend interface {
+	IsMining() bool
+	StartMining(local bool) error
+}
+
+// job is a work package handed out to the miners.
+type job struct {
+	id     string
+	work   *ethash.Work
+	nonces map[uint64]struct{} // Nonces already submitted, to reject duplicate shares
+}
+
+// share is a valid share submitted by a worker.
+type share struct {
+	time       time.Time
+	difficulty uint64
+}
+
+// WorkerStats is the mining record of a single worker.
+type WorkerStats struct {
+	Valid     uint64         `json:"valid"`     // Number of valid shares submitted
+	Stale     uint64         `json:"stale"`     // Number of shares submitted for unknown or old jobs
+	Invalid   uint64         `json:"invalid"`   // Number of shares not meeting the share difficulty
+	Blocks    uint64         `json:"blocks"`    // Number of shares that sealed a block
+	Hashrate  hexutil.Uint64 `json:"hashrate"`  // Hashrate estimated from the valid shares
+	Reported  hexutil.Uint64 `json:"reported"`  // Hashrate reported by the worker itself
+	LastShare time.Time      `json:"lastShare"` // Time of the last valid share
+
+	shares []share
+}
+
+// Service is a node.Service running a Stratum mining server.
+type Service struct {
+	config  Config
+	engine  *ethash.Ethash
+	backend Backend
+	target  *big.Int // Boundary the share solutions must not exceed
+
+	listener net.Listener
+	workCh   chan *ethash.Work
+	workSub  event.Subscription
+
+	jobs     map[string]*job
+	order    []string
+	current  *job
+	sessions map[*session]struct{}
+	workers  map[string]*WorkerStats
+	nonce    uint16 // Extra nonce of the next session
+	lock     sync.RWMutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a Stratum server serving the work of a full node's miner.
+func New(config Config, ethServ *eth.DEWH) (*Service, error) {
+	if ethServ == nil {
+		return nil, errors.New("Stratum server requires a full DEWH service")
+	}
+	engine, ok := ethServ.Engine().(*ethash.Ethash)
+	if !ok {
+		return nil, errors.New("Stratum server requires the ethash engine")
+	}
+	if config.Password == "" {
+		return nil, errors.New("Stratum server requires a worker password")
+	}
+	return newService(config, engine, ethServ), nil
+}
+
+// newService creates a Stratum server on top of an ethash engine.
+func newService(config Config, engine *ethash.Ethash, backend Backend) *Service {
+	if config.Difficulty == 0 {
+		log.Warn("Sanitizing invalid Stratum share difficulty", "provided", config.Difficulty, "updated", DefaultConfig.Difficulty)
+		config.Difficulty = DefaultConfig.Difficulty
+	}
+	return &Service{
+		config:   config,
+		engine:   engine,
+		backend:  backend,
+		target:   new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), new(big.Int).SetUint64(config.Difficulty)),
+		jobs:     make(map[string]*job),
+		sessions: make(map[*session]struct{}),
+		workers:  make(map[string]*WorkerStats),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the Stratum server (nil as it doesn't use the devp2p overlay network).
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints to inspect the
+// mining records of the workers.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "stratum",
+		Version:   "1.0",
+		Service:   &API{s},
+	}}
+}
+
+// Start implements node.Service, starting to accept Stratum connections.
+func (s *Service) Start(server *p2p.Server) error {
+	listener, err := net.Listen("tcp", s.config.ListenAddr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.workCh = make(chan *ethash.Work, workChanSize)
+	s.workSub = s.engine.SubscribeWork(s.workCh)
+
+	s.wg.Add(2)
+	go s.loop()
+	go s.accept()
+
+	log.Info("Stratum server started", "addr", listener.Addr(), "difficulty", s.config.Difficulty)
+	return nil
+}
+
+// Stop implements node.Service, closing the server and all open connections.
+func (s *Service) Stop() error {
+	if s.listener == nil {
+		return nil
+	}
+	close(s.quit)
+	s.listener.Close()
+	s.workSub.Unsubscribe()
+
+	s.lock.Lock()
+	for session := range s.sessions {
+		session.conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	log.Info("Stratum server stopped")
+	return nil
+}
+
+// loop turns the new work packages of the miner into jobs and broadcasts them
+// to the connected sessions.
+func (s *Service) loop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case work := <-s.workCh:
+			s.lock.Lock()
+			clean := s.current == nil || s.current.work.Number != work.Number
+			if clean {
+				s.jobs, s.order = make(map[string]*job), nil
+			}
+			current := &job{
+				id:     hexutil.Encode(work.Hash[:8])[2:],
+				work:   work,
+				nonces: make(map[uint64]struct{}),
+			}
+			s.jobs[current.id] = current
+			s.order = append(s.order, current.id)
+			for len(s.order) > maxJobs {
+				delete(s.jobs, s.order[0])
+				s.order = s.order[1:]
+			}
+			s.current = current
+
+			sessions := make([]*session, 0, len(s.sessions))
+			for session := range s.sessions {
+				sessions = append(sessions, session)
+			}
+			s.lock.Unlock()
+
+			// Queue the job without blocking, a lagging miner must not stall the engine
+			for _, session := range sessions {
+				session.queueJob(current, clean)
+			}
+
+		case <-s.workSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// accept keeps accepting miner connections until the listener is closed.
+func (s *Service) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+			default:
+				log.Warn("Stratum listener failed", "err", err)
+			}
+			return
+		}
+		s.lock.Lock()
+		session := newSession(s, conn, s.nonce)
+		s.nonce++
+		s.sessions[session] = struct{}{}
+		s.lock.Unlock()
+
+		s.wg.Add(2)
+		go func() {
+			defer s.wg.Done()
+			session.push()
+		}()
+		go func() {
+			defer s.wg.Done()
+			session.handle()
+			close(session.quit)
+
+			s.lock.Lock()
+			delete(s.sessions, session)
+			s.lock.Unlock()
+		}()
+	}
+}
+
+// authorize checks the password a worker authorizes with.
+func (s *Service) authorize(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), []byte(s.config.Password)) == 1
+}
+
+// ensureMining starts the local miner if it's not running yet, so that there is
+// work to hand out to the connected miners.
+func (s *Service) ensureMining() {
+	if !s.backend.IsMining() {
+		if err := s.backend.StartMining(false); err != nil {
+			log.Warn("Failed to start mining for Stratum miners", "err", err)
+		}
+	}
+}
+
+// currentJob returns the job to hand out to newly connected miners.
+func (s *Service) currentJob() *job {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current
+}
+
+// Share submission errors, as defined by the Stratum protocol.
+var (
+	errJobNotFound   = &stratumError{21, "Job not found"}
+	errDuplicate     = &stratumError{22, "Duplicate share"}
+	errLowDifficulty = &stratumError{23, "Low difficulty share"}
+)
+
+// submit validates a share of a worker, submitting it to the engine if it meets
+// the block difficulty too.
+func (s *Service) submit(worker string, jobID string, nonce uint64) *stratumError {
+	// Ensure the job is still valid and the share is not a duplicate
+	s.lock.Lock()
+	stats := s.worker(worker)
+	job := s.jobs[jobID]
+	if job == nil {
+		stats.Stale++
+		s.lock.Unlock()
+		return errJobNotFound
+	}
+	if _, ok := job.nonces[nonce]; ok {
+		stats.Invalid++
+		s.lock.Unlock()
+		return errDuplicate
+	}
+	job.nonces[nonce] = struct{}{}
+	s.lock.Unlock()
+
+	// Verify the solution against the block and share targets. Block solutions
+	// are always accepted, even if the block is easier than the share difficulty,
+	// and are credited with the easier of the two.
+	digest, result := s.engine.Hashimoto(job.work.Number, job.work.Hash, nonce)
+	value := result.Big()
+	solution := value.Cmp(job.work.Target) <= 0
+
+	difficulty := s.config.Difficulty
+	if job.work.Target.Cmp(s.target) > 0 {
+		difficulty = new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), job.work.Target).Uint64()
+	}
+	s.lock.Lock()
+	if !solution && value.Cmp(s.target) > 0 {
+		stats.Invalid++
+		s.lock.Unlock()
+		return errLowDifficulty
+	}
+	now := time.Now()
+	stats.Valid++
+	stats.LastShare = now
+	stats.shares = append(stats.shares, share{time: now, difficulty: difficulty})
+	stats.prune(now)
+	s.lock.Unlock()
+
+	// Submit block solutions without holding the lock, the engine may be busy
+	// announcing new work to the server
+	if solution {
+		if err := s.engine.SubmitWork(types.EncodeNonce(nonce), job.work.Hash, digest); err != nil {
+			log.Warn("Stratum block solution rejected", "worker", worker, "number", job.work.Number, "err", err)
+		} else {
+			log.Info("Stratum worker sealed a block", "worker", worker, "number", job.work.Number)
+
+			s.lock.Lock()
+			stats.Blocks++
+			s.lock.Unlock()
+		}
+	}
+	return nil
+}
+
+// report records the hashrate reported by a worker and forwards it to the
+// engine to include in the node's total.
+func (s *Service) report(worker string, rate uint64) {
+	s.lock.Lock()
+	s.worker(worker).Reported = hexutil.Uint64(rate)
+	s.lock.Unlock()
+
+	s.engine.SubmitHashRate(rate, crypto.Keccak256Hash([]byte(worker)))
+}
+
+// worker retrieves the record of a worker, creating it if needed. The caller
+// must hold the lock.
+func (s *Service) worker(name string) *WorkerStats {
+	stats, ok := s.workers[name]
+	if !ok {
+		stats = new(WorkerStats)
+		s.workers[name] = stats
+	}
+	return stats
+}
+
+// prune drops the shares outside of the hashrate estimation window and updates
+// the estimated hashrate of the worker.
+func (stats *WorkerStats) prune(now time.Time) {
+	cutoff := now.Add(-hashrateWindow)
+	for len(stats.shares) > 0 && stats.shares[0].time.Before(cutoff) {
+		stats.shares = stats.shares[1:]
+	}
+	var total uint64
+	for _, share := range stats.shares {
+		total += share.difficulty
+	}
+	stats.Hashrate = hexutil.Uint64(total / uint64(hashrateWindow/time.Second))
+}
+
+// API exposes the mining records of the Stratum workers over RPC.
+type API struct {
+	service *Service
+}
+
+// Workers retrieves the mining records of all the workers that submitted shares
+// or reported their hashrate.
+func (api *API) Workers() map[string]*WorkerStats {
+	s := api.service
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	workers := make(map[string]*WorkerStats, len(s.workers))
+	for name, stats := range s.workers {
+		stats.prune(time.Now())
+
+		cpy := *stats
+		cpy.shares = nil
+		workers[name] = &cpy
+	}
+	return workers
+}
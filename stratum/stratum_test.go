@@ -0,0 +1,240 @@
+// Copyright 2018 The go-DEWH Authors
+// This file is part of the go-DEWH library.
+//
+// The go-DEWH library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-DEWH library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-DEWH library. If not, see <http://www.gnu.org/licenses/>.
+
+package stratum
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DEWH/go-DEWH/common"
+	"github.com/DEWH/go-DEWH/consensus/ethash"
+	"github.com/DEWH/go-DEWH/core/types"
+)
+
+// testBackend is a mock miner recording whether it was started.
+type testBackend struct {
+	started int32
+}
+
+func (b *testBackend) IsMining() bool { return atomic.LoadInt32(&b.started) == 1 }
+func (b *testBackend) StartMining(local bool) error {
+	atomic.StoreInt32(&b.started, 1)
+	return nil
+}
+
+// testMiner is a Stratum client talking to the server under test.
+type testMiner struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+}
+
+// message is a response or notification received by the test miner.
+type message struct {
+	ID     *int              `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  []interface{}     `json:"error"`
+}
+
+func (m *testMiner) call(method string, params ...interface{}) *message {
+	m.id++
+	blob, _ := json.Marshal(map[string]interface{}{"id": m.id, "method": method, "params": params})
+	if _, err := m.conn.Write(append(blob, '\n')); err != nil {
+		m.t.Fatalf("failed to send %s: %v", method, err)
+	}
+	msg := m.read()
+	if msg.ID == nil || *msg.ID != m.id {
+		m.t.Fatalf("%s: unexpected reply %+v", method, msg)
+	}
+	return msg
+}
+
+func (m *testMiner) read() *message {
+	m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := m.reader.ReadBytes('\n')
+	if err != nil {
+		m.t.Fatalf("failed to read message: %v", err)
+	}
+	msg := new(message)
+	if err := json.Unmarshal(line, msg); err != nil {
+		m.t.Fatalf("failed to parse message %s: %v", line, err)
+	}
+	return msg
+}
+
+// Tests the full life cycle of a Stratum miner: subscribing, receiving work and
+// submitting shares, one of which seals a block.
+func TestMining(t *testing.T) {
+	engine := ethash.NewTester()
+	defer engine.Close()
+	engine.SetThreads(-1)
+
+	backend := new(testBackend)
+	service := newService(Config{ListenAddr: "127.0.0.1:0", Difficulty: 1, Password: "secret"}, engine, backend)
+	if err := service.Start(nil); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+	defer service.Stop()
+
+	conn, err := net.Dial("tcp", service.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	miner := &testMiner{t: t, conn: conn, reader: bufio.NewReader(conn)}
+
+	// Subscribe and authorize, ensuring the miner gets its extra nonce and difficulty
+	var subscription []json.RawMessage
+	if msg := miner.call("mining.subscribe", "test", protocolVersion); json.Unmarshal(msg.Result, &subscription) != nil || len(subscription) != 2 {
+		t.Fatalf("invalid subscription reply: %s", msg.Result)
+	}
+	var extranonce string
+	json.Unmarshal(subscription[1], &extranonce)
+	if len(extranonce) != 4 {
+		t.Fatalf("extra nonce length mismatch: have %d, want %d", len(extranonce), 4)
+	}
+	// Ensure mining is only started by authorized workers
+	if msg := miner.call("mining.authorize", "rig.1", "wrong"); msg.Error == nil {
+		t.Fatalf("authorization with invalid password accepted")
+	}
+	if backend.IsMining() {
+		t.Errorf("mining started by unauthorized worker")
+	}
+	if msg := miner.call("mining.authorize", "rig.1", "secret"); string(msg.Result) != "true" {
+		t.Fatalf("authorization failed: %s %v", msg.Result, msg.Error)
+	}
+	if !backend.IsMining() {
+		t.Errorf("mining not started on authorization")
+	}
+	if msg := miner.read(); msg.Method != "mining.set_difficulty" {
+		t.Fatalf("difficulty not set, got %+v", msg)
+	}
+	// Push a block to seal and ensure the miner gets a job for it
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	results := make(chan *types.Block, 1)
+	go func() {
+		block, _ := engine.Seal(nil, types.NewBlockWithHeader(header), nil)
+		results <- block
+	}()
+	msg := miner.read()
+	if msg.Method != "mining.notify" || len(msg.Params) != 4 {
+		t.Fatalf("job not received, got %+v", msg)
+	}
+	var jobID, hash string
+	json.Unmarshal(msg.Params[0], &jobID)
+	json.Unmarshal(msg.Params[2], &hash)
+	if want := header.HashNoNonce().Hex()[2:]; hash != want {
+		t.Fatalf("job hash mismatch: have %s, want %s", hash, want)
+	}
+	// Submit a stale share, a valid one and a duplicate
+	if msg := miner.call("mining.submit", "rig.1", "deadbeef", "000000000001"); msg.Error == nil {
+		t.Errorf("share for unknown job accepted")
+	}
+	if msg := miner.call("mining.submit", "rig.1", jobID, "000000000001"); string(msg.Result) != "true" {
+		t.Errorf("valid share rejected: %v", msg.Error)
+	}
+	if msg := miner.call("mining.submit", "rig.1", jobID, "000000000001"); msg.Error == nil {
+		t.Errorf("duplicate share accepted")
+	}
+	if msg := miner.call("mining.submit", "rig.2", jobID, "000000000002"); msg.Error == nil {
+		t.Errorf("share of unauthorized worker accepted")
+	}
+	// The block difficulty is trivial, so the valid share must have sealed it
+	select {
+	case block := <-results:
+		if want := fmt.Sprintf("%s000000000001", extranonce); fmt.Sprintf("%016x", block.Nonce()) != want {
+			t.Errorf("sealed nonce mismatch: have %016x, want %s", block.Nonce(), want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block not sealed")
+	}
+	stats := (&API{service}).Workers()["rig.1"]
+	if stats == nil {
+		t.Fatalf("worker not tracked")
+	}
+	if stats.Valid != 1 || stats.Stale != 1 || stats.Invalid != 1 || stats.Blocks != 1 {
+		t.Errorf("worker stats mismatch: have %+v", stats)
+	}
+}
+
+// Tests that queueing jobs to a lagging miner never blocks, dropping the stale
+// jobs but keeping their request to clean up old work.
+func TestQueueJobDropsStale(t *testing.T) {
+	session := newSession(nil, nil, 0)
+
+	jobs := []*job{{id: "01"}, {id: "02"}, {id: "03"}}
+	session.queueJob(jobs[0], false)
+	session.queueJob(jobs[1], true)
+	session.queueJob(jobs[2], false)
+
+	select {
+	case queued := <-session.jobs:
+		if queued.job != jobs[2] {
+			t.Errorf("queued job mismatch: have %s, want %s", queued.job.id, jobs[2].id)
+		}
+		if !queued.clean {
+			t.Errorf("clean flag of dropped job lost")
+		}
+	default:
+		t.Fatalf("no job queued")
+	}
+	select {
+	case queued := <-session.jobs:
+		t.Errorf("stale job %s still queued", queued.job.id)
+	default:
+	}
+}
+
+// Tests that shares solving the block are accepted even if the block is easier
+// than the share difficulty, while others must meet the share difficulty.
+func TestSubmitEasyBlock(t *testing.T) {
+	engine := ethash.NewTester()
+	defer engine.Close()
+
+	service := newService(Config{Difficulty: 1 << 62}, engine, new(testBackend))
+	work := &ethash.Work{
+		Hash:   common.HexToHash("0x01"),
+		Number: 1,
+		Target: new(big.Int).Lsh(common.Big1, 256), // Block difficulty of 1
+	}
+	service.jobs["01"] = &job{id: "01", work: work, nonces: make(map[uint64]struct{})}
+
+	if err := service.submit("rig", "01", 1); err != nil {
+		t.Fatalf("block solution rejected: %v", err)
+	}
+	// Make the block as hard as the shares, rejecting the next solution
+	work.Target = new(big.Int).Set(service.target)
+	if err := service.submit("rig", "01", 2); err != errLowDifficulty {
+		t.Fatalf("low difficulty share error mismatch: have %v, want %v", err, errLowDifficulty)
+	}
+	stats := service.workers["rig"]
+	if stats.Valid != 1 || stats.Invalid != 1 {
+		t.Errorf("worker stats mismatch: have %+v", stats)
+	}
+	if len(stats.shares) != 1 || stats.shares[0].difficulty != 1 {
+		t.Errorf("share not credited with the block difficulty: %+v", stats.shares)
+	}
+}